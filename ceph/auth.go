@@ -0,0 +1,122 @@
+package ceph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrNoCredentials is returned if a session has to re-login but neither a CredentialProvider is set nor Login was
+// called successfully before.
+var ErrNoCredentials = errors.New("no credentials available for login")
+
+// CredentialProvider implements a source of credentials used to (re-)login a session, e.g. a vault lookup.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialProviderFunc implements a CredentialProvider using an ordinary func.
+type CredentialProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials returns f(ctx).
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// authTransport implements a http.RoundTripper adding the session token as bearer token to every request and
+// re-authenticating the session exactly once if the mgr answers with http 401 (e.g. on an expired token).
+type authTransport struct {
+	session *Session
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isAuthRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	body, canRetry, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	token := t.session.Token()
+
+	resp, err := t.base.RoundTrip(withBearerToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !canRetry {
+		return resp, err
+	}
+
+	if errLogin := t.session.relogin(req.Context(), token); errLogin != nil {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	return t.base.RoundTrip(withBearerToken(withBody(req.Clone(req.Context()), body), t.session.Token()))
+}
+
+// readBody copies the body of req up front, resty recycles its request buffer as soon as the body is closed.
+// canRetry is false if the body can not be read again to send req once more.
+func readBody(req *http.Request) (body []byte, canRetry bool, err error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, false, nil
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer rc.Close()
+
+	body, err = ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return body, true, nil
+}
+
+// withBody sets body as body of req, which can be read again with GetBody. A nil body leaves req unchanged.
+func withBody(req *http.Request, body []byte) *http.Request {
+	if body == nil {
+		return req
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return req
+}
+
+// isAuthRequest checks if req targets /api/auth or one of its sub paths, which are never re-authenticated.
+func isAuthRequest(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/auth") || strings.Contains(req.URL.Path, "/auth/")
+}
+
+// withBearerToken returns a copy of req carrying token in the Authorization header, unless the caller set one.
+func withBearerToken(req *http.Request, token string) *http.Request {
+	authorization := req.Header.Get("Authorization")
+
+	if token == "" || (authorization != "" && !strings.HasPrefix(authorization, "Bearer ")) {
+		return req
+	}
+
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	return r
+}
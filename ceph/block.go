@@ -1,10 +1,14 @@
 package ceph
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -34,6 +38,9 @@ var (
 
 	// ErrNameSpaceAlreadyExists is returned if a namespace already exist for a rbd pool.
 	ErrNameSpaceAlreadyExists = errors.New("namespace already exists")
+
+	// ErrImageIDIsEmpty is returned if param imageID is empty.
+	ErrImageIDIsEmpty = errors.New("param imageID can not be empty")
 )
 
 const (
@@ -69,12 +76,36 @@ type RBD struct {
 	StripeUnit      *uint64            `json:"stripe_unit"`
 	DataPool        interface{}        `json:"data_pool"`
 	Parent          interface{}        `json:"parent"`
-	Snapshots       []interface{}      `json:"snapshots"`
+	Snapshots       []RBDSnapshot      `json:"snapshots"`
 	TotalDiskUsage  uint64             `json:"total_disk_usage"`
 	DiskUsage       uint64             `json:"disk_usage"`
 	Configuration   []RBDConfiguration `json:"configuration"`
+
+	// MirrorMode is MirrorImageModeJournal or MirrorImageModeSnapshot if mirroring is enabled for the image, Primary
+	// tells whether the image is the primary one of the mirrored images.
+	MirrorMode string `json:"mirror_mode,omitempty"`
+	Primary    *bool  `json:"primary,omitempty"`
 }
 
+// RBDSnapshot implements struct for the snapshots of an rbd image returned in RBD.Snapshots.
+type RBDSnapshot struct {
+	ID          int                `json:"id"`
+	Name        string             `json:"name"`
+	Size        uint64             `json:"size"`
+	Timestamp   time.Time          `json:"timestamp"`
+	IsProtected bool               `json:"is_protected"`
+	UsedBytes   *uint64            `json:"used_bytes"`
+	Children    []RBDSnapshotChild `json:"children"`
+}
+
+// RBDSnapshotChild implements struct for an image cloned from an rbd snapshot.
+type RBDSnapshotChild struct {
+	PoolName      string  `json:"pool_name"`
+	PoolNamespace *string `json:"pool_namespace"`
+	ImageName     string  `json:"image_name"`
+}
+
+// RBDQosConfig implements the qos limits of an rbd image. A limit of 0 means unlimited.
 type RBDQosConfig struct {
 	RbdQosBpsLimit       uint `json:"rbd_qos_bps_limit"`
 	RbdQosIopsLimit      uint `json:"rbd_qos_iops_limit"`
@@ -90,6 +121,28 @@ type RBDQosConfig struct {
 	RbdQosWriteIopsBurst uint `json:"rbd_qos_write_iops_burst"`
 }
 
+// QosConfig returns the qos limits of the image from its Configuration.
+func (r RBD) QosConfig() RBDQosConfig {
+	var qos RBDQosConfig
+
+	values := make(map[string]uint64)
+
+	for _, conf := range r.Configuration {
+		if !strings.HasPrefix(conf.Name, "rbd_qos_") {
+			continue
+		}
+
+		if v, err := strconv.ParseUint(conf.Value, 10, 64); err == nil {
+			values[conf.Name] = v
+		}
+	}
+
+	b, _ := json.Marshal(values)
+	_ = json.Unmarshal(b, &qos)
+
+	return qos
+}
+
 // RBDList implements struct received from GET /api/block/image.
 // --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-image.
 type RBDList []struct {
@@ -142,6 +195,8 @@ type RBDCopy struct {
 }
 
 // RBDError implements error struct returned.
+//
+// Deprecated: failed requests return an *APIError, use errors.As to get the details.
 type RBDError struct {
 	Detail    string `json:"detail"`
 	Code      string `json:"code"`
@@ -168,26 +223,37 @@ type RBDUpdate struct {
 
 // ListBlockImage gets a list of RBD block images (https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-image)
 func (c *Client) ListBlockImage(poolName string) (status int, rbdList RBDList, err error) {
-	var resp *resty.Response
+	return c.ListBlockImageWithContext(context.Background(), poolName)
+}
 
-	client := *c.Session.Client
+// ListBlockImageWithContext is like ListBlockImage but aborts the request if ctx is done.
+func (c *Client) ListBlockImageWithContext(ctx context.Context, poolName string) (status int, rbdList RBDList, err error) {
+	var resp *resty.Response
 
 	if poolName != "" {
-		resp, err = client.R().
+		req := c.Session.Client.R().
+			SetContext(ctx).
 			SetHeaders(defaultHeaderJson).
 			SetQueryParam("pool_name", poolName).
-			SetResult(&rbdList).
-			Get(c.Session.Server.getURL("block/image"))
+			SetResult(&rbdList)
+
+		resp, err = c.execute("ListBlockImage", req, resty.MethodGet, c.Session.Server.getURL("block/image"))
 	} else {
-		resp, err = client.R().
+		req := c.Session.Client.R().
+			SetContext(ctx).
 			SetHeaders(defaultHeaderJson).
-			SetResult(&rbdList).
-			Get(c.Session.Server.getURL("block/image"))
+			SetResult(&rbdList)
+
+		resp, err = c.execute("ListBlockImage", req, resty.MethodGet, c.Session.Server.getURL("block/image"))
 
 	}
 
+	if err != nil {
+		return 0, nil, ctxErr(ctx, "ListBlockImage", err)
+	}
+
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), nil, fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), nil, newAPIError(resp)
 	}
 
 	return resp.StatusCode(), rbdList, err
@@ -196,69 +262,99 @@ func (c *Client) ListBlockImage(poolName string) (status int, rbdList RBDList, e
 
 // GetBlockImage gets an RBD block image (https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-image-image_spec)
 func (c *Client) GetBlockImage(imageSpec string) (status int, rbd RBD, err error) {
+	return c.GetBlockImageWithContext(context.Background(), imageSpec)
+}
+
+// GetBlockImageWithContext is like GetBlockImage but aborts the request if ctx is done.
+func (c *Client) GetBlockImageWithContext(ctx context.Context, imageSpec string) (status int, rbd RBD, err error) {
 	var resp *resty.Response
 
 	if imageSpec == "" {
 		return 0, rbd, ErrImageSpecIsEmpty
 	}
 
-	client := *c.Session.Client
-
-	resp, err = client.
-		R().
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetResult(&rbd).
-		Get(c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))))
+		SetResult(&rbd)
+
+	resp, err = c.execute("GetBlockImage", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))))
+
+	if err != nil {
+		return 0, rbd, ctxErr(ctx, "GetBlockImage", err)
+	}
 
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), rbd, fmt.Errorf("could not get image %v: %v", imageSpec, resp.Error())
+		return resp.StatusCode(), rbd, newAPIError(resp)
 	}
 
 	return resp.StatusCode(), rbd, err
 }
 
 // CreateBlockImage creates an RBD image (https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image)
-func (c *Client) CreateBlockImage(rbdCreate RBDCreate, counter uint) (status int, err error) {
+func (c *Client) CreateBlockImage(rbdCreate RBDCreate) (status int, err error) {
+	return c.CreateBlockImageWithContext(context.Background(), rbdCreate)
+}
 
-	if counter > c.MaxIterations {
-		return 0, ErrMaxIterationsExceeded
-	}
+// CreateBlockImageWithContext is like CreateBlockImage but aborts the request and the task wait if ctx is done.
+func (c *Client) CreateBlockImageWithContext(ctx context.Context, rbdCreate RBDCreate) (status int, err error) {
+	err = c.retryTask(ctx, "CreateBlockImage", func(ctx context.Context) error {
+		status, err = c.createBlockImage(ctx, rbdCreate)
+		return err
+	})
 
-	counter++
+	return status, err
+}
+
+// CreateBlockImageAsync is like CreateBlockImageWithContext but returns at once. The TaskFuture reports the progress of
+// the rbd/create task and holds the result once done.
+func (c *Client) CreateBlockImageAsync(ctx context.Context, rbdCreate RBDCreate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.CreateBlockImageWithContext(ctx, rbdCreate)
+	})
+}
+
+// createBlockImage submits the rbd/create task once and waits until it is done.
+func (c *Client) createBlockImage(ctx context.Context, rbdCreate RBDCreate) (status int, err error) {
 
 	var (
-		resp      *resty.Response
-		exception Exception
+		resp *resty.Response
 	)
 
 	// create copy of client
-	client := *c.Session.Client
 
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
+	lookForTask := Task{
+		Name: "rbd/create",
+		MetaData: MetaData{
+			PoolName:  rbdCreate.PoolName,
+			Namespace: rbdCreate.Namespace,
+			ImageName: rbdCreate.Name,
+			ImageSpec: "", // ImageSpec is always empty for rbd/create
+		},
+	}
+
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetBody(rbdCreate).
-		Post(c.Session.Server.getURL("block/image"))
+		SetBody(rbdCreate)
+
+	resp, err = c.execute("CreateBlockImage", req, resty.MethodPost, c.Session.Server.getURL("block/image"))
 
 	if err != nil {
-		return 0, err
+		return 0, ctxErr(ctx, "CreateBlockImage", err)
 	}
 
 	if !resp.IsSuccess() {
-		if resp.StatusCode() == http.StatusBadRequest {
-			err = client.JSONUnmarshal(resp.Body(), &exception)
-			if err == nil {
-				c.Logger.Debugf("err %s (%s)", exception.Code, exception.Detail)
-				if exception.Code == RBDImageAlreadyExists {
-					return resp.StatusCode(), ErrEditImageAlreadyExists
-				}
-			}
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == RBDImageAlreadyExists {
+			apiErr.Err = ErrEditImageAlreadyExists
 		}
 
-		return resp.StatusCode(), fmt.Errorf("could not create image: %v on pool %v: %v ", rbdCreate.Name, rbdCreate.PoolName, resp.Error())
+		return resp.StatusCode(), apiErr
 	}
 
 	status = resp.StatusCode()
@@ -266,28 +362,16 @@ func (c *Client) CreateBlockImage(rbdCreate RBDCreate, counter uint) (status int
 	// check task state
 	switch status {
 	case http.StatusCreated, http.StatusAccepted:
-		lookForTask := Task{
-			Name: "rbd/create",
-			MetaData: MetaData{
-				PoolName:  rbdCreate.PoolName,
-				Namespace: rbdCreate.Namespace,
-				ImageName: rbdCreate.Name,
-				ImageSpec: "", // ImageSpec is always empty for rbd/create
-			},
-		}
-
-		lookForTask, err = c.WaitForTaskIsDone(lookForTask)
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
 		if err != nil {
-			return 0, err
+			return 0, ctxErr(ctx, "CreateBlockImage", err)
 		}
 
 		if !lookForTask.Success {
-			// try to create again
-			c.Logger.Debugf("call CreateBlockImage again with counter %d", counter)
-			return c.CreateBlockImage(rbdCreate, counter)
-		} else {
-			status = http.StatusCreated
+			return 0, newTaskError(lookForTask)
 		}
+
+		status = http.StatusCreated
 	}
 
 	return status, nil
@@ -295,12 +379,30 @@ func (c *Client) CreateBlockImage(rbdCreate RBDCreate, counter uint) (status int
 
 // CopyBlockImage create a copy of existing rbd.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-image_spec-copy.
-func (c *Client) CopyBlockImage(poolName string, nameSpace *string, imageName string, dst RBDCopy, counter uint) (status int, err error) {
-	if counter > c.MaxIterations {
-		return 0, ErrMaxIterationsExceeded
-	}
+func (c *Client) CopyBlockImage(poolName string, nameSpace *string, imageName string, dst RBDCopy) (status int, err error) {
+	return c.CopyBlockImageWithContext(context.Background(), poolName, nameSpace, imageName, dst)
+}
+
+// CopyBlockImageWithContext is like CopyBlockImage but aborts the request and the task wait if ctx is done.
+func (c *Client) CopyBlockImageWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string, dst RBDCopy) (status int, err error) {
+	err = c.retryTask(ctx, "CopyBlockImage", func(ctx context.Context) error {
+		status, err = c.copyBlockImage(ctx, poolName, nameSpace, imageName, dst)
+		return err
+	})
+
+	return status, err
+}
 
-	counter++
+// CopyBlockImageAsync is like CopyBlockImageWithContext but returns at once. The TaskFuture reports the progress of the
+// rbd/copy task and holds the result once done.
+func (c *Client) CopyBlockImageAsync(ctx context.Context, poolName string, nameSpace *string, imageName string, dst RBDCopy) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.CopyBlockImageWithContext(ctx, poolName, nameSpace, imageName, dst)
+	})
+}
+
+// copyBlockImage submits the rbd/copy task once and waits until it is done.
+func (c *Client) copyBlockImage(ctx context.Context, poolName string, nameSpace *string, imageName string, dst RBDCopy) (status int, err error) {
 
 	var resp *resty.Response
 	var imageSpec string
@@ -311,46 +413,49 @@ func (c *Client) CopyBlockImage(poolName string, nameSpace *string, imageName st
 		return 0, err
 	}
 
-	client := *c.Session.Client
+	lookForTask := Task{
+		Name: "rbd/copy",
+		MetaData: MetaData{
+			SrcImageSpec:  imageSpec,
+			DestPoolName:  dst.DestPoolName,
+			DestNamespace: dst.DestNameSpace,
+			DestImageName: dst.DestImageName,
+		},
+	}
 
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetBody(dst).
-		Post(c.Session.Server.getURL(fmt.Sprintf("block/image/%s/copy", url.QueryEscape(imageSpec))))
+		SetBody(dst)
+
+	resp, err = c.execute("CopyBlockImage", req, resty.MethodPost, c.Session.Server.getURL(fmt.Sprintf("block/image/%s/copy", url.QueryEscape(imageSpec))))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "CopyBlockImage", err)
+	}
 
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), newAPIError(resp)
 	}
 
 	status = resp.StatusCode()
 
 	switch status {
 	case http.StatusCreated, http.StatusAccepted:
-		lookForTask := Task{
-			Name: "rbd/copy",
-			MetaData: MetaData{
-				ImageSpec: imageSpec, // only ImageSpec is needed on copy
-			},
-		}
-
-		lookForTask, err = c.WaitForTaskIsDone(lookForTask)
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
 
 		if err != nil {
-			return 0, err
+			return 0, ctxErr(ctx, "CopyBlockImage", err)
 		}
 
 		if !lookForTask.Success {
-			// try delete again...
-			c.Logger.Debugf("calling CopyBlockImage with counter %d", counter)
-			return c.CopyBlockImage(poolName, nameSpace, imageName, dst, counter)
-		} else {
-			status = http.StatusNoContent
-			err = nil
+			return 0, newTaskError(lookForTask)
 		}
+
+		status = http.StatusNoContent
+		err = nil
 	}
 
 	return status, err
@@ -358,13 +463,30 @@ func (c *Client) CopyBlockImage(poolName string, nameSpace *string, imageName st
 
 // DeleteBlockImage deletes an RBD image defined with imageSpec
 // (https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-block-image-image_spec)
-func (c *Client) DeleteBlockImage(poolName string, nameSpace *string, imageName string, counter uint) (status int, err error) {
+func (c *Client) DeleteBlockImage(poolName string, nameSpace *string, imageName string) (status int, err error) {
+	return c.DeleteBlockImageWithContext(context.Background(), poolName, nameSpace, imageName)
+}
 
-	if counter > c.MaxIterations {
-		return 0, ErrMaxIterationsExceeded
-	}
+// DeleteBlockImageWithContext is like DeleteBlockImage but aborts the request and the task wait if ctx is done.
+func (c *Client) DeleteBlockImageWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string) (status int, err error) {
+	err = c.retryTask(ctx, "DeleteBlockImage", func(ctx context.Context) error {
+		status, err = c.deleteBlockImage(ctx, poolName, nameSpace, imageName)
+		return err
+	})
 
-	counter++
+	return status, err
+}
+
+// DeleteBlockImageAsync is like DeleteBlockImageWithContext but returns at once. The TaskFuture reports the progress of
+// the rbd/delete task and holds the result once done.
+func (c *Client) DeleteBlockImageAsync(ctx context.Context, poolName string, nameSpace *string, imageName string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.DeleteBlockImageWithContext(ctx, poolName, nameSpace, imageName)
+	})
+}
+
+// deleteBlockImage submits the rbd/delete task once and waits until it is done.
+func (c *Client) deleteBlockImage(ctx context.Context, poolName string, nameSpace *string, imageName string) (status int, err error) {
 
 	var (
 		resp      *resty.Response
@@ -376,45 +498,45 @@ func (c *Client) DeleteBlockImage(poolName string, nameSpace *string, imageName
 		return 0, err
 	}
 
-	client := *c.Session.Client
+	lookForTask := Task{
+		Name: "rbd/delete",
+		MetaData: MetaData{
+			ImageSpec: imageSpec, // only ImageSpec is needed on delete
+		},
+	}
 
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
-		SetHeaders(defaultHeaderJson).
-		Delete(c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))))
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	resp, err = c.execute("DeleteBlockImage", req, resty.MethodDelete, c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "DeleteBlockImage", err)
+	}
 
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), newAPIError(resp)
 	}
 
 	status = resp.StatusCode()
 
 	switch status {
 	case http.StatusAccepted, http.StatusNoContent, http.StatusBadRequest:
-		lookForTask := Task{
-			Name: "rbd/delete",
-			MetaData: MetaData{
-				ImageSpec: imageSpec, // only ImageSpec is needed on delete
-			},
-		}
-
-		lookForTask, err = c.WaitForTaskIsDone(lookForTask)
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
 
 		if err != nil {
-			return 0, err
+			return 0, ctxErr(ctx, "DeleteBlockImage", err)
 		}
 
 		if !lookForTask.Success {
-			// try delete again...
-			c.Logger.Debugf("calling DeleteBlockImage with counter %d", counter)
-			return c.DeleteBlockImage(poolName, nameSpace, imageName, counter)
-		} else {
-			status = http.StatusNoContent
-			err = nil
+			return 0, newTaskError(lookForTask)
 		}
+
+		status = http.StatusNoContent
+		err = nil
 	}
 
 	return status, err
@@ -424,17 +546,35 @@ func (c *Client) DeleteBlockImage(poolName string, nameSpace *string, imageName
 // --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-image_spec-move_trash
 // Attention: the documentation claims the response code on moving an image to the trash returns 201. But at least on
 // ceph version 16.2.7 (f9aa029788115b5df5eeee328f584156565ee5b7) pacific (stable) 200 is returned.
-func (c *Client) MoveBlockImageToTrash(poolName string, nameSpace *string, imageName string, delay time.Duration, counter uint) (status int, err error) {
-	if counter > c.MaxIterations {
-		return 0, ErrMaxIterationsExceeded
-	}
+func (c *Client) MoveBlockImageToTrash(poolName string, nameSpace *string, imageName string, delay time.Duration) (status int, err error) {
+	return c.MoveBlockImageToTrashWithContext(context.Background(), poolName, nameSpace, imageName, delay)
+}
+
+// MoveBlockImageToTrashWithContext is like MoveBlockImageToTrash but aborts the request and the task wait if ctx is
+// done.
+func (c *Client) MoveBlockImageToTrashWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string, delay time.Duration) (status int, err error) {
+	err = c.retryTask(ctx, "MoveBlockImageToTrash", func(ctx context.Context) error {
+		status, err = c.moveBlockImageToTrash(ctx, poolName, nameSpace, imageName, delay)
+		return err
+	})
+
+	return status, err
+}
 
-	counter++
+// MoveBlockImageToTrashAsync is like MoveBlockImageToTrashWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/trash/move task and holds the result once done.
+func (c *Client) MoveBlockImageToTrashAsync(ctx context.Context, poolName string, nameSpace *string, imageName string, delay time.Duration) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.MoveBlockImageToTrashWithContext(ctx, poolName, nameSpace, imageName, delay)
+	})
+}
+
+// moveBlockImageToTrash submits the rbd/trash/move task once and waits until it is done.
+func (c *Client) moveBlockImageToTrash(ctx context.Context, poolName string, nameSpace *string, imageName string, delay time.Duration) (status int, err error) {
 
 	var (
 		resp      *resty.Response
 		imageSpec string
-		exception Exception
 	)
 
 	imageSpec, err = CreateImageSpec(poolName, nameSpace, imageName)
@@ -443,60 +583,57 @@ func (c *Client) MoveBlockImageToTrash(poolName string, nameSpace *string, image
 		return 0, err
 	}
 
-	client := *c.Session.Client
-
 	delayPost := struct {
 		Delay float64 `json:"delay"`
 	}{Delay: delay.Seconds()}
 
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
+	lookForTask := Task{
+		Name: "rbd/trash/move",
+		MetaData: MetaData{
+			ImageSpec: imageSpec, // only ImageSpec is needed on delete
+		},
+	}
+
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetBody(delayPost).
-		Post(c.Session.Server.getURL(fmt.Sprintf("block/image/%s/move_trash", url.QueryEscape(imageSpec))))
+		SetBody(delayPost)
+
+	resp, err = c.execute("MoveBlockImageToTrash", req, resty.MethodPost, c.Session.Server.getURL(fmt.Sprintf("block/image/%s/move_trash", url.QueryEscape(imageSpec))))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "MoveBlockImageToTrash", err)
+	}
 
 	if !resp.IsSuccess() {
-		if resp.StatusCode() == http.StatusBadRequest {
-			err = client.JSONUnmarshal(resp.Body(), &exception)
-			if err == nil {
-				c.Logger.Debugf("err %s (%s)", exception.Code, exception.Detail)
-				if exception.Code == RBDImageAlreadyExists {
-					return resp.StatusCode(), ErrCreateImageAlreadyExists
-				}
-			}
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == RBDImageAlreadyExists {
+			apiErr.Err = ErrCreateImageAlreadyExists
 		}
 
-		return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), apiErr
 	}
 
 	status = resp.StatusCode()
 
 	switch status {
 	case http.StatusOK, http.StatusCreated, http.StatusBadRequest:
-		lookForTask := Task{
-			Name: "rbd/trash/move",
-			MetaData: MetaData{
-				ImageSpec: imageSpec, // only ImageSpec is needed on delete
-			},
-		}
-
-		lookForTask, err = c.WaitForTaskIsDone(lookForTask)
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
 
 		if err != nil {
-			return 0, err
+			return 0, ctxErr(ctx, "MoveBlockImageToTrash", err)
 		}
 
 		if !lookForTask.Success {
-			// try delete again...
-			c.Logger.Debugf("calling DeleteBlockImage with counter %d", counter)
-			return c.MoveBlockImageToTrash(poolName, nameSpace, imageName, delay, counter)
-		} else {
-			status = http.StatusOK
-			err = nil
+			return 0, newTaskError(lookForTask)
 		}
+
+		status = http.StatusOK
+		err = nil
 	}
 
 	return status, err
@@ -504,17 +641,34 @@ func (c *Client) MoveBlockImageToTrash(poolName string, nameSpace *string, image
 
 // UpdateBlockImage updates ceph rbd image (name, size etc al).
 // --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-image-image_spec
-func (c *Client) UpdateBlockImage(poolName string, nameSpace *string, imageName string, rbdUpdate RBDUpdate, counter uint) (status int, err error) {
-	if counter > c.MaxIterations {
-		return 0, ErrMaxIterationsExceeded
-	}
+func (c *Client) UpdateBlockImage(poolName string, nameSpace *string, imageName string, rbdUpdate RBDUpdate) (status int, err error) {
+	return c.UpdateBlockImageWithContext(context.Background(), poolName, nameSpace, imageName, rbdUpdate)
+}
 
-	counter++
+// UpdateBlockImageWithContext is like UpdateBlockImage but aborts the request and the task wait if ctx is done.
+func (c *Client) UpdateBlockImageWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string, rbdUpdate RBDUpdate) (status int, err error) {
+	err = c.retryTask(ctx, "UpdateBlockImage", func(ctx context.Context) error {
+		status, err = c.updateBlockImage(ctx, poolName, nameSpace, imageName, rbdUpdate)
+		return err
+	})
+
+	return status, err
+}
+
+// UpdateBlockImageAsync is like UpdateBlockImageWithContext but returns at once. The TaskFuture reports the progress of
+// the rbd/edit task and holds the result once done.
+func (c *Client) UpdateBlockImageAsync(ctx context.Context, poolName string, nameSpace *string, imageName string, rbdUpdate RBDUpdate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.UpdateBlockImageWithContext(ctx, poolName, nameSpace, imageName, rbdUpdate)
+	})
+}
+
+// updateBlockImage submits the rbd/edit task once and waits until it is done.
+func (c *Client) updateBlockImage(ctx context.Context, poolName string, nameSpace *string, imageName string, rbdUpdate RBDUpdate) (status int, err error) {
 
 	var (
 		resp      *resty.Response
 		imageSpec string
-		exception Exception
 	)
 
 	// check rbdUpdate
@@ -529,75 +683,105 @@ func (c *Client) UpdateBlockImage(poolName string, nameSpace *string, imageName
 		return 0, err
 	}
 
-	client := *c.Session.Client
+	lookForTask := Task{
+		Name: "rbd/edit",
+		MetaData: MetaData{
+			ImageSpec: imageSpec, // only ImageSpec is needed on delete
+		},
+	}
+
+	ctx = withTaskLogFields(ctx, lookForTask)
 
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetBody(rbdUpdate).
-		Put(c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))))
+		SetBody(rbdUpdate)
+
+	resp, err = c.execute("UpdateBlockImage", req, resty.MethodPut, c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "UpdateBlockImage", err)
+	}
 
 	if !resp.IsSuccess() {
-		if resp.StatusCode() == http.StatusBadRequest {
-			err = client.JSONUnmarshal(resp.Body(), &exception)
-			if err == nil {
-				c.Logger.Debugf("err %s (%s)", exception.Code, exception.Detail)
-				if exception.Code == "17" {
-					return resp.StatusCode(), ErrCreateImageAlreadyExists
-				}
-			}
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == ErrnoAlreadyExists {
+			apiErr.Err = ErrCreateImageAlreadyExists
 		}
 
-		return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), apiErr
 	}
 
 	status = resp.StatusCode()
 
 	switch status {
 	case http.StatusAccepted, http.StatusOK, http.StatusBadRequest:
-		lookForTask := Task{
-			Name: "rbd/edit",
-			MetaData: MetaData{
-				ImageSpec: imageSpec, // only ImageSpec is needed on delete
-			},
-		}
-
-		lookForTask, err = c.WaitForTaskIsDone(lookForTask)
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
 
 		if err != nil {
-			return 0, err
+			return 0, ctxErr(ctx, "UpdateBlockImage", err)
 		}
 
 		if !lookForTask.Success {
-			// try delete again...
-			c.Logger.Debugf("calling DeleteBlockImage with counter %d", counter)
-			return c.UpdateBlockImage(poolName, nameSpace, imageName, rbdUpdate, counter)
-		} else {
-			status = http.StatusOK
-			err = nil
+			return 0, newTaskError(lookForTask)
 		}
+
+		status = http.StatusOK
+		err = nil
 	}
 
 	return status, err
 
 }
 
-func (c *Client) retryConditionCheckForAccepted(r *resty.Response, _ error) bool {
-	switch r.StatusCode() {
-	case http.StatusOK,
-		http.StatusNoContent,
-		http.StatusNotFound,
-		http.StatusCreated,
-		http.StatusAccepted,
-		http.StatusBadRequest:
-		// no retry needed
-		c.Logger.Debugf("http status: %d --> no retry for %s", r.StatusCode(), r.Request.URL)
-		return false
+// SetBlockImageQos sets the qos limits of an rbd image. Limits of 0 are unlimited.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-image-image_spec
+func (c *Client) SetBlockImageQos(poolName string, nameSpace *string, imageName string, qos RBDQosConfig) (status int, err error) {
+	return c.SetBlockImageQosWithContext(context.Background(), poolName, nameSpace, imageName, qos)
+}
+
+// SetBlockImageQosWithContext is like SetBlockImageQos but aborts the request and the task wait if ctx is done.
+func (c *Client) SetBlockImageQosWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string, qos RBDQosConfig) (status int, err error) {
+	err = c.retryTask(ctx, "SetBlockImageQos", func(ctx context.Context) error {
+		status, err = c.setBlockImageQos(ctx, poolName, nameSpace, imageName, qos)
+		return err
+	})
+
+	return status, err
+}
+
+// SetBlockImageQosAsync is like SetBlockImageQosWithContext but returns at once. The TaskFuture reports the progress of
+// the rbd/edit task and holds the result once done.
+func (c *Client) SetBlockImageQosAsync(ctx context.Context, poolName string, nameSpace *string, imageName string, qos RBDQosConfig) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.SetBlockImageQosWithContext(ctx, poolName, nameSpace, imageName, qos)
+	})
+}
+
+// setBlockImageQos submits the rbd/edit task once and waits until it is done.
+func (c *Client) setBlockImageQos(ctx context.Context, poolName string, nameSpace *string, imageName string, qos RBDQosConfig) (status int, err error) {
+	imageSpec, err := CreateImageSpec(poolName, nameSpace, imageName)
+
+	if err != nil {
+		return 0, err
+	}
+
+	lookForTask := Task{
+		Name: "rbd/edit",
+		MetaData: MetaData{
+			ImageSpec: imageSpec,
+		},
 	}
 
-	c.Logger.Debugf("http status: %d --> retry for %s needed", r.StatusCode(), r.Request.URL)
-	return true // retry on all other status codes.
+	body := struct {
+		Configuration RBDQosConfig `json:"configuration"`
+	}{Configuration: qos}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(body)
+
+	return c.submitTask(ctx, "SetBlockImageQos", req, resty.MethodPut, c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))), lookForTask, http.StatusOK)
 }
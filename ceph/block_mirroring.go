@@ -0,0 +1,617 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrPeerUUIDIsEmpty is returned if param peerUUID is empty.
+	ErrPeerUUIDIsEmpty = errors.New("param peerUUID can not be empty")
+
+	// ErrMirroringPeerNotFound is returned by AddMirroringPeer if the peer added can not be found afterwards.
+	ErrMirroringPeerNotFound = errors.New("rbd mirroring peer not found")
+)
+
+// mirror modes of a pool
+const (
+	MirrorPoolModeDisabled = "disabled"
+	MirrorPoolModeImage    = "image"
+	MirrorPoolModePool     = "pool"
+)
+
+// mirror modes of an image: journal replays the journal of the image, snapshot replicates mirror snapshots taken by
+// the snapshot schedule.
+const (
+	MirrorImageModeJournal  = "journal"
+	MirrorImageModeSnapshot = "snapshot"
+)
+
+// directions of a peer imported by a bootstrap token
+const (
+	MirrorDirectionRX   = "rx"
+	MirrorDirectionRXTX = "rx-tx"
+)
+
+// MirroringPeer implements struct returned from GET /api/block/mirroring/pool/{pool_name}/peer/{peer_uuid} and send
+// to ceph to add or change a peer. ClusterName is the site name of the peer cluster, MonHost and Key are only needed
+// if the peer cluster is not configured on the mgr host.
+type MirroringPeer struct {
+	UUID        string `json:"uuid,omitempty"`
+	ClusterName string `json:"cluster_name"`
+	ClientID    string `json:"client_id"`
+	MonHost     string `json:"mon_host,omitempty"`
+	Key         string `json:"key,omitempty"`
+}
+
+// MirroringDaemon implements a rbd-mirror daemon of the mirroring summary.
+type MirroringDaemon struct {
+	ID             string `json:"id"`
+	InstanceID     string `json:"instance_id"`
+	Version        string `json:"version"`
+	ServerHostname string `json:"server_hostname"`
+	ClientID       string `json:"client_id"`
+	Leader         bool   `json:"leader"`
+	Health         string `json:"health"`
+	HealthColor    string `json:"health_color"`
+}
+
+// MirroringPool implements the mirroring state of a pool in the mirroring summary.
+type MirroringPool struct {
+	Name        string   `json:"name"`
+	MirrorMode  string   `json:"mirror_mode"`
+	Health      string   `json:"health"`
+	HealthColor string   `json:"health_color"`
+	PeerUUIDs   []string `json:"peer_uuids"`
+}
+
+// MirroringImage implements the replication state of a mirrored image in the mirroring summary. Progress is only set
+// for syncing images.
+type MirroringImage struct {
+	PoolName    string `json:"pool_name"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	Progress    int    `json:"progress,omitempty"`
+}
+
+// MirroringContent implements the daemons, pools and images of the mirroring summary, the images grouped by their
+// replication state.
+type MirroringContent struct {
+	Daemons      []MirroringDaemon `json:"daemons"`
+	Pools        []MirroringPool   `json:"pools"`
+	ImageError   []MirroringImage  `json:"image_error"`
+	ImageSyncing []MirroringImage  `json:"image_syncing"`
+	ImageReady   []MirroringImage  `json:"image_ready"`
+}
+
+// MirroringSummary implements struct returned from GET /api/block/mirroring/summary.
+type MirroringSummary struct {
+	SiteName    string           `json:"site_name"`
+	Status      int              `json:"status"`
+	ContentData MirroringContent `json:"content_data"`
+}
+
+// RBDMirroringUpdate implements the mirroring settings of an image send to ceph on PUT /api/block/image/{image_spec}.
+// ScheduleInterval (e.g. 1h or 30m) adds a mirror snapshot schedule to images in MirrorImageModeSnapshot.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-image-image_spec
+type RBDMirroringUpdate struct {
+	EnableMirror     *bool  `json:"enable_mirror,omitempty"`
+	MirrorMode       string `json:"mirror_mode,omitempty"`
+	Primary          *bool  `json:"primary,omitempty"`
+	Resync           bool   `json:"resync,omitempty"`
+	ScheduleInterval string `json:"schedule_interval,omitempty"`
+	RemoveScheduling bool   `json:"remove_scheduling,omitempty"`
+}
+
+// mirroringSiteName implements struct returned from and send to /api/block/mirroring/site_name.
+type mirroringSiteName struct {
+	SiteName string `json:"site_name"`
+}
+
+// mirroringPoolMode implements struct returned from and send to /api/block/mirroring/pool/{pool_name}.
+type mirroringPoolMode struct {
+	MirrorMode string `json:"mirror_mode"`
+}
+
+// mirroringToken implements struct returned from POST /api/block/mirroring/pool/{pool_name}/bootstrap/token and
+// send to POST /api/block/mirroring/pool/{pool_name}/bootstrap/peer.
+type mirroringToken struct {
+	Direction string `json:"direction,omitempty"`
+	Token     string `json:"token"`
+}
+
+// GetMirroringSiteName gets the site name of the cluster, used as cluster name by peers. It defaults to the fsid.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-mirroring-site_name
+func (c *Client) GetMirroringSiteName() (status int, siteName string, err error) {
+	return c.GetMirroringSiteNameWithContext(context.Background())
+}
+
+// GetMirroringSiteNameWithContext is like GetMirroringSiteName but aborts the request if ctx is done.
+func (c *Client) GetMirroringSiteNameWithContext(ctx context.Context) (status int, siteName string, err error) {
+	var result mirroringSiteName
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&result)
+
+	status, err = c.send(ctx, "GetMirroringSiteName", req, resty.MethodGet, "block/mirroring/site_name")
+
+	return status, result.SiteName, err
+}
+
+// SetMirroringSiteName sets the site name of the cluster. Set it before creating bootstrap tokens.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-mirroring-site_name
+func (c *Client) SetMirroringSiteName(siteName string) (status int, err error) {
+	return c.SetMirroringSiteNameWithContext(context.Background(), siteName)
+}
+
+// SetMirroringSiteNameWithContext is like SetMirroringSiteName but aborts the request if ctx is done.
+func (c *Client) SetMirroringSiteNameWithContext(ctx context.Context, siteName string) (status int, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(mirroringSiteName{SiteName: siteName})
+
+	return c.send(ctx, "SetMirroringSiteName", req, resty.MethodPut, "block/mirroring/site_name")
+}
+
+// GetMirroringPoolMode gets the mirror mode of a pool, one of MirrorPoolModeDisabled, MirrorPoolModeImage or
+// MirrorPoolModePool.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-mirroring-pool-pool_name
+func (c *Client) GetMirroringPoolMode(poolName string) (status int, mirrorMode string, err error) {
+	return c.GetMirroringPoolModeWithContext(context.Background(), poolName)
+}
+
+// GetMirroringPoolModeWithContext is like GetMirroringPoolMode but aborts the request if ctx is done.
+func (c *Client) GetMirroringPoolModeWithContext(ctx context.Context, poolName string) (status int, mirrorMode string, err error) {
+	var result mirroringPoolMode
+
+	if poolName == "" {
+		return 0, mirrorMode, ErrPoolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&result)
+
+	status, err = c.send(ctx, "GetMirroringPoolMode", req, resty.MethodGet, mirroringPoolPath(poolName))
+
+	return status, result.MirrorMode, err
+}
+
+// SetMirroringPoolMode sets the mirror mode of a pool: MirrorPoolModeImage mirrors the images mirroring is enabled
+// for, MirrorPoolModePool all images with the journaling feature.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-mirroring-pool-pool_name
+func (c *Client) SetMirroringPoolMode(poolName, mirrorMode string) (status int, err error) {
+	return c.SetMirroringPoolModeWithContext(context.Background(), poolName, mirrorMode)
+}
+
+// SetMirroringPoolModeWithContext is like SetMirroringPoolMode but aborts the request and the task wait if ctx is
+// done.
+func (c *Client) SetMirroringPoolModeWithContext(ctx context.Context, poolName, mirrorMode string) (status int, err error) {
+	err = c.retryTask(ctx, "SetMirroringPoolMode", func(ctx context.Context) error {
+		status, err = c.setMirroringPoolMode(ctx, poolName, mirrorMode)
+		return err
+	})
+
+	return status, err
+}
+
+// SetMirroringPoolModeAsync is like SetMirroringPoolModeWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/mirroring/pool/edit task and holds the result once done.
+func (c *Client) SetMirroringPoolModeAsync(ctx context.Context, poolName, mirrorMode string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.SetMirroringPoolModeWithContext(ctx, poolName, mirrorMode)
+	})
+}
+
+// setMirroringPoolMode submits the rbd/mirroring/pool/edit task once and waits until it is done.
+func (c *Client) setMirroringPoolMode(ctx context.Context, poolName, mirrorMode string) (status int, err error) {
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	lookForTask := Task{
+		Name: "rbd/mirroring/pool/edit",
+		MetaData: MetaData{
+			PoolName: poolName,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(mirroringPoolMode{MirrorMode: mirrorMode})
+
+	return c.submitTask(ctx, "SetMirroringPoolMode", req, resty.MethodPut, c.Session.Server.getURL(mirroringPoolPath(poolName)), lookForTask, http.StatusOK)
+}
+
+// CreateMirroringBootstrapToken creates a token granting a peer cluster access to the pool. Import it on the peer
+// cluster with ImportMirroringBootstrapToken.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-mirroring-pool-pool_name-bootstrap-token
+func (c *Client) CreateMirroringBootstrapToken(poolName string) (status int, token string, err error) {
+	return c.CreateMirroringBootstrapTokenWithContext(context.Background(), poolName)
+}
+
+// CreateMirroringBootstrapTokenWithContext is like CreateMirroringBootstrapToken but aborts the request if ctx is
+// done.
+func (c *Client) CreateMirroringBootstrapTokenWithContext(ctx context.Context, poolName string) (status int, token string, err error) {
+	var result mirroringToken
+
+	if poolName == "" {
+		return 0, token, ErrPoolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&result)
+
+	status, err = c.send(ctx, "CreateMirroringBootstrapToken", req, resty.MethodPost, mirroringPoolPath(poolName, "bootstrap", "token"))
+
+	return status, result.Token, err
+}
+
+// ImportMirroringBootstrapToken adds the cluster which created token (see CreateMirroringBootstrapToken) as peer of
+// the pool. direction is MirrorDirectionRX to only receive images or MirrorDirectionRXTX to mirror both ways.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-mirroring-pool-pool_name-bootstrap-peer
+func (c *Client) ImportMirroringBootstrapToken(poolName, direction, token string) (status int, err error) {
+	return c.ImportMirroringBootstrapTokenWithContext(context.Background(), poolName, direction, token)
+}
+
+// ImportMirroringBootstrapTokenWithContext is like ImportMirroringBootstrapToken but aborts the request if ctx is
+// done.
+func (c *Client) ImportMirroringBootstrapTokenWithContext(ctx context.Context, poolName, direction, token string) (status int, err error) {
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(mirroringToken{Direction: direction, Token: token})
+
+	return c.send(ctx, "ImportMirroringBootstrapToken", req, resty.MethodPost, mirroringPoolPath(poolName, "bootstrap", "peer"))
+}
+
+// ListMirroringPeers gets the uuids of the peers of a pool.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-mirroring-pool-pool_name-peer
+func (c *Client) ListMirroringPeers(poolName string) (status int, peerUUIDs []string, err error) {
+	return c.ListMirroringPeersWithContext(context.Background(), poolName)
+}
+
+// ListMirroringPeersWithContext is like ListMirroringPeers but aborts the request if ctx is done.
+func (c *Client) ListMirroringPeersWithContext(ctx context.Context, poolName string) (status int, peerUUIDs []string, err error) {
+	if poolName == "" {
+		return 0, peerUUIDs, ErrPoolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&peerUUIDs)
+
+	status, err = c.send(ctx, "ListMirroringPeers", req, resty.MethodGet, mirroringPoolPath(poolName, "peer"))
+
+	return status, peerUUIDs, err
+}
+
+// GetMirroringPeer gets a peer of a pool by uuid.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-mirroring-pool-pool_name-peer-peer_uuid
+func (c *Client) GetMirroringPeer(poolName, peerUUID string) (status int, peer MirroringPeer, err error) {
+	return c.GetMirroringPeerWithContext(context.Background(), poolName, peerUUID)
+}
+
+// GetMirroringPeerWithContext is like GetMirroringPeer but aborts the request if ctx is done.
+func (c *Client) GetMirroringPeerWithContext(ctx context.Context, poolName, peerUUID string) (status int, peer MirroringPeer, err error) {
+	if poolName == "" {
+		return 0, peer, ErrPoolNameIsEmpty
+	}
+
+	if peerUUID == "" {
+		return 0, peer, ErrPeerUUIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&peer)
+
+	status, err = c.send(ctx, "GetMirroringPeer", req, resty.MethodGet, mirroringPoolPath(poolName, "peer", peerUUID))
+
+	if err == nil && peer.UUID == "" {
+		peer.UUID = peerUUID
+	}
+
+	return status, peer, err
+}
+
+// AddMirroringPeer adds a peer to a pool and returns it with the uuid assigned by ceph.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-mirroring-pool-pool_name-peer
+func (c *Client) AddMirroringPeer(poolName string, peerAdd MirroringPeer) (status int, peer MirroringPeer, err error) {
+	return c.AddMirroringPeerWithContext(context.Background(), poolName, peerAdd)
+}
+
+// AddMirroringPeerWithContext is like AddMirroringPeer but aborts the requests and the task wait if ctx is done.
+func (c *Client) AddMirroringPeerWithContext(ctx context.Context, poolName string, peerAdd MirroringPeer) (status int, peer MirroringPeer, err error) {
+	err = c.retryTask(ctx, "AddMirroringPeer", func(ctx context.Context) error {
+		status, err = c.addMirroringPeer(ctx, poolName, peerAdd)
+		return err
+	})
+
+	if err != nil {
+		return status, peer, err
+	}
+
+	// the uuid is assigned by ceph, cluster name and client id identify the peer as well
+	_, uuids, err := c.ListMirroringPeersWithContext(ctx, poolName)
+	if err != nil {
+		return status, peer, err
+	}
+
+	for _, uuid := range uuids {
+		_, p, err := c.GetMirroringPeerWithContext(ctx, poolName, uuid)
+		if err != nil {
+			return status, peer, err
+		}
+
+		if p.ClusterName == peerAdd.ClusterName && p.ClientID == peerAdd.ClientID {
+			return status, p, nil
+		}
+	}
+
+	return status, peer, fmt.Errorf("%w: %s %s", ErrMirroringPeerNotFound, poolName, peerAdd.ClusterName)
+}
+
+// AddMirroringPeerAsync is like AddMirroringPeerWithContext but returns at once. The TaskFuture reports the progress
+// of the rbd/mirroring/peer/add task and holds the result once done, get the peer with ListMirroringPeers.
+func (c *Client) AddMirroringPeerAsync(ctx context.Context, poolName string, peerAdd MirroringPeer) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		status, _, err := c.AddMirroringPeerWithContext(ctx, poolName, peerAdd)
+		return status, err
+	})
+}
+
+// addMirroringPeer submits the rbd/mirroring/peer/add task once and waits until it is done.
+func (c *Client) addMirroringPeer(ctx context.Context, poolName string, peerAdd MirroringPeer) (status int, err error) {
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	peerAdd.UUID = ""
+
+	lookForTask := Task{
+		Name: "rbd/mirroring/peer/add",
+		MetaData: MetaData{
+			PoolName: poolName,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(peerAdd)
+
+	return c.submitTask(ctx, "AddMirroringPeer", req, resty.MethodPost, c.Session.Server.getURL(mirroringPoolPath(poolName, "peer")), lookForTask, http.StatusCreated)
+}
+
+// UpdateMirroringPeer changes the cluster name, client id, mon host or key of a peer.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-mirroring-pool-pool_name-peer-peer_uuid
+func (c *Client) UpdateMirroringPeer(poolName, peerUUID string, peerUpdate MirroringPeer) (status int, err error) {
+	return c.UpdateMirroringPeerWithContext(context.Background(), poolName, peerUUID, peerUpdate)
+}
+
+// UpdateMirroringPeerWithContext is like UpdateMirroringPeer but aborts the request and the task wait if ctx is done.
+func (c *Client) UpdateMirroringPeerWithContext(ctx context.Context, poolName, peerUUID string, peerUpdate MirroringPeer) (status int, err error) {
+	err = c.retryTask(ctx, "UpdateMirroringPeer", func(ctx context.Context) error {
+		status, err = c.updateMirroringPeer(ctx, poolName, peerUUID, peerUpdate)
+		return err
+	})
+
+	return status, err
+}
+
+// UpdateMirroringPeerAsync is like UpdateMirroringPeerWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/mirroring/peer/edit task and holds the result once done.
+func (c *Client) UpdateMirroringPeerAsync(ctx context.Context, poolName, peerUUID string, peerUpdate MirroringPeer) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.UpdateMirroringPeerWithContext(ctx, poolName, peerUUID, peerUpdate)
+	})
+}
+
+// updateMirroringPeer submits the rbd/mirroring/peer/edit task once and waits until it is done.
+func (c *Client) updateMirroringPeer(ctx context.Context, poolName, peerUUID string, peerUpdate MirroringPeer) (status int, err error) {
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	if peerUUID == "" {
+		return 0, ErrPeerUUIDIsEmpty
+	}
+
+	peerUpdate.UUID = ""
+
+	lookForTask := Task{
+		Name: "rbd/mirroring/peer/edit",
+		MetaData: MetaData{
+			PoolName: poolName,
+			PeerUUID: peerUUID,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(peerUpdate)
+
+	return c.submitTask(ctx, "UpdateMirroringPeer", req, resty.MethodPut, c.Session.Server.getURL(mirroringPoolPath(poolName, "peer", peerUUID)), lookForTask, http.StatusOK)
+}
+
+// RemoveMirroringPeer removes a peer from a pool.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-block-mirroring-pool-pool_name-peer-peer_uuid
+func (c *Client) RemoveMirroringPeer(poolName, peerUUID string) (status int, err error) {
+	return c.RemoveMirroringPeerWithContext(context.Background(), poolName, peerUUID)
+}
+
+// RemoveMirroringPeerWithContext is like RemoveMirroringPeer but aborts the request and the task wait if ctx is done.
+func (c *Client) RemoveMirroringPeerWithContext(ctx context.Context, poolName, peerUUID string) (status int, err error) {
+	err = c.retryTask(ctx, "RemoveMirroringPeer", func(ctx context.Context) error {
+		status, err = c.removeMirroringPeer(ctx, poolName, peerUUID)
+		return err
+	})
+
+	return status, err
+}
+
+// RemoveMirroringPeerAsync is like RemoveMirroringPeerWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/mirroring/peer/delete task and holds the result once done.
+func (c *Client) RemoveMirroringPeerAsync(ctx context.Context, poolName, peerUUID string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.RemoveMirroringPeerWithContext(ctx, poolName, peerUUID)
+	})
+}
+
+// removeMirroringPeer submits the rbd/mirroring/peer/delete task once and waits until it is done.
+func (c *Client) removeMirroringPeer(ctx context.Context, poolName, peerUUID string) (status int, err error) {
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	if peerUUID == "" {
+		return 0, ErrPeerUUIDIsEmpty
+	}
+
+	lookForTask := Task{
+		Name: "rbd/mirroring/peer/delete",
+		MetaData: MetaData{
+			PoolName: poolName,
+			PeerUUID: peerUUID,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson)
+
+	return c.submitTask(ctx, "RemoveMirroringPeer", req, resty.MethodDelete, c.Session.Server.getURL(mirroringPoolPath(poolName, "peer", peerUUID)), lookForTask, http.StatusNoContent)
+}
+
+// GetMirroringSummary gets the rbd-mirror daemons, the mirroring pools and the replication state of the mirrored
+// images.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-mirroring-summary
+func (c *Client) GetMirroringSummary() (status int, summary MirroringSummary, err error) {
+	return c.GetMirroringSummaryWithContext(context.Background())
+}
+
+// GetMirroringSummaryWithContext is like GetMirroringSummary but aborts the request if ctx is done.
+func (c *Client) GetMirroringSummaryWithContext(ctx context.Context) (status int, summary MirroringSummary, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&summary)
+
+	status, err = c.send(ctx, "GetMirroringSummary", req, resty.MethodGet, "block/mirroring/summary")
+
+	return status, summary, err
+}
+
+// UpdateImageMirroring changes the mirroring settings of an image, e.g. to promote or demote it on failover.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-image-image_spec
+func (c *Client) UpdateImageMirroring(poolName string, nameSpace *string, imageName string, mirroringUpdate RBDMirroringUpdate) (status int, err error) {
+	return c.UpdateImageMirroringWithContext(context.Background(), poolName, nameSpace, imageName, mirroringUpdate)
+}
+
+// UpdateImageMirroringWithContext is like UpdateImageMirroring but aborts the request and the task wait if ctx is
+// done.
+func (c *Client) UpdateImageMirroringWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string, mirroringUpdate RBDMirroringUpdate) (status int, err error) {
+	err = c.retryTask(ctx, "UpdateImageMirroring", func(ctx context.Context) error {
+		status, err = c.updateImageMirroring(ctx, poolName, nameSpace, imageName, mirroringUpdate)
+		return err
+	})
+
+	return status, err
+}
+
+// UpdateImageMirroringAsync is like UpdateImageMirroringWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/edit task and holds the result once done.
+func (c *Client) UpdateImageMirroringAsync(ctx context.Context, poolName string, nameSpace *string, imageName string, mirroringUpdate RBDMirroringUpdate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.UpdateImageMirroringWithContext(ctx, poolName, nameSpace, imageName, mirroringUpdate)
+	})
+}
+
+// updateImageMirroring submits the rbd/edit task once and waits until it is done.
+func (c *Client) updateImageMirroring(ctx context.Context, poolName string, nameSpace *string, imageName string, mirroringUpdate RBDMirroringUpdate) (status int, err error) {
+	imageSpec, err := CreateImageSpec(poolName, nameSpace, imageName)
+
+	if err != nil {
+		return 0, err
+	}
+
+	lookForTask := Task{
+		Name: "rbd/edit",
+		MetaData: MetaData{
+			ImageSpec: imageSpec,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(mirroringUpdate)
+
+	return c.submitTask(ctx, "UpdateImageMirroring", req, resty.MethodPut, c.Session.Server.getURL(fmt.Sprintf("block/image/%s", url.QueryEscape(imageSpec))), lookForTask, http.StatusOK)
+}
+
+// EnableImageMirroring enables mirroring of an image in a pool in MirrorPoolModeImage with mirrorMode
+// MirrorImageModeJournal or MirrorImageModeSnapshot.
+func (c *Client) EnableImageMirroring(poolName string, nameSpace *string, imageName, mirrorMode string) (status int, err error) {
+	return c.EnableImageMirroringWithContext(context.Background(), poolName, nameSpace, imageName, mirrorMode)
+}
+
+// EnableImageMirroringWithContext is like EnableImageMirroring but aborts the request and the task wait if ctx is
+// done.
+func (c *Client) EnableImageMirroringWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, mirrorMode string) (status int, err error) {
+	enable := true
+
+	return c.UpdateImageMirroringWithContext(ctx, poolName, nameSpace, imageName, RBDMirroringUpdate{EnableMirror: &enable, MirrorMode: mirrorMode})
+}
+
+// DisableImageMirroring disables mirroring of an image.
+func (c *Client) DisableImageMirroring(poolName string, nameSpace *string, imageName string) (status int, err error) {
+	return c.DisableImageMirroringWithContext(context.Background(), poolName, nameSpace, imageName)
+}
+
+// DisableImageMirroringWithContext is like DisableImageMirroring but aborts the request and the task wait if ctx is
+// done.
+func (c *Client) DisableImageMirroringWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string) (status int, err error) {
+	enable := false
+
+	return c.UpdateImageMirroringWithContext(ctx, poolName, nameSpace, imageName, RBDMirroringUpdate{EnableMirror: &enable})
+}
+
+// SetImageMirrorSnapshotSchedule schedules mirror snapshots of an image in MirrorImageModeSnapshot every interval
+// (e.g. 1h, 30m or 1d). An empty interval removes the schedule.
+func (c *Client) SetImageMirrorSnapshotSchedule(poolName string, nameSpace *string, imageName, interval string) (status int, err error) {
+	return c.SetImageMirrorSnapshotScheduleWithContext(context.Background(), poolName, nameSpace, imageName, interval)
+}
+
+// SetImageMirrorSnapshotScheduleWithContext is like SetImageMirrorSnapshotSchedule but aborts the request and the
+// task wait if ctx is done.
+func (c *Client) SetImageMirrorSnapshotScheduleWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, interval string) (status int, err error) {
+	return c.UpdateImageMirroringWithContext(ctx, poolName, nameSpace, imageName, RBDMirroringUpdate{ScheduleInterval: interval, RemoveScheduling: interval == ""})
+}
+
+// mirroringPoolPath returns the api path of the mirroring settings of pool poolName followed by elem.
+func mirroringPoolPath(poolName string, elem ...string) string {
+	p := "block/mirroring/pool/" + url.QueryEscape(poolName)
+
+	for _, e := range elem {
+		p += "/" + url.QueryEscape(e)
+	}
+
+	return p
+}
@@ -0,0 +1,175 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_MirroringBetweenClusters(t *testing.T) {
+	siteA := cephtest.NewServer()
+	defer siteA.Close()
+
+	siteB := cephtest.NewServer()
+	defer siteB.Close()
+
+	siteA.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	siteB.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	siteA.AddRBDMirrorDaemon("node-1")
+
+	clientA := newLoggedInClient(t, siteA)
+	clientB := newLoggedInClient(t, siteB)
+
+	for client, siteName := range map[*ceph.Client]string{clientA: "site-a", clientB: "site-b"} {
+		if _, err := client.SetMirroringSiteName(siteName); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := clientA.SetMirroringPoolMode("test-pool-1", ceph.MirrorPoolModeImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	_, token, err := clientA.CreateMirroringBootstrapToken("test-pool-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = clientB.ImportMirroringBootstrapToken("test-pool-1", ceph.MirrorDirectionRXTX, token); err != nil {
+		t.Fatal(err)
+	}
+
+	// the import enables mirroring of the pool in image mode
+	_, mirrorMode, err := clientB.GetMirroringPoolMode("test-pool-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mirrorMode != ceph.MirrorPoolModeImage {
+		t.Errorf("expected mirror mode %s - got %s", ceph.MirrorPoolModeImage, mirrorMode)
+	}
+
+	_, uuids, err := clientB.ListMirroringPeers("test-pool-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uuids) != 1 {
+		t.Fatalf("expected 1 peer - got %v", uuids)
+	}
+
+	_, peer, err := clientB.GetMirroringPeer("test-pool-1", uuids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if peer.ClusterName != "site-a" {
+		t.Errorf("expected peer site-a - got %+v", peer)
+	}
+
+	// site a learns about site b by adding the peer explicitly
+	status, peerB, err := clientA.AddMirroringPeer("test-pool-1", ceph.MirroringPeer{ClusterName: "site-b", ClientID: "rbd-mirror-peer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated || peerB.UUID == "" {
+		t.Errorf("expected http state 201 and a peer uuid - got %d %+v", status, peerB)
+	}
+
+	if _, _, err = clientA.AddMirroringPeer("test-pool-1", ceph.MirroringPeer{ClusterName: "site-b", ClientID: "rbd-mirror-peer"}); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	for _, name := range []string{"img-journal", "img-snapshot"} {
+		if _, err = clientA.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: name, Size: 1073741824}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = clientA.EnableImageMirroring("test-pool-1", nil, "img-journal", ceph.MirrorImageModeJournal); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = clientA.EnableImageMirroring("test-pool-1", nil, "img-snapshot", ceph.MirrorImageModeSnapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = clientA.SetImageMirrorSnapshotSchedule("test-pool-1", nil, "img-snapshot", "1h"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := siteA.MirrorSnapshotSchedule("test-pool-1/img-snapshot"); got != "1h" {
+		t.Errorf("expected schedule 1h - got %q", got)
+	}
+
+	// journal images have no snapshot schedule
+	_, err = clientA.SetImageMirrorSnapshotSchedule("test-pool-1", nil, "img-journal", "1h")
+
+	var apiErr *ceph.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "22" {
+		t.Errorf("expected errno 22 - got %v", err)
+	}
+
+	_, rbd, err := clientA.GetBlockImage("test-pool-1/img-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rbd.MirrorMode != ceph.MirrorImageModeJournal || rbd.Primary == nil || !*rbd.Primary {
+		t.Errorf("expected primary journal mirrored image - got %q %v", rbd.MirrorMode, rbd.Primary)
+	}
+
+	// fail over: demote the image on site a
+	demote := false
+
+	if _, err = clientA.UpdateImageMirroring("test-pool-1", nil, "img-journal", ceph.RBDMirroringUpdate{Primary: &demote}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, summary, err := clientA.GetMirroringSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.SiteName != "site-a" || len(summary.ContentData.Daemons) != 1 || len(summary.ContentData.Pools) != 1 {
+		t.Errorf("expected summary of site-a with 1 daemon and 1 pool - got %+v", summary)
+	}
+
+	if len(summary.ContentData.ImageReady) != 2 || len(summary.ContentData.ImageError) != 0 {
+		t.Errorf("expected 2 ready images - got %+v", summary.ContentData)
+	}
+
+	// without rbd-mirror daemon the image state is unknown
+	if _, err = clientB.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "img-b", Size: 1073741824}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = clientB.EnableImageMirroring("test-pool-1", nil, "img-b", ceph.MirrorImageModeSnapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, summary, err = clientB.GetMirroringSummary(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(summary.ContentData.ImageError) != 1 || summary.ContentData.ImageError[0].State != "Unknown" {
+		t.Errorf("expected 1 image with unknown state - got %+v", summary.ContentData)
+	}
+
+	if status, err = clientA.RemoveMirroringPeer("test-pool-1", peerB.UUID); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+}
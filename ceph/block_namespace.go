@@ -1,10 +1,9 @@
 package ceph
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
-	"time"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -17,6 +16,11 @@ type NameSpace struct {
 // GetBlockNameSpaceListInPool gets a list of CEPH RBD namespaces inside given pool.
 // see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-pool-pool_name-namespace
 func (c *Client) GetBlockNameSpaceListInPool(poolName string) (status int, ns []NameSpace, err error) {
+	return c.GetBlockNameSpaceListInPoolWithContext(context.Background(), poolName)
+}
+
+// GetBlockNameSpaceListInPoolWithContext is like GetBlockNameSpaceListInPool but aborts the request if ctx is done.
+func (c *Client) GetBlockNameSpaceListInPoolWithContext(ctx context.Context, poolName string) (status int, ns []NameSpace, err error) {
 
 	if poolName == "" {
 		return 0, ns, ErrPoolNameIsEmpty
@@ -24,23 +28,19 @@ func (c *Client) GetBlockNameSpaceListInPool(poolName string) (status int, ns []
 
 	var resp *resty.Response
 
-	client := *c.Session.Client
-
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetResult(ns).
-		Get(c.Session.Server.getURL(fmt.Sprintf("block/pool/%s/namespace/", url.QueryEscape(poolName))))
+		SetResult(&ns)
+
+	resp, err = c.execute("GetBlockNameSpaceListInPool", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("block/pool/%s/namespace/", url.QueryEscape(poolName))))
 
 	if err != nil {
-		return 0, ns, err
+		return 0, ns, ctxErr(ctx, "GetBlockNameSpaceListInPool", err)
 	}
 
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), ns, fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), ns, newAPIError(resp)
 	}
 
 	return resp.StatusCode(), ns, err
@@ -49,6 +49,11 @@ func (c *Client) GetBlockNameSpaceListInPool(poolName string) (status int, ns []
 // CreateBlockNameSpaceInPool creates a new namespace for given pool.
 // --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-pool-pool_name-namespace
 func (c *Client) CreateBlockNameSpaceInPool(poolName, nameSpace string) (status int, err error) {
+	return c.CreateBlockNameSpaceInPoolWithContext(context.Background(), poolName, nameSpace)
+}
+
+// CreateBlockNameSpaceInPoolWithContext is like CreateBlockNameSpaceInPool but aborts the request if ctx is done.
+func (c *Client) CreateBlockNameSpaceInPoolWithContext(ctx context.Context, poolName, nameSpace string) (status int, err error) {
 
 	if poolName == "" {
 		return 0, ErrPoolNameIsEmpty
@@ -59,42 +64,32 @@ func (c *Client) CreateBlockNameSpaceInPool(poolName, nameSpace string) (status
 	}
 
 	var (
-		resp      *resty.Response
-		exception Exception
-		ns        = NameSpace{
+		resp *resty.Response
+		ns   = NameSpace{
 			NameSpace: nameSpace,
 		}
 	)
 
-	client := *c.Session.Client
-
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetBody(ns).
-		Post(c.Session.Server.getURL(fmt.Sprintf("block/pool/%s/namespace/", url.QueryEscape(poolName))))
+		SetBody(ns)
+
+	resp, err = c.execute("CreateBlockNameSpaceInPool", req, resty.MethodPost, c.Session.Server.getURL(fmt.Sprintf("block/pool/%s/namespace/", url.QueryEscape(poolName))))
 
 	if err != nil {
-		return 0, err
+		return 0, ctxErr(ctx, "CreateBlockNameSpaceInPool", err)
 	}
 
 	if !resp.IsSuccess() {
-		if resp.StatusCode() == http.StatusBadRequest {
-			err = client.JSONUnmarshal(resp.Body(), &exception)
-			if err == nil {
-				c.Logger.Debugf("err %s (%s)", exception.Code, exception.Detail)
-				if exception.Code == NameSpaceAlreadyExists {
-					return resp.StatusCode(), ErrNameSpaceAlreadyExists
-				}
-				// return more generic error
-				return resp.StatusCode(), fmt.Errorf("could not create namespace: %v on pool %v: %v ", nameSpace, poolName, exception.Detail)
-			}
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == NameSpaceAlreadyExists {
+			apiErr.Err = ErrNameSpaceAlreadyExists
 		}
 
-		return resp.StatusCode(), fmt.Errorf("could not create namespace: %v on pool %v: %v ", nameSpace, poolName, resp.Error())
+		return resp.StatusCode(), apiErr
 	}
 
 	status = resp.StatusCode()
@@ -105,6 +100,11 @@ func (c *Client) CreateBlockNameSpaceInPool(poolName, nameSpace string) (status
 // DeleteBlockNameSpaceInPool creates a new namespace for given pool.
 // --> https://docs.ceph.com/en/pacific/mgr/ceph_api/index.html#delete--api-block-pool-pool_name-namespace-namespace
 func (c *Client) DeleteBlockNameSpaceInPool(poolName, nameSpace string) (status int, err error) {
+	return c.DeleteBlockNameSpaceInPoolWithContext(context.Background(), poolName, nameSpace)
+}
+
+// DeleteBlockNameSpaceInPoolWithContext is like DeleteBlockNameSpaceInPool but aborts the request if ctx is done.
+func (c *Client) DeleteBlockNameSpaceInPoolWithContext(ctx context.Context, poolName, nameSpace string) (status int, err error) {
 
 	if poolName == "" {
 		return 0, ErrPoolNameIsEmpty
@@ -115,38 +115,28 @@ func (c *Client) DeleteBlockNameSpaceInPool(poolName, nameSpace string) (status
 	}
 
 	var (
-		resp      *resty.Response
-		exception Exception
+		resp *resty.Response
 	)
 
-	client := *c.Session.Client
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
 
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		AddRetryCondition(c.retryConditionCheckForAccepted).
-		R().
-		SetHeaders(defaultHeaderJson).
-		Delete(c.Session.Server.getURL(fmt.Sprintf("block/pool/%s/namespace/%s", url.QueryEscape(poolName), url.QueryEscape(nameSpace))))
+	resp, err = c.execute("DeleteBlockNameSpaceInPool", req, resty.MethodDelete, c.Session.Server.getURL(fmt.Sprintf("block/pool/%s/namespace/%s", url.QueryEscape(poolName), url.QueryEscape(nameSpace))))
 
 	if err != nil {
-		return 0, err
+		return 0, ctxErr(ctx, "DeleteBlockNameSpaceInPool", err)
 	}
 
 	if !resp.IsSuccess() {
-		if resp.StatusCode() == http.StatusBadRequest {
-			err = client.JSONUnmarshal(resp.Body(), &exception)
-			if err == nil {
-				c.Logger.Debugf("err %s (%s)", exception.Code, exception.Detail)
-				if exception.Code == NameSpaceAlreadyExists {
-					return resp.StatusCode(), ErrNameSpaceAlreadyExists
-				}
-				// return more generic error
-				return resp.StatusCode(), fmt.Errorf("could not delete namespace: %v on pool %v: %v ", nameSpace, poolName, exception.Detail)
-			}
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == NameSpaceAlreadyExists {
+			apiErr.Err = ErrNameSpaceAlreadyExists
 		}
 
-		return resp.StatusCode(), fmt.Errorf("could not delete namespace: %v on pool %v: %v ", nameSpace, poolName, resp.Error())
+		return resp.StatusCode(), apiErr
 	}
 
 	status = resp.StatusCode()
@@ -1,31 +1,47 @@
 package ceph
 
 import (
+    "context"
     "fmt"
     "github.com/go-resty/resty/v2"
     "net/http"
     "net/url"
-    "time"
 )
 
 // CreateBlockSnapShot creates a snapshot on an RBD image.
 // see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-image_spec-snap
-func (c *Client) CreateBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string, counter uint) (status int, err error) {
+func (c *Client) CreateBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    return c.CreateBlockSnapShotWithContext(context.Background(), poolName, nameSpace, imageName, snapShotName)
+}
 
-    if counter > c.MaxIterations {
-        return 0, ErrMaxIterationsExceeded
-    }
+// CreateBlockSnapShotWithContext is like CreateBlockSnapShot but aborts the request and the task wait if ctx is done.
+func (c *Client) CreateBlockSnapShotWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    err = c.retryTask(ctx, "CreateBlockSnapShot", func(ctx context.Context) error {
+        status, err = c.createBlockSnapShot(ctx, poolName, nameSpace, imageName, snapShotName)
+        return err
+    })
+
+    return status, err
+}
+
+// CreateBlockSnapShotAsync is like CreateBlockSnapShotWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/snap/create task and holds the result once done.
+func (c *Client) CreateBlockSnapShotAsync(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) *TaskFuture {
+    return c.async(ctx, func(ctx context.Context) (int, error) {
+        return c.CreateBlockSnapShotWithContext(ctx, poolName, nameSpace, imageName, snapShotName)
+    })
+}
+
+// createBlockSnapShot submits the rbd/snap/create task once and waits until it is done.
+func (c *Client) createBlockSnapShot(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
 
     if snapShotName == "" {
         return 0, ErrSnapshotNameIsEmpty
     }
 
-    counter++
-
     var (
         resp      *resty.Response
         imageSpec string
-        exception Exception
     )
 
     imageSpec, err = CreateImageSpec(poolName, nameSpace, imageName)
@@ -34,61 +50,360 @@ func (c *Client) CreateBlockSnapShot(poolName string, nameSpace *string, imageNa
         return 0, err
     }
 
-    client := *c.Session.Client
-
     jsonBody := struct {
         SnapshotName string `json:"snapshot_name"`
     }{SnapshotName: snapShotName}
 
-    resp, err = client.
-        SetRetryCount(10).
-        SetRetryWaitTime(10 * time.Second).
-        AddRetryCondition(c.retryConditionCheckForAccepted).
-        R().
+    lookForTask := Task{
+        Name: "rbd/snap/create",
+        MetaData: MetaData{
+            ImageSpec:    imageSpec,
+            SnapshotName: snapShotName,
+        },
+    }
+
+    ctx = withTaskLogFields(ctx, lookForTask)
+
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaderJson).
-        SetBody(jsonBody).
-        Post(c.Session.Server.getURL(fmt.Sprintf("block/image/%s/snap", url.QueryEscape(imageSpec))))
+        SetBody(jsonBody)
+
+    resp, err = c.execute("CreateBlockSnapShot", req, resty.MethodPost, c.Session.Server.getURL(fmt.Sprintf("block/image/%s/snap", url.QueryEscape(imageSpec))))
+
+    if err != nil {
+        return 0, ctxErr(ctx, "CreateBlockSnapShot", err)
+    }
 
     if !resp.IsSuccess() {
-        if resp.StatusCode() == http.StatusBadRequest {
-            err = client.JSONUnmarshal(resp.Body(), &exception)
-            if err == nil {
-                c.Logger.Debugf("err %s (%s)", exception.Code, exception.Detail)
-                if exception.Code == RBDImageAlreadyExists {
-                    return resp.StatusCode(), ErrCreateImageAlreadyExists
-                }
-            }
+        apiErr := newAPIError(resp)
+        c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+        if apiErr.Code == RBDImageAlreadyExists {
+            apiErr.Err = ErrCreateImageAlreadyExists
         }
 
-        return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), apiErr
     }
 
     status = resp.StatusCode()
 
     switch status {
     case http.StatusAccepted, http.StatusCreated, http.StatusBadRequest:
-        lookForTask := Task{
-            Name: "rbd/snap/create",
-            MetaData: MetaData{
-                ImageSpec: imageSpec, // only ImageSpec is needed on delete
-            },
-        }
-
-        lookForTask, err = c.WaitForTaskIsDone(lookForTask)
+        lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
 
         if err != nil {
-            return 0, err
+            return 0, ctxErr(ctx, "CreateBlockSnapShot", err)
         }
 
         if !lookForTask.Success {
-            // try delete again...
-            c.Logger.Debugf("calling DeleteBlockImage with counter %d", counter)
-            return c.CreateBlockSnapShot(poolName, nameSpace, imageName, snapShotName, counter)
-        } else {
-            status = http.StatusCreated
-            err = nil
+            return 0, newTaskError(lookForTask)
         }
+
+        status = http.StatusCreated
+        err = nil
+    }
+
+    return status, err
+}
+
+// RBDSnapshotUpdate implements struct send to ceph on PUT /api/block/image/{image_spec}/snap/{snapshot_name}.
+// Unset fields are left unchanged.
+type RBDSnapshotUpdate struct {
+    NewSnapName string `json:"new_snap_name,omitempty"`
+    IsProtected *bool  `json:"is_protected,omitempty"`
+}
+
+// RBDClone implements struct send to ceph on POST /api/block/image/{image_spec}/snap/{snapshot_name}/clone.
+type RBDClone struct {
+    ChildPoolName  string        `json:"child_pool_name"`
+    ChildNamespace *string       `json:"child_namespace"`
+    ChildImageName string        `json:"child_image_name"`
+    ObjSize        uint64        `json:"obj_size,omitempty"`
+    Features       []string      `json:"features,omitempty"`
+    StripeUnit     *uint64       `json:"stripe_unit,omitempty"`
+    StripeCount    *uint         `json:"stripe_count,omitempty"`
+    DataPool       *string       `json:"data_pool,omitempty"`
+    Configuration  *RBDQosConfig `json:"configuration,omitempty"`
+}
+
+// snapShotURL returns the url of snapshot snapShotName of imageSpec, followed by the optional path elements.
+func (c *Client) snapShotURL(imageSpec, snapShotName string, elem ...string) string {
+    path := fmt.Sprintf("block/image/%s/snap/%s", url.QueryEscape(imageSpec), url.QueryEscape(snapShotName))
+
+    for _, e := range elem {
+        path += "/" + e
+    }
+
+    return c.Session.Server.getURL(path)
+}
+
+// ListBlockSnapShots gets the snapshots of an RBD image.
+// see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-image-image_spec
+func (c *Client) ListBlockSnapShots(poolName string, nameSpace *string, imageName string) (status int, snapshots []RBDSnapshot, err error) {
+    return c.ListBlockSnapShotsWithContext(context.Background(), poolName, nameSpace, imageName)
+}
+
+// ListBlockSnapShotsWithContext is like ListBlockSnapShots but aborts the request if ctx is done.
+func (c *Client) ListBlockSnapShotsWithContext(ctx context.Context, poolName string, nameSpace *string, imageName string) (status int, snapshots []RBDSnapshot, err error) {
+    var (
+        imageSpec string
+        rbd       RBD
+    )
+
+    imageSpec, err = CreateImageSpec(poolName, nameSpace, imageName)
+
+    if err != nil {
+        return 0, nil, err
+    }
+
+    status, rbd, err = c.GetBlockImageWithContext(ctx, imageSpec)
+
+    if err != nil {
+        return status, nil, err
+    }
+
+    return status, rbd.Snapshots, nil
+}
+
+// UpdateBlockSnapShot renames, protects or unprotects a snapshot of an RBD image.
+// see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-block-image-image_spec-snap-snapshot_name
+func (c *Client) UpdateBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string, update RBDSnapshotUpdate) (status int, err error) {
+    return c.UpdateBlockSnapShotWithContext(context.Background(), poolName, nameSpace, imageName, snapShotName, update)
+}
+
+// UpdateBlockSnapShotWithContext is like UpdateBlockSnapShot but aborts the request and the task wait if ctx is done.
+func (c *Client) UpdateBlockSnapShotWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string, update RBDSnapshotUpdate) (status int, err error) {
+    err = c.retryTask(ctx, "UpdateBlockSnapShot", func(ctx context.Context) error {
+        status, err = c.updateBlockSnapShot(ctx, poolName, nameSpace, imageName, snapShotName, update)
+        return err
+    })
+
+    return status, err
+}
+
+// UpdateBlockSnapShotAsync is like UpdateBlockSnapShotWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/snap/edit task and holds the result once done.
+func (c *Client) UpdateBlockSnapShotAsync(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string, update RBDSnapshotUpdate) *TaskFuture {
+    return c.async(ctx, func(ctx context.Context) (int, error) {
+        return c.UpdateBlockSnapShotWithContext(ctx, poolName, nameSpace, imageName, snapShotName, update)
+    })
+}
+
+// RenameBlockSnapShot renames a snapshot of an RBD image to newSnapShotName.
+func (c *Client) RenameBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName, newSnapShotName string) (status int, err error) {
+    if newSnapShotName == "" {
+        return 0, ErrSnapshotNameIsEmpty
+    }
+
+    return c.UpdateBlockSnapShot(poolName, nameSpace, imageName, snapShotName, RBDSnapshotUpdate{NewSnapName: newSnapShotName})
+}
+
+// ProtectBlockSnapShot protects a snapshot of an RBD image, which is needed before cloning it.
+func (c *Client) ProtectBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    protect := true
+    return c.UpdateBlockSnapShot(poolName, nameSpace, imageName, snapShotName, RBDSnapshotUpdate{IsProtected: &protect})
+}
+
+// UnprotectBlockSnapShot unprotects a snapshot of an RBD image. The mgr refuses it with errno 16 (ErrBusy) as long
+// as the snapshot has children.
+func (c *Client) UnprotectBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    protect := false
+    return c.UpdateBlockSnapShot(poolName, nameSpace, imageName, snapShotName, RBDSnapshotUpdate{IsProtected: &protect})
+}
+
+// updateBlockSnapShot submits the rbd/snap/edit task once and waits until it is done.
+func (c *Client) updateBlockSnapShot(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string, update RBDSnapshotUpdate) (status int, err error) {
+
+    if snapShotName == "" {
+        return 0, ErrSnapshotNameIsEmpty
+    }
+
+    imageSpec, err := CreateImageSpec(poolName, nameSpace, imageName)
+
+    if err != nil {
+        return 0, err
     }
 
+    req := c.Session.Client.R().
+        SetContext(ctx).
+        SetHeaders(defaultHeaderJson).
+        SetBody(update)
+
+    lookForTask := Task{
+        Name: "rbd/snap/edit",
+        MetaData: MetaData{
+            ImageSpec:    imageSpec,
+            SnapshotName: snapShotName,
+        },
+    }
+
+    return c.submitTask(ctx, "UpdateBlockSnapShot", req, resty.MethodPut, c.snapShotURL(imageSpec, snapShotName), lookForTask, http.StatusOK)
+}
+
+// RollbackBlockSnapShot rolls an RBD image back to one of its snapshots.
+// see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-image_spec-snap-snapshot_name-rollback
+func (c *Client) RollbackBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    return c.RollbackBlockSnapShotWithContext(context.Background(), poolName, nameSpace, imageName, snapShotName)
+}
+
+// RollbackBlockSnapShotWithContext is like RollbackBlockSnapShot but aborts the request and the task wait if ctx is done.
+func (c *Client) RollbackBlockSnapShotWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    err = c.retryTask(ctx, "RollbackBlockSnapShot", func(ctx context.Context) error {
+        status, err = c.rollbackBlockSnapShot(ctx, poolName, nameSpace, imageName, snapShotName)
+        return err
+    })
+
     return status, err
 }
+
+// RollbackBlockSnapShotAsync is like RollbackBlockSnapShotWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/snap/rollback task and holds the result once done.
+func (c *Client) RollbackBlockSnapShotAsync(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) *TaskFuture {
+    return c.async(ctx, func(ctx context.Context) (int, error) {
+        return c.RollbackBlockSnapShotWithContext(ctx, poolName, nameSpace, imageName, snapShotName)
+    })
+}
+
+// rollbackBlockSnapShot submits the rbd/snap/rollback task once and waits until it is done.
+func (c *Client) rollbackBlockSnapShot(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+
+    if snapShotName == "" {
+        return 0, ErrSnapshotNameIsEmpty
+    }
+
+    imageSpec, err := CreateImageSpec(poolName, nameSpace, imageName)
+
+    if err != nil {
+        return 0, err
+    }
+
+    req := c.Session.Client.R().
+        SetContext(ctx).
+        SetHeaders(defaultHeaderJson)
+
+    lookForTask := Task{
+        Name: "rbd/snap/rollback",
+        MetaData: MetaData{
+            ImageSpec:    imageSpec,
+            SnapshotName: snapShotName,
+        },
+    }
+
+    return c.submitTask(ctx, "RollbackBlockSnapShot", req, resty.MethodPost, c.snapShotURL(imageSpec, snapShotName, "rollback"), lookForTask, http.StatusCreated)
+}
+
+// DeleteBlockSnapShot deletes a snapshot of an RBD image. Protected snapshots have to be unprotected first.
+// see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-block-image-image_spec-snap-snapshot_name
+func (c *Client) DeleteBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    return c.DeleteBlockSnapShotWithContext(context.Background(), poolName, nameSpace, imageName, snapShotName)
+}
+
+// DeleteBlockSnapShotWithContext is like DeleteBlockSnapShot but aborts the request and the task wait if ctx is done.
+func (c *Client) DeleteBlockSnapShotWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+    err = c.retryTask(ctx, "DeleteBlockSnapShot", func(ctx context.Context) error {
+        status, err = c.deleteBlockSnapShot(ctx, poolName, nameSpace, imageName, snapShotName)
+        return err
+    })
+
+    return status, err
+}
+
+// DeleteBlockSnapShotAsync is like DeleteBlockSnapShotWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/snap/delete task and holds the result once done.
+func (c *Client) DeleteBlockSnapShotAsync(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) *TaskFuture {
+    return c.async(ctx, func(ctx context.Context) (int, error) {
+        return c.DeleteBlockSnapShotWithContext(ctx, poolName, nameSpace, imageName, snapShotName)
+    })
+}
+
+// deleteBlockSnapShot submits the rbd/snap/delete task once and waits until it is done.
+func (c *Client) deleteBlockSnapShot(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (status int, err error) {
+
+    if snapShotName == "" {
+        return 0, ErrSnapshotNameIsEmpty
+    }
+
+    imageSpec, err := CreateImageSpec(poolName, nameSpace, imageName)
+
+    if err != nil {
+        return 0, err
+    }
+
+    req := c.Session.Client.R().
+        SetContext(ctx).
+        SetHeaders(defaultHeaderJson)
+
+    lookForTask := Task{
+        Name: "rbd/snap/delete",
+        MetaData: MetaData{
+            ImageSpec:    imageSpec,
+            SnapshotName: snapShotName,
+        },
+    }
+
+    return c.submitTask(ctx, "DeleteBlockSnapShot", req, resty.MethodDelete, c.snapShotURL(imageSpec, snapShotName), lookForTask, http.StatusNoContent)
+}
+
+// CloneBlockSnapShot clones a protected snapshot of an RBD image to the new image defined by clone.
+// see --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-image_spec-snap-snapshot_name-clone
+func (c *Client) CloneBlockSnapShot(poolName string, nameSpace *string, imageName, snapShotName string, clone RBDClone) (status int, err error) {
+    return c.CloneBlockSnapShotWithContext(context.Background(), poolName, nameSpace, imageName, snapShotName, clone)
+}
+
+// CloneBlockSnapShotWithContext is like CloneBlockSnapShot but aborts the request and the task wait if ctx is done.
+func (c *Client) CloneBlockSnapShotWithContext(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string, clone RBDClone) (status int, err error) {
+    err = c.retryTask(ctx, "CloneBlockSnapShot", func(ctx context.Context) error {
+        status, err = c.cloneBlockSnapShot(ctx, poolName, nameSpace, imageName, snapShotName, clone)
+        return err
+    })
+
+    return status, err
+}
+
+// CloneBlockSnapShotAsync is like CloneBlockSnapShotWithContext but returns at once. The TaskFuture reports the
+// progress of the rbd/clone task and holds the result once done.
+func (c *Client) CloneBlockSnapShotAsync(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string, clone RBDClone) *TaskFuture {
+    return c.async(ctx, func(ctx context.Context) (int, error) {
+        return c.CloneBlockSnapShotWithContext(ctx, poolName, nameSpace, imageName, snapShotName, clone)
+    })
+}
+
+// cloneBlockSnapShot submits the rbd/clone task once and waits until it is done.
+func (c *Client) cloneBlockSnapShot(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string, clone RBDClone) (status int, err error) {
+
+    if snapShotName == "" {
+        return 0, ErrSnapshotNameIsEmpty
+    }
+
+    if clone.ChildPoolName == "" {
+        return 0, ErrPoolNameIsEmpty
+    }
+
+    if clone.ChildImageName == "" {
+        return 0, ErrImageNameIsEmpty
+    }
+
+    imageSpec, err := CreateImageSpec(poolName, nameSpace, imageName)
+
+    if err != nil {
+        return 0, err
+    }
+
+    req := c.Session.Client.R().
+        SetContext(ctx).
+        SetHeaders(defaultHeaderJson).
+        SetBody(clone)
+
+    lookForTask := Task{
+        Name: "rbd/clone",
+        MetaData: MetaData{
+            ParentImageSpec: imageSpec,
+            ChildPoolName:   clone.ChildPoolName,
+            ChildNamespace:  clone.ChildNamespace,
+            ChildImageName:  clone.ChildImageName,
+        },
+    }
+
+    return c.submitTask(ctx, "CloneBlockSnapShot", req, resty.MethodPost, c.snapShotURL(imageSpec, snapShotName, "clone"), lookForTask, http.StatusCreated)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
 )
 
 func TestClient_CreateBlockSnapShot(t *testing.T) {
@@ -41,7 +42,7 @@ func TestClient_CreateBlockSnapShot(t *testing.T) {
 		Configuration: nil,
 	}
 
-	statusCreate, errCreate := client.CreateBlockImage(rbd, 0)
+	statusCreate, errCreate := client.CreateBlockImage(rbd)
 
 	if errCreate != nil {
 		t.Error(errCreate)
@@ -52,7 +53,7 @@ func TestClient_CreateBlockSnapShot(t *testing.T) {
 	}
 
 	// create snapshot without name --> should auto generate snapshot name
-	status, errSnapshot := client.CreateBlockSnapShot(rbd.PoolName, rbd.Namespace, rbd.Name, "", 0)
+	status, errSnapshot := client.CreateBlockSnapShot(rbd.PoolName, rbd.Namespace, rbd.Name, "")
 
 	if !errors.Is(errSnapshot, ceph.ErrSnapshotNameIsEmpty) {
 		t.Errorf("exptected error %v - got error %v", ceph.ErrSnapshotNameIsEmpty, errSnapshot)
@@ -60,7 +61,7 @@ func TestClient_CreateBlockSnapShot(t *testing.T) {
 
 	// create snapshot without name --> should auto generate snapshot name
 	snapshotName := fmt.Sprintf("%s-snap-1", name)
-	status, errSnapshot = client.CreateBlockSnapShot(rbd.PoolName, rbd.Namespace, rbd.Name, snapshotName, 0)
+	status, errSnapshot = client.CreateBlockSnapShot(rbd.PoolName, rbd.Namespace, rbd.Name, snapshotName)
 
 	if errSnapshot != nil {
 		t.Error(errSnapshot)
@@ -71,3 +72,102 @@ func TestClient_CreateBlockSnapShot(t *testing.T) {
 	}
 
 }
+
+func TestClient_BlockSnapShotLifecycle(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.MaxAttempts = 1
+
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "parent", Size: 1073741824}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateBlockSnapShot("test-pool-1", nil, "parent", "snap-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.RenameBlockSnapShot("test-pool-1", nil, "parent", "snap-1", "snap-2")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	// cloning needs a protected snapshot
+	if _, err = client.ProtectBlockSnapShot("test-pool-1", nil, "parent", "snap-2"); err != nil {
+		t.Error(err)
+	}
+
+	clone := ceph.RBDClone{ChildPoolName: "test-pool-1", ChildImageName: "child"}
+
+	status, err = client.CloneBlockSnapShot("test-pool-1", nil, "parent", "snap-2", clone)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	_, snapshots, err := client.ListBlockSnapShots("test-pool-1", nil, "parent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snapshots) != 1 || snapshots[0].Name != "snap-2" || !snapshots[0].IsProtected || len(snapshots[0].Children) != 1 {
+		t.Fatalf("expected protected snapshot snap-2 with one child - got %+v", snapshots)
+	}
+
+	if child := snapshots[0].Children[0]; child.PoolName != "test-pool-1" || child.ImageName != "child" {
+		t.Errorf("expected child test-pool-1/child - got %+v", child)
+	}
+
+	// a snapshot with children can neither be unprotected nor deleted
+	if _, err = client.UnprotectBlockSnapShot("test-pool-1", nil, "parent", "snap-2"); !errors.Is(err, ceph.ErrBusy) {
+		t.Errorf("expected err %v - got %v", ceph.ErrBusy, err)
+	}
+
+	if _, err = client.DeleteBlockSnapShot("test-pool-1", nil, "parent", "snap-2"); !errors.Is(err, ceph.ErrBusy) {
+		t.Errorf("expected err %v - got %v", ceph.ErrBusy, err)
+	}
+
+	status, err = client.RollbackBlockSnapShot("test-pool-1", nil, "parent", "snap-2")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, err = client.DeleteBlockImage("test-pool-1", nil, "child"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.UnprotectBlockSnapShot("test-pool-1", nil, "parent", "snap-2"); err != nil {
+		t.Error(err)
+	}
+
+	status, err = client.DeleteBlockSnapShot("test-pool-1", nil, "parent", "snap-2")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, err = client.DeleteBlockSnapShot("test-pool-1", nil, "parent", "snap-2"); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	if _, err = client.CloneBlockSnapShot("test-pool-1", nil, "parent", "snap-2", ceph.RBDClone{ChildPoolName: "test-pool-1"}); !errors.Is(err, ceph.ErrImageNameIsEmpty) {
+		t.Errorf("expected err %v - got %v", ceph.ErrImageNameIsEmpty, err)
+	}
+}
@@ -1,27 +1,60 @@
 package ceph_test
 
 import (
+    "context"
+    "errors"
     "fmt"
     "github.com/chrisamti/ceph-rest-client/ceph"
+    "github.com/chrisamti/ceph-rest-client/ceph/cephtest"
     "net/http"
+    "os"
     "testing"
     "time"
 )
 
 var (
-    username = "test-user"
-    password = "XJEGy5yWrYxu758"
-    server   = "192.168.21.30"
+    username = cephtest.Username
+    password = cephtest.Password
+
+    // mgr is the fake ceph mgr shared by all tests, like a real test cluster would be.
+    mgr *cephtest.Server
 )
 
+func TestMain(m *testing.M) {
+    mgr = cephtest.NewServer()
+    mgr.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+    mgr.AddFS("cephfs")
+
+    code := m.Run()
+
+    mgr.Close()
+    os.Exit(code)
+}
+
 func getServer() ceph.Server {
-    return ceph.Server{
-        Address:            server,
-        Port:               8443,
-        Protocol:           "https",
-        APIPath:            "api",
-        InsecureSkipVerify: true,
+    return mgr.CephServer()
+}
+
+// newLoggedInClient returns a client logged in to srv.
+func newLoggedInClient(t *testing.T, srv *cephtest.Server) *ceph.Client {
+    t.Helper()
+
+    client, err := ceph.New(srv.CephServer())
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    status, err := client.Session.Login(cephtest.Username, cephtest.Password)
+    if err != nil {
+        t.Fatal(err)
     }
+
+    if status != http.StatusCreated {
+        t.Fatalf("could not login - expected http state 201 - got %d", status)
+    }
+
+    return client
 }
 
 func TestClient_CreateBlockImage(t *testing.T) {
@@ -53,7 +86,7 @@ func TestClient_CreateBlockImage(t *testing.T) {
         Configuration: nil,
     }
 
-    status, err = client.CreateBlockImage(rbd, 0)
+    status, err = client.CreateBlockImage(rbd)
 
     if err != nil {
         t.Error(err)
@@ -81,7 +114,7 @@ func TestClient_DeleteBlockImage(t *testing.T) {
         t.Fatalf("could not login - expected http state 201 - got %d", status)
     }
 
-    status, err = client.DeleteBlockImage("test-pool-1", nil, "rest-client-one-img-1", 0)
+    status, err = client.DeleteBlockImage("test-pool-1", nil, "rest-client-one-img-1")
 
     if err != nil {
         t.Error(err)
@@ -125,7 +158,7 @@ func TestClient_ListBlockImage(t *testing.T) {
                 DataPool:      nil,
                 Configuration: nil,
             }
-            return client.CreateBlockImage(rbd, 0)
+            return client.CreateBlockImage(rbd)
         }(i)
         if err != nil {
             t.Error(err)
@@ -164,8 +197,6 @@ func TestClient_ListBlockImage(t *testing.T) {
         t.Errorf("expected http state 200 - got %d", status)
     }
 
-    time.Sleep(10 * time.Second)
-
     if len(block) == 0 {
         t.Error("expected more than 0 block images")
     }
@@ -182,7 +213,7 @@ func TestClient_ListBlockImage(t *testing.T) {
     for j := 0; j < 10; j++ {
         _, err := func(i int) (int, error) {
             var imageName = fmt.Sprintf("rest-client-test-%d", i)
-            return client.DeleteBlockImage("test-pool-1", nil, imageName, 0)
+            return client.DeleteBlockImage("test-pool-1", nil, imageName)
         }(j)
 
         if err != nil {
@@ -221,7 +252,7 @@ func TestClient_UpdateBlockImage(t *testing.T) {
         Configuration: nil,
     }
 
-    statusCreate, errCreate := client.CreateBlockImage(rbd, 0)
+    statusCreate, errCreate := client.CreateBlockImage(rbd)
 
     if errCreate != nil {
         t.Error(errCreate)
@@ -240,7 +271,7 @@ func TestClient_UpdateBlockImage(t *testing.T) {
         Configuration: struct{}{},
     }
 
-    statusModify, errModify := client.UpdateBlockImage("test-pool-1", nil, "rest-client-update-img-1", rbdUpdate, 0)
+    statusModify, errModify := client.UpdateBlockImage("test-pool-1", nil, "rest-client-update-img-1", rbdUpdate)
 
     if errModify != nil {
         t.Error(err)
@@ -250,7 +281,7 @@ func TestClient_UpdateBlockImage(t *testing.T) {
         t.Errorf("expected http state 200 - got %d", statusCreate)
     }
 
-    statusDelete, errDelete := client.DeleteBlockImage("test-pool-1", nil, "rest-client-update-img-1-modified", 0)
+    statusDelete, errDelete := client.DeleteBlockImage("test-pool-1", nil, "rest-client-update-img-1-modified")
 
     if errDelete != nil {
         t.Errorf("expected http state 204 - got %d", statusDelete)
@@ -291,7 +322,7 @@ func TestClient_CopyBlockImage(t *testing.T) {
         Configuration: nil,
     }
 
-    statusCreate, errCreate := client.CreateBlockImage(rbd, 0)
+    statusCreate, errCreate := client.CreateBlockImage(rbd)
 
     if errCreate != nil {
         t.Error(errCreate)
@@ -328,20 +359,49 @@ func TestClient_CopyBlockImage(t *testing.T) {
         StripeUnit:    rbd.StripeUnit,
     }
 
-    statusCopy, errCopy := client.CopyBlockImage(rbdSrc.PoolName, rbdSrc.Namespace, rbdSrc.Name, dst, 0)
+    statusCopy, errCopy := client.CopyBlockImage(rbdSrc.PoolName, rbdSrc.Namespace, rbdSrc.Name, dst)
 
     if errCopy != nil {
         t.Error(errCopy)
     }
 
-    if statusCopy != http.StatusOK {
-        t.Errorf("expected http state 200 - got %d", statusCopy)
+    if statusCopy != http.StatusNoContent {
+        t.Errorf("expected http state 204 - got %d", statusCopy)
     }
 
     // try to delete test image
-    _, _ = client.DeleteBlockImage(rbd.PoolName, rbd.Namespace, rbd.Name, 0)
-    _, _ = client.DeleteBlockImage(dst.DestPoolName, dst.DestNameSpace, dst.DestImageName, 0)
+    _, _ = client.DeleteBlockImage(rbd.PoolName, rbd.Namespace, rbd.Name)
+    _, _ = client.DeleteBlockImage(dst.DestPoolName, dst.DestNameSpace, dst.DestImageName)
+
+}
+
+func TestClient_CopyBlockImageTaskMetaData(t *testing.T) {
+    srv := cephtest.NewServer()
+    defer srv.Close()
+
+    // the mgr reports rbd/copy with the source image spec and the destination, see the captured summary
+    srv.Replay(http.MethodPost, "/api/block/image/test-pool-1%2Frest-client-src-img-1/copy", http.StatusAccepted, nil)
+
+    if err := srv.ReplayFile(http.MethodGet, "/api/task", http.StatusOK, "outputs/summary-after-create-namespace.json"); err != nil {
+        t.Fatal(err)
+    }
+
+    client := newLoggedInClient(t, srv)
+    client.TaskPollInterval = 10 * time.Millisecond
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
 
+    dst := ceph.RBDCopy{DestPoolName: "test-pool-1", DestImageName: "rest-client-copy-img-1"}
+
+    status, err := client.CopyBlockImageWithContext(ctx, "test-pool-1", nil, "rest-client-src-img-1", dst)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if status != http.StatusNoContent {
+        t.Errorf("expected http state 204 - got %d", status)
+    }
 }
 
 func TestClient_MoveBlockImageToTrash(t *testing.T) {
@@ -375,7 +435,7 @@ func TestClient_MoveBlockImageToTrash(t *testing.T) {
         Configuration: nil,
     }
 
-    statusCreate, errCreate := client.CreateBlockImage(rbd, 0)
+    statusCreate, errCreate := client.CreateBlockImage(rbd)
 
     if errCreate != nil {
         t.Error(errCreate)
@@ -385,7 +445,7 @@ func TestClient_MoveBlockImageToTrash(t *testing.T) {
         t.Errorf("expected http state 201 - got %d", statusCreate)
     }
 
-    statusMoveToTrash, errMoveToTrash := client.MoveBlockImageToTrash(rbd.PoolName, rbd.Namespace, rbd.Name, time.Second*300, 0)
+    statusMoveToTrash, errMoveToTrash := client.MoveBlockImageToTrash(rbd.PoolName, rbd.Namespace, rbd.Name, time.Second*300)
 
     if errMoveToTrash != nil {
         t.Error(errMoveToTrash)
@@ -397,6 +457,127 @@ func TestClient_MoveBlockImageToTrash(t *testing.T) {
 
 }
 
+func TestClient_CreateBlockImageAlreadyExists(t *testing.T) {
+    srv := cephtest.NewServer()
+    defer srv.Close()
+
+    if err := srv.ReplayFile(http.MethodPost, "/api/block/image", http.StatusBadRequest, "outputs/err_create_image.json"); err != nil {
+        t.Fatal(err)
+    }
+
+    client := newLoggedInClient(t, srv)
+
+    status, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "rest-client-one-img-1", Size: 1073741824})
+
+    if !errors.Is(err, ceph.ErrEditImageAlreadyExists) {
+        t.Errorf("expected err %v - got %v", ceph.ErrEditImageAlreadyExists, err)
+    }
+
+    if status != http.StatusBadRequest {
+        t.Errorf("expected http state 400 - got %d", status)
+    }
+}
+
+func TestClient_CreateBlockImageSlowTask(t *testing.T) {
+    srv := cephtest.NewServer()
+    defer srv.Close()
+
+    srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+    srv.SetTaskDuration(200 * time.Millisecond)
+
+    client := newLoggedInClient(t, srv)
+    client.TaskPollInterval = 20 * time.Millisecond
+
+    status, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "slow-img", Size: 1073741824})
+
+    if err != nil {
+        t.Error(err)
+    }
+
+    if status != http.StatusCreated {
+        t.Errorf("expected http state 201 - got %d", status)
+    }
+
+    if polls := srv.RequestCount(http.MethodGet, "/api/task"); polls < 3 {
+        t.Errorf("expected at least 3 task polls - got %d", polls)
+    }
+}
+
+func TestClient_DeleteBlockImageFailedTask(t *testing.T) {
+    srv := cephtest.NewServer()
+    defer srv.Close()
+
+    srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+    client := newLoggedInClient(t, srv)
+    client.RetryPolicy.InitialBackoff = time.Millisecond
+
+    _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "busy-img", Size: 1073741824})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // rbd/delete fails twice with errno 16 (busy) --> image is deleted on the third submission.
+    srv.FailTask("rbd/delete", 2)
+
+    status, err := client.DeleteBlockImage("test-pool-1", nil, "busy-img")
+
+    if err != nil {
+        t.Error(err)
+    }
+
+    if status != http.StatusNoContent {
+        t.Errorf("expected http state 204 - got %d", status)
+    }
+
+    if deletes := srv.RequestCount(http.MethodDelete, "/api/block/image/test-pool-1%2Fbusy-img"); deletes != 3 {
+        t.Errorf("expected 3 delete requests - got %d", deletes)
+    }
+
+    // exceed max attempts
+    _, err = client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "busy-img", Size: 1073741824})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    client.RetryPolicy.MaxAttempts = 2
+    srv.FailTask("rbd/delete", 5)
+
+    _, err = client.DeleteBlockImage("test-pool-1", nil, "busy-img")
+
+    if !errors.Is(err, ceph.ErrRetriesExhausted) {
+        t.Errorf("expected err %v - got %v", ceph.ErrRetriesExhausted, err)
+    }
+
+    if deletes := srv.RequestCount(http.MethodDelete, "/api/block/image/test-pool-1%2Fbusy-img"); deletes != 5 {
+        t.Errorf("expected 5 delete requests - got %d", deletes)
+    }
+}
+
+func TestClient_DeleteBlockImageException(t *testing.T) {
+    srv := cephtest.NewServer()
+    defer srv.Close()
+
+    srv.InjectException(http.MethodDelete, "/api/block/image/test-pool-1%2Fimg", ceph.Exception{
+        Detail:    "[errno 16] RBD image is busy (error removing image)",
+        Code:      "16",
+        Component: "rbd",
+    })
+
+    client := newLoggedInClient(t, srv)
+    client.RetryPolicy.MaxAttempts = 1 // errno 16 is retried by default
+
+    status, err := client.DeleteBlockImage("test-pool-1", nil, "img")
+
+    if err == nil {
+        t.Error("expected error on injected exception - got nil")
+    }
+
+    if status != http.StatusBadRequest {
+        t.Errorf("expected http state 400 - got %d", status)
+    }
+}
+
 //func TestClient_GetBlockImage(t *testing.T) {
 //	client, err := ceph.New(getServer())
 //
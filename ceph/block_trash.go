@@ -0,0 +1,237 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RBDTrash implements struct for an image in the rbd trash returned from GET /api/block/image/trash.
+type RBDTrash struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Source           string    `json:"source"`
+	DeletionTime     time.Time `json:"deletion_time"`
+	DefermentEndTime time.Time `json:"deferment_end_time"`
+	PoolName         string    `json:"pool_name"`
+	Namespace        *string   `json:"namespace"`
+}
+
+// Expired checks if the deferment of the trashed image ended at now, so it can be removed without force.
+func (t RBDTrash) Expired(now time.Time) bool {
+	return !now.Before(t.DefermentEndTime)
+}
+
+// RBDTrashList implements struct received from GET /api/block/image/trash.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-image-trash
+type RBDTrashList []struct {
+	Status   int        `json:"status"`
+	Value    []RBDTrash `json:"value"`
+	PoolName string     `json:"pool_name"`
+}
+
+// ListBlockTrash gets the images in the rbd trash of poolName or of all pools if poolName is empty.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-block-image-trash
+func (c *Client) ListBlockTrash(poolName string) (status int, trashList RBDTrashList, err error) {
+	return c.ListBlockTrashWithContext(context.Background(), poolName)
+}
+
+// ListBlockTrashWithContext is like ListBlockTrash but aborts the request if ctx is done.
+func (c *Client) ListBlockTrashWithContext(ctx context.Context, poolName string) (status int, trashList RBDTrashList, err error) {
+	var resp *resty.Response
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&trashList)
+
+	if poolName != "" {
+		req.SetQueryParam("pool_name", poolName)
+	}
+
+	resp, err = c.execute("ListBlockTrash", req, resty.MethodGet, c.Session.Server.getURL("block/image/trash"))
+
+	if err != nil {
+		return 0, trashList, ctxErr(ctx, "ListBlockTrash", err)
+	}
+
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), trashList, newAPIError(resp)
+	}
+
+	return resp.StatusCode(), trashList, err
+}
+
+// RestoreBlockImageFromTrash restores the trashed image imageID as newImageName. Pass the name of the trashed image
+// to restore it under its former name.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-trash-image_id_spec-restore
+func (c *Client) RestoreBlockImageFromTrash(poolName string, nameSpace *string, imageID, newImageName string) (status int, err error) {
+	return c.RestoreBlockImageFromTrashWithContext(context.Background(), poolName, nameSpace, imageID, newImageName)
+}
+
+// RestoreBlockImageFromTrashWithContext is like RestoreBlockImageFromTrash but aborts the request and the task wait
+// if ctx is done.
+func (c *Client) RestoreBlockImageFromTrashWithContext(ctx context.Context, poolName string, nameSpace *string, imageID, newImageName string) (status int, err error) {
+	err = c.retryTask(ctx, "RestoreBlockImageFromTrash", func(ctx context.Context) error {
+		status, err = c.restoreBlockImageFromTrash(ctx, poolName, nameSpace, imageID, newImageName)
+		return err
+	})
+
+	return status, err
+}
+
+// RestoreBlockImageFromTrashAsync is like RestoreBlockImageFromTrashWithContext but returns at once. The TaskFuture
+// reports the progress of the rbd/trash/restore task and holds the result once done.
+func (c *Client) RestoreBlockImageFromTrashAsync(ctx context.Context, poolName string, nameSpace *string, imageID, newImageName string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.RestoreBlockImageFromTrashWithContext(ctx, poolName, nameSpace, imageID, newImageName)
+	})
+}
+
+// restoreBlockImageFromTrash submits the rbd/trash/restore task once and waits until it is done.
+func (c *Client) restoreBlockImageFromTrash(ctx context.Context, poolName string, nameSpace *string, imageID, newImageName string) (status int, err error) {
+	if newImageName == "" {
+		return 0, ErrImageNameIsEmpty
+	}
+
+	imageIDSpec, err := createImageIDSpec(poolName, nameSpace, imageID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	restore := struct {
+		NewImageName string `json:"new_image_name"`
+	}{NewImageName: newImageName}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(restore)
+
+	lookForTask := Task{
+		Name: "rbd/trash/restore",
+		MetaData: MetaData{
+			ImageIDSpec:  imageIDSpec,
+			NewImageName: newImageName,
+		},
+	}
+
+	reqURL := c.Session.Server.getURL(fmt.Sprintf("block/image/trash/%s/restore", url.QueryEscape(imageIDSpec)))
+
+	return c.submitTask(ctx, "RestoreBlockImageFromTrash", req, resty.MethodPost, reqURL, lookForTask, http.StatusCreated)
+}
+
+// RemoveBlockImageFromTrash removes the trashed image imageID permanently. Without force the mgr refuses to remove
+// images whose deferment did not end yet (ErrPermissionDenied).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-block-image-trash-image_id_spec
+func (c *Client) RemoveBlockImageFromTrash(poolName string, nameSpace *string, imageID string, force bool) (status int, err error) {
+	return c.RemoveBlockImageFromTrashWithContext(context.Background(), poolName, nameSpace, imageID, force)
+}
+
+// RemoveBlockImageFromTrashWithContext is like RemoveBlockImageFromTrash but aborts the request and the task wait if
+// ctx is done.
+func (c *Client) RemoveBlockImageFromTrashWithContext(ctx context.Context, poolName string, nameSpace *string, imageID string, force bool) (status int, err error) {
+	err = c.retryTask(ctx, "RemoveBlockImageFromTrash", func(ctx context.Context) error {
+		status, err = c.removeBlockImageFromTrash(ctx, poolName, nameSpace, imageID, force)
+		return err
+	})
+
+	return status, err
+}
+
+// RemoveBlockImageFromTrashAsync is like RemoveBlockImageFromTrashWithContext but returns at once. The TaskFuture
+// reports the progress of the rbd/trash/remove task and holds the result once done.
+func (c *Client) RemoveBlockImageFromTrashAsync(ctx context.Context, poolName string, nameSpace *string, imageID string, force bool) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.RemoveBlockImageFromTrashWithContext(ctx, poolName, nameSpace, imageID, force)
+	})
+}
+
+// removeBlockImageFromTrash submits the rbd/trash/remove task once and waits until it is done.
+func (c *Client) removeBlockImageFromTrash(ctx context.Context, poolName string, nameSpace *string, imageID string, force bool) (status int, err error) {
+	imageIDSpec, err := createImageIDSpec(poolName, nameSpace, imageID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("force", strconv.FormatBool(force))
+
+	lookForTask := Task{
+		Name: "rbd/trash/remove",
+		MetaData: MetaData{
+			ImageIDSpec: imageIDSpec,
+		},
+	}
+
+	reqURL := c.Session.Server.getURL(fmt.Sprintf("block/image/trash/%s", url.QueryEscape(imageIDSpec)))
+
+	return c.submitTask(ctx, "RemoveBlockImageFromTrash", req, resty.MethodDelete, reqURL, lookForTask, http.StatusNoContent)
+}
+
+// PurgeBlockTrash removes all images of the rbd trash of poolName whose deferment ended. If poolName is empty, the
+// trash of all pools is purged.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-block-image-trash-purge
+func (c *Client) PurgeBlockTrash(poolName string) (status int, err error) {
+	return c.PurgeBlockTrashWithContext(context.Background(), poolName)
+}
+
+// PurgeBlockTrashWithContext is like PurgeBlockTrash but aborts the request and the task wait if ctx is done.
+func (c *Client) PurgeBlockTrashWithContext(ctx context.Context, poolName string) (status int, err error) {
+	err = c.retryTask(ctx, "PurgeBlockTrash", func(ctx context.Context) error {
+		status, err = c.purgeBlockTrash(ctx, poolName)
+		return err
+	})
+
+	return status, err
+}
+
+// PurgeBlockTrashAsync is like PurgeBlockTrashWithContext but returns at once. The TaskFuture reports the progress of
+// the rbd/trash/purge task and holds the result once done.
+func (c *Client) PurgeBlockTrashAsync(ctx context.Context, poolName string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.PurgeBlockTrashWithContext(ctx, poolName)
+	})
+}
+
+// purgeBlockTrash submits the rbd/trash/purge task once and waits until it is done.
+func (c *Client) purgeBlockTrash(ctx context.Context, poolName string) (status int, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	if poolName != "" {
+		req.SetQueryParam("pool_name", poolName)
+	}
+
+	lookForTask := Task{
+		Name: "rbd/trash/purge",
+		MetaData: MetaData{
+			PoolName: poolName,
+		},
+	}
+
+	return c.submitTask(ctx, "PurgeBlockTrash", req, resty.MethodPost, c.Session.Server.getURL("block/image/trash/purge"), lookForTask, http.StatusCreated)
+}
+
+// createImageIDSpec creates the spec of a trashed rbd image, which uses the image id instead of the image name.
+func createImageIDSpec(poolName string, nameSpace *string, imageID string) (string, error) {
+	if poolName == "" {
+		return "", ErrPoolNameIsEmpty
+	}
+
+	if imageID == "" {
+		return "", ErrImageIDIsEmpty
+	}
+
+	return PathJoin(poolName, nameSpace, imageID), nil
+}
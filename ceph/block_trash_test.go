@@ -0,0 +1,120 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_BlockTrash(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+
+	for _, name := range []string{"trash-img-1", "trash-img-2"} {
+		if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: name, Size: 1073741824}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := client.MoveBlockImageToTrash("test-pool-1", nil, "trash-img-1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.MoveBlockImageToTrash("test-pool-1", nil, "trash-img-2", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	status, trashList, err := client.ListBlockTrash("test-pool-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if len(trashList) != 1 || len(trashList[0].Value) != 2 {
+		t.Fatalf("expected 2 images in trash of test-pool-1 - got %+v", trashList)
+	}
+
+	trash := make(map[string]ceph.RBDTrash)
+	for _, entry := range trashList[0].Value {
+		trash[entry.Name] = entry
+	}
+
+	deferred := trash["trash-img-1"]
+
+	if deferred.PoolName != "test-pool-1" || deferred.ID == "" {
+		t.Errorf("expected image in test-pool-1 with id - got %+v", deferred)
+	}
+
+	if deferred.Expired(time.Now()) || deferred.DefermentEndTime.Sub(deferred.DeletionTime) != time.Hour {
+		t.Errorf("expected deferment of 1h - got %v to %v", deferred.DeletionTime, deferred.DefermentEndTime)
+	}
+
+	// purge removes expired images only
+	status, err = client.PurgeBlockTrash("test-pool-1")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	_, trashList, err = client.ListBlockTrash("test-pool-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trashList[0].Value) != 1 || trashList[0].Value[0].Name != "trash-img-1" {
+		t.Fatalf("expected trash-img-1 left in trash - got %+v", trashList[0].Value)
+	}
+
+	status, err = client.RestoreBlockImageFromTrash("test-pool-1", nil, deferred.ID, "restored-img")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, _, err = client.GetBlockImage("test-pool-1/restored-img"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = client.MoveBlockImageToTrash("test-pool-1", nil, "restored-img", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// the deferment did not end yet
+	if _, err = client.RemoveBlockImageFromTrash("test-pool-1", nil, deferred.ID, false); !errors.Is(err, ceph.ErrPermissionDenied) {
+		t.Errorf("expected err %v - got %v", ceph.ErrPermissionDenied, err)
+	}
+
+	status, err = client.RemoveBlockImageFromTrash("test-pool-1", nil, deferred.ID, true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, err = client.RemoveBlockImageFromTrash("test-pool-1", nil, deferred.ID, true); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	if _, err = client.RestoreBlockImageFromTrash("test-pool-1", nil, "", "img"); !errors.Is(err, ceph.ErrImageIDIsEmpty) {
+		t.Errorf("expected err %v - got %v", ceph.ErrImageIDIsEmpty, err)
+	}
+}
@@ -0,0 +1,102 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// authorized checks the request for a token issued by POST /api/auth, either as bearer token or as cookie.
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	if token == "" {
+		if cookie, err := r.Cookie("token"); err == nil {
+			token = cookie.Value
+		}
+	}
+
+	_, ok := s.tokens[token]
+
+	return ok
+}
+
+// Expire invalidates all issued tokens, as the mgr does when a session expires.
+func (s *Server) Expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = make(map[string]struct{})
+}
+
+func (s *Server) handleRoot(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var credentials ceph.Credentials
+
+	if err := readJSON(r, &credentials); err != nil || credentials.Username != Username || credentials.Password != Password {
+		writeException(w, ceph.Exception{
+			Detail:    "Invalid credentials",
+			Code:      "invalid_credentials",
+			Component: "auth",
+			Status:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	token := fmt.Sprintf("token-%d", s.nextID())
+	s.tokens[token] = struct{}{}
+
+	http.SetCookie(w, &http.Cookie{Name: "token", Value: token, Path: "/", HttpOnly: true})
+
+	writeJSON(w, http.StatusCreated, ceph.Auth{
+		Token:       token,
+		Username:    credentials.Username,
+		Permissions: permissions(),
+	})
+}
+
+// permissions returns the permissions granted to Username.
+func permissions() ceph.Permissions {
+	all := []string{"create", "delete", "read", "update"}
+
+	return ceph.Permissions{
+		CephFS:   all,
+		Pool:     all,
+		RbdImage: all,
+	}
+}
+
+func (s *Server) handleCheckToken(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if _, ok := s.tokens[r.URL.Query().Get("token")]; !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"login_url":      "#/login",
+			"cluster_status": "POST_INSTALLED",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ceph.AuthCheck{
+		Username:    Username,
+		Permissions: permissions(),
+	})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if cookie, err := r.Cookie("token"); err == nil {
+		delete(s.tokens, cookie.Value)
+	}
+
+	delete(s.tokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+	http.SetCookie(w, &http.Cookie{Name: "token", Value: "", Path: "/", MaxAge: -1})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"redirect_url": "#/login",
+		"protocol":     "local",
+	})
+}
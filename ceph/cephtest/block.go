@@ -0,0 +1,627 @@
+package cephtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+const defaultObjSize = 4194304
+
+var defaultFeatures = []string{"deep-flatten", "exclusive-lock", "fast-diff", "layering", "object-map"}
+
+func imageNotFound(spec string) *ceph.Exception {
+	exception := newException("2", "rbd", fmt.Sprintf("[errno 2] RBD image not found (error opening image '%s')", spec))
+	return &exception
+}
+
+// qosValues returns the qos limits of qos by option name.
+func qosValues(qos *ceph.RBDQosConfig) map[string]uint64 {
+	var values map[string]uint64
+
+	if qos != nil {
+		b, _ := json.Marshal(qos)
+		_ = json.Unmarshal(b, &values)
+	}
+
+	return values
+}
+
+// setQosConfiguration sets the qos limits values of image like the dashboard does, as options of the image (source 2).
+// Like the dashboard, an empty configuration changes nothing.
+func setQosConfiguration(image *ceph.RBD, values map[string]uint64) {
+	if len(values) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configuration := []ceph.RBDConfiguration{}
+	for _, conf := range image.Configuration {
+		if _, ok := values[conf.Name]; !ok {
+			configuration = append(configuration, conf)
+		}
+	}
+
+	for _, name := range names {
+		configuration = append(configuration, ceph.RBDConfiguration{Name: name, Value: strconv.FormatUint(values[name], 10), Source: 2})
+	}
+
+	image.Configuration = configuration
+}
+
+// image returns the image defined by spec.
+func (s *Server) image(spec string) (*ceph.RBD, bool) {
+	image, ok := s.images[spec]
+	return image, ok
+}
+
+// addImage creates a new image and returns its spec.
+func (s *Server) addImage(poolName string, nameSpace *string, name string, size, objSize uint64, features []string) (string, *ceph.Exception) {
+	if _, ok := s.pools[poolName]; !ok {
+		exception := newException("2", "rbd", fmt.Sprintf("[errno 2] error opening pool '%s'", poolName))
+		return "", &exception
+	}
+
+	if nameSpace != nil && *nameSpace != "" {
+		if _, ok := s.namespaces[poolName][*nameSpace]; !ok {
+			exception := newException("2", "rbd", fmt.Sprintf("[errno 2] error opening namespace '%s'", *nameSpace))
+			return "", &exception
+		}
+	}
+
+	spec := ceph.PathJoin(poolName, nameSpace, name)
+
+	if _, ok := s.images[spec]; ok {
+		exception := newException(ceph.RBDImageAlreadyExists, "rbd", "[errno 17] RBD image already exists (error creating image)")
+		return "", &exception
+	}
+
+	if objSize == 0 {
+		objSize = defaultObjSize
+	}
+
+	if len(features) == 0 {
+		features = defaultFeatures
+	}
+
+	id := fmt.Sprintf("%014x", s.nextID())
+
+	var ns *string
+	if nameSpace != nil {
+		ns = new(string)
+		*ns = *nameSpace
+	}
+
+	s.images[spec] = &ceph.RBD{
+		Size:            size,
+		ObjSize:         objSize,
+		NumObjs:         uint((size + objSize - 1) / objSize),
+		Order:           22,
+		BlockNamePrefix: fmt.Sprintf("rbd_data.%s", id),
+		Name:            name,
+		UniqueID:        fmt.Sprintf("%s/%s", poolName, id),
+		ID:              id,
+		ImageFormat:     2,
+		PoolName:        poolName,
+		Namespace:       ns,
+		FeaturesName:    features,
+		Timestamp:       time.Now().UTC().Truncate(time.Second),
+		Snapshots:       []ceph.RBDSnapshot{},
+		Configuration:   []ceph.RBDConfiguration{},
+	}
+
+	return spec, nil
+}
+
+func (s *Server) handleListImages(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	poolName := r.URL.Query().Get("pool_name")
+
+	var poolNames []string
+	for name := range s.pools {
+		if poolName == "" || poolName == name {
+			poolNames = append(poolNames, name)
+		}
+	}
+	sort.Strings(poolNames)
+
+	list := ceph.RBDList{}
+
+	for _, name := range poolNames {
+		var specs []string
+		for spec, image := range s.images {
+			if image.PoolName == name {
+				specs = append(specs, spec)
+			}
+		}
+		sort.Strings(specs)
+
+		images := make([]ceph.RBD, 0, len(specs))
+		for _, spec := range specs {
+			images = append(images, *s.images[spec])
+		}
+
+		list = append(list, struct {
+			Status   int        `json:"status"`
+			Value    []ceph.RBD `json:"value"`
+			PoolName string     `json:"pool_name"`
+		}{Status: 0, Value: images, PoolName: name})
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetImage(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	image, ok := s.image(vars["image_spec"])
+	if !ok {
+		exception := imageNotFound(vars["image_spec"])
+		exception.Status = http.StatusNotFound
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, image)
+}
+
+func (s *Server) handleCreateImage(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var create ceph.RBDCreate
+
+	if err := readJSON(r, &create); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	metaData := ceph.MetaData{
+		PoolName:  create.PoolName,
+		Namespace: create.Namespace,
+		ImageName: create.Name,
+	}
+
+	status, exception := s.runTask("rbd/create", metaData, http.StatusCreated, func() *ceph.Exception {
+		spec, exception := s.addImage(create.PoolName, create.Namespace, create.Name, create.Size, create.ObjSize, create.Features)
+		if exception == nil {
+			setQosConfiguration(s.images[spec], qosValues(create.Configuration))
+		}
+
+		return exception
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleUpdateImage(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var update struct {
+		ceph.RBDUpdate
+		ceph.RBDMirroringUpdate
+
+		// Configuration holds the qos limits set with SetBlockImageQos
+		Configuration map[string]uint64 `json:"configuration"`
+	}
+
+	spec := vars["image_spec"]
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	status, exception := s.runTask("rbd/edit", ceph.MetaData{ImageSpec: spec}, http.StatusOK, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		if update.Name != "" && update.Name != image.Name {
+			newSpec := ceph.PathJoin(image.PoolName, image.Namespace, update.Name)
+			if _, exists := s.images[newSpec]; exists {
+				exception := newException(ceph.RBDImageAlreadyExists, "rbd", "[errno 17] RBD image already exists (error renaming image)")
+				return &exception
+			}
+
+			delete(s.images, spec)
+			image.Name = update.Name
+			s.images[newSpec] = image
+		}
+
+		if update.Size > 0 {
+			if uint64(update.Size) < image.Size {
+				exception := newException("22", "rbd", "Cannot shrink an RBD image")
+				return &exception
+			}
+			image.Size = uint64(update.Size)
+			image.NumObjs = uint((image.Size + image.ObjSize - 1) / image.ObjSize)
+		}
+
+		if update.Features != nil {
+			image.FeaturesName = update.Features
+		}
+
+		setQosConfiguration(image, update.Configuration)
+
+		return s.updateImageMirroring(image, update.RBDMirroringUpdate)
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleDeleteImage(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	spec := vars["image_spec"]
+
+	status, exception := s.runTask("rbd/delete", ceph.MetaData{ImageSpec: spec}, http.StatusNoContent, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		s.detachClone(image)
+		delete(s.images, spec)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleCopyImage(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var dst ceph.RBDCopy
+
+	spec := vars["image_spec"]
+
+	if err := readJSON(r, &dst); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	metaData := ceph.MetaData{
+		SrcImageSpec:  spec,
+		DestPoolName:  dst.DestPoolName,
+		DestNamespace: dst.DestNameSpace,
+		DestImageName: dst.DestImageName,
+	}
+
+	status, exception := s.runTask("rbd/copy", metaData, http.StatusCreated, func() *ceph.Exception {
+		src, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		_, exception := s.addImage(dst.DestPoolName, dst.DestNameSpace, dst.DestImageName, src.Size, dst.ObjSize, dst.Features)
+		return exception
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleMoveImageToTrash(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var body struct {
+		Delay float64 `json:"delay"`
+	}
+
+	spec := vars["image_spec"]
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	status, exception := s.runTask("rbd/trash/move", ceph.MetaData{ImageSpec: spec}, http.StatusOK, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+
+		s.trash[ceph.PathJoin(image.PoolName, image.Namespace, image.ID)] = &trashEntry{
+			RBDTrash: ceph.RBDTrash{
+				ID:               image.ID,
+				Name:             image.Name,
+				Source:           "USER",
+				DeletionTime:     now,
+				DefermentEndTime: now.Add(time.Duration(body.Delay * float64(time.Second))),
+				PoolName:         image.PoolName,
+				Namespace:        image.Namespace,
+			},
+			image: image,
+		}
+		delete(s.images, spec)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleCreateImageSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var body struct {
+		SnapshotName string `json:"snapshot_name"`
+	}
+
+	spec := vars["image_spec"]
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	status, exception := s.runTask("rbd/snap/create", ceph.MetaData{ImageSpec: spec, SnapshotName: body.SnapshotName}, http.StatusCreated, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		if _, ok := snapshot(image, body.SnapshotName); ok {
+			exception := newException(ceph.RBDImageAlreadyExists, "rbd", "[errno 17] RBD snapshot already exists (error creating snapshot)")
+			return &exception
+		}
+
+		image.Snapshots = append(image.Snapshots, ceph.RBDSnapshot{
+			ID:        s.nextID(),
+			Name:      body.SnapshotName,
+			Size:      image.Size,
+			Timestamp: time.Now().UTC().Truncate(time.Second),
+			Children:  []ceph.RBDSnapshotChild{},
+		})
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func snapshotNotFound(spec, name string) *ceph.Exception {
+	exception := newException("2", "rbd", fmt.Sprintf("[errno 2] RBD snapshot not found (error opening snapshot '%s@%s')", spec, name))
+	return &exception
+}
+
+// snapshot returns the snapshot name of image.
+func snapshot(image *ceph.RBD, name string) (*ceph.RBDSnapshot, bool) {
+	for i := range image.Snapshots {
+		if image.Snapshots[i].Name == name {
+			return &image.Snapshots[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Server) handleUpdateImageSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var update ceph.RBDSnapshotUpdate
+
+	spec, name := vars["image_spec"], vars["snapshot_name"]
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	status, exception := s.runTask("rbd/snap/edit", ceph.MetaData{ImageSpec: spec, SnapshotName: name}, http.StatusOK, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		snap, ok := snapshot(image, name)
+		if !ok {
+			return snapshotNotFound(spec, name)
+		}
+
+		if update.NewSnapName != "" && update.NewSnapName != name {
+			if _, exists := snapshot(image, update.NewSnapName); exists {
+				exception := newException(ceph.RBDImageAlreadyExists, "rbd", "[errno 17] RBD snapshot already exists (error renaming snapshot)")
+				return &exception
+			}
+			snap.Name = update.NewSnapName
+		}
+
+		if update.IsProtected != nil {
+			if !*update.IsProtected && len(snap.Children) > 0 {
+				exception := newException(ceph.ErrnoBusy, "rbd", "[errno 16] RBD snapshot has children (error unprotecting snapshot)")
+				return &exception
+			}
+			snap.IsProtected = *update.IsProtected
+		}
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleDeleteImageSnapshot(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	spec, name := vars["image_spec"], vars["snapshot_name"]
+
+	status, exception := s.runTask("rbd/snap/delete", ceph.MetaData{ImageSpec: spec, SnapshotName: name}, http.StatusNoContent, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		snap, ok := snapshot(image, name)
+		if !ok {
+			return snapshotNotFound(spec, name)
+		}
+
+		if snap.IsProtected {
+			exception := newException(ceph.ErrnoBusy, "rbd", "[errno 16] RBD snapshot is protected (error removing snapshot)")
+			return &exception
+		}
+
+		for i := range image.Snapshots {
+			if image.Snapshots[i].Name == name {
+				image.Snapshots = append(image.Snapshots[:i], image.Snapshots[i+1:]...)
+				break
+			}
+		}
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleRollbackImageSnapshot(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	spec, name := vars["image_spec"], vars["snapshot_name"]
+
+	status, exception := s.runTask("rbd/snap/rollback", ceph.MetaData{ImageSpec: spec, SnapshotName: name}, http.StatusCreated, func() *ceph.Exception {
+		image, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		snap, ok := snapshot(image, name)
+		if !ok {
+			return snapshotNotFound(spec, name)
+		}
+
+		image.Size = snap.Size
+		image.NumObjs = uint((image.Size + image.ObjSize - 1) / image.ObjSize)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleCloneImageSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var clone ceph.RBDClone
+
+	spec, name := vars["image_spec"], vars["snapshot_name"]
+
+	if err := readJSON(r, &clone); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	metaData := ceph.MetaData{
+		ParentImageSpec: spec,
+		ChildPoolName:   clone.ChildPoolName,
+		ChildNamespace:  clone.ChildNamespace,
+		ChildImageName:  clone.ChildImageName,
+	}
+
+	status, exception := s.runTask("rbd/clone", metaData, http.StatusCreated, func() *ceph.Exception {
+		parent, ok := s.image(spec)
+		if !ok {
+			return imageNotFound(spec)
+		}
+
+		snap, ok := snapshot(parent, name)
+		if !ok {
+			return snapshotNotFound(spec, name)
+		}
+
+		if !snap.IsProtected {
+			exception := newException("22", "rbd", "[errno 22] RBD snapshot is not protected (error cloning image)")
+			return &exception
+		}
+
+		childSpec, exception := s.addImage(clone.ChildPoolName, clone.ChildNamespace, clone.ChildImageName, snap.Size, clone.ObjSize, clone.Features)
+		if exception != nil {
+			return exception
+		}
+
+		s.images[childSpec].Parent = map[string]interface{}{
+			"pool_name":      parent.PoolName,
+			"pool_namespace": parent.Namespace,
+			"image_name":     parent.Name,
+			"snap_name":      snap.Name,
+		}
+
+		snap.Children = append(snap.Children, ceph.RBDSnapshotChild{
+			PoolName:      clone.ChildPoolName,
+			PoolNamespace: s.images[childSpec].Namespace,
+			ImageName:     clone.ChildImageName,
+		})
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+// detachClone removes image from the children of the snapshot it was cloned from, if any.
+func (s *Server) detachClone(image *ceph.RBD) {
+	parent, ok := image.Parent.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	parentImage, ok := s.image(ceph.PathJoin(parent["pool_name"].(string), parent["pool_namespace"].(*string), parent["image_name"].(string)))
+	if !ok {
+		return
+	}
+
+	snap, ok := snapshot(parentImage, parent["snap_name"].(string))
+	if !ok {
+		return
+	}
+
+	spec := ceph.PathJoin(image.PoolName, image.Namespace, image.Name)
+
+	for i, child := range snap.Children {
+		if ceph.PathJoin(child.PoolName, child.PoolNamespace, child.ImageName) == spec {
+			snap.Children = append(snap.Children[:i], snap.Children[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,551 @@
+package cephtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// mirroringPool implements the mirror mode and the peers of a pool.
+type mirroringPool struct {
+	mode  string
+	peers map[string]*ceph.MirroringPeer
+}
+
+// mirroringToken implements the decoded bootstrap token of the fake. Real tokens lack the site name, ceph reads it
+// from the peer cluster on import.
+type mirroringToken struct {
+	FSID     string `json:"fsid"`
+	ClientID string `json:"client_id"`
+	Key      string `json:"key"`
+	MonHost  string `json:"mon_host"`
+	SiteName string `json:"site_name"`
+}
+
+// scheduleIntervalPattern matches intervals of mirror snapshot schedules, e.g. 30m, 1h or 1d.
+var scheduleIntervalPattern = regexp.MustCompile(`^[1-9][0-9]*[mhd]$`)
+
+// AddRBDMirrorDaemon adds a running rbd-mirror daemon on hostname and returns its id. Without daemon the mirrored
+// images are reported with state unknown.
+func (s *Server) AddRBDMirrorDaemon(hostname string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := fmt.Sprintf("%d", 4100+s.nextID())
+
+	s.rbdMirrorDaemons = append(s.rbdMirrorDaemons, ceph.MirroringDaemon{
+		ID:             id,
+		InstanceID:     id,
+		Version:        "16.2.10",
+		ServerHostname: hostname,
+		ClientID:       "rbd-mirror." + hostname,
+		Leader:         len(s.rbdMirrorDaemons) == 0,
+		Health:         "OK",
+		HealthColor:    "success",
+	})
+
+	return id
+}
+
+// MirrorSnapshotSchedule returns the interval of the mirror snapshot schedule of the image imageSpec or an empty
+// string if the image has no schedule.
+func (s *Server) MirrorSnapshotSchedule(imageSpec string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.mirrorSchedules[imageSpec]
+}
+
+// mirroring returns the mirroring settings of poolName, created on first use.
+func (s *Server) mirroring(poolName string) *mirroringPool {
+	pool, ok := s.mirroringPools[poolName]
+	if !ok {
+		pool = &mirroringPool{mode: ceph.MirrorPoolModeDisabled, peers: make(map[string]*ceph.MirroringPeer)}
+		s.mirroringPools[poolName] = pool
+	}
+
+	return pool
+}
+
+// mirroringException creates an exception of the rbd component for errno code.
+func mirroringException(code, detail string) *ceph.Exception {
+	exception := newException(code, "rbd", detail)
+	return &exception
+}
+
+// lookupMirroringPool returns the mirroring settings of the pool_name path variable or writes an exception.
+func (s *Server) lookupMirroringPool(w http.ResponseWriter, vars map[string]string) (*mirroringPool, bool) {
+	if _, ok := s.pools[vars["pool_name"]]; !ok {
+		writeException(w, *mirroringException("2", fmt.Sprintf("[errno 2] error opening pool '%s'", vars["pool_name"])))
+		return nil, false
+	}
+
+	return s.mirroring(vars["pool_name"]), true
+}
+
+// addMirroringPeer adds peer to pool and returns its uuid.
+func (s *Server) addMirroringPeer(pool *mirroringPool, peer ceph.MirroringPeer) (string, *ceph.Exception) {
+	if peer.ClusterName == "" || peer.ClientID == "" {
+		return "", mirroringException("22", "[errno 22] RBD invalid argument (error adding mirror peer)")
+	}
+
+	if pool.mode == ceph.MirrorPoolModeDisabled {
+		return "", mirroringException("22", "[errno 22] RBD invalid argument (mirroring not enabled on the pool)")
+	}
+
+	for _, other := range pool.peers {
+		if other.ClusterName == peer.ClusterName && other.ClientID == peer.ClientID {
+			return "", mirroringException("17", "[errno 17] RBD mirror peer already exists")
+		}
+	}
+
+	id := s.nextID()
+	peer.UUID = fmt.Sprintf("%08x-%04x-4%03x-8%03x-%012x", id, id, id, id, id)
+	pool.peers[peer.UUID] = &peer
+
+	return peer.UUID, nil
+}
+
+// updateImageMirroring applies the mirroring settings of update to image.
+func (s *Server) updateImageMirroring(image *ceph.RBD, update ceph.RBDMirroringUpdate) *ceph.Exception {
+	spec := ceph.PathJoin(image.PoolName, image.Namespace, image.Name)
+
+	if update.EnableMirror != nil && *update.EnableMirror && image.MirrorMode == "" {
+		if s.mirroring(image.PoolName).mode != ceph.MirrorPoolModeImage {
+			return mirroringException("22", "[errno 22] RBD invalid argument (cannot enable mirroring in current pool mirroring mode)")
+		}
+
+		switch update.MirrorMode {
+		case ceph.MirrorImageModeJournal, "":
+			if !hasFeature(image, "exclusive-lock") {
+				return mirroringException("22", "[errno 22] RBD invalid argument (journaling requires exclusive-lock)")
+			}
+
+			if !hasFeature(image, "journaling") {
+				image.FeaturesName = append(append([]string(nil), image.FeaturesName...), "journaling")
+				sort.Strings(image.FeaturesName)
+			}
+
+			image.MirrorMode = ceph.MirrorImageModeJournal
+		case ceph.MirrorImageModeSnapshot:
+			image.MirrorMode = ceph.MirrorImageModeSnapshot
+		default:
+			return mirroringException("22", fmt.Sprintf("[errno 22] RBD invalid argument (invalid mirror image mode '%s')", update.MirrorMode))
+		}
+
+		primary := true
+		image.Primary = &primary
+	}
+
+	if update.EnableMirror != nil && !*update.EnableMirror {
+		image.MirrorMode = ""
+		image.Primary = nil
+		delete(s.mirrorSchedules, spec)
+	}
+
+	if update.Primary != nil {
+		if image.MirrorMode == "" {
+			return mirroringException("22", "[errno 22] RBD invalid argument (mirroring not enabled on the image)")
+		}
+
+		primary := *update.Primary
+		image.Primary = &primary
+	}
+
+	if update.ScheduleInterval != "" {
+		if image.MirrorMode != ceph.MirrorImageModeSnapshot {
+			return mirroringException("22", "[errno 22] RBD invalid argument (snapshot mirroring not enabled on the image)")
+		}
+
+		if !scheduleIntervalPattern.MatchString(update.ScheduleInterval) {
+			return mirroringException("22", fmt.Sprintf("[errno 22] RBD invalid argument (invalid schedule interval '%s')", update.ScheduleInterval))
+		}
+
+		s.mirrorSchedules[spec] = update.ScheduleInterval
+	}
+
+	if update.RemoveScheduling {
+		delete(s.mirrorSchedules, spec)
+	}
+
+	return nil
+}
+
+// hasFeature checks if feature is enabled for image.
+func hasFeature(image *ceph.RBD, feature string) bool {
+	for _, f := range image.FeaturesName {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) handleGetMirroringSiteName(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	writeJSON(w, http.StatusOK, map[string]string{"site_name": s.mirroringSiteName})
+}
+
+func (s *Server) handleSetMirroringSiteName(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body struct {
+		SiteName string `json:"site_name"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("22", "rbd", err.Error()))
+		return
+	}
+
+	if body.SiteName == "" {
+		writeException(w, *mirroringException("22", "[errno 22] RBD invalid argument (site name can not be empty)"))
+		return
+	}
+
+	s.mirroringSiteName = body.SiteName
+
+	writeJSON(w, http.StatusOK, map[string]string{"site_name": s.mirroringSiteName})
+}
+
+func (s *Server) handleGetMirroringSummary(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	summary := ceph.MirroringSummary{
+		SiteName: s.mirroringSiteName,
+		ContentData: ceph.MirroringContent{
+			Daemons:      append([]ceph.MirroringDaemon{}, s.rbdMirrorDaemons...),
+			Pools:        []ceph.MirroringPool{},
+			ImageError:   []ceph.MirroringImage{},
+			ImageSyncing: []ceph.MirroringImage{},
+			ImageReady:   []ceph.MirroringImage{},
+		},
+	}
+
+	health, color := "OK", "success"
+	if len(s.rbdMirrorDaemons) == 0 {
+		health, color = "Warning", "warning"
+	}
+
+	for _, name := range s.poolNames() {
+		pool := s.mirroring(name)
+		if pool.mode == ceph.MirrorPoolModeDisabled {
+			continue
+		}
+
+		uuids := make([]string, 0, len(pool.peers))
+		for uuid := range pool.peers {
+			uuids = append(uuids, uuid)
+		}
+		sort.Strings(uuids)
+
+		summary.ContentData.Pools = append(summary.ContentData.Pools, ceph.MirroringPool{
+			Name:        name,
+			MirrorMode:  pool.mode,
+			Health:      health,
+			HealthColor: color,
+			PeerUUIDs:   uuids,
+		})
+	}
+
+	specs := make([]string, 0, len(s.images))
+	for spec := range s.images {
+		specs = append(specs, spec)
+	}
+	sort.Strings(specs)
+
+	for _, spec := range specs {
+		image := s.images[spec]
+		if image.MirrorMode == "" {
+			continue
+		}
+
+		status := ceph.MirroringImage{PoolName: image.PoolName, Name: image.Name}
+
+		switch {
+		case len(s.rbdMirrorDaemons) == 0:
+			status.State, status.Description = "Unknown", "status not found"
+			summary.ContentData.ImageError = append(summary.ContentData.ImageError, status)
+		case image.Primary != nil && *image.Primary:
+			status.State, status.Description = "Stopped", "local image is primary"
+			summary.ContentData.ImageReady = append(summary.ContentData.ImageReady, status)
+		default:
+			status.State, status.Description = "Replaying", "replaying"
+			summary.ContentData.ImageReady = append(summary.ContentData.ImageReady, status)
+		}
+	}
+
+	if len(s.rbdMirrorDaemons) == 0 && len(summary.ContentData.Pools) > 0 {
+		summary.Status = 1
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (s *Server) handleGetMirroringPool(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"mirror_mode": pool.mode})
+}
+
+func (s *Server) handleSetMirroringPool(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		MirrorMode string `json:"mirror_mode"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("22", "rbd", err.Error()))
+		return
+	}
+
+	md := ceph.MetaData{PoolName: vars["pool_name"]}
+
+	status, exception := s.runTask("rbd/mirroring/pool/edit", md, http.StatusOK, func() *ceph.Exception {
+		switch body.MirrorMode {
+		case ceph.MirrorPoolModeDisabled:
+			for _, image := range s.images {
+				if image.PoolName == vars["pool_name"] && image.MirrorMode != "" {
+					return mirroringException("22", "[errno 22] RBD invalid argument (mirroring is enabled on one or more images)")
+				}
+			}
+		case ceph.MirrorPoolModeImage, ceph.MirrorPoolModePool:
+		default:
+			return mirroringException("22", fmt.Sprintf("[errno 22] RBD invalid argument (invalid mirror mode '%s')", body.MirrorMode))
+		}
+
+		pool.mode = body.MirrorMode
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleCreateMirroringBootstrapToken(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	if pool.mode == ceph.MirrorPoolModeDisabled {
+		writeException(w, *mirroringException("22", "[errno 22] RBD invalid argument (mirroring not enabled on the pool)"))
+		return
+	}
+
+	token, _ := json.Marshal(mirroringToken{
+		FSID:     fsid,
+		ClientID: "rbd-mirror-peer",
+		Key:      "AQBmZmFrZWtleWZvcnRlc3RzMDAwMDAwMDAwMDA=",
+		MonHost:  "[v2:" + s.Listener.Addr().String() + "]",
+		SiteName: s.mirroringSiteName,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{"token": base64.StdEncoding.EncodeToString(token)})
+}
+
+func (s *Server) handleImportMirroringBootstrapToken(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Direction string `json:"direction"`
+		Token     string `json:"token"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("22", "rbd", err.Error()))
+		return
+	}
+
+	var token mirroringToken
+
+	decoded, err := base64.StdEncoding.DecodeString(body.Token)
+	if err == nil {
+		err = json.Unmarshal(decoded, &token)
+	}
+
+	if err != nil || token.SiteName == "" {
+		writeException(w, *mirroringException("22", "[errno 22] RBD invalid argument (failed to decode peer bootstrap token)"))
+		return
+	}
+
+	if body.Direction != ceph.MirrorDirectionRX && body.Direction != ceph.MirrorDirectionRXTX {
+		writeException(w, *mirroringException("22", fmt.Sprintf("[errno 22] RBD invalid argument (invalid direction '%s')", body.Direction)))
+		return
+	}
+
+	if token.SiteName == s.mirroringSiteName {
+		writeException(w, *mirroringException("22", "[errno 22] RBD invalid argument (cannot import token for local cluster)"))
+		return
+	}
+
+	if pool.mode == ceph.MirrorPoolModeDisabled {
+		pool.mode = ceph.MirrorPoolModeImage
+	}
+
+	for _, peer := range pool.peers {
+		if peer.ClusterName == token.SiteName {
+			writeJSON(w, http.StatusOK, map[string]interface{}{})
+			return
+		}
+	}
+
+	peer := ceph.MirroringPeer{ClusterName: token.SiteName, ClientID: token.ClientID, MonHost: token.MonHost, Key: token.Key}
+
+	if _, exception := s.addMirroringPeer(pool, peer); exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *Server) handleListMirroringPeers(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	uuids := make([]string, 0, len(pool.peers))
+	for uuid := range pool.peers {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	writeJSON(w, http.StatusOK, uuids)
+}
+
+func (s *Server) handleGetMirroringPeer(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	peer, ok := pool.peers[vars["peer_uuid"]]
+	if !ok {
+		exception := mirroringException("2", fmt.Sprintf("[errno 2] RBD mirror peer %s not found", vars["peer_uuid"]))
+		exception.Status = http.StatusNotFound
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, peer)
+}
+
+func (s *Server) handleAddMirroringPeer(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	var peer ceph.MirroringPeer
+
+	if err := readJSON(r, &peer); err != nil {
+		writeException(w, newException("22", "rbd", err.Error()))
+		return
+	}
+
+	md := ceph.MetaData{PoolName: vars["pool_name"]}
+
+	status, exception := s.runTask("rbd/mirroring/peer/add", md, http.StatusCreated, func() *ceph.Exception {
+		_, exception := s.addMirroringPeer(pool, peer)
+		return exception
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleUpdateMirroringPeer(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	var update ceph.MirroringPeer
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, newException("22", "rbd", err.Error()))
+		return
+	}
+
+	md := ceph.MetaData{PoolName: vars["pool_name"], PeerUUID: vars["peer_uuid"]}
+
+	status, exception := s.runTask("rbd/mirroring/peer/edit", md, http.StatusOK, func() *ceph.Exception {
+		peer, ok := pool.peers[vars["peer_uuid"]]
+		if !ok {
+			return mirroringException("2", fmt.Sprintf("[errno 2] RBD mirror peer %s not found", vars["peer_uuid"]))
+		}
+
+		if update.ClusterName != "" {
+			peer.ClusterName = update.ClusterName
+		}
+
+		if update.ClientID != "" {
+			peer.ClientID = update.ClientID
+		}
+
+		if update.MonHost != "" {
+			peer.MonHost = update.MonHost
+		}
+
+		if update.Key != "" {
+			peer.Key = update.Key
+		}
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleRemoveMirroringPeer(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	pool, ok := s.lookupMirroringPool(w, vars)
+	if !ok {
+		return
+	}
+
+	md := ceph.MetaData{PoolName: vars["pool_name"], PeerUUID: vars["peer_uuid"]}
+
+	status, exception := s.runTask("rbd/mirroring/peer/delete", md, http.StatusNoContent, func() *ceph.Exception {
+		if _, ok := pool.peers[vars["peer_uuid"]]; !ok {
+			return mirroringException("2", fmt.Sprintf("[errno 2] RBD mirror peer %s not found", vars["peer_uuid"]))
+		}
+
+		delete(pool.peers, vars["peer_uuid"])
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
@@ -0,0 +1,100 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// AddNameSpace creates namespace in the rbd pool poolName.
+func (s *Server) AddNameSpace(poolName, nameSpace string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.namespaces[poolName]; !ok {
+		s.namespaces[poolName] = make(map[string]struct{})
+	}
+
+	s.namespaces[poolName][nameSpace] = struct{}{}
+}
+
+func (s *Server) handleListNamespaces(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	poolName := vars["pool_name"]
+
+	namespaces, ok := s.namespaces[poolName]
+	if !ok {
+		exception := poolNotFound(poolName)
+		writeException(w, exception)
+		return
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]ceph.NameSpace, 0, len(names))
+	for _, name := range names {
+		ns := ceph.NameSpace{NameSpace: name}
+		for _, image := range s.images {
+			if image.PoolName == poolName && image.Namespace != nil && *image.Namespace == name {
+				ns.NumImages++
+			}
+		}
+		list = append(list, ns)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleCreateNamespace(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var ns ceph.NameSpace
+
+	poolName := vars["pool_name"]
+
+	if err := readJSON(r, &ns); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	namespaces, ok := s.namespaces[poolName]
+	if !ok {
+		exception := poolNotFound(poolName)
+		exception.Status = http.StatusBadRequest
+		writeException(w, exception)
+		return
+	}
+
+	if _, exists := namespaces[ns.NameSpace]; exists {
+		writeException(w, newException(ceph.NameSpaceAlreadyExists, "rbd",
+			fmt.Sprintf("Namespace '%s' already exists.", ns.NameSpace)))
+		return
+	}
+
+	namespaces[ns.NameSpace] = struct{}{}
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handleDeleteNamespace(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	poolName, nameSpace := vars["pool_name"], vars["namespace"]
+
+	if _, ok := s.namespaces[poolName][nameSpace]; !ok {
+		writeException(w, newException("2", "rbd", fmt.Sprintf("[errno 2] namespace '%s' does not exist", nameSpace)))
+		return
+	}
+
+	for _, image := range s.images {
+		if image.PoolName == poolName && image.Namespace != nil && *image.Namespace == nameSpace {
+			writeException(w, newException("namespace_has_images", "rbd", "Namespace contains images which must be deleted first."))
+			return
+		}
+	}
+
+	delete(s.namespaces[poolName], nameSpace)
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,155 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// trashEntry implements an image moved to the rbd trash.
+type trashEntry struct {
+	ceph.RBDTrash
+	image *ceph.RBD
+}
+
+func trashEntryNotFound(idSpec string) *ceph.Exception {
+	exception := newException("2", "rbd", fmt.Sprintf("[errno 2] RBD image not found (error opening image '%s' in trash)", idSpec))
+	return &exception
+}
+
+func (s *Server) handleListTrash(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	poolName := r.URL.Query().Get("pool_name")
+
+	var poolNames []string
+	for name := range s.pools {
+		if poolName == "" || poolName == name {
+			poolNames = append(poolNames, name)
+		}
+	}
+	sort.Strings(poolNames)
+
+	list := ceph.RBDTrashList{}
+
+	for _, name := range poolNames {
+		var idSpecs []string
+		for idSpec, entry := range s.trash {
+			if entry.PoolName == name {
+				idSpecs = append(idSpecs, idSpec)
+			}
+		}
+		sort.Strings(idSpecs)
+
+		entries := make([]ceph.RBDTrash, 0, len(idSpecs))
+		for _, idSpec := range idSpecs {
+			entries = append(entries, s.trash[idSpec].RBDTrash)
+		}
+
+		list = append(list, struct {
+			Status   int             `json:"status"`
+			Value    []ceph.RBDTrash `json:"value"`
+			PoolName string          `json:"pool_name"`
+		}{Status: 0, Value: entries, PoolName: name})
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleRestoreTrash(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var body struct {
+		NewImageName string `json:"new_image_name"`
+	}
+
+	idSpec := vars["image_id_spec"]
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "rbd", err.Error()))
+		return
+	}
+
+	metaData := ceph.MetaData{ImageIDSpec: idSpec, NewImageName: body.NewImageName}
+
+	status, exception := s.runTask("rbd/trash/restore", metaData, http.StatusCreated, func() *ceph.Exception {
+		entry, ok := s.trash[idSpec]
+		if !ok {
+			return trashEntryNotFound(idSpec)
+		}
+
+		spec := ceph.PathJoin(entry.PoolName, entry.Namespace, body.NewImageName)
+		if _, exists := s.images[spec]; exists {
+			exception := newException(ceph.RBDImageAlreadyExists, "rbd", "[errno 17] RBD image already exists (error restoring image from trash)")
+			return &exception
+		}
+
+		entry.image.Name = body.NewImageName
+		s.images[spec] = entry.image
+		delete(s.trash, idSpec)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleRemoveTrash(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	idSpec := vars["image_id_spec"]
+	force := r.URL.Query().Get("force") == "true"
+
+	status, exception := s.runTask("rbd/trash/remove", ceph.MetaData{ImageIDSpec: idSpec}, http.StatusNoContent, func() *ceph.Exception {
+		entry, ok := s.trash[idSpec]
+		if !ok {
+			return trashEntryNotFound(idSpec)
+		}
+
+		if !force && !entry.Expired(time.Now()) {
+			exception := newException(ceph.ErrnoPermission, "rbd", "[errno 1] RBD permission error (error deleting image from trash)")
+			return &exception
+		}
+
+		delete(s.trash, idSpec)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handlePurgeTrash(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	poolName := r.URL.Query().Get("pool_name")
+
+	status, exception := s.runTask("rbd/trash/purge", ceph.MetaData{PoolName: poolName}, http.StatusCreated, func() *ceph.Exception {
+		if _, ok := s.pools[poolName]; poolName != "" && !ok {
+			exception := newException("2", "rbd", fmt.Sprintf("[errno 2] error opening pool '%s'", poolName))
+			return &exception
+		}
+
+		now := time.Now()
+
+		for idSpec, entry := range s.trash {
+			if (poolName == "" || entry.PoolName == poolName) && entry.Expired(now) {
+				delete(s.trash, idSpec)
+			}
+		}
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
@@ -0,0 +1,441 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// fileSystem implements an in-memory ceph fs directory tree.
+type fileSystem struct {
+	name    string
+	dirs    map[string]*ceph.Directory
+	clients []ceph.FSClient
+	groups  map[string]*subvolumeGroup
+}
+
+// mdsCounters lists the performance counters reported by GET /api/cephfs/{fs_id}/mds_counters.
+var mdsCounters = []string{
+	"mds_server.handle_client_request", "mds_log.ev", "mds_cache.num_strays", "mds.inodes", "mds.caps", "mds_mem.ino",
+}
+
+// AddFS creates a ceph fs named name and returns its id.
+func (s *Server) AddFS(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID()
+
+	s.fileSystem[id] = &fileSystem{
+		name: name,
+		dirs: map[string]*ceph.Directory{
+			"/": {
+				Name:      "/",
+				Path:      "/",
+				Parent:    "",
+				Snapshots: []interface{}{},
+				Quotas:    ceph.Quota{},
+			},
+		},
+		groups: map[string]*subvolumeGroup{},
+	}
+
+	return id
+}
+
+// AddFSClient adds a kernel client on hostname mounting the directory root of the ceph fs fsID and returns its
+// session id.
+func (s *Server) AddFSClient(fsID int, hostname, root string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := 4000 + s.nextID()
+
+	fs := s.fileSystem[fsID]
+	fs.clients = append(fs.clients, ceph.FSClient{
+		ID:      id,
+		State:   "open",
+		NumCaps: 1,
+		Inst:    fmt.Sprintf("client.%d v1:10.0.0.%d:0/%d", id, len(fs.clients)+1, id),
+		Type:    "kernel",
+		Version: "5.15.0",
+		ClientMetadata: ceph.FSClientMetadata{
+			EntityID:      "admin",
+			Hostname:      hostname,
+			Root:          path.Clean("/" + root),
+			KernelVersion: "5.15.0",
+		},
+	})
+
+	return id
+}
+
+// mdsName returns the name of the active mds daemon of fs.
+func (fs *fileSystem) mdsName() string {
+	return fs.name + ".a"
+}
+
+// lookupFS resolves the fs_id path variable and writes a http 404 if the ceph fs does not exist.
+func (s *Server) lookupFS(w http.ResponseWriter, vars map[string]string) (*fileSystem, bool) {
+	id, err := strconv.Atoi(vars["fs_id"])
+	if err == nil {
+		if fs, ok := s.fileSystem[id]; ok {
+			return fs, true
+		}
+	}
+
+	exception := newException("2", "cephfs", fmt.Sprintf("CephFS id '%s' not found", vars["fs_id"]))
+	exception.Status = http.StatusNotFound
+	writeException(w, exception)
+
+	return nil, false
+}
+
+// mkdirs creates p and all missing parent directories.
+func (fs *fileSystem) mkdirs(p string) {
+	p = path.Clean("/" + p)
+
+	if _, ok := fs.dirs[p]; ok {
+		return
+	}
+
+	parent := path.Dir(p)
+	fs.mkdirs(parent)
+
+	fs.dirs[p] = &ceph.Directory{
+		Name:      path.Base(p),
+		Path:      p,
+		Parent:    parent,
+		Snapshots: []interface{}{},
+		Quotas:    ceph.Quota{},
+	}
+}
+
+// depth returns the number of path elements of p below base.
+func depth(base, p string) int {
+	rel := strings.Trim(strings.TrimPrefix(p, base), "/")
+	if rel == "" {
+		return 0
+	}
+
+	return strings.Count(rel, "/") + 1
+}
+
+func (s *Server) handleListFS(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	ids := make([]int, 0, len(s.fileSystem))
+	for id := range s.fileSystem {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	list := make([]ceph.FS, 0, len(ids))
+	for _, id := range ids {
+		fs := ceph.FS{ID: id}
+		fs.MdsMap.FsName = s.fileSystem[id].name
+		fs.MdsMap.Enabled = true
+		fs.MdsMap.MaxMds = 1
+		list = append(list, fs)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetFS(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	id, _ := strconv.Atoi(vars["fs_id"])
+
+	caps := 0
+	for _, client := range fs.clients {
+		caps += client.NumCaps
+	}
+
+	avail := s.capacity().TotalAvailBytes / 3
+
+	writeJSON(w, http.StatusOK, ceph.FSDetail{
+		CephFS: ceph.FSInfo{
+			ID:          id,
+			Name:        fs.name,
+			ClientCount: len(fs.clients),
+			Ranks: []ceph.FSRank{{
+				Rank:  0,
+				State: "active",
+				Mds:   fs.mdsName(),
+				Dns:   len(fs.dirs),
+				Inos:  len(fs.dirs),
+				Dirs:  len(fs.dirs),
+				Caps:  caps,
+			}},
+			Pools: []ceph.FSPool{
+				{Pool: fmt.Sprintf("cephfs.%s.meta", fs.name), Type: "metadata", Used: uint64(len(fs.dirs)) * 4096, Avail: avail},
+				{Pool: fmt.Sprintf("cephfs.%s.data", fs.name), Type: "data", Avail: avail},
+			},
+		},
+		Standbys: []ceph.FSStandby{{Name: fs.name + ".b"}},
+		Versions: map[string][]string{
+			"ceph version 16.2.10 (45fa1a083152e41a408d15505f594ec5f1b4fe17) pacific (stable)": {fs.mdsName(), fs.name + ".b"},
+		},
+	})
+}
+
+func (s *Server) handleListFSClients(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":   append([]ceph.FSClient{}, fs.clients...),
+		"status": 0,
+	})
+}
+
+func (s *Server) handleEvictFSClient(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(vars["client_id"])
+	if err != nil {
+		writeException(w, newException("22", "cephfs", fmt.Sprintf("invalid client id '%s'", vars["client_id"])))
+		return
+	}
+
+	// like ceph tell mds client evict, evicting a client not connected succeeds
+	for i, client := range fs.clients {
+		if client.ID == id {
+			fs.clients = append(fs.clients[:i], fs.clients[i+1:]...)
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleGetMDSCounters(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	now := float64(time.Now().UnixNano()) / 1e9
+
+	counters := make(map[string][]ceph.MDSCounterSample, len(mdsCounters))
+	for _, name := range mdsCounters {
+		counters[name] = []ceph.MDSCounterSample{}
+	}
+
+	counters["mds.inodes"] = []ceph.MDSCounterSample{{now, float64(len(fs.dirs))}}
+	counters["mds.caps"] = []ceph.MDSCounterSample{{now, float64(len(fs.clients))}}
+
+	writeJSON(w, http.StatusOK, ceph.MDSCounters{fs.mdsName(): counters})
+}
+
+func (s *Server) handleGetRootDirectory(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fs.dirs["/"])
+}
+
+func (s *Server) handleListDir(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	base := path.Clean("/" + r.URL.Query().Get("path"))
+
+	maxDepth := 1
+	if d, err := strconv.Atoi(r.URL.Query().Get("depth")); err == nil {
+		maxDepth = d
+	}
+
+	if _, exists := fs.dirs[base]; !exists {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("[errno 2] error in ls_dir '%s'", base)))
+		return
+	}
+
+	var paths []string
+	for p := range fs.dirs {
+		if p == base || !(base == "/" || strings.HasPrefix(p, base+"/")) {
+			continue
+		}
+
+		if depth(base, p) <= maxDepth {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	dirs := make([]ceph.Directory, 0, len(paths))
+	for _, p := range paths {
+		dirs = append(dirs, *fs.dirs[p])
+	}
+
+	writeJSON(w, http.StatusOK, dirs)
+}
+
+func (s *Server) handleCreateDir(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var body struct {
+		Path string `json:"path"`
+	}
+
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	fs.mkdirs(body.Path)
+
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleDeleteDir(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	p := path.Clean("/" + r.URL.Query().Get("path"))
+
+	if _, exists := fs.dirs[p]; !exists || p == "/" {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("[errno 2] error in rmdir '%s'", p)))
+		return
+	}
+
+	for other := range fs.dirs {
+		if strings.HasPrefix(other, p+"/") {
+			writeException(w, newException("39", "cephfs", fmt.Sprintf("[errno 39] error in rmdir '%s'", p)))
+			return
+		}
+	}
+
+	delete(fs.dirs, p)
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleGetQuota(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	dir, exists := fs.dirs[path.Clean("/"+r.URL.Query().Get("path"))]
+	if !exists {
+		writeException(w, newException("2", "cephfs", "[errno 2] error in getxattr"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{
+		"max_bytes": dir.Quotas.MaxBytes,
+		"max_files": dir.Quotas.MaxFiles,
+	})
+}
+
+func (s *Server) handleSetQuota(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var quota ceph.Quota
+
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	if err := readJSON(r, &quota); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	dir, exists := fs.dirs[path.Clean("/"+quota.Path)]
+	if !exists {
+		writeException(w, newException("2", "cephfs", "[errno 2] error in setxattr"))
+		return
+	}
+
+	dir.Quotas.MaxBytes = quota.MaxBytes
+	dir.Quotas.MaxFiles = quota.MaxFiles
+
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleCreateSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var snap ceph.SnapShot
+
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	if err := readJSON(r, &snap); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	dir, exists := fs.dirs[path.Clean("/"+snap.Path)]
+	if !exists {
+		writeException(w, newException("2", "cephfs", "[errno 2] error in mkdir"))
+		return
+	}
+
+	if snap.Name == "" {
+		snap.Name = time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+
+	for _, existing := range dir.Snapshots {
+		if existing.(map[string]interface{})["name"] == snap.Name {
+			writeException(w, newException("17", "cephfs", "[errno 17] error in mkdir"))
+			return
+		}
+	}
+
+	dir.Snapshots = append(dir.Snapshots, map[string]interface{}{
+		"name":    snap.Name,
+		"path":    path.Join(dir.Path, ".snap", snap.Name),
+		"created": time.Now().UTC().Format(time.RFC3339),
+	})
+
+	writeJSON(w, http.StatusOK, snap.Name)
+}
+
+func (s *Server) handleDeleteSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupFS(w, vars)
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+
+	dir, exists := fs.dirs[path.Clean("/"+r.URL.Query().Get("path"))]
+	if !exists {
+		writeException(w, newException("2", "cephfs", "[errno 2] error in rmdir"))
+		return
+	}
+
+	for i, existing := range dir.Snapshots {
+		if existing.(map[string]interface{})["name"] == name {
+			dir.Snapshots = append(dir.Snapshots[:i], dir.Snapshots[i+1:]...)
+			writeJSON(w, http.StatusOK, nil)
+			return
+		}
+	}
+
+	writeException(w, newException("2", "cephfs", "[errno 2] error in rmdir"))
+}
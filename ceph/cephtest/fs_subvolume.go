@@ -0,0 +1,700 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// noGroup is the group of the subvolumes created without group name.
+const noGroup = "_nogroup"
+
+// subvolumeGroup implements an in-memory subvolume group with its subvolumes.
+type subvolumeGroup struct {
+	info       ceph.FSSubvolumeGroupInfo
+	subvolumes map[string]*subvolume
+}
+
+// subvolume implements an in-memory subvolume with its snapshots. A clone is in-progress until cloned.
+type subvolume struct {
+	info      ceph.FSSubvolumeInfo
+	snapshots map[string]*subvolumeSnapshot
+	cloned    time.Time
+}
+
+// subvolumeSnapshot implements an in-memory subvolume snapshot with the clones created from it.
+type subvolumeSnapshot struct {
+	info   ceph.FSSubvolumeSnapshotInfo
+	clones []*subvolume
+}
+
+// volumeTime formats t like the volumes module does.
+func volumeTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// parseMode parses the octal mode of a subvolume or group directory, 755 by default.
+func parseMode(mode string) (int, error) {
+	if mode == "" {
+		mode = "755"
+	}
+
+	m, err := strconv.ParseUint(mode, 8, 32)
+
+	return 0o40000 | int(m), err
+}
+
+// bytesPercent returns the usage in percent of quota like the volumes module does.
+func bytesPercent(used uint64, quota ceph.FSQuotaBytes) string {
+	if quota == 0 {
+		return "undefined"
+	}
+
+	return fmt.Sprintf("%.2f", float64(used)*100/float64(quota))
+}
+
+// state returns the state of sv, which changes from in-progress to complete once cloned.
+func (sv *subvolume) state(now time.Time) string {
+	if sv.info.Type == ceph.FSSubvolumeTypeClone && now.Before(sv.cloned) {
+		return ceph.FSSubvolumeStateInProgress
+	}
+
+	return sv.info.State
+}
+
+// lookupVolume resolves the vol_name path variable and writes an errno 2 exception if the ceph fs does not exist.
+func (s *Server) lookupVolume(w http.ResponseWriter, volName string) (*fileSystem, bool) {
+	for _, fs := range s.fileSystem {
+		if fs.name == volName {
+			return fs, true
+		}
+	}
+
+	writeException(w, newException("2", "cephfs", fmt.Sprintf("volume '%s' does not exist", volName)))
+
+	return nil, false
+}
+
+// lookupGroup returns the subvolume group name of fs (the default group if name is empty) and writes an errno 2
+// exception if the group does not exist.
+func (fs *fileSystem) lookupGroup(w http.ResponseWriter, name string) (*subvolumeGroup, bool) {
+	if name == "" || name == noGroup {
+		if _, ok := fs.groups[noGroup]; !ok {
+			fs.groups[noGroup] = &subvolumeGroup{subvolumes: map[string]*subvolume{}}
+		}
+
+		return fs.groups[noGroup], true
+	}
+
+	group, ok := fs.groups[name]
+	if !ok {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("subvolume group '%s' does not exist", name)))
+		return nil, false
+	}
+
+	return group, true
+}
+
+// lookupSubvolume returns the subvolume name of group and writes an errno 2 exception if it does not exist.
+func (group *subvolumeGroup) lookupSubvolume(w http.ResponseWriter, name string) (*subvolume, bool) {
+	sv, ok := group.subvolumes[name]
+	if !ok {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("subvolume '%s' does not exist", name)))
+		return nil, false
+	}
+
+	return sv, true
+}
+
+// createSubvolume adds the subvolume name with the quota, mode, owner and data pool of info to group of fs and creates
+// its directory below /volumes.
+func (s *Server) createSubvolume(fs *fileSystem, group *subvolumeGroup, groupName, name string, info ceph.FSSubvolumeInfo) *subvolume {
+	if groupName == "" {
+		groupName = noGroup
+	}
+
+	if info.DataPool == "" {
+		info.DataPool = fmt.Sprintf("cephfs.%s.data", fs.name)
+	}
+
+	id := s.nextID()
+
+	info.Path = path.Join("/volumes", groupName, name, fmt.Sprintf("%08x-%04x-4%03x-8%03x-%012x", id, id, id, id, id))
+	info.Atime = volumeTime(time.Now())
+	info.CreatedAt, info.Ctime, info.Mtime = info.Atime, info.Atime, info.Atime
+	info.BytesPercent = bytesPercent(0, info.BytesQuota)
+	info.Features = []string{"snapshot-clone", "snapshot-autoprotect", "snapshot-retention"}
+	info.MonAddrs = []string{"10.0.0.1:6789", "10.0.0.2:6789", "10.0.0.3:6789"}
+	info.State = ceph.FSSubvolumeStateComplete
+
+	if info.Type == "" {
+		info.Type = ceph.FSSubvolumeTypeSubvolume
+	}
+
+	fs.mkdirs(info.Path)
+	fs.dirs[info.Path].Quotas.MaxBytes = int(info.BytesQuota)
+
+	sv := &subvolume{info: info, snapshots: map[string]*subvolumeSnapshot{}}
+	group.subvolumes[name] = sv
+
+	return sv
+}
+
+// removeDirs removes the directory p of fs with all directories below.
+func (fs *fileSystem) removeDirs(p string) {
+	for other := range fs.dirs {
+		if other == p || strings.HasPrefix(other, p+"/") {
+			delete(fs.dirs, other)
+		}
+	}
+}
+
+func (s *Server) handleListFSSubvolumes(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, r.URL.Query().Get("group_name"))
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(group.subvolumes))
+	for name := range group.subvolumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+
+	list := make([]ceph.FSSubvolume, 0, len(names))
+	for _, name := range names {
+		sv := group.subvolumes[name]
+
+		info := sv.info
+		info.State = sv.state(now)
+		list = append(list, ceph.FSSubvolume{Name: name, Info: info})
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetFSSubvolume(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, r.URL.Query().Get("group_name"))
+	if !ok {
+		return
+	}
+
+	sv, ok := group.lookupSubvolume(w, r.URL.Query().Get("subvol_name"))
+	if !ok {
+		return
+	}
+
+	info := sv.info
+	info.State = sv.state(time.Now())
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleCreateFSSubvolume(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body ceph.FSSubvolumeCreate
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	fs, ok := s.lookupVolume(w, body.VolName)
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, body.GroupName)
+	if !ok {
+		return
+	}
+
+	// like ceph fs subvolume create, creating an existing subvolume succeeds
+	if _, exists := group.subvolumes[body.SubvolName]; exists {
+		writeJSON(w, http.StatusCreated, fmt.Sprintf("Subvolume %s created successfully", body.SubvolName))
+		return
+	}
+
+	mode, err := parseMode(body.Mode)
+	if err != nil {
+		writeException(w, newException("22", "cephfs", fmt.Sprintf("invalid mode '%s'", body.Mode)))
+		return
+	}
+
+	info := ceph.FSSubvolumeInfo{BytesQuota: ceph.FSQuotaBytes(body.Size), DataPool: body.PoolLayout, Mode: mode}
+
+	if body.UID != nil {
+		info.UID = *body.UID
+	}
+
+	if body.GID != nil {
+		info.GID = *body.GID
+	}
+
+	if body.NamespaceIsolated {
+		info.PoolNamespace = "fsvolumens_" + body.SubvolName
+	}
+
+	s.createSubvolume(fs, group, body.GroupName, body.SubvolName, info)
+
+	writeJSON(w, http.StatusCreated, fmt.Sprintf("Subvolume %s created successfully", body.SubvolName))
+}
+
+func (s *Server) handleResizeFSSubvolume(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var body struct {
+		SubvolName string            `json:"subvol_name"`
+		GroupName  string            `json:"group_name"`
+		Size       ceph.FSQuotaBytes `json:"size"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("22", "cephfs", err.Error()))
+		return
+	}
+
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, body.GroupName)
+	if !ok {
+		return
+	}
+
+	sv, ok := group.lookupSubvolume(w, body.SubvolName)
+	if !ok {
+		return
+	}
+
+	sv.info.BytesQuota = body.Size
+	sv.info.BytesPercent = bytesPercent(sv.info.BytesUsed, body.Size)
+
+	if dir, exists := fs.dirs[sv.info.Path]; exists {
+		dir.Quotas.MaxBytes = int(body.Size)
+	}
+
+	writeJSON(w, http.StatusOK, fmt.Sprintf("Subvolume %s updated successfully", body.SubvolName))
+}
+
+func (s *Server) handleDeleteFSSubvolume(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, r.URL.Query().Get("group_name"))
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("subvol_name")
+
+	sv, ok := group.lookupSubvolume(w, name)
+	if !ok {
+		return
+	}
+
+	if sv.state(time.Now()) == ceph.FSSubvolumeStateInProgress {
+		writeException(w, newException("11", "cephfs", fmt.Sprintf("subvolume '%s' clone in-progress", name)))
+		return
+	}
+
+	if len(sv.snapshots) > 0 {
+		if r.URL.Query().Get("retain_snapshots") != "true" {
+			writeException(w, newException("39", "cephfs", fmt.Sprintf("subvolume '%s' has snapshots", name)))
+			return
+		}
+
+		sv.info.State = ceph.FSSubvolumeStateSnapshotRetained
+		fs.removeDirs(path.Dir(sv.info.Path))
+
+		writeJSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	delete(group.subvolumes, name)
+	fs.removeDirs(path.Dir(sv.info.Path))
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleListFSSubvolumeGroups(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(fs.groups))
+	for name := range fs.groups {
+		if name != noGroup {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	list := make([]ceph.FSSubvolumeGroup, 0, len(names))
+	for _, name := range names {
+		list = append(list, ceph.FSSubvolumeGroup{Name: name, Info: fs.groups[name].info})
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetFSSubvolumeGroup(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("group_name")
+
+	group, exists := fs.groups[name]
+	if !exists || name == noGroup {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("subvolume group '%s' does not exist", name)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, group.info)
+}
+
+func (s *Server) handleCreateFSSubvolumeGroup(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body ceph.FSSubvolumeGroupCreate
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	fs, ok := s.lookupVolume(w, body.VolName)
+	if !ok {
+		return
+	}
+
+	if body.GroupName == noGroup {
+		writeException(w, newException("1", "cephfs", fmt.Sprintf("operation not permitted on group '%s'", noGroup)))
+		return
+	}
+
+	// like ceph fs subvolumegroup create, creating an existing group succeeds
+	if _, exists := fs.groups[body.GroupName]; exists {
+		writeJSON(w, http.StatusCreated, fmt.Sprintf("Subvolume group %s created successfully", body.GroupName))
+		return
+	}
+
+	mode, err := parseMode(body.Mode)
+	if err != nil {
+		writeException(w, newException("22", "cephfs", fmt.Sprintf("invalid mode '%s'", body.Mode)))
+		return
+	}
+
+	info := ceph.FSSubvolumeGroupInfo{
+		BytesQuota: ceph.FSQuotaBytes(body.Size),
+		DataPool:   body.PoolLayout,
+		Mode:       mode,
+		MonAddrs:   []string{"10.0.0.1:6789", "10.0.0.2:6789", "10.0.0.3:6789"},
+	}
+
+	info.Atime = volumeTime(time.Now())
+	info.CreatedAt, info.Ctime, info.Mtime = info.Atime, info.Atime, info.Atime
+	info.BytesPercent = bytesPercent(0, info.BytesQuota)
+
+	if info.DataPool == "" {
+		info.DataPool = fmt.Sprintf("cephfs.%s.data", fs.name)
+	}
+
+	if body.UID != nil {
+		info.UID = *body.UID
+	}
+
+	if body.GID != nil {
+		info.GID = *body.GID
+	}
+
+	p := path.Join("/volumes", body.GroupName)
+	fs.mkdirs(p)
+	fs.dirs[p].Quotas.MaxBytes = int(body.Size)
+
+	fs.groups[body.GroupName] = &subvolumeGroup{info: info, subvolumes: map[string]*subvolume{}}
+
+	writeJSON(w, http.StatusCreated, fmt.Sprintf("Subvolume group %s created successfully", body.GroupName))
+}
+
+func (s *Server) handleResizeFSSubvolumeGroup(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var body struct {
+		GroupName string            `json:"group_name"`
+		Size      ceph.FSQuotaBytes `json:"size"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("22", "cephfs", err.Error()))
+		return
+	}
+
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	group, exists := fs.groups[body.GroupName]
+	if !exists || body.GroupName == noGroup {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("subvolume group '%s' does not exist", body.GroupName)))
+		return
+	}
+
+	group.info.BytesQuota = body.Size
+	group.info.BytesPercent = bytesPercent(group.info.BytesUsed, body.Size)
+	fs.dirs[path.Join("/volumes", body.GroupName)].Quotas.MaxBytes = int(body.Size)
+
+	writeJSON(w, http.StatusOK, fmt.Sprintf("Subvolume group %s updated successfully", body.GroupName))
+}
+
+func (s *Server) handleDeleteFSSubvolumeGroup(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("group_name")
+
+	group, exists := fs.groups[name]
+	if !exists || name == noGroup {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("subvolume group '%s' does not exist", name)))
+		return
+	}
+
+	if len(group.subvolumes) > 0 {
+		writeException(w, newException("39", "cephfs", fmt.Sprintf("subvolume group '%s' contains subvolume(s)", name)))
+		return
+	}
+
+	delete(fs.groups, name)
+	fs.removeDirs(path.Join("/volumes", name))
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// lookupSnapshotSubvolume resolves vol_name, group_name and subvol_name of a snapshot request and writes an errno 2
+// exception if any of them does not exist.
+func (s *Server) lookupSnapshotSubvolume(w http.ResponseWriter, volName, groupName, subvolName string) (*subvolume, bool) {
+	fs, ok := s.lookupVolume(w, volName)
+	if !ok {
+		return nil, false
+	}
+
+	group, ok := fs.lookupGroup(w, groupName)
+	if !ok {
+		return nil, false
+	}
+
+	return group.lookupSubvolume(w, subvolName)
+}
+
+// snapshotInfo returns the info of snap with the clones not complete at now.
+func (snap *subvolumeSnapshot) snapshotInfo(now time.Time) ceph.FSSubvolumeSnapshotInfo {
+	info := snap.info
+	info.HasPendingClones = "no"
+
+	for _, clone := range snap.clones {
+		if clone.state(now) == ceph.FSSubvolumeStateInProgress {
+			info.PendingClones = append(info.PendingClones, ceph.FSPendingClone{Name: path.Base(path.Dir(clone.info.Path))})
+			info.HasPendingClones = "yes"
+		}
+	}
+
+	return info
+}
+
+func (s *Server) handleListFSSubvolumeSnapshots(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	sv, ok := s.lookupSnapshotSubvolume(w, vars["vol_name"], r.URL.Query().Get("group_name"), vars["subvol_name"])
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(sv.snapshots))
+	for name := range sv.snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+
+	list := make([]ceph.FSSubvolumeSnapshot, 0, len(names))
+	for _, name := range names {
+		list = append(list, ceph.FSSubvolumeSnapshot{Name: name, Info: sv.snapshots[name].snapshotInfo(now)})
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetFSSubvolumeSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	sv, ok := s.lookupSnapshotSubvolume(w, vars["vol_name"], r.URL.Query().Get("group_name"), vars["subvol_name"])
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("snap_name")
+
+	snap, exists := sv.snapshots[name]
+	if !exists {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("snapshot '%s' does not exist", name)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, snap.snapshotInfo(time.Now()))
+}
+
+func (s *Server) handleCreateFSSubvolumeSnapshot(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body struct {
+		VolName    string `json:"vol_name"`
+		SubvolName string `json:"subvol_name"`
+		SnapName   string `json:"snap_name"`
+		GroupName  string `json:"group_name"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	sv, ok := s.lookupSnapshotSubvolume(w, body.VolName, body.GroupName, body.SubvolName)
+	if !ok {
+		return
+	}
+
+	if sv.info.State == ceph.FSSubvolumeStateSnapshotRetained {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("subvolume '%s' is removed and has only snapshots retained", body.SubvolName)))
+		return
+	}
+
+	if _, exists := sv.snapshots[body.SnapName]; exists {
+		writeException(w, newException("17", "cephfs", fmt.Sprintf("snapshot '%s' already exists", body.SnapName)))
+		return
+	}
+
+	sv.snapshots[body.SnapName] = &subvolumeSnapshot{
+		info: ceph.FSSubvolumeSnapshotInfo{
+			CreatedAt: volumeTime(time.Now()),
+			DataPool:  sv.info.DataPool,
+			Size:      sv.info.BytesUsed,
+		},
+	}
+
+	writeJSON(w, http.StatusCreated, fmt.Sprintf("Subvolume snapshot %s created successfully", body.SnapName))
+}
+
+func (s *Server) handleDeleteFSSubvolumeSnapshot(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	fs, ok := s.lookupVolume(w, vars["vol_name"])
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, r.URL.Query().Get("group_name"))
+	if !ok {
+		return
+	}
+
+	sv, ok := group.lookupSubvolume(w, vars["subvol_name"])
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("snap_name")
+
+	snap, exists := sv.snapshots[name]
+	if !exists {
+		// like ceph fs subvolume snapshot rm --force, deleting a missing snapshot succeeds if forced
+		if r.URL.Query().Get("force") == "true" {
+			writeJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("snapshot '%s' does not exist", name)))
+		return
+	}
+
+	if snap.snapshotInfo(time.Now()).HasPendingClones == "yes" {
+		writeException(w, newException("11", "cephfs", fmt.Sprintf("snapshot '%s' has pending clones", name)))
+		return
+	}
+
+	delete(sv.snapshots, name)
+
+	// a subvolume deleted with retained snapshots is gone with its last snapshot
+	if sv.info.State == ceph.FSSubvolumeStateSnapshotRetained && len(sv.snapshots) == 0 {
+		delete(group.subvolumes, vars["subvol_name"])
+	}
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleCloneFSSubvolumeSnapshot(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body ceph.FSSubvolumeClone
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("invalid_body", "cephfs", err.Error()))
+		return
+	}
+
+	fs, ok := s.lookupVolume(w, body.VolName)
+	if !ok {
+		return
+	}
+
+	group, ok := fs.lookupGroup(w, body.GroupName)
+	if !ok {
+		return
+	}
+
+	sv, ok := group.lookupSubvolume(w, body.SubvolName)
+	if !ok {
+		return
+	}
+
+	snap, exists := sv.snapshots[body.SnapName]
+	if !exists {
+		writeException(w, newException("2", "cephfs", fmt.Sprintf("snapshot '%s' does not exist", body.SnapName)))
+		return
+	}
+
+	target, ok := fs.lookupGroup(w, body.TargetGroupName)
+	if !ok {
+		return
+	}
+
+	if _, exists = target.subvolumes[body.CloneName]; exists {
+		writeException(w, newException("17", "cephfs", fmt.Sprintf("subvolume '%s' exists", body.CloneName)))
+		return
+	}
+
+	clone := s.createSubvolume(fs, target, body.TargetGroupName, body.CloneName, ceph.FSSubvolumeInfo{
+		BytesQuota: sv.info.BytesQuota,
+		DataPool:   body.PoolLayout,
+		Mode:       sv.info.Mode,
+		UID:        sv.info.UID,
+		GID:        sv.info.GID,
+		Type:       ceph.FSSubvolumeTypeClone,
+	})
+	clone.cloned = time.Now().Add(s.taskDuration)
+
+	snap.clones = append(snap.clones, clone)
+
+	writeJSON(w, http.StatusCreated, fmt.Sprintf("Clone %s created successfully", body.CloneName))
+}
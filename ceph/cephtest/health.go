@@ -0,0 +1,167 @@
+package cephtest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// fake cluster layout reported by the health endpoints
+const (
+	fakeHosts    = 3
+	fakeOsdBytes = 1 << 40
+
+	// fsid is also the default rbd mirroring site name
+	fsid = "f4b8c6ee-0d3b-4c4e-9b4b-3c0e6c6b7a10"
+)
+
+// SetHealth sets the health status of the cluster (e.g. ceph.HealthErr) and the failing health checks.
+func (s *Server) SetHealth(status string, checks ...ceph.HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if checks == nil {
+		checks = []ceph.HealthCheck{}
+	}
+
+	s.health.Status = status
+	s.health.Checks = checks
+}
+
+func (s *Server) handleGetSummary(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	tasks := s.currentTasks("")
+
+	summary := ceph.Summary{
+		HealthStatus:      s.health.Status,
+		MgrID:             "x",
+		MgrHost:           "http://" + r.Host + "/",
+		HaveMonConnection: true,
+		ExecutingTasks:    tasks.ExecutingTasks,
+		FinishedTasks:     tasks.FinishedTasks,
+		Version:           "ceph version 16.2.7 (f9aa029788115b5df5eeee328f584156565ee5b7) pacific (stable)",
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (s *Server) handleGetHealthMinimal(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	health := ceph.HealthMinimal{
+		Health:       s.health,
+		MonStatus:    s.monStatus(),
+		OsdMap:       s.osdMap(),
+		PgInfo:       s.pgInfo(),
+		Df:           ceph.HealthDf{Stats: s.capacity()},
+		MgrMap:       ceph.HealthMgrMap{ActiveName: "x"},
+		FsMap:        map[string]interface{}{"filesystems": []interface{}{}, "standbys": []interface{}{}},
+		Hosts:        fakeHosts,
+		Pools:        len(s.pools),
+		Rgw:          len(s.rgwDaemons),
+		IscsiDaemons: ceph.HealthIscsiDaemons{Up: len(s.iscsiGateways)},
+		ScrubStatus:  "Inactive",
+	}
+
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (s *Server) handleGetHealthFull(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	health := ceph.HealthFull{
+		Health:       s.health,
+		MonStatus:    s.monStatus(),
+		OsdMap:       s.osdMap(),
+		PgInfo:       s.pgInfo(),
+		Df:           ceph.HealthDf{Stats: s.capacity(), Pools: []ceph.HealthDfPool{}},
+		MgrMap:       map[string]interface{}{"active_name": "x", "standbys": []interface{}{}},
+		FsMap:        map[string]interface{}{"filesystems": []interface{}{}, "standbys": []interface{}{}},
+		Hosts:        fakeHosts,
+		Pools:        []map[string]interface{}{},
+		Rgw:          len(s.rgwDaemons),
+		IscsiDaemons: ceph.HealthIscsiDaemons{Up: len(s.iscsiGateways)},
+		ScrubStatus:  "Inactive",
+	}
+
+	for _, name := range s.poolNames() {
+		pool := s.pools[name]
+
+		dfPool := ceph.HealthDfPool{Name: pool.PoolName, ID: pool.Pool}
+		dfPool.Stats.MaxAvail = fakeHosts * fakeOsdBytes / uint64(pool.Size)
+		health.Df.Pools = append(health.Df.Pools, dfPool)
+
+		// the mgr lists the pools of the osd map, which hold the same attributes as GET /api/pool
+		var m map[string]interface{}
+		b, _ := json.Marshal(pool)
+		_ = json.Unmarshal(b, &m)
+		health.Pools = append(health.Pools, m)
+	}
+
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (s *Server) monStatus() ceph.HealthMonStatus {
+	var status ceph.HealthMonStatus
+
+	status.MonMap.Epoch = 1
+	status.MonMap.FSID = fsid
+
+	for i, name := range []string{"a", "b", "c"} {
+		status.MonMap.Mons = append(status.MonMap.Mons, ceph.HealthMon{Rank: i, Name: name})
+		status.Quorum = append(status.Quorum, i)
+	}
+
+	return status
+}
+
+// osdMap returns the osds added with AddOSD, or one osd per fake host if none were added.
+func (s *Server) osdMap() ceph.HealthOsdMap {
+	var osdMap ceph.HealthOsdMap
+
+	for _, id := range s.osdIDs() {
+		osd := s.osds[id]
+		osdMap.Osds = append(osdMap.Osds, ceph.HealthOsd{
+			Osd: osd.OSD, UUID: osd.UUID, Up: osd.Up, In: osd.In, Weight: osd.Weight,
+			PrimaryAffinity: osd.PrimaryAffinity, State: osd.State,
+		})
+	}
+
+	if len(osdMap.Osds) > 0 {
+		return osdMap
+	}
+
+	for i := 0; i < fakeHosts; i++ {
+		osdMap.Osds = append(osdMap.Osds, ceph.HealthOsd{
+			Osd: i, Up: 1, In: 1, Weight: 1, PrimaryAffinity: 1, State: []string{"exists", "up"},
+		})
+	}
+
+	return osdMap
+}
+
+func (s *Server) pgInfo() ceph.HealthPgInfo {
+	info := ceph.HealthPgInfo{Statuses: map[string]int{}}
+
+	var pgs int
+	for _, pool := range s.pools {
+		pgs += int(pool.PgNum)
+	}
+
+	if pgs > 0 {
+		info.Statuses["active+clean"] = pgs
+		info.PgsPerOsd = float64(pgs) / fakeHosts
+	}
+
+	return info
+}
+
+func (s *Server) capacity() ceph.HealthCapacity {
+	var used uint64
+
+	for _, img := range s.images {
+		used += img.DiskUsage
+	}
+
+	return ceph.HealthCapacity{
+		TotalBytes:        fakeHosts * fakeOsdBytes,
+		TotalAvailBytes:   fakeHosts*fakeOsdBytes - used,
+		TotalUsedRawBytes: used,
+	}
+}
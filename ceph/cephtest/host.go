@@ -0,0 +1,336 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// fakeHost implements a host managed by the orchestrator with its disks and daemons.
+type fakeHost struct {
+	ceph.Host
+	devices []ceph.InventoryDevice
+	daemons []ceph.HostDaemon
+}
+
+// SetOrchestratorAvailable makes the orchestrator (un)available. Without orchestrator, adding, changing and removing
+// hosts fails with code orchestrator_status_unavailable. The orchestrator is available by default.
+func (s *Server) SetOrchestratorAvailable(available bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orchestratorUnavailable = !available
+}
+
+// requireOrchestrator writes an exception if the orchestrator is unavailable.
+func (s *Server) requireOrchestrator(w http.ResponseWriter) bool {
+	if !s.orchestratorUnavailable {
+		return true
+	}
+
+	writeException(w, ceph.Exception{
+		Detail:    "Orchestrator is unavailable: No orchestrator configured (try `ceph orch set backend`)",
+		Code:      ceph.CodeOrchestratorUnavailable,
+		Component: "orchestrator",
+		Status:    http.StatusServiceUnavailable,
+	})
+
+	return false
+}
+
+// requireVersion writes a http 415 like the dashboard does if the request does not accept version of the endpoint,
+// e.g. 0.1 for the endpoints versioned as experimental.
+func requireVersion(w http.ResponseWriter, r *http.Request, version string) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "application/vnd.ceph.api.v"+version+"+json" {
+		return true
+	}
+
+	writeException(w, ceph.Exception{
+		Detail:    fmt.Sprintf("Incorrect version: endpoint is '%s', client requested '%s'", version, acceptedVersion(accept)),
+		Code:      "invalid_version",
+		Component: "dashboard",
+		Status:    http.StatusUnsupportedMediaType,
+	})
+
+	return false
+}
+
+// acceptedVersion returns the api version of an Accept header like application/vnd.ceph.api.v1.0+json.
+func acceptedVersion(accept string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(accept, "application/vnd.ceph.api.v"), "+json")
+}
+
+func hostNotFound(hostname string) ceph.Exception {
+	exception := newException("2", "orchestrator", fmt.Sprintf("host %s not found", hostname))
+	exception.Status = http.StatusNotFound
+	return exception
+}
+
+// lookupHost returns the host of the hostname path variable or writes an exception.
+func (s *Server) lookupHost(w http.ResponseWriter, vars map[string]string) (*fakeHost, bool) {
+	host, ok := s.hosts[vars["hostname"]]
+	if !ok {
+		writeException(w, hostNotFound(vars["hostname"]))
+	}
+
+	return host, ok
+}
+
+// host returns the host with the osds on it as services.
+func (s *Server) host(h *fakeHost) ceph.Host {
+	host := h.Host
+	host.Sources.Orchestrator = !s.orchestratorUnavailable
+	host.Services = []ceph.HostService{}
+
+	for _, id := range s.osdIDs() {
+		if s.osds[id].Host.Name == host.Hostname {
+			host.Services = append(host.Services, ceph.HostService{Type: "osd", ID: fmt.Sprint(id)})
+		}
+	}
+
+	return host
+}
+
+func (s *Server) handleGetOrchestratorStatus(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	status := ceph.OrchestratorStatus{Available: !s.orchestratorUnavailable}
+
+	if s.orchestratorUnavailable {
+		status.Message = "No orchestrator configured (try `ceph orch set backend`)"
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleListHosts(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	names := make([]string, 0, len(s.hosts))
+	for name := range s.hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hosts := make([]ceph.Host, 0, len(names))
+	for _, name := range names {
+		hosts = append(hosts, s.host(s.hosts[name]))
+	}
+
+	writeJSON(w, http.StatusOK, hosts)
+}
+
+func (s *Server) handleGetHost(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	host, ok := s.lookupHost(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.host(host))
+}
+
+func (s *Server) handleAddHost(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !requireVersion(w, r, "0.1") || !s.requireOrchestrator(w) {
+		return
+	}
+
+	var add ceph.HostAdd
+
+	if err := readJSON(r, &add); err != nil || add.Hostname == "" {
+		writeException(w, newException("22", "orchestrator", "hostname is required"))
+		return
+	}
+
+	if _, ok := s.hosts[add.Hostname]; ok {
+		exception := newException("host_already_exists", "orchestrator", fmt.Sprintf("Host '%s' already exists", add.Hostname))
+		writeException(w, exception)
+		return
+	}
+
+	host := &fakeHost{Host: ceph.Host{
+		Hostname:    add.Hostname,
+		Addr:        add.Addr,
+		Labels:      add.Labels,
+		CephVersion: "ceph version 16.2.7 (f9aa029788115b5df5eeee328f584156565ee5b7) pacific (stable)",
+		Status:      add.Status,
+	}}
+
+	host.Sources.Ceph = true
+
+	if host.Addr == "" {
+		host.Addr = add.Hostname
+	}
+
+	if host.Labels == nil {
+		host.Labels = []string{}
+	}
+
+	for i, dev := range []string{"sdb", "sdc"} {
+		host.devices = append(host.devices, ceph.InventoryDevice{
+			Path:              "/dev/" + dev,
+			DeviceID:          fmt.Sprintf("FAKE_DISK_%s_%d", add.Hostname, i),
+			HumanReadableType: "hdd",
+			Available:         true,
+			RejectedReasons:   []string{},
+			SysAPI:            map[string]interface{}{"size": float64(fakeOsdBytes), "rotational": "1"},
+			Lvs:               []map[string]interface{}{},
+			OsdIDs:            []int{},
+		})
+	}
+
+	for _, daemonType := range []string{"crash", "node-exporter"} {
+		host.daemons = append(host.daemons, ceph.HostDaemon{
+			DaemonType:  daemonType,
+			DaemonID:    add.Hostname,
+			DaemonName:  daemonType + "." + add.Hostname,
+			Hostname:    add.Hostname,
+			Status:      1,
+			StatusDesc:  "running",
+			LastRefresh: time.Now().UTC(),
+		})
+	}
+
+	s.hosts[add.Hostname] = host
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handleUpdateHost(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if !requireVersion(w, r, "0.1") || !s.requireOrchestrator(w) {
+		return
+	}
+
+	host, ok := s.lookupHost(w, vars)
+	if !ok {
+		return
+	}
+
+	var update struct {
+		UpdateLabels bool      `json:"update_labels"`
+		Labels       *[]string `json:"labels"`
+		Maintenance  bool      `json:"maintenance"`
+		Force        bool      `json:"force"`
+		Drain        bool      `json:"drain"`
+	}
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, newException("22", "orchestrator", err.Error()))
+		return
+	}
+
+	if update.UpdateLabels {
+		if update.Labels == nil {
+			writeException(w, newException("22", "orchestrator", "Expected list of labels. Please check API documentation."))
+			return
+		}
+
+		host.Labels = append([]string{}, *update.Labels...)
+	}
+
+	if update.Maintenance {
+		switch {
+		case host.InMaintenance():
+			host.Status = ""
+		case !update.Force && s.hasActiveOSDs(host.Hostname):
+			writeException(w, newException("16", "orchestrator", fmt.Sprintf("unsafe to stop osd(s) at this time on host %s", host.Hostname)))
+			return
+		default:
+			host.Status = ceph.HostStatusMaintenance
+		}
+	}
+
+	if update.Drain {
+		host.Labels = append(host.Labels, "_no_schedule")
+		host.daemons = []ceph.HostDaemon{}
+	}
+
+	writeJSON(w, http.StatusOK, nil)
+}
+
+// hasActiveOSDs checks if osds on hostname are up and in.
+func (s *Server) hasActiveOSDs(hostname string) bool {
+	for _, osd := range s.osds {
+		if osd.Host.Name == hostname && osd.Up == 1 && osd.In == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) handleRemoveHost(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	if !s.requireOrchestrator(w) {
+		return
+	}
+
+	host, ok := s.lookupHost(w, vars)
+	if !ok {
+		return
+	}
+
+	if len(host.daemons) > 0 {
+		writeException(w, newException("22", "orchestrator", fmt.Sprintf("Not allowed to remove %s from cluster. The following daemons are running in the host: %s", host.Hostname, host.daemons[0].DaemonName)))
+		return
+	}
+
+	delete(s.hosts, host.Hostname)
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleListHostDevices(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	host, ok := s.lookupHost(w, vars)
+	if !ok {
+		return
+	}
+
+	devices := make([]ceph.HostDevice, 0, len(host.devices))
+
+	for _, d := range host.devices {
+		device := ceph.HostDevice{
+			DevID:    d.DeviceID,
+			Location: []ceph.HostDeviceLocation{{Host: host.Hostname, Dev: strings.TrimPrefix(d.Path, "/dev/")}},
+			Daemons:  []string{},
+		}
+
+		for _, id := range d.OsdIDs {
+			device.Daemons = append(device.Daemons, fmt.Sprintf("osd.%d", id))
+		}
+
+		devices = append(devices, device)
+	}
+
+	writeJSON(w, http.StatusOK, devices)
+}
+
+func (s *Server) handleGetHostInventory(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	if !s.requireOrchestrator(w) {
+		return
+	}
+
+	host, ok := s.lookupHost(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ceph.HostInventory{
+		Name:    host.Hostname,
+		Addr:    host.Addr,
+		Labels:  host.Labels,
+		Devices: host.devices,
+	})
+}
+
+func (s *Server) handleListHostDaemons(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	if !s.requireOrchestrator(w) {
+		return
+	}
+
+	host, ok := s.lookupHost(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, host.daemons)
+}
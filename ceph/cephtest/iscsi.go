@@ -0,0 +1,305 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// AddISCSIGateway adds the iscsi gateway hostname listening on ip.
+func (s *Server) AddISCSIGateway(hostname, ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.iscsiGateways[hostname] = ip
+}
+
+// iscsiException creates an exception of the iscsi component like the ones raised by the dashboard.
+func iscsiException(code, detail string) *ceph.Exception {
+	exception := newException(code, "iscsi", detail)
+	return &exception
+}
+
+// iscsiTargetIQNs returns the iqns of all targets sorted.
+func (s *Server) iscsiTargetIQNs() []string {
+	iqns := make([]string, 0, len(s.iscsiTargets))
+	for iqn := range s.iscsiTargets {
+		iqns = append(iqns, iqn)
+	}
+	sort.Strings(iqns)
+
+	return iqns
+}
+
+// lookupISCSITarget returns the target of the target_iqn path variable or writes a 404.
+func (s *Server) lookupISCSITarget(w http.ResponseWriter, vars map[string]string) (*ceph.ISCSITarget, bool) {
+	target, ok := s.iscsiTargets[vars["target_iqn"]]
+	if !ok {
+		exception := newException("target_does_not_exist", "iscsi", fmt.Sprintf("Target %s does not exist", vars["target_iqn"]))
+		exception.Status = http.StatusNotFound
+		writeException(w, exception)
+	}
+
+	return target, ok
+}
+
+// validateISCSITarget checks the iqn, the portals, the disks and the clients of target. oldIQN is the iqn of the
+// target edited, empty for new targets.
+func (s *Server) validateISCSITarget(target ceph.ISCSITargetCreate, oldIQN string) *ceph.Exception {
+	if !strings.HasPrefix(target.TargetIQN, "iqn.") {
+		return iscsiException("target_iqn_invalid", fmt.Sprintf("Target IQN %s is not valid", target.TargetIQN))
+	}
+
+	if _, ok := s.iscsiTargets[target.TargetIQN]; ok && target.TargetIQN != oldIQN {
+		return iscsiException("target_already_exists", fmt.Sprintf("Target %s already exists", target.TargetIQN))
+	}
+
+	if len(target.Portals) == 0 {
+		return iscsiException("portals_required", "At least one portal is required")
+	}
+
+	for _, portal := range target.Portals {
+		if ip, ok := s.iscsiGateways[portal.Host]; !ok || ip != portal.IP {
+			return iscsiException("portal_does_not_exist", fmt.Sprintf("Gateway %s does not listen on %s", portal.Host, portal.IP))
+		}
+	}
+
+	disks := make(map[ceph.ISCSIImage]struct{})
+
+	for _, disk := range target.Disks {
+		spec := ceph.PathJoin(disk.Pool, nil, disk.Image)
+
+		if _, ok := s.image(spec); !ok {
+			return iscsiException("image_does_not_exist", fmt.Sprintf("Image %s does not exist", spec))
+		}
+
+		for _, iqn := range s.iscsiTargetIQNs() {
+			if iqn == oldIQN {
+				continue
+			}
+
+			for _, other := range s.iscsiTargets[iqn].Disks {
+				if other.Pool == disk.Pool && other.Image == disk.Image {
+					return iscsiException("image_already_in_use", fmt.Sprintf("Image %s is already exported by %s", spec, iqn))
+				}
+			}
+		}
+
+		disks[ceph.ISCSIImage{Pool: disk.Pool, Image: disk.Image}] = struct{}{}
+	}
+
+	for _, client := range target.Clients {
+		for _, lun := range client.LUNs {
+			if _, ok := disks[lun]; !ok {
+				return iscsiException("lun_does_not_exist", fmt.Sprintf("Client %s uses disk %s/%s not exported by the target", client.ClientIQN, lun.Pool, lun.Image))
+			}
+		}
+	}
+
+	for _, group := range target.Groups {
+		for _, disk := range group.Disks {
+			if _, ok := disks[disk]; !ok {
+				return iscsiException("disk_does_not_exist", fmt.Sprintf("Group %s uses disk %s/%s not exported by the target", group.GroupID, disk.Pool, disk.Image))
+			}
+		}
+	}
+
+	return nil
+}
+
+// iscsiTarget returns the target of create with the luns assigned in order of the disks.
+func iscsiTarget(create ceph.ISCSITargetCreate) *ceph.ISCSITarget {
+	disks := make([]ceph.ISCSIDisk, 0, len(create.Disks))
+	for i, disk := range create.Disks {
+		lun := i
+		disk.LUN = &lun
+		disks = append(disks, disk)
+	}
+
+	clients := make([]ceph.ISCSIClient, 0, len(create.Clients))
+	for _, client := range create.Clients {
+		client.Info = &ceph.ISCSIClientInfo{IPAddress: []string{}, State: map[string][]string{}}
+		clients = append(clients, client)
+	}
+
+	return &ceph.ISCSITarget{
+		TargetIQN:      create.TargetIQN,
+		TargetControls: create.TargetControls,
+		ACLEnabled:     create.ACLEnabled,
+		Auth:           create.Auth,
+		Portals:        create.Portals,
+		Disks:          disks,
+		Clients:        clients,
+		Groups:         create.Groups,
+		Info:           &ceph.ISCSITargetInfo{},
+	}
+}
+
+func (s *Server) handleGetISCSIStatus(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	if len(s.iscsiGateways) == 0 {
+		writeJSON(w, http.StatusOK, ceph.ISCSIStatus{Message: "There are no gateways defined"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ceph.ISCSIStatus{Available: true})
+}
+
+func (s *Server) handleGetISCSIOverview(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	overview := ceph.ISCSIOverview{Gateways: []ceph.ISCSIGateway{}, Disks: []map[string]interface{}{}}
+
+	names := make([]string, 0, len(s.iscsiGateways))
+	for name := range s.iscsiGateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		gateway := ceph.ISCSIGateway{Name: name, State: "up"}
+
+		for _, target := range s.iscsiTargets {
+			for _, portal := range target.Portals {
+				if portal.Host == name {
+					gateway.NumTargets++
+					break
+				}
+			}
+		}
+
+		overview.Gateways = append(overview.Gateways, gateway)
+	}
+
+	writeJSON(w, http.StatusOK, overview)
+}
+
+func (s *Server) handleGetISCSIDiscoveryAuth(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	writeJSON(w, http.StatusOK, s.iscsiDiscoveryAuth)
+}
+
+func (s *Server) handleSetISCSIDiscoveryAuth(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var auth ceph.ISCSIAuth
+
+	if err := readJSON(r, &auth); err != nil {
+		writeException(w, newException("22", "iscsi", err.Error()))
+		return
+	}
+
+	if (auth.User == "") != (auth.Password == "") || (auth.MutualUser != "" && auth.User == "") {
+		writeException(w, *iscsiException("invalid_discovery_credentials", "Bad authentication"))
+		return
+	}
+
+	s.iscsiDiscoveryAuth = auth
+
+	writeJSON(w, http.StatusOK, auth)
+}
+
+func (s *Server) handleListISCSITargets(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	targets := []ceph.ISCSITarget{}
+
+	for _, iqn := range s.iscsiTargetIQNs() {
+		targets = append(targets, *s.iscsiTargets[iqn])
+	}
+
+	writeJSON(w, http.StatusOK, targets)
+}
+
+func (s *Server) handleGetISCSITarget(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	target, ok := s.lookupISCSITarget(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, target)
+}
+
+func (s *Server) handleCreateISCSITarget(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var create ceph.ISCSITargetCreate
+
+	if err := readJSON(r, &create); err != nil {
+		writeException(w, newException("22", "iscsi", err.Error()))
+		return
+	}
+
+	md := ceph.MetaData{TargetIQN: create.TargetIQN}
+
+	status, exception := s.runTask("iscsi/target/create", md, http.StatusCreated, func() *ceph.Exception {
+		if exception := s.validateISCSITarget(create, ""); exception != nil {
+			return exception
+		}
+
+		s.iscsiTargets[create.TargetIQN] = iscsiTarget(create)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleUpdateISCSITarget(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	target, ok := s.lookupISCSITarget(w, vars)
+	if !ok {
+		return
+	}
+
+	var edit struct {
+		ceph.ISCSITargetCreate
+		NewTargetIQN string `json:"new_target_iqn"`
+	}
+
+	if err := readJSON(r, &edit); err != nil {
+		writeException(w, newException("22", "iscsi", err.Error()))
+		return
+	}
+
+	update := edit.ISCSITargetCreate
+	update.TargetIQN = edit.NewTargetIQN
+
+	md := ceph.MetaData{TargetIQN: target.TargetIQN}
+
+	status, exception := s.runTask("iscsi/target/edit", md, http.StatusOK, func() *ceph.Exception {
+		if exception := s.validateISCSITarget(update, target.TargetIQN); exception != nil {
+			return exception
+		}
+
+		delete(s.iscsiTargets, target.TargetIQN)
+		s.iscsiTargets[update.TargetIQN] = iscsiTarget(update)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleDeleteISCSITarget(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	target, ok := s.lookupISCSITarget(w, vars)
+	if !ok {
+		return
+	}
+
+	md := ceph.MetaData{TargetIQN: target.TargetIQN}
+
+	status, exception := s.runTask("iscsi/target/delete", md, http.StatusNoContent, func() *ceph.Exception {
+		delete(s.iscsiTargets, target.TargetIQN)
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
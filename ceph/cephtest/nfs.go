@@ -0,0 +1,268 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// nfsCluster implements a nfs cluster with its nfs-ganesha daemons and exports.
+type nfsCluster struct {
+	daemons []ceph.NFSDaemon
+	exports map[int]*ceph.NFSExport
+	nextID  int
+}
+
+// AddNFSCluster adds the nfs cluster clusterID with a running nfs-ganesha daemon on each of hostnames.
+func (s *Server) AddNFSCluster(clusterID string, hostnames ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster := &nfsCluster{exports: make(map[int]*ceph.NFSExport), nextID: 1}
+
+	for _, hostname := range hostnames {
+		cluster.daemons = append(cluster.daemons, ceph.NFSDaemon{
+			DaemonID:    fmt.Sprintf("%s.%s", clusterID, hostname),
+			ClusterID:   clusterID,
+			ClusterType: "orchestrator",
+			Status:      1,
+			StatusDesc:  "running",
+		})
+	}
+
+	s.nfsClusters[clusterID] = cluster
+}
+
+// nfsClusterIDs returns the ids of all nfs clusters sorted.
+func (s *Server) nfsClusterIDs() []string {
+	ids := make([]string, 0, len(s.nfsClusters))
+	for id := range s.nfsClusters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// nfsException creates an exception of the nfs component returned by failed nfs tasks.
+func nfsException(code, detail string) *ceph.Exception {
+	exception := newException(code, "nfs", detail)
+	return &exception
+}
+
+// lookupNFSExport returns the export of the cluster_id and export_id path variables or writes an exception.
+func (s *Server) lookupNFSExport(w http.ResponseWriter, vars map[string]string) (*nfsCluster, *ceph.NFSExport, bool) {
+	cluster, ok := s.nfsClusters[vars["cluster_id"]]
+	if ok {
+		id, _ := strconv.Atoi(vars["export_id"])
+		if export, ok := cluster.exports[id]; ok {
+			return cluster, export, true
+		}
+	}
+
+	exception := newException("2", "nfs", fmt.Sprintf("export %s of cluster %s not found", vars["export_id"], vars["cluster_id"]))
+	exception.Status = http.StatusNotFound
+	writeException(w, exception)
+
+	return nil, nil, false
+}
+
+// validateNFSExport checks the cluster, the pseudo path and the exported ceph fs directory of an export with id
+// exportID (0 for new exports).
+func (s *Server) validateNFSExport(export ceph.NFSExportCreate, exportID int) *ceph.Exception {
+	cluster, ok := s.nfsClusters[export.ClusterID]
+	if !ok {
+		return nfsException("2", fmt.Sprintf("cluster %s does not exist", export.ClusterID))
+	}
+
+	if !path.IsAbs(export.Pseudo) {
+		return nfsException("22", fmt.Sprintf("pseudo path %s is not an absolute path", export.Pseudo))
+	}
+
+	for id, other := range cluster.exports {
+		if id != exportID && other.Pseudo == path.Clean(export.Pseudo) {
+			return nfsException("17", fmt.Sprintf("pseudo path %s is already in use", export.Pseudo))
+		}
+	}
+
+	switch export.FSAL.Name {
+	case ceph.NFSFSALCephFS:
+		for _, fs := range s.fileSystem {
+			if fs.name != export.FSAL.FSName {
+				continue
+			}
+
+			if _, ok := fs.dirs[path.Clean("/"+export.Path)]; !ok {
+				return nfsException("2", fmt.Sprintf("path %s does not exist in ceph fs %s", export.Path, fs.name))
+			}
+
+			return nil
+		}
+
+		return nfsException("2", fmt.Sprintf("ceph fs %s does not exist", export.FSAL.FSName))
+	case ceph.NFSFSALRGW:
+		if _, ok := s.rgwBuckets[export.Path]; !ok {
+			return nfsException("2", fmt.Sprintf("bucket %s does not exist", export.Path))
+		}
+
+		return nil
+	}
+
+	return nfsException("22", fmt.Sprintf("fsal %s is not supported", export.FSAL.Name))
+}
+
+// nfsExport returns the export exportID of the cluster for export.
+func nfsExport(export ceph.NFSExportCreate, exportID int) *ceph.NFSExport {
+	if export.Clients == nil {
+		export.Clients = []ceph.NFSClient{}
+	}
+
+	return &ceph.NFSExport{
+		ExportID:      exportID,
+		ClusterID:     export.ClusterID,
+		Path:          export.Path,
+		Pseudo:        path.Clean(export.Pseudo),
+		AccessType:    export.AccessType,
+		Squash:        export.Squash,
+		SecurityLabel: export.SecurityLabel,
+		Protocols:     export.Protocols,
+		Transports:    export.Transports,
+		FSAL:          export.FSAL,
+		Clients:       export.Clients,
+	}
+}
+
+func (s *Server) handleListNFSClusters(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	writeJSON(w, http.StatusOK, s.nfsClusterIDs())
+}
+
+func (s *Server) handleListNFSDaemons(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	daemons := []ceph.NFSDaemon{}
+
+	for _, id := range s.nfsClusterIDs() {
+		daemons = append(daemons, s.nfsClusters[id].daemons...)
+	}
+
+	writeJSON(w, http.StatusOK, daemons)
+}
+
+func (s *Server) handleListNFSExports(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	exports := []ceph.NFSExport{}
+
+	for _, clusterID := range s.nfsClusterIDs() {
+		cluster := s.nfsClusters[clusterID]
+
+		ids := make([]int, 0, len(cluster.exports))
+		for id := range cluster.exports {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			exports = append(exports, *cluster.exports[id])
+		}
+	}
+
+	writeJSON(w, http.StatusOK, exports)
+}
+
+func (s *Server) handleGetNFSExport(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	_, export, ok := s.lookupNFSExport(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, export)
+}
+
+func (s *Server) handleCreateNFSExport(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var create ceph.NFSExportCreate
+
+	if err := readJSON(r, &create); err != nil {
+		writeException(w, newException("22", "nfs", err.Error()))
+		return
+	}
+
+	md := ceph.MetaData{ClusterID: create.ClusterID, Path: create.Path, Fsal: create.FSAL.Name}
+
+	status, exception := s.runTask("nfs/create", md, http.StatusCreated, func() *ceph.Exception {
+		if exception := s.validateNFSExport(create, 0); exception != nil {
+			return exception
+		}
+
+		cluster := s.nfsClusters[create.ClusterID]
+		cluster.exports[cluster.nextID] = nfsExport(create, cluster.nextID)
+		cluster.nextID++
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleUpdateNFSExport(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	cluster, export, ok := s.lookupNFSExport(w, vars)
+	if !ok {
+		return
+	}
+
+	var update ceph.NFSExportCreate
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, newException("22", "nfs", err.Error()))
+		return
+	}
+
+	md := ceph.MetaData{ClusterID: vars["cluster_id"], ExportID: vars["export_id"]}
+
+	status, exception := s.runTask("nfs/edit", md, http.StatusOK, func() *ceph.Exception {
+		if update.ClusterID != export.ClusterID {
+			return nfsException("22", "the cluster of an export can not be changed")
+		}
+
+		if exception := s.validateNFSExport(update, export.ExportID); exception != nil {
+			return exception
+		}
+
+		cluster.exports[export.ExportID] = nfsExport(update, export.ExportID)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleDeleteNFSExport(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	cluster, export, ok := s.lookupNFSExport(w, vars)
+	if !ok {
+		return
+	}
+
+	md := ceph.MetaData{ClusterID: vars["cluster_id"], ExportID: vars["export_id"]}
+
+	status, exception := s.runTask("nfs/delete", md, http.StatusNoContent, func() *ceph.Exception {
+		delete(cluster.exports, export.ExportID)
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
@@ -0,0 +1,335 @@
+package cephtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// fakeOsdPgs is the number of pgs an osd stores while it is in.
+const fakeOsdPgs = 32
+
+// AddOSD creates an osd which is up and in on host with the lowest free id, e.g. AddOSD("node-1", "hdd").
+func (s *Server) AddOSD(host, deviceClass string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := 0
+	for s.osds[id] != nil {
+		id++
+	}
+
+	s.osds[id] = &ceph.OSD{
+		ID:              id,
+		OSD:             id,
+		UUID:            fmt.Sprintf("00000000-0000-0000-0000-%012d", id),
+		Up:              1,
+		In:              1,
+		Weight:          1,
+		PrimaryAffinity: 1,
+		State:           []string{"exists", "up"},
+		Host:            ceph.OSDHost{ID: -1, Name: host, Type: "host", TypeID: 1, Children: []int{id}},
+		Stats: ceph.OSDStats{
+			NumPG:         fakeOsdPgs,
+			StatBytes:     fakeOsdBytes,
+			StatBytesUsed: fakeOsdBytes / 10,
+		},
+		StatsHistory: map[string][][]float64{"op_in_bytes": {}, "op_out_bytes": {}},
+		Tree: ceph.OSDTree{
+			ID:              id,
+			Name:            fmt.Sprintf("osd.%d", id),
+			Type:            "osd",
+			DeviceClass:     deviceClass,
+			CrushWeight:     1,
+			Depth:           2,
+			Exists:          1,
+			Status:          "up",
+			Reweight:        1,
+			PrimaryAffinity: 1,
+		},
+		OsdType:           "bluestore",
+		OperationalStatus: "working",
+	}
+
+	return id
+}
+
+func osdNotFound(id string) *ceph.Exception {
+	exception := newException("2", "osd", fmt.Sprintf("osd.%s does not exist", id))
+	return &exception
+}
+
+// lookupOSD returns the osd of the svc_id path variable or writes an exception.
+func (s *Server) lookupOSD(w http.ResponseWriter, vars map[string]string) (*ceph.OSD, bool) {
+	if id, err := strconv.Atoi(vars["svc_id"]); err == nil {
+		if osd, ok := s.osds[id]; ok {
+			return osd, true
+		}
+	}
+
+	writeException(w, *osdNotFound(vars["svc_id"]))
+
+	return nil, false
+}
+
+// osdIDs returns the ids of all osds sorted.
+func (s *Server) osdIDs() []int {
+	ids := make([]int, 0, len(s.osds))
+	for id := range s.osds {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+// setOSDState updates the state, the tree status and the stored pgs of osd after it was marked.
+func setOSDState(osd *ceph.OSD) {
+	osd.State = []string{"exists"}
+	osd.Tree.Status = "down"
+
+	if osd.Up == 1 {
+		osd.State = append(osd.State, "up")
+		osd.Tree.Status = "up"
+	}
+
+	// the pgs of an osd marked out are moved to the other osds
+	osd.Stats.NumPG = 0
+	if osd.In == 1 {
+		osd.Stats.NumPG = fakeOsdPgs
+	}
+}
+
+func (s *Server) handleListOSDs(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	osds := make([]ceph.OSD, 0, len(s.osds))
+	for _, id := range s.osdIDs() {
+		osds = append(osds, *s.osds[id])
+	}
+
+	writeJSON(w, http.StatusOK, osds)
+}
+
+func (s *Server) handleGetOSD(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	osd, ok := s.lookupOSD(w, vars)
+	if !ok {
+		return
+	}
+
+	detail := ceph.OSDDetail{
+		OsdMap: *osd,
+		OsdMetadata: map[string]interface{}{
+			"hostname":             osd.Host.Name,
+			"osd_objectstore":      osd.OsdType,
+			"default_device_class": osd.Tree.DeviceClass,
+		},
+		Histogram: "osd down",
+	}
+
+	if osd.Up == 1 {
+		detail.Histogram = map[string]interface{}{}
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+func (s *Server) handleMarkOSD(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	osd, ok := s.lookupOSD(w, vars)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Action string `json:"action"`
+	}
+
+	if err := readJSON(r, &body); err != nil {
+		writeException(w, newException("22", "osd", err.Error()))
+		return
+	}
+
+	switch body.Action {
+	case ceph.OSDMarkIn:
+		osd.In = 1
+	case ceph.OSDMarkOut:
+		osd.In = 0
+	case ceph.OSDMarkDown:
+		osd.Up = 0
+	case ceph.OSDMarkLost:
+		if osd.Up == 1 {
+			writeException(w, newException("16", "osd", fmt.Sprintf("osd.%d is not down", osd.ID)))
+			return
+		}
+	default:
+		writeException(w, newException("22", "osd", fmt.Sprintf("Invalid OSD mark action: %s", body.Action)))
+		return
+	}
+
+	setOSDState(osd)
+
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleReweightOSD(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	osd, ok := s.lookupOSD(w, vars)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Weight float64 `json:"weight"`
+	}
+
+	if err := readJSON(r, &body); err != nil || body.Weight < 0 || body.Weight > 1 {
+		writeException(w, newException("22", "osd", "weight must be in the range [0.0, 1.0]"))
+		return
+	}
+
+	osd.Weight = body.Weight
+	osd.Tree.Reweight = body.Weight
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handleScrubOSD(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	if _, ok := s.lookupOSD(w, vars); !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handleGetOSDFlags(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	writeJSON(w, http.StatusOK, s.osdFlags)
+}
+
+func (s *Server) handleSetOSDFlags(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var body struct {
+		Flags []string `json:"flags"`
+	}
+
+	if err := readJSON(r, &body); err != nil || body.Flags == nil {
+		writeException(w, newException("22", "osd", "flags are required"))
+		return
+	}
+
+	s.osdFlags = body.Flags
+
+	writeJSON(w, http.StatusOK, s.osdFlags)
+}
+
+func (s *Server) handleSafeToDestroyOSD(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var ids []int
+
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("ids")), &ids); err != nil {
+		writeException(w, newException("22", "osd", "ids must be a json list"))
+		return
+	}
+
+	check := ceph.OSDSafeToDestroy{
+		SafeToDestroy: []int{},
+		Active:        []int{},
+		MissingStats:  []int{},
+		StoredPgs:     []int{},
+	}
+
+	for _, id := range ids {
+		osd, ok := s.osds[id]
+		if !ok {
+			writeException(w, *osdNotFound(strconv.Itoa(id)))
+			return
+		}
+
+		if osd.Stats.NumPG > 0 {
+			check.StoredPgs = append(check.StoredPgs, id)
+			continue
+		}
+
+		check.SafeToDestroy = append(check.SafeToDestroy, id)
+	}
+
+	check.IsSafeToDestroy = len(check.StoredPgs) == 0
+	if !check.IsSafeToDestroy {
+		check.Message = fmt.Sprintf("OSD(s) %v still store pgs", check.StoredPgs)
+	}
+
+	writeJSON(w, http.StatusOK, check)
+}
+
+func (s *Server) handleSafeToDeleteOSD(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var notOut []string
+
+	for _, svcID := range r.URL.Query()["svc_ids"] {
+		id, err := strconv.Atoi(svcID)
+		if err != nil || s.osds[id] == nil {
+			writeException(w, *osdNotFound(svcID))
+			return
+		}
+
+		if s.osds[id].In == 1 {
+			notOut = append(notOut, svcID)
+		}
+	}
+
+	check := ceph.OSDSafeToDelete{IsSafeToDelete: len(notOut) == 0}
+	if !check.IsSafeToDelete {
+		check.Message = fmt.Sprintf("OSD(s) %v are still in", notOut)
+	}
+
+	writeJSON(w, http.StatusOK, check)
+}
+
+func (s *Server) handleDestroyOSD(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	osd, ok := s.lookupOSD(w, vars)
+	if !ok {
+		return
+	}
+
+	if osd.Up == 1 {
+		writeException(w, newException("16", "osd", fmt.Sprintf("osd.%d is not down", osd.ID)))
+		return
+	}
+
+	osd.State = []string{"exists", "destroyed"}
+	osd.Tree.Status = "destroyed"
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handlePurgeOSD(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	osd, ok := s.lookupOSD(w, vars)
+	if !ok {
+		return
+	}
+
+	if osd.Up == 1 {
+		writeException(w, newException("16", "osd", fmt.Sprintf("osd.%d is not down", osd.ID)))
+		return
+	}
+
+	delete(s.osds, osd.ID)
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handleDeleteOSD(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	osd, ok := s.lookupOSD(w, vars)
+	if !ok {
+		return
+	}
+
+	status, exception := s.runTask("osd/delete", ceph.MetaData{SvcID: vars["svc_id"]}, http.StatusNoContent, func() *ceph.Exception {
+		delete(s.osds, osd.ID)
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
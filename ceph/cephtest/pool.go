@@ -0,0 +1,247 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// AddPool creates a replicated pool with applications enabled, e.g. AddPool("test-pool-1", "rbd").
+func (s *Server) AddPool(name string, applications ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addPool(ceph.PoolCreate{Pool: name, PoolType: ceph.PoolTypeReplicated, ApplicationMetadata: applications})
+}
+
+func (s *Server) addPool(create ceph.PoolCreate) {
+	pool := &ceph.Pool{
+		Pool:                s.nextID(),
+		PoolName:            create.Pool,
+		Type:                create.PoolType,
+		Size:                create.Size,
+		MinSize:             create.MinSize,
+		CrushRule:           create.RuleName,
+		ErasureCodeProfile:  create.ErasureCodeProfile,
+		PgNum:               create.PgNum,
+		PgPlacementNum:      create.PgNum,
+		PgNumTarget:         create.PgNum,
+		PgAutoscaleMode:     create.PgAutoscaleMode,
+		ApplicationMetadata: create.ApplicationMetadata,
+		Options:             map[string]interface{}{},
+	}
+
+	if pool.Size == 0 {
+		pool.Size = 3
+	}
+
+	if pool.PgNum == 0 {
+		pool.PgNum, pool.PgPlacementNum, pool.PgNumTarget = 32, 32, 32
+	}
+
+	if pool.PgAutoscaleMode == "" {
+		pool.PgAutoscaleMode = ceph.PgAutoscaleModeOn
+	}
+
+	if pool.CrushRule == "" {
+		pool.CrushRule = fmt.Sprintf("%s_rule", pool.Type)
+	}
+
+	if pool.ApplicationMetadata == nil {
+		pool.ApplicationMetadata = []string{}
+	}
+
+	if create.QuotaMaxBytes != nil {
+		pool.QuotaMaxBytes = *create.QuotaMaxBytes
+	}
+
+	if create.QuotaMaxObjects != nil {
+		pool.QuotaMaxObjects = *create.QuotaMaxObjects
+	}
+
+	if create.CompressionMode != "" {
+		pool.Options["compression_mode"] = create.CompressionMode
+	}
+
+	if create.CompressionAlgorithm != "" {
+		pool.Options["compression_algorithm"] = create.CompressionAlgorithm
+	}
+
+	s.pools[create.Pool] = pool
+	s.namespaces[create.Pool] = make(map[string]struct{})
+}
+
+func poolNotFound(name string) ceph.Exception {
+	exception := newException("2", "pool", fmt.Sprintf("[errno 2] pool '%s' does not exist", name))
+	exception.Status = http.StatusNotFound
+	return exception
+}
+
+// poolNames returns the names of all pools sorted.
+func (s *Server) poolNames() []string {
+	names := make([]string, 0, len(s.pools))
+	for name := range s.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *Server) handleListPools(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	names := s.poolNames()
+
+	pools := make([]ceph.Pool, 0, len(names))
+	for _, name := range names {
+		pools = append(pools, *s.pools[name])
+	}
+
+	writeJSON(w, http.StatusOK, pools)
+}
+
+func (s *Server) handleGetPool(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	pool, ok := s.pools[vars["pool_name"]]
+	if !ok {
+		writeException(w, poolNotFound(vars["pool_name"]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pool)
+}
+
+func (s *Server) handleCreatePool(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var create ceph.PoolCreate
+
+	if err := readJSON(r, &create); err != nil {
+		writeException(w, newException("invalid_body", "pool", err.Error()))
+		return
+	}
+
+	status, exception := s.runTask("pool/create", ceph.MetaData{PoolName: create.Pool}, http.StatusCreated, func() *ceph.Exception {
+		if _, ok := s.pools[create.Pool]; ok {
+			exception := newException("17", "pool", fmt.Sprintf("[errno 17] pool '%s' already exists", create.Pool))
+			return &exception
+		}
+
+		s.addPool(create)
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleUpdatePool(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	var update ceph.PoolUpdate
+
+	name := vars["pool_name"]
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, newException("invalid_body", "pool", err.Error()))
+		return
+	}
+
+	status, exception := s.runTask("pool/edit", ceph.MetaData{PoolName: name}, http.StatusOK, func() *ceph.Exception {
+		pool, ok := s.pools[name]
+		if !ok {
+			exception := poolNotFound(name)
+			exception.Status = http.StatusBadRequest
+			return &exception
+		}
+
+		if update.Pool != "" && update.Pool != name {
+			if _, exists := s.pools[update.Pool]; exists {
+				exception := newException("17", "pool", fmt.Sprintf("[errno 17] pool '%s' already exists", update.Pool))
+				return &exception
+			}
+
+			delete(s.pools, name)
+			pool.PoolName = update.Pool
+			s.pools[update.Pool] = pool
+			s.namespaces[update.Pool] = s.namespaces[name]
+			delete(s.namespaces, name)
+		}
+
+		if update.PgNum != 0 {
+			pool.PgNum, pool.PgPlacementNum, pool.PgNumTarget = update.PgNum, update.PgNum, update.PgNum
+		}
+
+		if update.PgAutoscaleMode != "" {
+			pool.PgAutoscaleMode = update.PgAutoscaleMode
+		}
+
+		if update.Size != 0 {
+			pool.Size = update.Size
+		}
+
+		if update.MinSize != 0 {
+			pool.MinSize = update.MinSize
+		}
+
+		if update.ApplicationMetadata != nil {
+			pool.ApplicationMetadata = update.ApplicationMetadata
+		}
+
+		if update.QuotaMaxBytes != nil {
+			pool.QuotaMaxBytes = *update.QuotaMaxBytes
+		}
+
+		if update.QuotaMaxObjects != nil {
+			pool.QuotaMaxObjects = *update.QuotaMaxObjects
+		}
+
+		if update.CompressionMode != "" {
+			pool.Options["compression_mode"] = update.CompressionMode
+		}
+
+		if update.CompressionAlgorithm != "" {
+			pool.Options["compression_algorithm"] = update.CompressionAlgorithm
+		}
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
+
+func (s *Server) handleDeletePool(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	name := vars["pool_name"]
+
+	status, exception := s.runTask("pool/delete", ceph.MetaData{PoolName: name}, http.StatusNoContent, func() *ceph.Exception {
+		if _, ok := s.pools[name]; !ok {
+			exception := poolNotFound(name)
+			exception.Status = http.StatusBadRequest
+			return &exception
+		}
+
+		delete(s.pools, name)
+		delete(s.namespaces, name)
+
+		for spec, image := range s.images {
+			if image.PoolName == name {
+				delete(s.images, spec)
+			}
+		}
+
+		return nil
+	})
+
+	if exception != nil {
+		writeException(w, *exception)
+		return
+	}
+
+	writeJSON(w, status, nil)
+}
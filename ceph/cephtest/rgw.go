@@ -0,0 +1,702 @@
+package cephtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// AddRGWDaemon adds an object gateway daemon running on hostname and returns its id. Without daemon the object
+// gateway is reported unavailable and requests for users and buckets fail.
+func (s *Server) AddRGWDaemon(hostname string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := fmt.Sprintf("rgw.%s.%d", hostname, len(s.rgwDaemons))
+
+	s.rgwDaemons = append(s.rgwDaemons, ceph.RGWDaemon{
+		ID:             id,
+		ServiceMapID:   fmt.Sprint(4100 + len(s.rgwDaemons)),
+		Version:        "ceph version 16.2.7 (f9aa029788115b5df5eeee328f584156565ee5b7) pacific (stable)",
+		ServerHostname: hostname,
+		ZonegroupName:  "default",
+		Default:        len(s.rgwDaemons) == 0,
+	})
+
+	return id
+}
+
+// AddRGWObjects adds objects of size bytes each to bucket, e.g. to test deleting a bucket which is not empty.
+func (s *Server) AddRGWObjects(bucket string, objects int, size uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.rgwBuckets[bucket]
+	if !ok {
+		return
+	}
+
+	usage := b.Usage["rgw.main"]
+	usage.NumObjects += uint64(objects)
+	usage.Size += uint64(objects) * size
+	usage.SizeActual = usage.Size
+	usage.SizeUtilized = usage.Size
+	usage.SizeKb = usage.Size / 1024
+	usage.SizeKbActual = usage.SizeKb
+
+	b.Usage["rgw.main"] = usage
+}
+
+// requireRGW writes an exception if no object gateway daemon is running.
+func (s *Server) requireRGW(w http.ResponseWriter) bool {
+	if len(s.rgwDaemons) > 0 {
+		return true
+	}
+
+	writeException(w, ceph.Exception{
+		Detail:    "No RGW service is running.",
+		Component: "rgw",
+		Status:    http.StatusInternalServerError,
+	})
+
+	return false
+}
+
+// rgwException creates an exception like the dashboard does for errors returned by the object gateway, e.g.
+// NoSuchUser.
+func rgwException(status int, code, detail string) ceph.Exception {
+	return ceph.Exception{Detail: detail, Code: code, Component: "rgw", Status: status}
+}
+
+// lookupRGWUser returns the user of the uid path variable or writes an exception.
+func (s *Server) lookupRGWUser(w http.ResponseWriter, vars map[string]string) (*ceph.RGWUser, bool) {
+	if !s.requireRGW(w) {
+		return nil, false
+	}
+
+	user, ok := s.rgwUsers[vars["uid"]]
+	if !ok {
+		writeException(w, rgwException(http.StatusNotFound, "NoSuchUser", fmt.Sprintf("user %s does not exist", vars["uid"])))
+	}
+
+	return user, ok
+}
+
+// lookupRGWBucket returns the bucket of the bucket path variable or writes an exception.
+func (s *Server) lookupRGWBucket(w http.ResponseWriter, vars map[string]string) (*ceph.RGWBucket, bool) {
+	if !s.requireRGW(w) {
+		return nil, false
+	}
+
+	bucket, ok := s.rgwBuckets[vars["bucket"]]
+	if !ok {
+		writeException(w, rgwException(http.StatusNotFound, "NoSuchBucket", fmt.Sprintf("bucket %s does not exist", vars["bucket"])))
+	}
+
+	return bucket, ok
+}
+
+// newRGWKey returns a new s3 access key and secret key.
+func (s *Server) newRGWKey() (string, string) {
+	id := s.nextID()
+	return fmt.Sprintf("FAKE%016d", id), fmt.Sprintf("fake-secret-%028d", id)
+}
+
+// rgwPermissions returns the permissions of a subuser as reported by the object gateway for access.
+func rgwPermissions(access string) string {
+	switch access {
+	case ceph.RGWAccessReadWrite:
+		return "read-write"
+	case ceph.RGWAccessFull:
+		return "full-control"
+	}
+
+	return access
+}
+
+func (s *Server) handleGetRGWStatus(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	status := ceph.RGWStatus{Available: len(s.rgwDaemons) > 0}
+
+	if !status.Available {
+		status.Message = "No RGW service is running."
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleListRGWDaemons(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	daemons := append([]ceph.RGWDaemon{}, s.rgwDaemons...)
+
+	writeJSON(w, http.StatusOK, daemons)
+}
+
+func (s *Server) handleListRGWUsers(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	if !s.requireRGW(w) {
+		return
+	}
+
+	uids := make([]string, 0, len(s.rgwUsers))
+	for uid := range s.rgwUsers {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	writeJSON(w, http.StatusOK, uids)
+}
+
+func (s *Server) handleGetRGWUser(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	result := *user
+
+	if r.URL.Query().Get("stats") == "true" {
+		stats := ceph.RGWUsage{}
+
+		for _, bucket := range s.rgwBuckets {
+			if bucket.Owner == user.UID {
+				usage := bucket.Usage["rgw.main"]
+				stats.Size += usage.Size
+				stats.SizeActual += usage.SizeActual
+				stats.SizeUtilized += usage.SizeUtilized
+				stats.SizeKb += usage.SizeKb
+				stats.SizeKbActual += usage.SizeKbActual
+				stats.NumObjects += usage.NumObjects
+			}
+		}
+
+		result.Stats = &stats
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleCreateRGWUser(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !s.requireRGW(w) {
+		return
+	}
+
+	var create ceph.RGWUserCreate
+
+	if err := readJSON(r, &create); err != nil || create.UID == "" {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", "uid is required"))
+		return
+	}
+
+	if _, ok := s.rgwUsers[create.UID]; ok {
+		writeException(w, rgwException(http.StatusConflict, "UserAlreadyExists", fmt.Sprintf("user %s already exists", create.UID)))
+		return
+	}
+
+	disabled := ceph.RGWQuota{MaxSize: -1, MaxObjects: -1}
+
+	user := &ceph.RGWUser{
+		UID:         create.UID,
+		UserID:      create.UID,
+		DisplayName: create.DisplayName,
+		Email:       create.Email,
+		MaxBuckets:  1000,
+		Subusers:    []ceph.RGWSubuser{},
+		Keys:        []ceph.RGWKey{},
+		SwiftKeys:   []ceph.RGWSwiftKey{},
+		Caps:        []ceph.RGWCap{},
+		BucketQuota: disabled,
+		UserQuota:   disabled,
+		Type:        "rgw",
+	}
+
+	if i := strings.Index(create.UID, "$"); i >= 0 {
+		user.Tenant, user.UserID = create.UID[:i], create.UID[i+1:]
+	}
+
+	if create.MaxBuckets != nil {
+		user.MaxBuckets = *create.MaxBuckets
+	}
+
+	if create.Suspended {
+		user.Suspended = 1
+	}
+
+	switch {
+	case create.AccessKey != "" || create.SecretKey != "":
+		user.Keys = append(user.Keys, ceph.RGWKey{User: create.UID, AccessKey: create.AccessKey, SecretKey: create.SecretKey})
+	case create.GenerateKey:
+		accessKey, secretKey := s.newRGWKey()
+		user.Keys = append(user.Keys, ceph.RGWKey{User: create.UID, AccessKey: accessKey, SecretKey: secretKey})
+	}
+
+	s.rgwUsers[create.UID] = user
+
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (s *Server) handleUpdateRGWUser(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	var update ceph.RGWUserUpdate
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", err.Error()))
+		return
+	}
+
+	if update.DisplayName != "" {
+		user.DisplayName = update.DisplayName
+	}
+
+	if update.Email != "" {
+		user.Email = update.Email
+	}
+
+	if update.MaxBuckets != nil {
+		user.MaxBuckets = *update.MaxBuckets
+	}
+
+	if update.Suspended != nil {
+		user.Suspended = 0
+		if *update.Suspended {
+			user.Suspended = 1
+		}
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (s *Server) handleDeleteRGWUser(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	delete(s.rgwUsers, user.UID)
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleCreateRGWSubuser(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	var create ceph.RGWSubuserCreate
+
+	if err := readJSON(r, &create); err != nil || create.Subuser == "" {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", "subuser is required"))
+		return
+	}
+
+	id := user.UID + ":" + create.Subuser
+
+	for _, subuser := range user.Subusers {
+		if subuser.ID == id {
+			writeException(w, rgwException(http.StatusConflict, "SubuserExists", fmt.Sprintf("subuser %s already exists", id)))
+			return
+		}
+	}
+
+	user.Subusers = append(user.Subusers, ceph.RGWSubuser{ID: id, Permissions: rgwPermissions(create.Access)})
+
+	switch {
+	case create.SecretKey != "":
+		user.SwiftKeys = append(user.SwiftKeys, ceph.RGWSwiftKey{User: id, SecretKey: create.SecretKey})
+	case create.GenerateSecret:
+		_, secretKey := s.newRGWKey()
+		user.SwiftKeys = append(user.SwiftKeys, ceph.RGWSwiftKey{User: id, SecretKey: secretKey})
+	}
+
+	writeJSON(w, http.StatusCreated, user.Subusers)
+}
+
+func (s *Server) handleDeleteRGWSubuser(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	id := user.UID + ":" + vars["subuser"]
+
+	subusers := user.Subusers[:0]
+	for _, subuser := range user.Subusers {
+		if subuser.ID != id {
+			subusers = append(subusers, subuser)
+		}
+	}
+
+	if len(subusers) == len(user.Subusers) {
+		writeException(w, rgwException(http.StatusNotFound, "NoSuchSubUser", fmt.Sprintf("subuser %s does not exist", id)))
+		return
+	}
+
+	user.Subusers = subusers
+
+	if r.URL.Query().Get("purge_keys") != "false" {
+		swiftKeys := user.SwiftKeys[:0]
+		for _, key := range user.SwiftKeys {
+			if key.User != id {
+				swiftKeys = append(swiftKeys, key)
+			}
+		}
+		user.SwiftKeys = swiftKeys
+	}
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleCreateRGWKey(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	var create ceph.RGWKeyCreate
+
+	if err := readJSON(r, &create); err != nil {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", err.Error()))
+		return
+	}
+
+	owner := user.UID
+	if create.Subuser != "" {
+		owner += ":" + create.Subuser
+	}
+
+	accessKey, secretKey := create.AccessKey, create.SecretKey
+	if create.GenerateKey {
+		accessKey, secretKey = s.newRGWKey()
+	}
+
+	if create.KeyType == ceph.RGWKeyTypeSwift {
+		if create.Subuser == "" {
+			writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", "swift keys require a subuser"))
+			return
+		}
+
+		user.SwiftKeys = append(user.SwiftKeys, ceph.RGWSwiftKey{User: owner, SecretKey: secretKey})
+
+		writeJSON(w, http.StatusCreated, user.SwiftKeys)
+		return
+	}
+
+	user.Keys = append(user.Keys, ceph.RGWKey{User: owner, AccessKey: accessKey, SecretKey: secretKey})
+
+	writeJSON(w, http.StatusCreated, user.Keys)
+}
+
+func (s *Server) handleDeleteRGWKey(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+
+	if query.Get("key_type") == ceph.RGWKeyTypeSwift {
+		id := user.UID + ":" + query.Get("subuser")
+
+		swiftKeys := user.SwiftKeys[:0]
+		for _, key := range user.SwiftKeys {
+			if key.User != id {
+				swiftKeys = append(swiftKeys, key)
+			}
+		}
+		user.SwiftKeys = swiftKeys
+
+		writeJSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	keys := user.Keys[:0]
+	for _, key := range user.Keys {
+		if key.AccessKey != query.Get("access_key") {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == len(user.Keys) {
+		writeException(w, rgwException(http.StatusNotFound, "InvalidAccessKeyId", fmt.Sprintf("access key %s does not exist", query.Get("access_key"))))
+		return
+	}
+
+	user.Keys = keys
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleAddRGWUserCapability(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	var capability ceph.RGWCap
+
+	if err := readJSON(r, &capability); err != nil || capability.Type == "" || capability.Perm == "" {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", "type and perm are required"))
+		return
+	}
+
+	found := false
+
+	for i, c := range user.Caps {
+		if c.Type == capability.Type {
+			user.Caps[i].Perm = capability.Perm
+			found = true
+		}
+	}
+
+	if !found {
+		user.Caps = append(user.Caps, capability)
+	}
+
+	writeJSON(w, http.StatusCreated, user.Caps)
+}
+
+func (s *Server) handleRemoveRGWUserCapability(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	capType := r.URL.Query().Get("type")
+
+	caps := user.Caps[:0]
+	for _, c := range user.Caps {
+		if c.Type != capType {
+			caps = append(caps, c)
+		}
+	}
+	user.Caps = caps
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleGetRGWUserQuota(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ceph.RGWUserQuota{BucketQuota: user.BucketQuota, UserQuota: user.UserQuota})
+}
+
+func (s *Server) handleSetRGWUserQuota(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	user, ok := s.lookupRGWUser(w, vars)
+	if !ok {
+		return
+	}
+
+	var update ceph.RGWQuotaUpdate
+
+	if err := readJSON(r, &update); err != nil {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", err.Error()))
+		return
+	}
+
+	quota := ceph.RGWQuota{
+		Enabled:    update.Enabled,
+		MaxSize:    -1,
+		MaxSizeKb:  update.MaxSizeKb,
+		MaxObjects: update.MaxObjects,
+	}
+
+	if update.MaxSizeKb >= 0 {
+		quota.MaxSize = update.MaxSizeKb * 1024
+	}
+
+	switch update.QuotaType {
+	case ceph.RGWQuotaTypeUser:
+		user.UserQuota = quota
+	case ceph.RGWQuotaTypeBucket:
+		user.BucketQuota = quota
+	default:
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", fmt.Sprintf("invalid quota type %q", update.QuotaType)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleListRGWBuckets(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !s.requireRGW(w) {
+		return
+	}
+
+	uid := r.URL.Query().Get("uid")
+
+	names := make([]string, 0, len(s.rgwBuckets))
+	for name, bucket := range s.rgwBuckets {
+		if uid == "" || bucket.Owner == uid {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// without stats the mgr lists the bucket names only
+	if r.URL.Query().Get("stats") != "true" {
+		writeJSON(w, http.StatusOK, names)
+		return
+	}
+
+	buckets := make([]ceph.RGWBucket, 0, len(names))
+	for _, name := range names {
+		buckets = append(buckets, *s.rgwBuckets[name])
+	}
+
+	writeJSON(w, http.StatusOK, buckets)
+}
+
+func (s *Server) handleGetRGWBucket(w http.ResponseWriter, _ *http.Request, vars map[string]string) {
+	bucket, ok := s.lookupRGWBucket(w, vars)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bucket)
+}
+
+func (s *Server) handleCreateRGWBucket(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if !s.requireRGW(w) {
+		return
+	}
+
+	var create ceph.RGWBucketCreate
+
+	if err := readJSON(r, &create); err != nil || create.Bucket == "" {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidBucketName", "bucket is required"))
+		return
+	}
+
+	if _, ok := s.rgwBuckets[create.Bucket]; ok {
+		writeException(w, rgwException(http.StatusConflict, "BucketAlreadyExists", fmt.Sprintf("bucket %s already exists", create.Bucket)))
+		return
+	}
+
+	if _, ok := s.rgwUsers[create.UID]; !ok {
+		writeException(w, rgwException(http.StatusNotFound, "NoSuchUser", fmt.Sprintf("user %s does not exist", create.UID)))
+		return
+	}
+
+	if create.PlacementTarget != "" && create.Zonegroup == "" {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", "placement target requires a zonegroup"))
+		return
+	}
+
+	id := fmt.Sprintf("f4b8c6ee-0d3b-4c4e-9b4b-3c0e6c6b7a10.%d.1", s.nextID())
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000000Z")
+
+	bucket := &ceph.RGWBucket{
+		Bucket:        create.Bucket,
+		ID:            id,
+		Marker:        id,
+		Owner:         create.UID,
+		Zonegroup:     create.Zonegroup,
+		PlacementRule: create.PlacementTarget,
+		IndexType:     "Normal",
+		NumShards:     11,
+		Mtime:         now,
+		CreationTime:  now,
+		Usage:         map[string]ceph.RGWUsage{"rgw.main": {}},
+		BucketQuota:   ceph.RGWQuota{MaxSize: -1, MaxObjects: -1},
+		Versioning:    ceph.RGWVersioningSuspended,
+		MfaDelete:     "Disabled",
+		LockEnabled:   create.LockEnabled,
+	}
+
+	if bucket.Zonegroup == "" {
+		bucket.Zonegroup = "default"
+	}
+
+	if bucket.PlacementRule == "" {
+		bucket.PlacementRule = "default-placement"
+	}
+
+	if create.LockEnabled {
+		bucket.Versioning = ceph.RGWVersioningEnabled
+		bucket.LockMode = create.LockMode
+		bucket.LockRetentionPeriodDays = create.LockRetentionPeriodDays
+		bucket.LockRetentionPeriodYears = create.LockRetentionPeriodYears
+	}
+
+	s.rgwBuckets[create.Bucket] = bucket
+
+	writeJSON(w, http.StatusCreated, nil)
+}
+
+func (s *Server) handleUpdateRGWBucket(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	bucket, ok := s.lookupRGWBucket(w, vars)
+	if !ok {
+		return
+	}
+
+	var update ceph.RGWBucketUpdate
+
+	if err := readJSON(r, &update); err != nil || update.BucketID == "" || update.UID == "" {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", "bucket_id and uid are required"))
+		return
+	}
+
+	if update.BucketID != bucket.ID {
+		writeException(w, rgwException(http.StatusBadRequest, "InvalidArgument", fmt.Sprintf("bucket id %s does not match", update.BucketID)))
+		return
+	}
+
+	if update.UID != bucket.Owner {
+		if _, ok := s.rgwUsers[update.UID]; !ok {
+			writeException(w, rgwException(http.StatusNotFound, "NoSuchUser", fmt.Sprintf("user %s does not exist", update.UID)))
+			return
+		}
+	}
+
+	if update.LockMode != "" && !bucket.LockEnabled {
+		writeException(w, rgwException(http.StatusConflict, "InvalidBucketState", fmt.Sprintf("object lock is not enabled for bucket %s", bucket.Bucket)))
+		return
+	}
+
+	// object lock needs versioning, which can not be suspended once the lock is enabled
+	if update.VersioningState == ceph.RGWVersioningSuspended && bucket.LockEnabled {
+		writeException(w, rgwException(http.StatusConflict, "InvalidBucketState", fmt.Sprintf("versioning can not be suspended for bucket %s with object lock", bucket.Bucket)))
+		return
+	}
+
+	bucket.Owner = update.UID
+
+	if update.VersioningState != "" {
+		bucket.Versioning = update.VersioningState
+	}
+
+	if update.LockMode != "" {
+		bucket.LockMode = update.LockMode
+		bucket.LockRetentionPeriodDays = update.LockRetentionPeriodDays
+		bucket.LockRetentionPeriodYears = update.LockRetentionPeriodYears
+	}
+
+	bucket.Mtime = time.Now().UTC().Format("2006-01-02T15:04:05.000000Z")
+
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleDeleteRGWBucket(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	bucket, ok := s.lookupRGWBucket(w, vars)
+	if !ok {
+		return
+	}
+
+	if bucket.Usage["rgw.main"].NumObjects > 0 && r.URL.Query().Get("purge_objects") != "true" {
+		writeException(w, rgwException(http.StatusConflict, "BucketNotEmpty", fmt.Sprintf("bucket %s is not empty", bucket.Bucket)))
+		return
+	}
+
+	delete(s.rgwBuckets, bucket.Bucket)
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,218 @@
+package cephtest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handlerFunc implements a route handler receiving the path variables of the matched route.
+type handlerFunc func(w http.ResponseWriter, r *http.Request, vars map[string]string)
+
+// route implements a single api endpoint like "block/image/{image_spec}".
+type route struct {
+	method  string
+	pattern []string
+	public  bool
+	handler handlerFunc
+}
+
+// match checks method and path segments against the route and returns the path variables.
+func (rt route) match(method string, segments []string) (map[string]string, bool) {
+	if rt.method != method || len(rt.pattern) != len(segments) {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+
+	for i, p := range rt.pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			vars[strings.Trim(p, "{}")] = segments[i]
+			continue
+		}
+
+		if p != segments[i] {
+			return nil, false
+		}
+	}
+
+	return vars, true
+}
+
+// handle registers handler for method and pattern, requiring a logged-in session.
+func (s *Server) handle(method, pattern string, handler handlerFunc) {
+	s.routes = append(s.routes, route{method: method, pattern: splitPattern(pattern), handler: handler})
+}
+
+// handlePublic registers handler for method and pattern without requiring a logged-in session.
+func (s *Server) handlePublic(method, pattern string, handler handlerFunc) {
+	s.routes = append(s.routes, route{method: method, pattern: splitPattern(pattern), public: true, handler: handler})
+}
+
+// handleUI registers handler for method and pattern below the ui api path, requiring a logged-in session.
+func (s *Server) handleUI(method, pattern string, handler handlerFunc) {
+	s.handle(method, UIAPIPath+"/"+pattern, handler)
+}
+
+func splitPattern(pattern string) []string {
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return []string{}
+	}
+
+	return strings.Split(pattern, "/")
+}
+
+func (s *Server) registerRoutes() {
+	s.handlePublic(http.MethodGet, "", s.handleRoot)
+	s.handlePublic(http.MethodPost, "auth", s.handleLogin)
+	s.handlePublic(http.MethodPost, "auth/logout", s.handleLogout)
+	s.handlePublic(http.MethodPost, "auth/check", s.handleCheckToken)
+
+	s.handle(http.MethodGet, "task", s.handleGetTask)
+
+	s.handle(http.MethodGet, "summary", s.handleGetSummary)
+	s.handle(http.MethodGet, "health/minimal", s.handleGetHealthMinimal)
+	s.handle(http.MethodGet, "health/full", s.handleGetHealthFull)
+
+	s.handle(http.MethodGet, "pool", s.handleListPools)
+	s.handle(http.MethodPost, "pool", s.handleCreatePool)
+	s.handle(http.MethodGet, "pool/{pool_name}", s.handleGetPool)
+	s.handle(http.MethodPut, "pool/{pool_name}", s.handleUpdatePool)
+	s.handle(http.MethodDelete, "pool/{pool_name}", s.handleDeletePool)
+
+	// registered before block/image/{image_spec}, which would match block/image/trash as well
+	s.handle(http.MethodGet, "block/image/trash", s.handleListTrash)
+	s.handle(http.MethodPost, "block/image/trash/purge", s.handlePurgeTrash)
+	s.handle(http.MethodPost, "block/image/trash/{image_id_spec}/restore", s.handleRestoreTrash)
+	s.handle(http.MethodDelete, "block/image/trash/{image_id_spec}", s.handleRemoveTrash)
+
+	s.handle(http.MethodGet, "block/image", s.handleListImages)
+	s.handle(http.MethodPost, "block/image", s.handleCreateImage)
+	s.handle(http.MethodGet, "block/image/{image_spec}", s.handleGetImage)
+	s.handle(http.MethodPut, "block/image/{image_spec}", s.handleUpdateImage)
+	s.handle(http.MethodDelete, "block/image/{image_spec}", s.handleDeleteImage)
+	s.handle(http.MethodPost, "block/image/{image_spec}/copy", s.handleCopyImage)
+	s.handle(http.MethodPost, "block/image/{image_spec}/move_trash", s.handleMoveImageToTrash)
+	s.handle(http.MethodPost, "block/image/{image_spec}/snap", s.handleCreateImageSnapshot)
+	s.handle(http.MethodPut, "block/image/{image_spec}/snap/{snapshot_name}", s.handleUpdateImageSnapshot)
+	s.handle(http.MethodDelete, "block/image/{image_spec}/snap/{snapshot_name}", s.handleDeleteImageSnapshot)
+	s.handle(http.MethodPost, "block/image/{image_spec}/snap/{snapshot_name}/rollback", s.handleRollbackImageSnapshot)
+	s.handle(http.MethodPost, "block/image/{image_spec}/snap/{snapshot_name}/clone", s.handleCloneImageSnapshot)
+
+	s.handle(http.MethodGet, "block/mirroring/site_name", s.handleGetMirroringSiteName)
+	s.handle(http.MethodPut, "block/mirroring/site_name", s.handleSetMirroringSiteName)
+	s.handle(http.MethodGet, "block/mirroring/summary", s.handleGetMirroringSummary)
+	s.handle(http.MethodGet, "block/mirroring/pool/{pool_name}", s.handleGetMirroringPool)
+	s.handle(http.MethodPut, "block/mirroring/pool/{pool_name}", s.handleSetMirroringPool)
+	s.handle(http.MethodPost, "block/mirroring/pool/{pool_name}/bootstrap/token", s.handleCreateMirroringBootstrapToken)
+	s.handle(http.MethodPost, "block/mirroring/pool/{pool_name}/bootstrap/peer", s.handleImportMirroringBootstrapToken)
+	s.handle(http.MethodGet, "block/mirroring/pool/{pool_name}/peer", s.handleListMirroringPeers)
+	s.handle(http.MethodPost, "block/mirroring/pool/{pool_name}/peer", s.handleAddMirroringPeer)
+	s.handle(http.MethodGet, "block/mirroring/pool/{pool_name}/peer/{peer_uuid}", s.handleGetMirroringPeer)
+	s.handle(http.MethodPut, "block/mirroring/pool/{pool_name}/peer/{peer_uuid}", s.handleUpdateMirroringPeer)
+	s.handle(http.MethodDelete, "block/mirroring/pool/{pool_name}/peer/{peer_uuid}", s.handleRemoveMirroringPeer)
+
+	s.handle(http.MethodGet, "block/pool/{pool_name}/namespace", s.handleListNamespaces)
+	s.handle(http.MethodPost, "block/pool/{pool_name}/namespace", s.handleCreateNamespace)
+	s.handle(http.MethodDelete, "block/pool/{pool_name}/namespace/{namespace}", s.handleDeleteNamespace)
+
+	// registered before osd/{svc_id}, which would match osd/flags as well
+	s.handle(http.MethodGet, "osd/flags", s.handleGetOSDFlags)
+	s.handle(http.MethodPut, "osd/flags", s.handleSetOSDFlags)
+	s.handle(http.MethodGet, "osd/safe_to_destroy", s.handleSafeToDestroyOSD)
+	s.handle(http.MethodGet, "osd/safe_to_delete", s.handleSafeToDeleteOSD)
+
+	s.handle(http.MethodGet, "osd", s.handleListOSDs)
+	s.handle(http.MethodGet, "osd/{svc_id}", s.handleGetOSD)
+	s.handle(http.MethodDelete, "osd/{svc_id}", s.handleDeleteOSD)
+	s.handle(http.MethodPut, "osd/{svc_id}/mark", s.handleMarkOSD)
+	s.handle(http.MethodPost, "osd/{svc_id}/reweight", s.handleReweightOSD)
+	s.handle(http.MethodPost, "osd/{svc_id}/scrub", s.handleScrubOSD)
+	s.handle(http.MethodPost, "osd/{svc_id}/destroy", s.handleDestroyOSD)
+	s.handle(http.MethodPost, "osd/{svc_id}/purge", s.handlePurgeOSD)
+
+	s.handle(http.MethodGet, "orchestrator/status", s.handleGetOrchestratorStatus)
+
+	s.handle(http.MethodGet, "host", s.handleListHosts)
+	s.handle(http.MethodPost, "host", s.handleAddHost)
+	s.handle(http.MethodGet, "host/{hostname}", s.handleGetHost)
+	s.handle(http.MethodPut, "host/{hostname}", s.handleUpdateHost)
+	s.handle(http.MethodDelete, "host/{hostname}", s.handleRemoveHost)
+	s.handle(http.MethodGet, "host/{hostname}/devices", s.handleListHostDevices)
+	s.handle(http.MethodGet, "host/{hostname}/inventory", s.handleGetHostInventory)
+	s.handle(http.MethodGet, "host/{hostname}/daemons", s.handleListHostDaemons)
+
+	s.handle(http.MethodGet, "rgw/status", s.handleGetRGWStatus)
+	s.handle(http.MethodGet, "rgw/daemon", s.handleListRGWDaemons)
+
+	s.handle(http.MethodGet, "rgw/user", s.handleListRGWUsers)
+	s.handle(http.MethodPost, "rgw/user", s.handleCreateRGWUser)
+	s.handle(http.MethodGet, "rgw/user/{uid}", s.handleGetRGWUser)
+	s.handle(http.MethodPut, "rgw/user/{uid}", s.handleUpdateRGWUser)
+	s.handle(http.MethodDelete, "rgw/user/{uid}", s.handleDeleteRGWUser)
+	s.handle(http.MethodPost, "rgw/user/{uid}/subuser", s.handleCreateRGWSubuser)
+	s.handle(http.MethodDelete, "rgw/user/{uid}/subuser/{subuser}", s.handleDeleteRGWSubuser)
+	s.handle(http.MethodPost, "rgw/user/{uid}/key", s.handleCreateRGWKey)
+	s.handle(http.MethodDelete, "rgw/user/{uid}/key", s.handleDeleteRGWKey)
+	s.handle(http.MethodPost, "rgw/user/{uid}/capability", s.handleAddRGWUserCapability)
+	s.handle(http.MethodDelete, "rgw/user/{uid}/capability", s.handleRemoveRGWUserCapability)
+	s.handle(http.MethodGet, "rgw/user/{uid}/quota", s.handleGetRGWUserQuota)
+	s.handle(http.MethodPut, "rgw/user/{uid}/quota", s.handleSetRGWUserQuota)
+
+	s.handle(http.MethodGet, "rgw/bucket", s.handleListRGWBuckets)
+	s.handle(http.MethodPost, "rgw/bucket", s.handleCreateRGWBucket)
+	s.handle(http.MethodGet, "rgw/bucket/{bucket}", s.handleGetRGWBucket)
+	s.handle(http.MethodPut, "rgw/bucket/{bucket}", s.handleUpdateRGWBucket)
+	s.handle(http.MethodDelete, "rgw/bucket/{bucket}", s.handleDeleteRGWBucket)
+
+	s.handle(http.MethodGet, "nfs-ganesha/cluster", s.handleListNFSClusters)
+	s.handle(http.MethodGet, "nfs-ganesha/daemon", s.handleListNFSDaemons)
+	s.handle(http.MethodGet, "nfs-ganesha/export", s.handleListNFSExports)
+	s.handle(http.MethodPost, "nfs-ganesha/export", s.handleCreateNFSExport)
+	s.handle(http.MethodGet, "nfs-ganesha/export/{cluster_id}/{export_id}", s.handleGetNFSExport)
+	s.handle(http.MethodPut, "nfs-ganesha/export/{cluster_id}/{export_id}", s.handleUpdateNFSExport)
+	s.handle(http.MethodDelete, "nfs-ganesha/export/{cluster_id}/{export_id}", s.handleDeleteNFSExport)
+
+	s.handleUI(http.MethodGet, "iscsi/status", s.handleGetISCSIStatus)
+	s.handleUI(http.MethodGet, "iscsi/overview", s.handleGetISCSIOverview)
+	s.handle(http.MethodGet, "iscsi/discoveryauth", s.handleGetISCSIDiscoveryAuth)
+	s.handle(http.MethodPut, "iscsi/discoveryauth", s.handleSetISCSIDiscoveryAuth)
+	s.handle(http.MethodGet, "iscsi/target", s.handleListISCSITargets)
+	s.handle(http.MethodPost, "iscsi/target", s.handleCreateISCSITarget)
+	s.handle(http.MethodGet, "iscsi/target/{target_iqn}", s.handleGetISCSITarget)
+	s.handle(http.MethodPut, "iscsi/target/{target_iqn}", s.handleUpdateISCSITarget)
+	s.handle(http.MethodDelete, "iscsi/target/{target_iqn}", s.handleDeleteISCSITarget)
+
+	s.handle(http.MethodGet, "cephfs", s.handleListFS)
+
+	// the subvolume routes go before the cephfs/{fs_id} routes, the group and snapshot routes before the subvolume
+	// routes, which match some of their paths as well
+	s.handle(http.MethodGet, "cephfs/subvolume/group/{vol_name}", s.handleListFSSubvolumeGroups)
+	s.handle(http.MethodGet, "cephfs/subvolume/group/{vol_name}/info", s.handleGetFSSubvolumeGroup)
+	s.handle(http.MethodPost, "cephfs/subvolume/group", s.handleCreateFSSubvolumeGroup)
+	s.handle(http.MethodPut, "cephfs/subvolume/group/{vol_name}", s.handleResizeFSSubvolumeGroup)
+	s.handle(http.MethodDelete, "cephfs/subvolume/group/{vol_name}", s.handleDeleteFSSubvolumeGroup)
+	s.handle(http.MethodGet, "cephfs/subvolume/snapshot/{vol_name}/{subvol_name}", s.handleListFSSubvolumeSnapshots)
+	s.handle(http.MethodGet, "cephfs/subvolume/snapshot/{vol_name}/{subvol_name}/info", s.handleGetFSSubvolumeSnapshot)
+	s.handle(http.MethodPost, "cephfs/subvolume/snapshot", s.handleCreateFSSubvolumeSnapshot)
+	s.handle(http.MethodDelete, "cephfs/subvolume/snapshot/{vol_name}/{subvol_name}", s.handleDeleteFSSubvolumeSnapshot)
+	s.handle(http.MethodPost, "cephfs/subvolume/snapshot/clone", s.handleCloneFSSubvolumeSnapshot)
+	s.handle(http.MethodGet, "cephfs/subvolume/{vol_name}", s.handleListFSSubvolumes)
+	s.handle(http.MethodGet, "cephfs/subvolume/{vol_name}/info", s.handleGetFSSubvolume)
+	s.handle(http.MethodPost, "cephfs/subvolume", s.handleCreateFSSubvolume)
+	s.handle(http.MethodPut, "cephfs/subvolume/{vol_name}", s.handleResizeFSSubvolume)
+	s.handle(http.MethodDelete, "cephfs/subvolume/{vol_name}", s.handleDeleteFSSubvolume)
+
+	s.handle(http.MethodGet, "cephfs/{fs_id}", s.handleGetFS)
+	s.handle(http.MethodGet, "cephfs/{fs_id}/clients", s.handleListFSClients)
+	s.handle(http.MethodDelete, "cephfs/{fs_id}/client/{client_id}", s.handleEvictFSClient)
+	s.handle(http.MethodGet, "cephfs/{fs_id}/mds_counters", s.handleGetMDSCounters)
+	s.handle(http.MethodGet, "cephfs/{fs_id}/get_root_directory", s.handleGetRootDirectory)
+	s.handle(http.MethodGet, "cephfs/{fs_id}/ls_dir", s.handleListDir)
+	s.handle(http.MethodPost, "cephfs/{fs_id}/tree", s.handleCreateDir)
+	s.handle(http.MethodDelete, "cephfs/{fs_id}/tree", s.handleDeleteDir)
+	s.handle(http.MethodGet, "cephfs/{fs_id}/quota", s.handleGetQuota)
+	s.handle(http.MethodPut, "cephfs/{fs_id}/quota", s.handleSetQuota)
+	s.handle(http.MethodPost, "cephfs/{fs_id}/snapshot", s.handleCreateSnapshot)
+	s.handle(http.MethodDelete, "cephfs/{fs_id}/snapshot", s.handleDeleteSnapshot)
+}
@@ -0,0 +1,358 @@
+// Package cephtest implements an offline fake of the ceph mgr dashboard rest api for tests.
+//
+// The fake keeps pools, rbd images, rbd namespaces, the rbd trash, rbd mirroring, ceph fs directories, clients and
+// subvolumes, osds, hosts, object gateway users and buckets, nfs exports, iscsi targets, the cluster health and tasks
+// in memory and answers like a ceph pacific mgr would. Captured responses (see ceph/outputs) can be replayed for single
+// endpoints and redirects, exceptions, slow or failing tasks can be injected to test the retry and task-wait logic of
+// the client.
+package cephtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+const (
+	// Username is the only user accepted by POST /api/auth.
+	Username = "test-user"
+
+	// Password is the password of Username.
+	Password = "test-password"
+
+	// APIPath is the api path served by the fake.
+	APIPath = "api"
+
+	// UIAPIPath is the path of the endpoints the dashboard serves for its ui only.
+	UIAPIPath = "ui-api"
+)
+
+// replay implements a recorded response returned for a method and path.
+type replay struct {
+	status int
+	body   []byte
+}
+
+// Server implements a fake ceph mgr dashboard rest api on top of httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	routes []route
+
+	tokens       map[string]struct{}
+	redirect     string
+	taskDuration time.Duration
+	taskFailures map[string]int
+	exceptions   map[string][]ceph.Exception
+	replays      map[string]replay
+	requests     map[string]int
+
+	tasks       []*task
+	pools       map[string]*ceph.Pool
+	images      map[string]*ceph.RBD
+	trash       map[string]*trashEntry
+	namespaces  map[string]map[string]struct{}
+	fileSystem  map[int]*fileSystem
+	health      ceph.Health
+	osds        map[int]*ceph.OSD
+	osdFlags    []string
+	hosts       map[string]*fakeHost
+	rgwDaemons  []ceph.RGWDaemon
+	rgwUsers    map[string]*ceph.RGWUser
+	rgwBuckets  map[string]*ceph.RGWBucket
+	nfsClusters map[string]*nfsCluster
+
+	iscsiGateways      map[string]string
+	iscsiTargets       map[string]*ceph.ISCSITarget
+	iscsiDiscoveryAuth ceph.ISCSIAuth
+
+	mirroringSiteName string
+	mirroringPools    map[string]*mirroringPool
+	mirrorSchedules   map[string]string
+	rbdMirrorDaemons  []ceph.MirroringDaemon
+
+	orchestratorUnavailable bool
+
+	sequence int
+}
+
+// NewServer starts and returns a new fake ceph mgr serving http.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewTLSServer starts and returns a new fake ceph mgr serving https with a self-signed certificate.
+// The caller should call Close when finished, to shut it down.
+func NewTLSServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewTLSServer(s)
+	return s
+}
+
+// NewUnstartedServer returns a new fake ceph mgr which is not started yet, e.g. to require client certificates by
+// setting TLS before calling StartTLS. The caller should call Start or StartTLS, and Close when finished.
+func NewUnstartedServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+func newServer() *Server {
+	s := &Server{
+		tokens:          make(map[string]struct{}),
+		taskFailures:    make(map[string]int),
+		exceptions:      make(map[string][]ceph.Exception),
+		replays:         make(map[string]replay),
+		requests:        make(map[string]int),
+		pools:           make(map[string]*ceph.Pool),
+		images:          make(map[string]*ceph.RBD),
+		trash:           make(map[string]*trashEntry),
+		namespaces:      make(map[string]map[string]struct{}),
+		fileSystem:      make(map[int]*fileSystem),
+		osds:            make(map[int]*ceph.OSD),
+		hosts:           make(map[string]*fakeHost),
+		rgwUsers:        make(map[string]*ceph.RGWUser),
+		rgwBuckets:      make(map[string]*ceph.RGWBucket),
+		nfsClusters:     make(map[string]*nfsCluster),
+		iscsiGateways:   make(map[string]string),
+		iscsiTargets:    make(map[string]*ceph.ISCSITarget),
+		mirroringPools:  make(map[string]*mirroringPool),
+		mirrorSchedules: make(map[string]string),
+		osdFlags:        []string{"sortbitwise", "recovery_deletes", "purged_snapdirs", "pglog_hardlimit"},
+		health:          ceph.Health{Status: ceph.HealthOK, Checks: []ceph.HealthCheck{}, Mutes: []interface{}{}},
+	}
+
+	s.registerRoutes()
+
+	return s
+}
+
+// CephServer returns the ceph.Server configuration pointing to the fake.
+func (s *Server) CephServer() ceph.Server {
+	u, _ := url.Parse(s.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	return ceph.Server{
+		Address:            u.Hostname(),
+		Port:               uint(port),
+		Protocol:           u.Scheme,
+		APIPath:            APIPath,
+		InsecureSkipVerify: u.Scheme == "https",
+	}
+}
+
+// CephEndpoint returns the ceph.Endpoint pointing to the fake, e.g. to list it in ceph.Server.Endpoints.
+func (s *Server) CephEndpoint() ceph.Endpoint {
+	server := s.CephServer()
+
+	return ceph.Endpoint{Address: server.Address, Port: server.Port, Protocol: server.Protocol}
+}
+
+// Redirect makes the fake behave like a standby mgr answering every request with a http 303 pointing to location.
+// An empty location turns the fake back into the active mgr.
+func (s *Server) Redirect(location string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.redirect = strings.TrimSuffix(location, "/")
+}
+
+// SetTaskDuration sets how long tasks are reported as executing and ceph fs subvolume clones as in-progress before
+// they finish. With a duration of zero (the default) tasks and clones finish immediately.
+func (s *Server) SetTaskDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taskDuration = d
+}
+
+// FailTask lets the next times tasks with name (e.g. rbd/delete) finish unsuccessfully with errno 16 (busy).
+func (s *Server) FailTask(name string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taskFailures[name] += times
+}
+
+// InjectException lets the next request for method and path (e.g. POST /api/block/image) fail with exception.
+// The http status is taken from exception.Status and defaults to 400.
+func (s *Server) InjectException(method, path string, exception ceph.Exception) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := requestKey(method, path)
+	s.exceptions[key] = append(s.exceptions[key], exception)
+}
+
+// Replay answers every request for method and path with status and body instead of the stateful fake.
+func (s *Server) Replay(method, path string, status int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replays[requestKey(method, path)] = replay{status: status, body: body}
+}
+
+// ReplayFile is like Replay but reads the body from file (e.g. ceph/outputs/tasks.json).
+func (s *Server) ReplayFile(method, path string, status int, file string) error {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	s.Replay(method, path, status, body)
+
+	return nil
+}
+
+// RequestCount returns how many requests for method and path were received.
+func (s *Server) RequestCount(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[requestKey(method, path)]
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := requestKey(r.Method, r.URL.EscapedPath())
+	s.requests[key]++
+
+	if s.redirect != "" {
+		http.Redirect(w, r, s.redirect+r.URL.RequestURI(), http.StatusSeeOther)
+		return
+	}
+
+	if exceptions := s.exceptions[key]; len(exceptions) > 0 {
+		s.exceptions[key] = exceptions[1:]
+		writeException(w, exceptions[0])
+		return
+	}
+
+	if rp, ok := s.replays[key]; ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rp.status)
+		_, _ = w.Write(rp.body)
+		return
+	}
+
+	segments, ok := apiSegments(r.URL.EscapedPath())
+	if !ok {
+		writeNotFound(w, r)
+		return
+	}
+
+	for _, rt := range s.routes {
+		vars, match := rt.match(r.Method, segments)
+		if !match {
+			continue
+		}
+
+		if !rt.public && !s.authorized(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"detail": "You are not authorized to access that resource",
+				"status": http.StatusUnauthorized,
+			})
+			return
+		}
+
+		rt.handler(w, r, vars)
+		return
+	}
+
+	writeNotFound(w, r)
+}
+
+// nextID returns a new unique id used for pools and images.
+func (s *Server) nextID() int {
+	s.sequence++
+	return s.sequence
+}
+
+// requestKey builds the key used for replays, exceptions and request counting.
+func requestKey(method, path string) string {
+	return fmt.Sprintf("%s %s", strings.ToUpper(method), strings.TrimSuffix(path, "/"))
+}
+
+// apiSegments splits the escaped request path below /api into unescaped segments. Paths below /ui-api keep ui-api as
+// first segment (see handleUI).
+func apiSegments(escapedPath string) ([]string, bool) {
+	p := strings.Trim(escapedPath, "/")
+
+	switch {
+	case p == APIPath || strings.HasPrefix(p, APIPath+"/"):
+		p = strings.TrimPrefix(strings.TrimPrefix(p, APIPath), "/")
+	case strings.HasPrefix(p, UIAPIPath+"/"):
+	default:
+		return nil, false
+	}
+
+	if p == "" {
+		return []string{}, true
+	}
+
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		unescaped, err := url.PathUnescape(seg)
+		if err != nil {
+			return nil, false
+		}
+		segments[i] = unescaped
+	}
+
+	return segments, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"detail": fmt.Sprintf("The path '%s' was not found.", r.URL.Path),
+		"status": "404 Not Found",
+	})
+}
+
+// writeException writes a ceph exception as returned by the dashboard on failed requests and tasks.
+func writeException(w http.ResponseWriter, exception ceph.Exception) {
+	if exception.Status == 0 {
+		exception.Status = http.StatusBadRequest
+	}
+
+	writeJSON(w, exception.Status, exception)
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// newException creates an exception for a ceph errno like the dashboard does for failed rados/rbd calls.
+func newException(code, component, detail string) ceph.Exception {
+	return ceph.Exception{
+		Detail:    detail,
+		Code:      code,
+		Component: component,
+		Status:    http.StatusBadRequest,
+	}
+}
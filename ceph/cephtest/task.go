@@ -0,0 +1,100 @@
+package cephtest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// task implements a dashboard task which is executing until finish.
+type task struct {
+	ceph.Task
+	finish time.Time
+}
+
+// runTask runs apply as task name and returns the http status the dashboard answers with.
+// status is returned if the task finished at once, otherwise http 202 is returned. If apply fails, the exception is
+// recorded on the finished task and returned for a http 400 answer.
+func (s *Server) runTask(name string, metaData ceph.MetaData, status int, apply func() *ceph.Exception) (int, *ceph.Exception) {
+	now := time.Now()
+
+	t := &task{
+		Task: ceph.Task{
+			Name:      name,
+			MetaData:  metaData,
+			BeginTime: now,
+		},
+		finish: now.Add(s.taskDuration),
+	}
+
+	s.tasks = append(s.tasks, t)
+
+	if s.taskFailures[name] > 0 {
+		s.taskFailures[name]--
+
+		exception := newException("16", "rbd", "[errno 16] RBD image is busy")
+		exception.Task.Name = name
+		exception.Task.MetaData = metaData
+		t.Exception = exception
+
+		return http.StatusAccepted, nil
+	}
+
+	if exception := apply(); exception != nil {
+		exception.Task.Name = name
+		exception.Task.MetaData = metaData
+		t.Exception = *exception
+		t.finish = now
+
+		return exception.Status, exception
+	}
+
+	t.Success = true
+	t.Progress = 100
+
+	if s.taskDuration > 0 {
+		return http.StatusAccepted, nil
+	}
+
+	return status, nil
+}
+
+// currentTasks splits all tasks into executing and finished tasks at now. Executing tasks report their progress by
+// the elapsed part of the task duration.
+// Like on the mgr, the most recent tasks are listed first.
+func (s *Server) currentTasks(name string) ceph.Tasks {
+	now := time.Now()
+
+	tasks := ceph.Tasks{
+		ExecutingTasks: []ceph.Task{},
+		FinishedTasks:  []ceph.Task{},
+	}
+
+	for i := len(s.tasks) - 1; i >= 0; i-- {
+		t := s.tasks[i]
+
+		if name != "" && t.Name != name {
+			continue
+		}
+
+		if now.Before(t.finish) {
+			executing := t.Task
+			executing.Success = false
+			executing.Progress = int(100 * now.Sub(t.BeginTime) / t.finish.Sub(t.BeginTime))
+			tasks.ExecutingTasks = append(tasks.ExecutingTasks, executing)
+			continue
+		}
+
+		finished := t.Task
+		finished.EndTime = t.finish
+		finished.Duration = t.finish.Sub(t.BeginTime).Seconds()
+		tasks.FinishedTasks = append(tasks.FinishedTasks, finished)
+	}
+
+	return tasks
+}
+
+func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	writeJSON(w, http.StatusOK, s.currentTasks(r.URL.Query().Get("name")))
+}
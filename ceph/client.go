@@ -1,23 +1,54 @@
 package ceph
 
-import "errors"
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultTaskPollInterval is the default interval between two /api/task polls while waiting for a task.
+const DefaultTaskPollInterval = 5 * time.Second
 
 type Client struct {
-	Session       *Session
-	MaxIterations uint
-	Logger        *Adapter
+	Session          *Session
+	TaskPollInterval time.Duration
+	Logger           *Adapter
+
+	// RetryPolicy defines how failed requests and failed tasks are retried. A zero RetryPolicy means
+	// DefaultRetryPolicy.
+	RetryPolicy RetryPolicy
+
+	// TaskTracker polls /api/task for all calls waiting for a task. It is created on first use if nil.
+	TaskTracker *TaskTracker
+
+	trackerMu sync.Mutex
 }
 
-var ErrMaxIterationsExceeded = errors.New("max recursive iterations exceeded")
+// ErrMaxIterationsExceeded is returned if a task still failed after all re-submissions.
+//
+// Deprecated: use ErrRetriesExhausted.
+var ErrMaxIterationsExceeded = ErrRetriesExhausted
 
-func New(server Server) (client *Client, err error) {
+// New creates a new ceph rest api client for server configured by opts, e.g. WithCACertPool, WithTimeout or
+// WithLogger. An invalid option is reported before any request is made.
+func New(server Server, opts ...Option) (client *Client, err error) {
+	return NewWithContext(context.Background(), server, opts...)
+}
+
+// NewWithContext is like New but aborts the active mgr lookup if ctx is done.
+func NewWithContext(ctx context.Context, server Server, opts ...Option) (client *Client, err error) {
+	o, err := newOptions(opts)
 
-	client = &Client{MaxIterations: 30}
-	if client.Logger == nil {
-		client.Logger = NewLogger()
+	if err != nil {
+		return nil, err
 	}
 
-	client.Session, err = NewSession(server)
+	client = &Client{TaskPollInterval: DefaultTaskPollInterval, RetryPolicy: DefaultRetryPolicy(), Logger: o.logger}
+	client.TaskTracker = NewTaskTracker(client)
+
+	client.Session, err = newSession(ctx, server, o)
 
 	if err != nil {
 		return nil, err
@@ -25,3 +56,24 @@ func New(server Server) (client *Client, err error) {
 
 	return client, nil
 }
+
+// send sends req with method to subPath below the api path. The http status is returned along with an *APIError if
+// the mgr answered with an error. op names the calling method in errors of a done ctx.
+func (c *Client) send(ctx context.Context, op string, req *resty.Request, method, subPath string) (status int, err error) {
+	return c.sendURL(ctx, op, req, method, c.Session.Server.getURL(subPath))
+}
+
+// sendURL is like send but sends req to the absolute url, e.g. one below the ui api path.
+func (c *Client) sendURL(ctx context.Context, op string, req *resty.Request, method, url string) (status int, err error) {
+	resp, err := c.execute(op, req, method, url)
+
+	if err != nil {
+		return 0, ctxErr(ctx, op, err)
+	}
+
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), newAPIError(resp)
+	}
+
+	return resp.StatusCode(), nil
+}
@@ -0,0 +1,189 @@
+package ceph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Error categories an *APIError matches with errors.Is, e.g. errors.Is(err, ceph.ErrNotFound).
+var (
+	// ErrNotFound matches errors for resources which do not exist (http 404, errno 2, code *_not_found or, for the
+	// iscsi gateways, *_does_not_exist).
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists matches errors for resources which already exist (errno 17, code *_already_exists or, for the
+	// object gateway, *AlreadyExists).
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrBusy matches errors for resources which are busy, e.g. an rbd image still having watchers (errno 16).
+	ErrBusy = errors.New("resource busy")
+
+	// ErrPermissionDenied matches errors for operations the user is not allowed to do (http 403, errno 1 or 13).
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrUnauthorized matches errors for requests without a valid token (http 401).
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrOrchestratorUnavailable matches errors for requests needing an orchestrator (e.g. cephadm) while none is
+	// available (code orchestrator_status_unavailable).
+	ErrOrchestratorUnavailable = errors.New("orchestrator unavailable")
+)
+
+// Ceph error codes (errno) used by the dashboard in exceptions.
+const (
+	ErrnoPermission    = "1"
+	ErrnoNotFound      = "2"
+	ErrnoAccess        = "13"
+	ErrnoBusy          = "16"
+	ErrnoAlreadyExists = "17"
+
+	// CodeOrchestratorUnavailable is the code of errors for requests needing an unavailable orchestrator.
+	CodeOrchestratorUnavailable = "orchestrator_status_unavailable"
+)
+
+// APIError implements the error returned for failed requests and failed tasks.
+// Use errors.As to get the details and errors.Is to check the category (ErrNotFound, ErrAlreadyExists, ErrBusy,
+// ErrPermissionDenied, ErrUnauthorized or ErrOrchestratorUnavailable).
+type APIError struct {
+	// StatusCode is the http status returned by the mgr.
+	StatusCode int
+
+	// Code is the ceph error code, either an errno (e.g. "17") or a name (e.g. "namespace_already_exists").
+	Code string
+
+	// Component is the ceph component which failed (e.g. rbd or pool).
+	Component string
+
+	// Detail is the error message returned by the mgr.
+	Detail string
+
+	// Method and URL of the failed request. Both are empty for tasks failed in the background.
+	Method string
+	URL    string
+
+	// Task is the failed task, if any.
+	Task *Task
+
+	// Err is a more specific error wrapped by the APIError (e.g. ErrCreateImageAlreadyExists), if any.
+	Err error
+}
+
+// Error implements error.
+func (e *APIError) Error() string {
+	var b strings.Builder
+
+	if e.Method != "" {
+		fmt.Fprintf(&b, "%s %s: ", e.Method, e.URL)
+	}
+
+	if e.Task != nil {
+		fmt.Fprintf(&b, "task %s failed: ", e.Task.Name)
+	}
+
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, "http %d", e.StatusCode)
+	} else {
+		b.WriteString("error")
+	}
+
+	if e.Code != "" {
+		fmt.Fprintf(&b, " (code %s", e.Code)
+		if e.Component != "" {
+			fmt.Fprintf(&b, ", component %s", e.Component)
+		}
+		b.WriteString(")")
+	}
+
+	if e.Detail != "" {
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	}
+
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the wrapped Err.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is maps the APIError to the error categories ErrNotFound, ErrAlreadyExists, ErrBusy, ErrPermissionDenied,
+// ErrUnauthorized and ErrOrchestratorUnavailable.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.Code == ErrnoNotFound || strings.HasSuffix(e.Code, "not_found") ||
+			strings.HasSuffix(e.Code, "does_not_exist")
+	case ErrAlreadyExists:
+		return e.Code == ErrnoAlreadyExists || strings.HasSuffix(e.Code, "already_exists") ||
+			strings.HasSuffix(e.Code, "AlreadyExists")
+	case ErrBusy:
+		return e.Code == ErrnoBusy
+	case ErrPermissionDenied:
+		return e.StatusCode == http.StatusForbidden || e.Code == ErrnoPermission || e.Code == ErrnoAccess
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrOrchestratorUnavailable:
+		return e.Code == CodeOrchestratorUnavailable
+	}
+
+	return false
+}
+
+// apiErrorBody implements the error body returned by the dashboard. Other than in Exception status is not
+// decoded, as the dashboard sends it either as int or as string (e.g. "404 Not Found").
+type apiErrorBody struct {
+	Detail    string `json:"detail"`
+	Code      string `json:"code"`
+	Component string `json:"component"`
+	Task      *struct {
+		Name     string   `json:"name"`
+		MetaData MetaData `json:"metadata"`
+	} `json:"task"`
+}
+
+// newAPIError creates an APIError for the failed response resp, decoding the ceph exception in the body if any.
+func newAPIError(resp *resty.Response) *APIError {
+	var body apiErrorBody
+
+	apiErr := &APIError{StatusCode: resp.StatusCode()}
+
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.URL = resp.Request.URL
+	}
+
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		apiErr.Detail = strings.TrimSpace(resp.String())
+		return apiErr
+	}
+
+	apiErr.Code = body.Code
+	apiErr.Component = body.Component
+	apiErr.Detail = body.Detail
+
+	if body.Task != nil && body.Task.Name != "" {
+		apiErr.Task = &Task{Name: body.Task.Name, MetaData: body.Task.MetaData}
+	}
+
+	return apiErr
+}
+
+// newTaskError creates an APIError for the unsuccessfully finished task.
+func newTaskError(task Task) *APIError {
+	return &APIError{
+		StatusCode: task.Exception.Status,
+		Code:       task.Exception.Code,
+		Component:  task.Exception.Component,
+		Detail:     task.Exception.Detail,
+		Task:       &task,
+	}
+}
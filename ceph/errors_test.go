@@ -0,0 +1,180 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *ceph.APIError
+		target error
+		want   bool
+	}{
+		{"http 404", &ceph.APIError{StatusCode: http.StatusNotFound}, ceph.ErrNotFound, true},
+		{"errno 2", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: "2"}, ceph.ErrNotFound, true},
+		{"iscsi target_does_not_exist", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: "target_does_not_exist"}, ceph.ErrNotFound, true},
+		{"errno 17", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: "17"}, ceph.ErrAlreadyExists, true},
+		{"namespace_already_exists", &ceph.APIError{Code: ceph.NameSpaceAlreadyExists}, ceph.ErrAlreadyExists, true},
+		{"rgw UserAlreadyExists", &ceph.APIError{StatusCode: http.StatusConflict, Code: "UserAlreadyExists"}, ceph.ErrAlreadyExists, true},
+		{"errno 16", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: "16"}, ceph.ErrBusy, true},
+		{"errno 17 is not busy", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: "17"}, ceph.ErrBusy, false},
+		{"http 403", &ceph.APIError{StatusCode: http.StatusForbidden}, ceph.ErrPermissionDenied, true},
+		{"errno 13", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: "13"}, ceph.ErrPermissionDenied, true},
+		{"http 401", &ceph.APIError{StatusCode: http.StatusUnauthorized}, ceph.ErrUnauthorized, true},
+		{"http 401 is not found", &ceph.APIError{StatusCode: http.StatusUnauthorized}, ceph.ErrNotFound, false},
+		{"wrapped err", &ceph.APIError{Code: "17", Err: ceph.ErrPoolAlreadyExists}, ceph.ErrPoolAlreadyExists, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v - want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_APIErrorNotFound(t *testing.T) {
+	client := newLoggedInClient(t, mgr)
+
+	status, _, err := client.GetBlockImage("test-pool-1/does-not-exist")
+
+	if status != http.StatusNotFound {
+		t.Errorf("expected http state 404 - got %d", status)
+	}
+
+	if !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	var apiErr *ceph.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *ceph.APIError - got %T", err)
+	}
+
+	if apiErr.Method != http.MethodGet {
+		t.Errorf("expected method GET - got %s", apiErr.Method)
+	}
+
+	if !strings.HasSuffix(apiErr.URL, "/api/block/image/test-pool-1%2Fdoes-not-exist") {
+		t.Errorf("unexpected url %s", apiErr.URL)
+	}
+
+	if apiErr.Component != "rbd" {
+		t.Errorf("expected component rbd - got %s", apiErr.Component)
+	}
+}
+
+func TestClient_APIErrorAlreadyExists(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	if err := srv.ReplayFile(http.MethodPost, "/api/block/image", http.StatusBadRequest, "outputs/err_create_image.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newLoggedInClient(t, srv)
+
+	_, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "rest-client-one-img-1", Size: 1073741824})
+
+	if !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	var apiErr *ceph.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *ceph.APIError - got %T", err)
+	}
+
+	if apiErr.Task == nil || apiErr.Task.Name != "rbd/create" || apiErr.Task.MetaData.ImageName != "rest-client-one-img-1" {
+		t.Errorf("expected failing task rbd/create - got %+v", apiErr.Task)
+	}
+}
+
+func TestClient_APIErrorInjected(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.InjectException(http.MethodDelete, "/api/block/image/test-pool-1%2Fimg", ceph.Exception{
+		Detail:    "[errno 16] RBD image is busy (error removing image)",
+		Code:      "16",
+		Component: "rbd",
+	})
+	srv.InjectException(http.MethodGet, "/api/block/image/test-pool-1%2Fimg", ceph.Exception{
+		Detail: "Access denied",
+		Status: http.StatusForbidden,
+	})
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.MaxAttempts = 1 // errno 16 is retried by default
+
+	_, err := client.DeleteBlockImage("test-pool-1", nil, "img")
+
+	if !errors.Is(err, ceph.ErrBusy) {
+		t.Errorf("expected err %v - got %v", ceph.ErrBusy, err)
+	}
+
+	_, _, err = client.GetBlockImage("test-pool-1/img")
+
+	if !errors.Is(err, ceph.ErrPermissionDenied) {
+		t.Errorf("expected err %v - got %v", ceph.ErrPermissionDenied, err)
+	}
+}
+
+func TestClient_APIErrorUnauthorized(t *testing.T) {
+	client, err := ceph.New(getServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, _, err := client.ListPools(false)
+
+	if status != http.StatusUnauthorized {
+		t.Errorf("expected http state 401 - got %d", status)
+	}
+
+	if !errors.Is(err, ceph.ErrUnauthorized) {
+		t.Errorf("expected err %v - got %v", ceph.ErrUnauthorized, err)
+	}
+}
+
+func TestClient_APIErrorFailedTask(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.MaxAttempts = 2
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	_, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "busy-img", Size: 1073741824})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.FailTask("rbd/delete", 5)
+
+	_, err = client.DeleteBlockImage("test-pool-1", nil, "busy-img")
+
+	if !errors.Is(err, ceph.ErrBusy) || !errors.Is(err, ceph.ErrRetriesExhausted) {
+		t.Errorf("expected err %v and %v - got %v", ceph.ErrBusy, ceph.ErrRetriesExhausted, err)
+	}
+
+	var apiErr *ceph.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *ceph.APIError - got %T", err)
+	}
+
+	if apiErr.Task == nil || apiErr.Task.Name != "rbd/delete" || apiErr.Task.MetaData.ImageSpec != "test-pool-1/busy-img" {
+		t.Errorf("expected failing task rbd/delete - got %+v", apiErr.Task)
+	}
+}
@@ -0,0 +1,244 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrNoActiveMgr is returned if none of the mgrs of the server answered while looking for the active mgr.
+var ErrNoActiveMgr = errors.New("no active mgr found")
+
+// defaultProbeTimeout limits a single request looking for the active mgr if no timeout is set with WithTimeout.
+const defaultProbeTimeout = 10 * time.Second
+
+// failoverTransport implements a http.RoundTripper sending every request to the active mgr. If the mgr can not be
+// reached or answers with http 303 (it turned standby), the active mgr is looked up again and the request is sent
+// once more to the new one. Requests other than GET and HEAD failing after they were sent (e.g. connection reset)
+// are not sent again, the mgr may have processed them already.
+type failoverTransport struct {
+	session *Session
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, canRetry, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := t.session.ActiveEndpoint()
+
+	resp, err := t.base.RoundTrip(withEndpoint(req, endpoint))
+	if !canRetry || req.Context().Err() != nil || (err == nil && resp.StatusCode != http.StatusSeeOther) {
+		return resp, err
+	}
+
+	if err != nil && req.Method != http.MethodGet && req.Method != http.MethodHead && !notSent(err) {
+		return resp, err
+	}
+
+	next, errDiscover := t.session.rediscover(req.Context(), endpoint)
+	if errDiscover != nil || next == endpoint {
+		return resp, err
+	}
+
+	if resp != nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	return t.base.RoundTrip(withEndpoint(withBody(req.Clone(req.Context()), body), next))
+}
+
+// withEndpoint returns a copy of req sent to endpoint, unless req targets it already or endpoint is unset.
+func withEndpoint(req *http.Request, endpoint Endpoint) *http.Request {
+	if endpoint == (Endpoint{}) || (req.URL.Scheme == endpoint.Protocol && req.URL.Host == endpoint.host()) {
+		return req
+	}
+
+	r := req.Clone(req.Context())
+	r.URL.Scheme = endpoint.Protocol
+	r.URL.Host = endpoint.host()
+	r.Host = ""
+
+	return r
+}
+
+// rediscover looks for the active mgr again after failed stopped answering as active mgr. Concurrent callers share
+// a single lookup.
+func (s *Session) rediscover(ctx context.Context, failed Endpoint) (Endpoint, error) {
+	s.discoverMu.Lock()
+	defer s.discoverMu.Unlock()
+
+	if active := s.ActiveEndpoint(); active != failed {
+		// already switched by a concurrent request
+		return active, nil
+	}
+
+	return s.discover(ctx)
+}
+
+// discover probes the mgrs (the active one first) and stores the first mgr answering as active mgr.
+// s.discoverMu must be held.
+func (s *Session) discover(ctx context.Context) (Endpoint, error) {
+	previous := s.ActiveEndpoint()
+
+	var lastErr error
+
+	for _, candidate := range s.candidates() {
+		active, err := s.probeEndpoint(ctx, candidate)
+		if err != nil {
+			if ctx.Err() != nil {
+				return Endpoint{}, ctxErr(ctx, "CheckGetMgrAddress", err)
+			}
+
+			s.logger().Log(ctx, LogLevelDebug, "mgr not available",
+				LogField{Key: "endpoint", Value: candidate.String()},
+				LogField{Key: "error", Value: err},
+			)
+
+			lastErr = err
+			continue
+		}
+
+		s.mu.Lock()
+		s.active = active
+		s.mu.Unlock()
+
+		if active != previous {
+			s.logger().Log(ctx, LogLevelInfo, "active mgr changed",
+				LogField{Key: "from", Value: previous.String()},
+				LogField{Key: "to", Value: active.String()},
+			)
+		}
+
+		return active, nil
+	}
+
+	return Endpoint{}, &noActiveMgrError{err: lastErr}
+}
+
+// noActiveMgrError implements the error returned if no mgr answered, wrapping the error of the last mgr probed.
+type noActiveMgrError struct {
+	err error
+}
+
+func (e *noActiveMgrError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrNoActiveMgr, e.err)
+}
+
+// Is reports ErrNoActiveMgr.
+func (e *noActiveMgrError) Is(target error) bool {
+	return target == ErrNoActiveMgr
+}
+
+// Unwrap returns the error of the last mgr probed.
+func (e *noActiveMgrError) Unwrap() error {
+	return e.err
+}
+
+// candidates returns the mgrs to probe without duplicates: the active mgr, the mgrs the session was created with and
+// the mgrs of the server.
+func (s *Session) candidates() []Endpoint {
+	s.mu.RLock()
+	list := append([]Endpoint{s.active}, s.endpoints...)
+	s.mu.RUnlock()
+
+	list = append(list, s.Server.endpoints()...)
+
+	seen := make(map[Endpoint]struct{}, len(list))
+	candidates := list[:0]
+
+	for _, e := range list {
+		if _, ok := seen[e]; ok || e.Address == "" {
+			continue
+		}
+
+		seen[e] = struct{}{}
+		candidates = append(candidates, e)
+	}
+
+	return candidates
+}
+
+// probeEndpoint requests the api root of endpoint and returns the active mgr: the endpoint itself or the location of
+// a http 303 sent by a standby mgr. Mgrs answering with http 5xx are treated as not available.
+func (s *Session) probeEndpoint(ctx context.Context, endpoint Endpoint) (Endpoint, error) {
+	timeout := s.probeTimeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/%s/", endpoint.String(), s.Server.APIPath), nil)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	// send the headers of the session (e.g. User-Agent) as well
+	if s.Client != nil && s.Client.Header != nil {
+		req.Header = s.Client.Header.Clone()
+	}
+
+	for k, v := range defaultHeaders {
+		req.Header.Set(k, v)
+	}
+
+	probe := s.probe
+	if probe == nil {
+		probe = http.DefaultTransport
+	}
+
+	resp, err := probe.RoundTrip(req)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusSeeOther && resp.Header.Get("Location") != "":
+		return endpointFromLocation(resp.Header.Get("Location"))
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return Endpoint{}, fmt.Errorf("mgr answered with http %d", resp.StatusCode)
+	}
+
+	return endpoint, nil
+}
+
+// endpointFromLocation returns the mgr a http 303 points to. The port defaults to the one of the scheme.
+func endpointFromLocation(location string) (Endpoint, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	if u.Hostname() == "" {
+		return Endpoint{}, fmt.Errorf("mgr redirected to location without host: %s", location)
+	}
+
+	port := 443
+	if u.Scheme == "http" {
+		port = 80
+	}
+
+	if u.Port() != "" {
+		if port, err = strconv.Atoi(u.Port()); err != nil {
+			return Endpoint{}, err
+		}
+	}
+
+	return Endpoint{Address: u.Hostname(), Port: uint(port), Protocol: u.Scheme}, nil
+}
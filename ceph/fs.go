@@ -1,6 +1,7 @@
 package ceph
 
 import (
+    "context"
     "fmt"
     "github.com/go-resty/resty/v2"
 )
@@ -46,6 +47,48 @@ type FS struct {
     ID     int    `json:"id"`
 }
 
+// FSRank implements an active mds rank of a ceph fs with the counters of its mds daemon.
+type FSRank struct {
+    Rank     int     `json:"rank"`
+    State    string  `json:"state"`
+    Mds      string  `json:"mds"`
+    Activity float64 `json:"activity"`
+    Dns      int     `json:"dns"`
+    Inos     int     `json:"inos"`
+    Dirs     int     `json:"dirs"`
+    Caps     int     `json:"caps"`
+}
+
+// FSPool implements a metadata or data pool of a ceph fs with its usage in bytes.
+type FSPool struct {
+    Pool  string `json:"pool"`
+    Type  string `json:"type"`
+    Used  uint64 `json:"used"`
+    Avail uint64 `json:"avail"`
+}
+
+// FSInfo implements the ceph fs returned in FSDetail.
+type FSInfo struct {
+    ID          int      `json:"id"`
+    Name        string   `json:"name"`
+    ClientCount int      `json:"client_count"`
+    Ranks       []FSRank `json:"ranks"`
+    Pools       []FSPool `json:"pools"`
+}
+
+// FSStandby implements a standby mds daemon.
+type FSStandby struct {
+    Name string `json:"name"`
+}
+
+// FSDetail implements struct returned from GET /api/cephfs/{fs_id}. Versions maps the ceph versions to the mds
+// daemons running them.
+type FSDetail struct {
+    CephFS   FSInfo              `json:"cephfs"`
+    Standbys []FSStandby         `json:"standbys"`
+    Versions map[string][]string `json:"versions"`
+}
+
 // Quota implements a ceph fs quota.
 type Quota struct {
     MaxBytes int    `json:"max_bytes"`
@@ -70,55 +113,83 @@ type SnapShot struct {
 // ListFS gets all possible ceph fs available.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs.
 func (c *Client) ListFS() (status int, list []FS, err error) {
-    var resp *resty.Response
+    return c.ListFSWithContext(context.Background())
+}
 
-    client := *c.Session.Client
+// ListFSWithContext is like ListFS but aborts the request if ctx is done.
+func (c *Client) ListFSWithContext(ctx context.Context) (status int, list []FS, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        SetResult(&list).
-        Get(c.Session.Server.getURL("cephfs"))
+        SetResult(&list)
+
+    resp, err = c.execute("ListFS", req, resty.MethodGet, c.Session.Server.getURL("cephfs"))
+
+    if err != nil {
+        return 0, nil, ctxErr(ctx, "ListFS", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), nil, fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), nil, newAPIError(resp)
     }
 
     return resp.StatusCode(), list, err
 }
 
-// GetFS gets a specific ceph fs by id.
-// See https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs.
-func (c *Client) GetFS(id int) (status int, fs interface{}, err error) {
+// GetFS gets a specific ceph fs by id with its active mds ranks, standby mds daemons and pools.
+// See https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-fs_id.
+func (c *Client) GetFS(id int) (status int, fs FSDetail, err error) {
+    return c.GetFSWithContext(context.Background(), id)
+}
 
-    var resp *resty.Response
+// GetFSWithContext is like GetFS but aborts the request if ctx is done.
+func (c *Client) GetFSWithContext(ctx context.Context, id int) (status int, fs FSDetail, err error) {
 
-    client := *c.Session.Client
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        Get(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d", id)))
+        SetResult(&fs)
+
+    resp, err = c.execute("GetFS", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d", id)))
+
+    if err != nil {
+        return 0, fs, ctxErr(ctx, "GetFS", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), nil, fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), fs, newAPIError(resp)
     }
 
-    return resp.StatusCode(), nil, err
+    return resp.StatusCode(), fs, err
 }
 
 // GetRootDirectory gets the ceph fs root directory.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-fs_id-get_root_directory.
 func (c *Client) GetRootDirectory(id int) (status int, rootDir Directory, err error) {
-    var resp *resty.Response
+    return c.GetRootDirectoryWithContext(context.Background(), id)
+}
 
-    client := *c.Session.Client
+// GetRootDirectoryWithContext is like GetRootDirectory but aborts the request if ctx is done.
+func (c *Client) GetRootDirectoryWithContext(ctx context.Context, id int) (status int, rootDir Directory, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        SetResult(&rootDir).
-        Get(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/get_root_directory", id)))
+        SetResult(&rootDir)
+
+    resp, err = c.execute("GetRootDirectory", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/get_root_directory", id)))
+
+    if err != nil {
+        return 0, rootDir, ctxErr(ctx, "GetRootDirectory", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), rootDir, fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), rootDir, newAPIError(resp)
     }
 
     return resp.StatusCode(), rootDir, err
@@ -127,19 +198,28 @@ func (c *Client) GetRootDirectory(id int) (status int, rootDir Directory, err er
 // ListDir gets a list if ceph fs directories.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-fs_id-ls_dir.
 func (c *Client) ListDir(id int, path string, depth uint) (status int, dir []Directory, err error) {
-    var resp *resty.Response
+    return c.ListDirWithContext(context.Background(), id, path, depth)
+}
 
-    client := *c.Session.Client
+// ListDirWithContext is like ListDir but aborts the request if ctx is done.
+func (c *Client) ListDirWithContext(ctx context.Context, id int, path string, depth uint) (status int, dir []Directory, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
         SetResult(&dir).
         SetQueryParam("path", path).
-        SetQueryParam("depth", fmt.Sprintf("%d", depth)).
-        Get(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/ls_dir", id)))
+        SetQueryParam("depth", fmt.Sprintf("%d", depth))
+
+    resp, err = c.execute("ListDir", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/ls_dir", id)))
+
+    if err != nil {
+        return 0, dir, ctxErr(ctx, "ListDir", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), dir, fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), dir, newAPIError(resp)
     }
 
     return resp.StatusCode(), dir, err
@@ -148,21 +228,30 @@ func (c *Client) ListDir(id int, path string, depth uint) (status int, dir []Dir
 // CreateDir creates a ceph fs directory.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-fs_id-tree.
 func (c *Client) CreateDir(id int, path string) (status int, err error) {
-    var resp *resty.Response
+    return c.CreateDirWithContext(context.Background(), id, path)
+}
 
-    client := *c.Session.Client
+// CreateDirWithContext is like CreateDir but aborts the request if ctx is done.
+func (c *Client) CreateDirWithContext(ctx context.Context, id int, path string) (status int, err error) {
+    var resp *resty.Response
 
     body := struct {
         Path string `json:"path"`
     }{Path: path}
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        SetBody(body).
-        Post(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/tree", id)))
+        SetBody(body)
+
+    resp, err = c.execute("CreateDir", req, resty.MethodPost, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/tree", id)))
+
+    if err != nil {
+        return 0, ctxErr(ctx, "CreateDir", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), newAPIError(resp)
     }
 
     return resp.StatusCode(), err
@@ -171,17 +260,26 @@ func (c *Client) CreateDir(id int, path string) (status int, err error) {
 // DeleteDir remove a directory from ceph fs.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-cephfs-fs_id-tree.
 func (c *Client) DeleteDir(id int, path string) (status int, err error) {
-    var resp *resty.Response
+    return c.DeleteDirWithContext(context.Background(), id, path)
+}
 
-    client := *c.Session.Client
+// DeleteDirWithContext is like DeleteDir but aborts the request if ctx is done.
+func (c *Client) DeleteDirWithContext(ctx context.Context, id int, path string) (status int, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        SetQueryParam("path", path).
-        Delete(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/tree", id)))
+        SetQueryParam("path", path)
+
+    resp, err = c.execute("DeleteDir", req, resty.MethodDelete, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/tree", id)))
+
+    if err != nil {
+        return 0, ctxErr(ctx, "DeleteDir", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), newAPIError(resp)
     }
 
     return resp.StatusCode(), err
@@ -190,18 +288,27 @@ func (c *Client) DeleteDir(id int, path string) (status int, err error) {
 // GetQuota gets ceph fs quota for given path.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-fs_id-quota.
 func (c *Client) GetQuota(id int64, path string) (status int, quotas Quota, err error) {
-    var resp *resty.Response
+    return c.GetQuotaWithContext(context.Background(), id, path)
+}
 
-    client := *c.Session.Client
+// GetQuotaWithContext is like GetQuota but aborts the request if ctx is done.
+func (c *Client) GetQuotaWithContext(ctx context.Context, id int64, path string) (status int, quotas Quota, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
         SetResult(&quotas).
-        SetQueryParam("path", path).
-        Get(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/quota", id)))
+        SetQueryParam("path", path)
+
+    resp, err = c.execute("GetQuota", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/quota", id)))
+
+    if err != nil {
+        return 0, quotas, ctxErr(ctx, "GetQuota", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), quotas, fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), quotas, newAPIError(resp)
     }
 
     return resp.StatusCode(), quotas, err
@@ -210,17 +317,26 @@ func (c *Client) GetQuota(id int64, path string) (status int, quotas Quota, err
 // SetQuota sets ceph fs quota defined by path.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-cephfs-fs_id-quota.
 func (c *Client) SetQuota(id int, quota Quota) (status int, err error) {
-    var resp *resty.Response
+    return c.SetQuotaWithContext(context.Background(), id, quota)
+}
 
-    client := *c.Session.Client
+// SetQuotaWithContext is like SetQuota but aborts the request if ctx is done.
+func (c *Client) SetQuotaWithContext(ctx context.Context, id int, quota Quota) (status int, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        SetBody(quota).
-        Put(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/quota", id)))
+        SetBody(quota)
+
+    resp, err = c.execute("SetQuota", req, resty.MethodPut, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/quota", id)))
+
+    if err != nil {
+        return 0, ctxErr(ctx, "SetQuota", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), newAPIError(resp)
     }
 
     return resp.StatusCode(), err
@@ -229,17 +345,26 @@ func (c *Client) SetQuota(id int, quota Quota) (status int, err error) {
 // CreateSnapShot creates a ceph fs snapshot defined in the SnapShot struct.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-fs_id-snapshot.
 func (c *Client) CreateSnapShot(id int, snap SnapShot) (status int, err error) {
-    var resp *resty.Response
+    return c.CreateSnapShotWithContext(context.Background(), id, snap)
+}
 
-    client := *c.Session.Client
+// CreateSnapShotWithContext is like CreateSnapShot but aborts the request if ctx is done.
+func (c *Client) CreateSnapShotWithContext(ctx context.Context, id int, snap SnapShot) (status int, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
-        SetBody(snap).
-        Post(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/snapshot", id)))
+        SetBody(snap)
+
+    resp, err = c.execute("CreateSnapShot", req, resty.MethodPost, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/snapshot", id)))
+
+    if err != nil {
+        return 0, ctxErr(ctx, "CreateSnapShot", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), newAPIError(resp)
     }
 
     return resp.StatusCode(), err
@@ -249,18 +374,27 @@ func (c *Client) CreateSnapShot(id int, snap SnapShot) (status int, err error) {
 // DeleteSnapShot creates a ceph fs snapshot defined in the SnapShot struct.
 // See https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-fs_id-snapshot.
 func (c *Client) DeleteSnapShot(id int, snap SnapShot) (status int, err error) {
-    var resp *resty.Response
+    return c.DeleteSnapShotWithContext(context.Background(), id, snap)
+}
 
-    client := *c.Session.Client
+// DeleteSnapShotWithContext is like DeleteSnapShot but aborts the request if ctx is done.
+func (c *Client) DeleteSnapShotWithContext(ctx context.Context, id int, snap SnapShot) (status int, err error) {
+    var resp *resty.Response
 
-    resp, err = client.R().
+    req := c.Session.Client.R().
+        SetContext(ctx).
         SetHeaders(defaultHeaders).
         SetQueryParam("name", snap.Name).
-        SetQueryParam("path", snap.Path).
-        Delete(c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/snapshot", id)))
+        SetQueryParam("path", snap.Path)
+
+    resp, err = c.execute("DeleteSnapShot", req, resty.MethodDelete, c.Session.Server.getURL(fmt.Sprintf("cephfs/%d/snapshot", id)))
+
+    if err != nil {
+        return 0, ctxErr(ctx, "DeleteSnapShot", err)
+    }
 
     if !resp.IsSuccess() {
-        return resp.StatusCode(), fmt.Errorf("%v", resp.RawResponse)
+        return resp.StatusCode(), newAPIError(resp)
     }
 
     return resp.StatusCode(), err
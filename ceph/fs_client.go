@@ -0,0 +1,116 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// FSClientMetadata implements the metadata a ceph fs client reports on mount. Root is the directory mounted,
+// MountPoint the local mount point of ceph-fuse clients.
+type FSClientMetadata struct {
+	EntityID      string `json:"entity_id"`
+	Hostname      string `json:"hostname"`
+	Root          string `json:"root"`
+	MountPoint    string `json:"mount_point,omitempty"`
+	CephVersion   string `json:"ceph_version,omitempty"`
+	KernelVersion string `json:"kernel_version,omitempty"`
+}
+
+// FSClient implements a session of a ceph fs client returned from GET /api/cephfs/{fs_id}/clients. Type is kernel or
+// userspace, Inst the entity and the address of the client.
+type FSClient struct {
+	ID               int              `json:"id"`
+	State            string           `json:"state"`
+	NumCaps          int              `json:"num_caps"`
+	NumLeases        int              `json:"num_leases"`
+	RequestLoadAvg   float64          `json:"request_load_avg"`
+	Uptime           float64          `json:"uptime"`
+	RequestsInFlight int              `json:"requests_in_flight"`
+	Inst             string           `json:"inst"`
+	Type             string           `json:"type"`
+	Version          string           `json:"version"`
+	ClientMetadata   FSClientMetadata `json:"client_metadata"`
+}
+
+// fsClients implements struct returned from GET /api/cephfs/{fs_id}/clients.
+type fsClients struct {
+	Data   []FSClient `json:"data"`
+	Status int        `json:"status"`
+}
+
+// MDSCounterSample implements a sample of a mds performance counter: the unix time in seconds and the value.
+type MDSCounterSample [2]float64
+
+// Time returns the time the sample was taken.
+func (s MDSCounterSample) Time() time.Time {
+	sec, frac := math.Modf(s[0])
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+// Value returns the value of the counter.
+func (s MDSCounterSample) Value() float64 {
+	return s[1]
+}
+
+// MDSCounters implements struct returned from GET /api/cephfs/{fs_id}/mds_counters: the samples of each performance
+// counter (e.g. mds_server.handle_client_request or mds.caps) by mds daemon.
+type MDSCounters map[string]map[string][]MDSCounterSample
+
+// ListFSClients gets the clients with an open session to the ceph fs. The mgr caches the sessions, a client mounted
+// just now may be missing.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-fs_id-clients
+func (c *Client) ListFSClients(id int) (status int, clients []FSClient, err error) {
+	return c.ListFSClientsWithContext(context.Background(), id)
+}
+
+// ListFSClientsWithContext is like ListFSClients but aborts the request if ctx is done.
+func (c *Client) ListFSClientsWithContext(ctx context.Context, id int) (status int, clients []FSClient, err error) {
+	var result fsClients
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&result)
+
+	status, err = c.send(ctx, "ListFSClients", req, resty.MethodGet, fmt.Sprintf("cephfs/%d/clients", id))
+
+	return status, result.Data, err
+}
+
+// EvictFSClient evicts a client from the ceph fs by session id (see FSClient.ID). The client is blocklisted and has
+// to mount the ceph fs again.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-cephfs-fs_id-client-client_id
+func (c *Client) EvictFSClient(id int, clientID int) (status int, err error) {
+	return c.EvictFSClientWithContext(context.Background(), id, clientID)
+}
+
+// EvictFSClientWithContext is like EvictFSClient but aborts the request if ctx is done.
+func (c *Client) EvictFSClientWithContext(ctx context.Context, id int, clientID int) (status int, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	return c.send(ctx, "EvictFSClient", req, resty.MethodDelete, fmt.Sprintf("cephfs/%d/client/%d", id, clientID))
+}
+
+// GetMDSCounters gets the recent samples of the performance counters of the mds daemons of the ceph fs.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-fs_id-mds_counters
+func (c *Client) GetMDSCounters(id int) (status int, counters MDSCounters, err error) {
+	return c.GetMDSCountersWithContext(context.Background(), id)
+}
+
+// GetMDSCountersWithContext is like GetMDSCounters but aborts the request if ctx is done.
+func (c *Client) GetMDSCountersWithContext(ctx context.Context, id int) (status int, counters MDSCounters, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&counters)
+
+	status, err = c.send(ctx, "GetMDSCounters", req, resty.MethodGet, fmt.Sprintf("cephfs/%d/mds_counters", id))
+
+	return status, counters, err
+}
@@ -0,0 +1,64 @@
+package ceph_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_FSClients(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	fsID := srv.AddFS("cephfs")
+	first := srv.AddFSClient(fsID, "node-1", "/tenants/tenant-1")
+	srv.AddFSClient(fsID, "node-2", "/tenants/tenant-2")
+
+	client := newLoggedInClient(t, srv)
+
+	_, fs, err := client.GetFS(fsID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fs.CephFS.Name != "cephfs" || fs.CephFS.ClientCount != 2 || len(fs.CephFS.Ranks) != 1 || len(fs.CephFS.Pools) != 2 {
+		t.Errorf("expected ceph fs with 2 clients, 1 rank and 2 pools - got %+v", fs)
+	}
+
+	_, clients, err := client.ListFSClients(fsID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(clients) != 2 || clients[0].ClientMetadata.Hostname != "node-1" || clients[0].ClientMetadata.Root != "/tenants/tenant-1" {
+		t.Errorf("expected client of node-1 mounting /tenants/tenant-1 - got %+v", clients)
+	}
+
+	status, err := client.EvictFSClient(fsID, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, clients, err = client.ListFSClients(fsID); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(clients) != 1 || clients[0].ClientMetadata.Hostname != "node-2" {
+		t.Errorf("expected client of node-2 only - got %+v", clients)
+	}
+
+	_, counters, err := client.GetMDSCounters(fsID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := counters[fs.CephFS.Ranks[0].Mds]["mds.caps"]
+	if len(samples) != 1 || samples[0].Value() != 1 || samples[0].Time().IsZero() {
+		t.Errorf("expected 1 sample of mds.caps - got %+v", counters)
+	}
+}
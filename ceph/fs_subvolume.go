@@ -0,0 +1,660 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrFSVolNameIsEmpty is returned if param vol_name is empty.
+	ErrFSVolNameIsEmpty = errors.New("param vol_name can not be empty")
+
+	// ErrFSSubvolNameIsEmpty is returned if param subvol_name is empty.
+	ErrFSSubvolNameIsEmpty = errors.New("param subvol_name can not be empty")
+
+	// ErrFSGroupNameIsEmpty is returned if param group_name is empty.
+	ErrFSGroupNameIsEmpty = errors.New("param group_name can not be empty")
+
+	// ErrFSSnapNameIsEmpty is returned if param snap_name is empty.
+	ErrFSSnapNameIsEmpty = errors.New("param snap_name can not be empty")
+)
+
+// states of a subvolume. A clone is pending or in-progress until the data of the snapshot is copied, a subvolume
+// deleted with retained snapshots is snapshot-retained.
+const (
+	FSSubvolumeStateComplete         = "complete"
+	FSSubvolumeStatePending          = "pending"
+	FSSubvolumeStateInProgress       = "in-progress"
+	FSSubvolumeStateFailed           = "failed"
+	FSSubvolumeStateCanceled         = "canceled"
+	FSSubvolumeStateSnapshotRetained = "snapshot-retained"
+)
+
+// types of a subvolume
+const (
+	FSSubvolumeTypeSubvolume = "subvolume"
+	FSSubvolumeTypeClone     = "clone"
+)
+
+// fsQuotaInfinite is the quota the volumes module reports (and accepts) for subvolumes and groups without quota.
+const fsQuotaInfinite = "infinite"
+
+// FSQuotaBytes implements the quota of a subvolume or a subvolume group in bytes. 0 means no quota, which the
+// volumes module reports as infinite.
+type FSQuotaBytes uint64
+
+// MarshalJSON implements json.Marshaler.
+func (q FSQuotaBytes) MarshalJSON() ([]byte, error) {
+	if q == 0 {
+		return json.Marshal(fsQuotaInfinite)
+	}
+
+	return json.Marshal(uint64(q))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (q *FSQuotaBytes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s == fsQuotaInfinite {
+			*q = 0
+			return nil
+		}
+
+		b = []byte(s)
+	}
+
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*q = FSQuotaBytes(n)
+
+	return nil
+}
+
+// String returns the quota the way the volumes module accepts it.
+func (q FSQuotaBytes) String() string {
+	if q == 0 {
+		return fsQuotaInfinite
+	}
+
+	return strconv.FormatUint(uint64(q), 10)
+}
+
+// FSSubvolumeInfo implements the info of a subvolume (see ceph fs subvolume info). BytesPercent is the usage in percent
+// of the quota or undefined for subvolumes without quota, Path the directory to mount.
+type FSSubvolumeInfo struct {
+	Atime         string       `json:"atime"`
+	BytesPercent  string       `json:"bytes_pcent"`
+	BytesQuota    FSQuotaBytes `json:"bytes_quota"`
+	BytesUsed     uint64       `json:"bytes_used"`
+	CreatedAt     string       `json:"created_at"`
+	Ctime         string       `json:"ctime"`
+	DataPool      string       `json:"data_pool"`
+	Features      []string     `json:"features"`
+	GID           int          `json:"gid"`
+	Mode          int          `json:"mode"`
+	MonAddrs      []string     `json:"mon_addrs"`
+	Mtime         string       `json:"mtime"`
+	Path          string       `json:"path"`
+	PoolNamespace string       `json:"pool_namespace"`
+	State         string       `json:"state"`
+	Type          string       `json:"type"`
+	UID           int          `json:"uid"`
+}
+
+// FSSubvolume implements a subvolume returned from GET /api/cephfs/subvolume/{vol_name}.
+type FSSubvolume struct {
+	Name string          `json:"name"`
+	Info FSSubvolumeInfo `json:"info"`
+}
+
+// FSSubvolumeCreate implements struct for POST /api/cephfs/subvolume. Size is the quota in bytes (0 for none), Mode
+// the octal permissions of the subvolume directory (e.g. 755). Set NamespaceIsolated to store the data in a rados
+// namespace of its own.
+type FSSubvolumeCreate struct {
+	VolName           string `json:"vol_name"`
+	SubvolName        string `json:"subvol_name"`
+	GroupName         string `json:"group_name,omitempty"`
+	Size              uint64 `json:"size,omitempty"`
+	PoolLayout        string `json:"pool_layout,omitempty"`
+	UID               *int   `json:"uid,omitempty"`
+	GID               *int   `json:"gid,omitempty"`
+	Mode              string `json:"mode,omitempty"`
+	NamespaceIsolated bool   `json:"namespace_isolated,omitempty"`
+}
+
+// FSSubvolumeGroupInfo implements the info of a subvolume group (see ceph fs subvolumegroup info).
+type FSSubvolumeGroupInfo struct {
+	Atime        string       `json:"atime"`
+	BytesPercent string       `json:"bytes_pcent"`
+	BytesQuota   FSQuotaBytes `json:"bytes_quota"`
+	BytesUsed    uint64       `json:"bytes_used"`
+	CreatedAt    string       `json:"created_at"`
+	Ctime        string       `json:"ctime"`
+	DataPool     string       `json:"data_pool"`
+	GID          int          `json:"gid"`
+	Mode         int          `json:"mode"`
+	MonAddrs     []string     `json:"mon_addrs"`
+	Mtime        string       `json:"mtime"`
+	UID          int          `json:"uid"`
+}
+
+// FSSubvolumeGroup implements a subvolume group returned from GET /api/cephfs/subvolume/group/{vol_name}.
+type FSSubvolumeGroup struct {
+	Name string               `json:"name"`
+	Info FSSubvolumeGroupInfo `json:"info"`
+}
+
+// FSSubvolumeGroupCreate implements struct for POST /api/cephfs/subvolume/group. Size is the quota in bytes of all
+// subvolumes of the group together (0 for none).
+type FSSubvolumeGroupCreate struct {
+	VolName    string `json:"vol_name"`
+	GroupName  string `json:"group_name"`
+	Size       uint64 `json:"size,omitempty"`
+	PoolLayout string `json:"pool_layout,omitempty"`
+	UID        *int   `json:"uid,omitempty"`
+	GID        *int   `json:"gid,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+// FSPendingClone implements a clone of a subvolume snapshot not complete yet.
+type FSPendingClone struct {
+	Name        string `json:"name"`
+	TargetGroup string `json:"target_group,omitempty"`
+}
+
+// FSSubvolumeSnapshotInfo implements the info of a subvolume snapshot (see ceph fs subvolume snapshot info).
+// HasPendingClones is yes or no; a snapshot with pending clones can not be deleted.
+type FSSubvolumeSnapshotInfo struct {
+	CreatedAt        string           `json:"created_at"`
+	DataPool         string           `json:"data_pool"`
+	HasPendingClones string           `json:"has_pending_clones"`
+	PendingClones    []FSPendingClone `json:"pending_clones,omitempty"`
+	Size             uint64           `json:"size"`
+}
+
+// FSSubvolumeSnapshot implements a snapshot returned from GET /api/cephfs/subvolume/snapshot/{vol_name}/{subvol_name}.
+type FSSubvolumeSnapshot struct {
+	Name string                  `json:"name"`
+	Info FSSubvolumeSnapshotInfo `json:"info"`
+}
+
+// FSSubvolumeClone implements struct for POST /api/cephfs/subvolume/snapshot/clone. GroupName is the group of the
+// subvolume cloned, TargetGroupName the group of the clone.
+type FSSubvolumeClone struct {
+	VolName         string `json:"vol_name"`
+	SubvolName      string `json:"subvol_name"`
+	SnapName        string `json:"snap_name"`
+	CloneName       string `json:"clone_name"`
+	GroupName       string `json:"group_name,omitempty"`
+	TargetGroupName string `json:"target_group_name,omitempty"`
+	PoolLayout      string `json:"pool_layout,omitempty"`
+}
+
+// ListFSSubvolumes gets the subvolumes of the ceph fs volName with their info. An empty groupName lists the
+// subvolumes not in a group.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-vol_name
+func (c *Client) ListFSSubvolumes(volName, groupName string) (status int, subvolumes []FSSubvolume, err error) {
+	return c.ListFSSubvolumesWithContext(context.Background(), volName, groupName)
+}
+
+// ListFSSubvolumesWithContext is like ListFSSubvolumes but aborts the request if ctx is done.
+func (c *Client) ListFSSubvolumesWithContext(ctx context.Context, volName, groupName string) (status int, subvolumes []FSSubvolume, err error) {
+	if volName == "" {
+		return 0, nil, ErrFSVolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("info", "true").
+		SetResult(&subvolumes)
+
+	if groupName != "" {
+		req.SetQueryParam("group_name", groupName)
+	}
+
+	status, err = c.send(ctx, "ListFSSubvolumes", req, resty.MethodGet, fsSubvolumePath(volName))
+
+	return status, subvolumes, err
+}
+
+// GetFSSubvolume gets the info of a subvolume, e.g. the state of a clone.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-vol_name-info
+func (c *Client) GetFSSubvolume(volName, subvolName, groupName string) (status int, info FSSubvolumeInfo, err error) {
+	return c.GetFSSubvolumeWithContext(context.Background(), volName, subvolName, groupName)
+}
+
+// GetFSSubvolumeWithContext is like GetFSSubvolume but aborts the request if ctx is done.
+func (c *Client) GetFSSubvolumeWithContext(ctx context.Context, volName, subvolName, groupName string) (status int, info FSSubvolumeInfo, err error) {
+	if volName == "" {
+		return 0, info, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, info, ErrFSSubvolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("subvol_name", subvolName).
+		SetResult(&info)
+
+	if groupName != "" {
+		req.SetQueryParam("group_name", groupName)
+	}
+
+	status, err = c.send(ctx, "GetFSSubvolume", req, resty.MethodGet, fsSubvolumePath(volName, "info"))
+
+	return status, info, err
+}
+
+// GetFSSubvolumePath gets the path of a subvolume in the ceph fs (like ceph fs subvolume getpath), i.e. the directory
+// clients mount.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-vol_name-info
+func (c *Client) GetFSSubvolumePath(volName, subvolName, groupName string) (status int, path string, err error) {
+	return c.GetFSSubvolumePathWithContext(context.Background(), volName, subvolName, groupName)
+}
+
+// GetFSSubvolumePathWithContext is like GetFSSubvolumePath but aborts the request if ctx is done.
+func (c *Client) GetFSSubvolumePathWithContext(ctx context.Context, volName, subvolName, groupName string) (status int, path string, err error) {
+	status, info, err := c.GetFSSubvolumeWithContext(ctx, volName, subvolName, groupName)
+
+	return status, info.Path, err
+}
+
+// CreateFSSubvolume creates a subvolume. Creating an existing subvolume succeeds and changes neither its size nor
+// its permissions.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-subvolume
+func (c *Client) CreateFSSubvolume(subvolume FSSubvolumeCreate) (status int, err error) {
+	return c.CreateFSSubvolumeWithContext(context.Background(), subvolume)
+}
+
+// CreateFSSubvolumeWithContext is like CreateFSSubvolume but aborts the request if ctx is done.
+func (c *Client) CreateFSSubvolumeWithContext(ctx context.Context, subvolume FSSubvolumeCreate) (status int, err error) {
+	if subvolume.VolName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if subvolume.SubvolName == "" {
+		return 0, ErrFSSubvolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(subvolume)
+
+	return c.send(ctx, "CreateFSSubvolume", req, resty.MethodPost, "cephfs/subvolume")
+}
+
+// ResizeFSSubvolume sets the quota of a subvolume to size bytes. A size of 0 removes the quota.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-cephfs-subvolume-vol_name
+func (c *Client) ResizeFSSubvolume(volName, subvolName, groupName string, size uint64) (status int, err error) {
+	return c.ResizeFSSubvolumeWithContext(context.Background(), volName, subvolName, groupName, size)
+}
+
+// ResizeFSSubvolumeWithContext is like ResizeFSSubvolume but aborts the request if ctx is done.
+func (c *Client) ResizeFSSubvolumeWithContext(ctx context.Context, volName, subvolName, groupName string, size uint64) (status int, err error) {
+	if volName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, ErrFSSubvolNameIsEmpty
+	}
+
+	body := struct {
+		SubvolName string `json:"subvol_name"`
+		GroupName  string `json:"group_name,omitempty"`
+		Size       string `json:"size"`
+	}{SubvolName: subvolName, GroupName: groupName, Size: FSQuotaBytes(size).String()}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(body)
+
+	return c.send(ctx, "ResizeFSSubvolume", req, resty.MethodPut, fsSubvolumePath(volName))
+}
+
+// DeleteFSSubvolume deletes a subvolume with its data. A subvolume with snapshots can only be deleted with
+// retainSnapshots set; it is kept in state snapshot-retained until its last snapshot is deleted.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-cephfs-subvolume-vol_name
+func (c *Client) DeleteFSSubvolume(volName, subvolName, groupName string, retainSnapshots bool) (status int, err error) {
+	return c.DeleteFSSubvolumeWithContext(context.Background(), volName, subvolName, groupName, retainSnapshots)
+}
+
+// DeleteFSSubvolumeWithContext is like DeleteFSSubvolume but aborts the request if ctx is done.
+func (c *Client) DeleteFSSubvolumeWithContext(ctx context.Context, volName, subvolName, groupName string, retainSnapshots bool) (status int, err error) {
+	if volName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, ErrFSSubvolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("subvol_name", subvolName).
+		SetQueryParam("retain_snapshots", strconv.FormatBool(retainSnapshots))
+
+	if groupName != "" {
+		req.SetQueryParam("group_name", groupName)
+	}
+
+	return c.send(ctx, "DeleteFSSubvolume", req, resty.MethodDelete, fsSubvolumePath(volName))
+}
+
+// ListFSSubvolumeGroups gets the subvolume groups of the ceph fs volName with their info.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-group-vol_name
+func (c *Client) ListFSSubvolumeGroups(volName string) (status int, groups []FSSubvolumeGroup, err error) {
+	return c.ListFSSubvolumeGroupsWithContext(context.Background(), volName)
+}
+
+// ListFSSubvolumeGroupsWithContext is like ListFSSubvolumeGroups but aborts the request if ctx is done.
+func (c *Client) ListFSSubvolumeGroupsWithContext(ctx context.Context, volName string) (status int, groups []FSSubvolumeGroup, err error) {
+	if volName == "" {
+		return 0, nil, ErrFSVolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("info", "true").
+		SetResult(&groups)
+
+	status, err = c.send(ctx, "ListFSSubvolumeGroups", req, resty.MethodGet, fsSubvolumePath("group", volName))
+
+	return status, groups, err
+}
+
+// GetFSSubvolumeGroup gets the info of a subvolume group.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-group-vol_name-info
+func (c *Client) GetFSSubvolumeGroup(volName, groupName string) (status int, info FSSubvolumeGroupInfo, err error) {
+	return c.GetFSSubvolumeGroupWithContext(context.Background(), volName, groupName)
+}
+
+// GetFSSubvolumeGroupWithContext is like GetFSSubvolumeGroup but aborts the request if ctx is done.
+func (c *Client) GetFSSubvolumeGroupWithContext(ctx context.Context, volName, groupName string) (status int, info FSSubvolumeGroupInfo, err error) {
+	if volName == "" {
+		return 0, info, ErrFSVolNameIsEmpty
+	}
+
+	if groupName == "" {
+		return 0, info, ErrFSGroupNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("group_name", groupName).
+		SetResult(&info)
+
+	status, err = c.send(ctx, "GetFSSubvolumeGroup", req, resty.MethodGet, fsSubvolumePath("group", volName, "info"))
+
+	return status, info, err
+}
+
+// CreateFSSubvolumeGroup creates a subvolume group.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-subvolume-group
+func (c *Client) CreateFSSubvolumeGroup(group FSSubvolumeGroupCreate) (status int, err error) {
+	return c.CreateFSSubvolumeGroupWithContext(context.Background(), group)
+}
+
+// CreateFSSubvolumeGroupWithContext is like CreateFSSubvolumeGroup but aborts the request if ctx is done.
+func (c *Client) CreateFSSubvolumeGroupWithContext(ctx context.Context, group FSSubvolumeGroupCreate) (status int, err error) {
+	if group.VolName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if group.GroupName == "" {
+		return 0, ErrFSGroupNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(group)
+
+	return c.send(ctx, "CreateFSSubvolumeGroup", req, resty.MethodPost, "cephfs/subvolume/group")
+}
+
+// ResizeFSSubvolumeGroup sets the quota of a subvolume group to size bytes. A size of 0 removes the quota.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-cephfs-subvolume-group-vol_name
+func (c *Client) ResizeFSSubvolumeGroup(volName, groupName string, size uint64) (status int, err error) {
+	return c.ResizeFSSubvolumeGroupWithContext(context.Background(), volName, groupName, size)
+}
+
+// ResizeFSSubvolumeGroupWithContext is like ResizeFSSubvolumeGroup but aborts the request if ctx is done.
+func (c *Client) ResizeFSSubvolumeGroupWithContext(ctx context.Context, volName, groupName string, size uint64) (status int, err error) {
+	if volName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if groupName == "" {
+		return 0, ErrFSGroupNameIsEmpty
+	}
+
+	body := struct {
+		GroupName string `json:"group_name"`
+		Size      string `json:"size"`
+	}{GroupName: groupName, Size: FSQuotaBytes(size).String()}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(body)
+
+	return c.send(ctx, "ResizeFSSubvolumeGroup", req, resty.MethodPut, fsSubvolumePath("group", volName))
+}
+
+// DeleteFSSubvolumeGroup deletes an empty subvolume group.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-cephfs-subvolume-group-vol_name
+func (c *Client) DeleteFSSubvolumeGroup(volName, groupName string) (status int, err error) {
+	return c.DeleteFSSubvolumeGroupWithContext(context.Background(), volName, groupName)
+}
+
+// DeleteFSSubvolumeGroupWithContext is like DeleteFSSubvolumeGroup but aborts the request if ctx is done.
+func (c *Client) DeleteFSSubvolumeGroupWithContext(ctx context.Context, volName, groupName string) (status int, err error) {
+	if volName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if groupName == "" {
+		return 0, ErrFSGroupNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("group_name", groupName)
+
+	return c.send(ctx, "DeleteFSSubvolumeGroup", req, resty.MethodDelete, fsSubvolumePath("group", volName))
+}
+
+// ListFSSubvolumeSnapshots gets the snapshots of a subvolume with their info.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-snapshot-vol_name-subvol_name
+func (c *Client) ListFSSubvolumeSnapshots(volName, subvolName, groupName string) (status int, snapshots []FSSubvolumeSnapshot, err error) {
+	return c.ListFSSubvolumeSnapshotsWithContext(context.Background(), volName, subvolName, groupName)
+}
+
+// ListFSSubvolumeSnapshotsWithContext is like ListFSSubvolumeSnapshots but aborts the request if ctx is done.
+func (c *Client) ListFSSubvolumeSnapshotsWithContext(ctx context.Context, volName, subvolName, groupName string) (status int, snapshots []FSSubvolumeSnapshot, err error) {
+	if volName == "" {
+		return 0, nil, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, nil, ErrFSSubvolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("info", "true").
+		SetResult(&snapshots)
+
+	if groupName != "" {
+		req.SetQueryParam("group_name", groupName)
+	}
+
+	status, err = c.send(ctx, "ListFSSubvolumeSnapshots", req, resty.MethodGet, fsSubvolumePath("snapshot", volName, subvolName))
+
+	return status, snapshots, err
+}
+
+// GetFSSubvolumeSnapshot gets the info of a subvolume snapshot, e.g. its pending clones.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-cephfs-subvolume-snapshot-vol_name-subvol_name-info
+func (c *Client) GetFSSubvolumeSnapshot(volName, subvolName, snapName, groupName string) (status int, info FSSubvolumeSnapshotInfo, err error) {
+	return c.GetFSSubvolumeSnapshotWithContext(context.Background(), volName, subvolName, snapName, groupName)
+}
+
+// GetFSSubvolumeSnapshotWithContext is like GetFSSubvolumeSnapshot but aborts the request if ctx is done.
+func (c *Client) GetFSSubvolumeSnapshotWithContext(ctx context.Context, volName, subvolName, snapName, groupName string) (status int, info FSSubvolumeSnapshotInfo, err error) {
+	if volName == "" {
+		return 0, info, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, info, ErrFSSubvolNameIsEmpty
+	}
+
+	if snapName == "" {
+		return 0, info, ErrFSSnapNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("snap_name", snapName).
+		SetResult(&info)
+
+	if groupName != "" {
+		req.SetQueryParam("group_name", groupName)
+	}
+
+	status, err = c.send(ctx, "GetFSSubvolumeSnapshot", req, resty.MethodGet, fsSubvolumePath("snapshot", volName, subvolName, "info"))
+
+	return status, info, err
+}
+
+// CreateFSSubvolumeSnapshot creates a snapshot of a subvolume.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-subvolume-snapshot
+func (c *Client) CreateFSSubvolumeSnapshot(volName, subvolName, snapName, groupName string) (status int, err error) {
+	return c.CreateFSSubvolumeSnapshotWithContext(context.Background(), volName, subvolName, snapName, groupName)
+}
+
+// CreateFSSubvolumeSnapshotWithContext is like CreateFSSubvolumeSnapshot but aborts the request if ctx is done.
+func (c *Client) CreateFSSubvolumeSnapshotWithContext(ctx context.Context, volName, subvolName, snapName, groupName string) (status int, err error) {
+	if volName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, ErrFSSubvolNameIsEmpty
+	}
+
+	if snapName == "" {
+		return 0, ErrFSSnapNameIsEmpty
+	}
+
+	body := struct {
+		VolName    string `json:"vol_name"`
+		SubvolName string `json:"subvol_name"`
+		SnapName   string `json:"snap_name"`
+		GroupName  string `json:"group_name,omitempty"`
+	}{VolName: volName, SubvolName: subvolName, SnapName: snapName, GroupName: groupName}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(body)
+
+	return c.send(ctx, "CreateFSSubvolumeSnapshot", req, resty.MethodPost, "cephfs/subvolume/snapshot")
+}
+
+// DeleteFSSubvolumeSnapshot deletes a snapshot of a subvolume. Set force to succeed if the snapshot does not exist.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-cephfs-subvolume-snapshot-vol_name-subvol_name
+func (c *Client) DeleteFSSubvolumeSnapshot(volName, subvolName, snapName, groupName string, force bool) (status int, err error) {
+	return c.DeleteFSSubvolumeSnapshotWithContext(context.Background(), volName, subvolName, snapName, groupName, force)
+}
+
+// DeleteFSSubvolumeSnapshotWithContext is like DeleteFSSubvolumeSnapshot but aborts the request if ctx is done.
+func (c *Client) DeleteFSSubvolumeSnapshotWithContext(ctx context.Context, volName, subvolName, snapName, groupName string, force bool) (status int, err error) {
+	if volName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if subvolName == "" {
+		return 0, ErrFSSubvolNameIsEmpty
+	}
+
+	if snapName == "" {
+		return 0, ErrFSSnapNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("snap_name", snapName).
+		SetQueryParam("force", strconv.FormatBool(force))
+
+	if groupName != "" {
+		req.SetQueryParam("group_name", groupName)
+	}
+
+	return c.send(ctx, "DeleteFSSubvolumeSnapshot", req, resty.MethodDelete, fsSubvolumePath("snapshot", volName, subvolName))
+}
+
+// CloneFSSubvolumeSnapshot creates the subvolume clone.CloneName from a snapshot. The data is copied in the
+// background: the clone is in state pending or in-progress (see GetFSSubvolume) until it is complete.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-cephfs-subvolume-snapshot-clone
+func (c *Client) CloneFSSubvolumeSnapshot(clone FSSubvolumeClone) (status int, err error) {
+	return c.CloneFSSubvolumeSnapshotWithContext(context.Background(), clone)
+}
+
+// CloneFSSubvolumeSnapshotWithContext is like CloneFSSubvolumeSnapshot but aborts the request if ctx is done.
+func (c *Client) CloneFSSubvolumeSnapshotWithContext(ctx context.Context, clone FSSubvolumeClone) (status int, err error) {
+	if clone.VolName == "" {
+		return 0, ErrFSVolNameIsEmpty
+	}
+
+	if clone.SubvolName == "" || clone.CloneName == "" {
+		return 0, ErrFSSubvolNameIsEmpty
+	}
+
+	if clone.SnapName == "" {
+		return 0, ErrFSSnapNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(clone)
+
+	return c.send(ctx, "CloneFSSubvolumeSnapshot", req, resty.MethodPost, "cephfs/subvolume/snapshot/clone")
+}
+
+// fsSubvolumePath returns the api path below cephfs/subvolume.
+func fsSubvolumePath(elem ...string) string {
+	p := "cephfs/subvolume"
+
+	for _, e := range elem {
+		p += "/" + url.QueryEscape(e)
+	}
+
+	return p
+}
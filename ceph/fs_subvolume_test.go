@@ -0,0 +1,282 @@
+package ceph_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestFSQuotaBytes_JSON(t *testing.T) {
+	tests := []struct {
+		json  string
+		quota ceph.FSQuotaBytes
+	}{
+		{json: `"infinite"`, quota: 0},
+		{json: `10737418240`, quota: 10 << 30},
+		{json: `"10737418240"`, quota: 10 << 30},
+	}
+
+	for _, tt := range tests {
+		var quota ceph.FSQuotaBytes
+		if err := json.Unmarshal([]byte(tt.json), &quota); err != nil {
+			t.Fatal(err)
+		}
+
+		if quota != tt.quota {
+			t.Errorf("expected quota %d for %s - got %d", tt.quota, tt.json, quota)
+		}
+	}
+
+	b, err := json.Marshal(ceph.FSQuotaBytes(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `"infinite"` {
+		t.Errorf("expected \"infinite\" - got %s", b)
+	}
+}
+
+func TestClient_FSSubvolumes(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	fsID := srv.AddFS("cephfs")
+
+	client := newLoggedInClient(t, srv)
+
+	status, err := client.CreateFSSubvolumeGroup(ceph.FSSubvolumeGroupCreate{VolName: "cephfs", GroupName: "csi", Size: 100 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	_, groups, err := client.ListFSSubvolumeGroups("cephfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 1 || groups[0].Name != "csi" || groups[0].Info.BytesQuota != 100<<30 {
+		t.Errorf("expected group csi with 100 GiB quota - got %+v", groups)
+	}
+
+	uid := 1000
+
+	if _, err = client.CreateFSSubvolume(ceph.FSSubvolumeCreate{VolName: "cephfs", SubvolName: "tenant-1", GroupName: "csi", Size: 1 << 30, UID: &uid, Mode: "750"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, info, err := client.GetFSSubvolume("cephfs", "tenant-1", "csi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.BytesQuota != 1<<30 || info.UID != 1000 || info.Mode != 040750 || info.State != ceph.FSSubvolumeStateComplete {
+		t.Errorf("expected complete subvolume with 1 GiB quota owned by 1000 - got %+v", info)
+	}
+
+	_, p, err := client.GetFSSubvolumePath("cephfs", "tenant-1", "csi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(p, "/volumes/csi/tenant-1/") {
+		t.Errorf("expected path below /volumes/csi/tenant-1 - got %s", p)
+	}
+
+	// the subvolume is a plain directory of the ceph fs
+	_, quota, err := client.GetQuota(int64(fsID), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quota.MaxBytes != 1<<30 {
+		t.Errorf("expected max_bytes %d - got %d", 1<<30, quota.MaxBytes)
+	}
+
+	if status, err = client.ResizeFSSubvolume("cephfs", "tenant-1", "csi", 2<<30); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if _, info, err = client.GetFSSubvolume("cephfs", "tenant-1", "csi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.BytesQuota != 2<<30 || info.BytesPercent != "0.00" {
+		t.Errorf("expected 2 GiB quota - got %d (%s%%)", info.BytesQuota, info.BytesPercent)
+	}
+
+	// no quota at all
+	if _, err = client.ResizeFSSubvolume("cephfs", "tenant-1", "csi", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, info, err = client.GetFSSubvolume("cephfs", "tenant-1", "csi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.BytesQuota != 0 || info.BytesPercent != "undefined" {
+		t.Errorf("expected no quota - got %d (%s%%)", info.BytesQuota, info.BytesPercent)
+	}
+
+	if _, _, err = client.GetFSSubvolume("cephfs", "tenant-1", ""); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	_, subvolumes, err := client.ListFSSubvolumes("cephfs", "csi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(subvolumes) != 1 || subvolumes[0].Name != "tenant-1" {
+		t.Errorf("expected subvolume tenant-1 - got %+v", subvolumes)
+	}
+
+	if _, err = client.DeleteFSSubvolumeGroup("cephfs", "csi"); err == nil {
+		t.Error("expected err deleting a group with subvolumes - got nil")
+	}
+
+	if status, err = client.DeleteFSSubvolume("cephfs", "tenant-1", "csi", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if status, err = client.DeleteFSSubvolumeGroup("cephfs", "csi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, err = client.CreateFSSubvolume(ceph.FSSubvolumeCreate{VolName: "cephfs", SubvolName: "tenant-1", GroupName: "csi"}); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	if _, err = client.CreateFSSubvolume(ceph.FSSubvolumeCreate{VolName: "cephfs"}); err != ceph.ErrFSSubvolNameIsEmpty {
+		t.Errorf("expected err %v - got %v", ceph.ErrFSSubvolNameIsEmpty, err)
+	}
+}
+
+func TestClient_FSSubvolumeSnapshots(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddFS("cephfs")
+	srv.SetTaskDuration(200 * time.Millisecond)
+
+	client := newLoggedInClient(t, srv)
+
+	if _, err := client.CreateFSSubvolume(ceph.FSSubvolumeCreate{VolName: "cephfs", SubvolName: "tenant-1", Size: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.CreateFSSubvolumeSnapshot("cephfs", "tenant-1", "snap-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, err = client.CreateFSSubvolumeSnapshot("cephfs", "tenant-1", "snap-1", ""); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	if _, err = client.CloneFSSubvolumeSnapshot(ceph.FSSubvolumeClone{VolName: "cephfs", SubvolName: "tenant-1", SnapName: "snap-1", CloneName: "tenant-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, info, err := client.GetFSSubvolume("cephfs", "tenant-2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Type != ceph.FSSubvolumeTypeClone || info.State != ceph.FSSubvolumeStateInProgress || info.BytesQuota != 1<<30 {
+		t.Errorf("expected clone in-progress with 1 GiB quota - got %+v", info)
+	}
+
+	_, snapInfo, err := client.GetFSSubvolumeSnapshot("cephfs", "tenant-1", "snap-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapInfo.HasPendingClones != "yes" || len(snapInfo.PendingClones) != 1 || snapInfo.PendingClones[0].Name != "tenant-2" {
+		t.Errorf("expected pending clone tenant-2 - got %+v", snapInfo)
+	}
+
+	if _, err = client.DeleteFSSubvolumeSnapshot("cephfs", "tenant-1", "snap-1", "", false); err == nil {
+		t.Error("expected err deleting a snapshot with pending clones - got nil")
+	}
+
+	time.Sleep(250 * time.Millisecond)
+
+	if _, info, err = client.GetFSSubvolume("cephfs", "tenant-2", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.State != ceph.FSSubvolumeStateComplete {
+		t.Errorf("expected clone %s - got %s", ceph.FSSubvolumeStateComplete, info.State)
+	}
+
+	// a subvolume with snapshots is only deleted with its snapshots retained
+	if _, err = client.DeleteFSSubvolume("cephfs", "tenant-1", "", false); err == nil {
+		t.Error("expected err deleting a subvolume with snapshots - got nil")
+	}
+
+	if _, err = client.DeleteFSSubvolume("cephfs", "tenant-1", "", true); err != nil {
+		t.Fatal(err)
+	}
+
+	_, subvolumes, err := client.ListFSSubvolumes("cephfs", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(subvolumes) != 2 || subvolumes[0].Info.State != ceph.FSSubvolumeStateSnapshotRetained {
+		t.Errorf("expected tenant-1 with snapshots retained and tenant-2 - got %+v", subvolumes)
+	}
+
+	_, snapshots, err := client.ListFSSubvolumeSnapshots("cephfs", "tenant-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snapshots) != 1 || snapshots[0].Name != "snap-1" || snapshots[0].Info.HasPendingClones != "no" {
+		t.Errorf("expected snapshot snap-1 without pending clones - got %+v", snapshots)
+	}
+
+	if status, err = client.DeleteFSSubvolumeSnapshot("cephfs", "tenant-1", "snap-1", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	// the last snapshot removes the retained subvolume
+	if _, _, err = client.GetFSSubvolume("cephfs", "tenant-1", ""); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	if _, err = client.DeleteFSSubvolumeSnapshot("cephfs", "tenant-2", "snap-1", "", true); err != nil {
+		t.Errorf("expected no err deleting a missing snapshot with force - got %v", err)
+	}
+}
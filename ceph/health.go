@@ -0,0 +1,248 @@
+package ceph
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// health status of the cluster and severity of health checks
+const (
+	HealthOK   = "HEALTH_OK"
+	HealthWarn = "HEALTH_WARN"
+	HealthErr  = "HEALTH_ERR"
+)
+
+// Summary implements struct returned from GET /api/summary.
+type Summary struct {
+	HealthStatus      string `json:"health_status"`
+	MgrID             string `json:"mgr_id"`
+	MgrHost           string `json:"mgr_host"`
+	HaveMonConnection bool   `json:"have_mon_connection"`
+	ExecutingTasks    []Task `json:"executing_tasks"`
+	FinishedTasks     []Task `json:"finished_tasks"`
+	Version           string `json:"version"`
+	RbdMirroring      struct {
+		Warnings int `json:"warnings"`
+		Errors   int `json:"errors"`
+	} `json:"rbd_mirroring"`
+}
+
+// Health implements the health status and the failing health checks of the cluster.
+type Health struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+	Mutes  []interface{} `json:"mutes"`
+}
+
+// ChecksBySeverity returns the health checks with severity (e.g. HealthErr).
+func (h Health) ChecksBySeverity(severity string) []HealthCheck {
+	var checks []HealthCheck
+
+	for _, check := range h.Checks {
+		if check.Severity == severity {
+			checks = append(checks, check)
+		}
+	}
+
+	return checks
+}
+
+// HealthCheck implements a single failing health check, e.g. OSD_DOWN.
+type HealthCheck struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Summary  struct {
+		Message string `json:"message"`
+		Count   int    `json:"count"`
+	} `json:"summary"`
+	Detail []struct {
+		Message string `json:"message"`
+	} `json:"detail"`
+	Muted bool `json:"muted"`
+}
+
+// HealthMon implements a monitor of the mon map.
+type HealthMon struct {
+	Rank       int    `json:"rank"`
+	Name       string `json:"name"`
+	Addr       string `json:"addr"`
+	PublicAddr string `json:"public_addr"`
+}
+
+// HealthMonStatus implements the monitors and the quorum of the cluster.
+type HealthMonStatus struct {
+	MonMap struct {
+		Epoch int         `json:"epoch"`
+		FSID  string      `json:"fsid"`
+		Mons  []HealthMon `json:"mons"`
+	} `json:"monmap"`
+	Quorum []int `json:"quorum"`
+}
+
+// HealthOsd implements an osd of the osd map. Up and In are 1 if the osd is up and in, 0 otherwise.
+type HealthOsd struct {
+	Osd             int      `json:"osd"`
+	UUID            string   `json:"uuid"`
+	Up              int      `json:"up"`
+	In              int      `json:"in"`
+	Weight          float64  `json:"weight"`
+	PrimaryAffinity float64  `json:"primary_affinity"`
+	State           []string `json:"state"`
+}
+
+// HealthOsdMap implements the osds of the cluster.
+type HealthOsdMap struct {
+	Osds []HealthOsd `json:"osds"`
+}
+
+// HealthPgInfo implements the placement group status of the cluster. Statuses counts the pgs per state, e.g.
+// "active+clean".
+type HealthPgInfo struct {
+	ObjectStats struct {
+		NumObjects          uint64 `json:"num_objects"`
+		NumObjectsDegraded  uint64 `json:"num_objects_degraded"`
+		NumObjectsMisplaced uint64 `json:"num_objects_misplaced"`
+		NumObjectsUnfound   uint64 `json:"num_objects_unfound"`
+	} `json:"object_stats"`
+	PgsPerOsd float64        `json:"pgs_per_osd"`
+	Statuses  map[string]int `json:"statuses"`
+}
+
+// HealthCapacity implements the raw capacity of the cluster.
+type HealthCapacity struct {
+	TotalAvailBytes   uint64 `json:"total_avail_bytes"`
+	TotalBytes        uint64 `json:"total_bytes"`
+	TotalUsedRawBytes uint64 `json:"total_used_raw_bytes"`
+}
+
+// HealthDfPool implements the usage of a single pool.
+type HealthDfPool struct {
+	Name  string `json:"name"`
+	ID    int    `json:"id"`
+	Stats struct {
+		Stored      uint64  `json:"stored"`
+		Objects     uint64  `json:"objects"`
+		BytesUsed   uint64  `json:"bytes_used"`
+		PercentUsed float64 `json:"percent_used"`
+		MaxAvail    uint64  `json:"max_avail"`
+	} `json:"stats"`
+}
+
+// HealthDf implements the usage of the cluster. Pools is only set by GetHealthFull.
+type HealthDf struct {
+	Stats HealthCapacity `json:"stats"`
+	Pools []HealthDfPool `json:"pools"`
+}
+
+// HealthMgrMap implements the active and the standby mgrs.
+type HealthMgrMap struct {
+	ActiveName string `json:"active_name"`
+	Standbys   []struct {
+		Name string `json:"name"`
+	} `json:"standbys"`
+}
+
+// HealthClientPerf implements the client io of the cluster.
+type HealthClientPerf struct {
+	ReadBytesSec          float64 `json:"read_bytes_sec"`
+	ReadOpPerSec          float64 `json:"read_op_per_sec"`
+	WriteBytesSec         float64 `json:"write_bytes_sec"`
+	WriteOpPerSec         float64 `json:"write_op_per_sec"`
+	RecoveringBytesPerSec float64 `json:"recovering_bytes_per_sec"`
+}
+
+// HealthIscsiDaemons implements the number of iscsi gateways up and down.
+type HealthIscsiDaemons struct {
+	Up   int `json:"up"`
+	Down int `json:"down"`
+}
+
+// HealthMinimal implements struct returned from GET /api/health/minimal.
+type HealthMinimal struct {
+	Health       Health                 `json:"health"`
+	MonStatus    HealthMonStatus        `json:"mon_status"`
+	OsdMap       HealthOsdMap           `json:"osd_map"`
+	PgInfo       HealthPgInfo           `json:"pg_info"`
+	Df           HealthDf               `json:"df"`
+	MgrMap       HealthMgrMap           `json:"mgr_map"`
+	FsMap        map[string]interface{} `json:"fs_map"`
+	ClientPerf   HealthClientPerf       `json:"client_perf"`
+	IscsiDaemons HealthIscsiDaemons     `json:"iscsi_daemons"`
+	Hosts        int                    `json:"hosts"`
+	Pools        int                    `json:"pools"`
+	Rgw          int                    `json:"rgw"`
+	ScrubStatus  string                 `json:"scrub_status"`
+}
+
+// HealthFull implements struct returned from GET /api/health/full. Unlike HealthMinimal it lists the pools with
+// their settings instead of counting them.
+type HealthFull struct {
+	Health       Health                   `json:"health"`
+	MonStatus    HealthMonStatus          `json:"mon_status"`
+	OsdMap       HealthOsdMap             `json:"osd_map"`
+	PgInfo       HealthPgInfo             `json:"pg_info"`
+	Df           HealthDf                 `json:"df"`
+	MgrMap       map[string]interface{}   `json:"mgr_map"`
+	FsMap        map[string]interface{}   `json:"fs_map"`
+	ClientPerf   HealthClientPerf         `json:"client_perf"`
+	IscsiDaemons HealthIscsiDaemons       `json:"iscsi_daemons"`
+	Hosts        int                      `json:"hosts"`
+	Pools        []map[string]interface{} `json:"pools"`
+	Rgw          int                      `json:"rgw"`
+	ScrubStatus  string                   `json:"scrub_status"`
+}
+
+// GetSummary gets the health status, the active mgr and the tasks of the cluster.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-summary
+func (c *Client) GetSummary() (status int, summary Summary, err error) {
+	return c.GetSummaryWithContext(context.Background())
+}
+
+// GetSummaryWithContext is like GetSummary but aborts the request if ctx is done.
+func (c *Client) GetSummaryWithContext(ctx context.Context) (status int, summary Summary, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&summary)
+
+	status, err = c.send(ctx, "GetSummary", req, resty.MethodGet, "summary")
+
+	return status, summary, err
+}
+
+// GetHealthMinimal gets the health checks, mon, osd and pg status and the capacity of the cluster.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-health-minimal
+func (c *Client) GetHealthMinimal() (status int, health HealthMinimal, err error) {
+	return c.GetHealthMinimalWithContext(context.Background())
+}
+
+// GetHealthMinimalWithContext is like GetHealthMinimal but aborts the request if ctx is done.
+func (c *Client) GetHealthMinimalWithContext(ctx context.Context) (status int, health HealthMinimal, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&health)
+
+	status, err = c.send(ctx, "GetHealthMinimal", req, resty.MethodGet, "health/minimal")
+
+	return status, health, err
+}
+
+// GetHealthFull is like GetHealthMinimal but returns the mon, osd, mgr and pool details as well.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-health-full
+func (c *Client) GetHealthFull() (status int, health HealthFull, err error) {
+	return c.GetHealthFullWithContext(context.Background())
+}
+
+// GetHealthFullWithContext is like GetHealthFull but aborts the request if ctx is done.
+func (c *Client) GetHealthFullWithContext(ctx context.Context) (status int, health HealthFull, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&health)
+
+	status, err = c.send(ctx, "GetHealthFull", req, resty.MethodGet, "health/full")
+
+	return status, health, err
+}
@@ -0,0 +1,97 @@
+package ceph_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_GetSummary(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	if err := srv.ReplayFile(http.MethodGet, "/api/summary", http.StatusOK, "outputs/summary.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newLoggedInClient(t, srv)
+
+	status, summary, err := client.GetSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if summary.HealthStatus != ceph.HealthWarn || summary.MgrID != "idea" || !summary.HaveMonConnection {
+		t.Errorf("expected summary of mgr idea with %s - got %+v", ceph.HealthWarn, summary)
+	}
+
+	if len(summary.FinishedTasks) == 0 || summary.FinishedTasks[0].Exception.Code != ceph.ErrnoBusy {
+		t.Errorf("expected finished rbd/delete task failed with errno 16 - got %+v", summary.FinishedTasks)
+	}
+}
+
+func TestClient_GetHealth(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+
+	_, health, err := client.GetHealthMinimal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if health.Health.Status != ceph.HealthOK || len(health.Health.Checks) != 0 {
+		t.Errorf("expected %s without checks - got %+v", ceph.HealthOK, health.Health)
+	}
+
+	if health.Pools != 1 || len(health.OsdMap.Osds) == 0 || len(health.MonStatus.Quorum) == 0 {
+		t.Errorf("expected 1 pool, osds and mon quorum - got %+v", health)
+	}
+
+	if health.Df.Stats.TotalBytes == 0 || health.PgInfo.Statuses["active+clean"] == 0 {
+		t.Errorf("expected capacity and active+clean pgs - got %+v %+v", health.Df.Stats, health.PgInfo)
+	}
+
+	var osdDown ceph.HealthCheck
+	osdDown.Type = "OSD_DOWN"
+	osdDown.Severity = ceph.HealthErr
+	osdDown.Summary.Message = "1 osds down"
+	osdDown.Summary.Count = 1
+
+	srv.SetHealth(ceph.HealthErr, osdDown)
+
+	_, summary, err := client.GetSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.HealthStatus != ceph.HealthErr {
+		t.Errorf("expected summary with %s - got %s", ceph.HealthErr, summary.HealthStatus)
+	}
+
+	status, full, err := client.GetHealthFull()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if checks := full.Health.ChecksBySeverity(ceph.HealthErr); len(checks) != 1 || checks[0].Summary.Message != "1 osds down" {
+		t.Errorf("expected check OSD_DOWN with severity %s - got %+v", ceph.HealthErr, full.Health.Checks)
+	}
+
+	if len(full.Pools) != 1 || full.Pools[0]["pool_name"] != "test-pool-1" || len(full.Df.Pools) != 1 {
+		t.Errorf("expected pool test-pool-1 - got %+v %+v", full.Pools, full.Df.Pools)
+	}
+}
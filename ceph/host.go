@@ -0,0 +1,358 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrHostNameIsEmpty is returned if param hostname is empty.
+var ErrHostNameIsEmpty = errors.New("param hostname can not be empty")
+
+// HostStatusMaintenance is the status of a host in maintenance mode.
+const HostStatusMaintenance = "maintenance"
+
+// Host implements struct returned from GET /api/host and GET /api/host/{hostname}.
+type Host struct {
+	Hostname    string        `json:"hostname"`
+	Addr        string        `json:"addr"`
+	Labels      []string      `json:"labels"`
+	Services    []HostService `json:"services"`
+	CephVersion string        `json:"ceph_version"`
+	Status      string        `json:"status"`
+	Sources     struct {
+		Ceph         bool `json:"ceph"`
+		Orchestrator bool `json:"orchestrator"`
+	} `json:"sources"`
+}
+
+// InMaintenance checks if the host is in maintenance mode.
+func (h Host) InMaintenance() bool {
+	return h.Status == HostStatusMaintenance
+}
+
+// HostService implements a ceph service running on a host, e.g. mon.a.
+type HostService struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// HostAdd implements struct send to ceph to add a host to the orchestrator on POST /api/host (api version 0.1).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-host
+type HostAdd struct {
+	Hostname string   `json:"hostname"`
+	Addr     string   `json:"addr,omitempty"`
+	Labels   []string `json:"labels,omitempty"`
+	Status   string   `json:"status,omitempty"`
+}
+
+// hostUpdate implements struct send to ceph on PUT /api/host/{hostname} (api version 0.1). Maintenance toggles the
+// maintenance mode. Labels is always sent, an empty list clears the labels while the mgr rejects a missing one.
+type hostUpdate struct {
+	UpdateLabels bool     `json:"update_labels,omitempty"`
+	Labels       []string `json:"labels"`
+	Maintenance  bool     `json:"maintenance,omitempty"`
+	Force        bool     `json:"force,omitempty"`
+	Drain        bool     `json:"drain,omitempty"`
+}
+
+// HostDevice implements struct returned from GET /api/host/{hostname}/devices.
+type HostDevice struct {
+	DevID    string               `json:"devid"`
+	Location []HostDeviceLocation `json:"location"`
+	Daemons  []string             `json:"daemons"`
+}
+
+// HostDeviceLocation implements the host and the device name (e.g. sdb) a device is attached to.
+type HostDeviceLocation struct {
+	Host string `json:"host"`
+	Dev  string `json:"dev"`
+}
+
+// HostInventory implements struct returned from GET /api/host/{hostname}/inventory.
+type HostInventory struct {
+	Name    string            `json:"name"`
+	Addr    string            `json:"addr"`
+	Labels  []string          `json:"labels"`
+	Devices []InventoryDevice `json:"devices"`
+}
+
+// InventoryDevice implements a disk of a host found by the orchestrator. Available is true if an osd can be created
+// on the disk, otherwise RejectedReasons tells why not.
+type InventoryDevice struct {
+	Path              string                   `json:"path"`
+	DeviceID          string                   `json:"device_id"`
+	HumanReadableType string                   `json:"human_readable_type"`
+	Available         bool                     `json:"available"`
+	RejectedReasons   []string                 `json:"rejected_reasons"`
+	SysAPI            map[string]interface{}   `json:"sys_api"`
+	Lvs               []map[string]interface{} `json:"lvs"`
+	OsdIDs            []int                    `json:"osd_ids"`
+}
+
+// HostDaemon implements struct returned from GET /api/host/{hostname}/daemons. Status is 1 if the daemon is running.
+type HostDaemon struct {
+	DaemonType         string    `json:"daemon_type"`
+	DaemonID           string    `json:"daemon_id"`
+	DaemonName         string    `json:"daemon_name"`
+	Hostname           string    `json:"hostname"`
+	ContainerID        string    `json:"container_id"`
+	ContainerImageName string    `json:"container_image_name"`
+	Version            string    `json:"version"`
+	Status             int       `json:"status"`
+	StatusDesc         string    `json:"status_desc"`
+	LastRefresh        time.Time `json:"last_refresh"`
+}
+
+// OrchestratorStatus implements struct returned from GET /api/orchestrator/status. Features lists the orchestrator
+// features by name, e.g. get_hosts.
+type OrchestratorStatus struct {
+	Available bool   `json:"available"`
+	Message   string `json:"message"`
+	Features  map[string]struct {
+		Available bool `json:"available"`
+	} `json:"features"`
+}
+
+// GetOrchestratorStatus gets if an orchestrator (e.g. cephadm) is available and which features it supports.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-orchestrator-status
+func (c *Client) GetOrchestratorStatus() (status int, orchestrator OrchestratorStatus, err error) {
+	return c.GetOrchestratorStatusWithContext(context.Background())
+}
+
+// GetOrchestratorStatusWithContext is like GetOrchestratorStatus but aborts the request if ctx is done.
+func (c *Client) GetOrchestratorStatusWithContext(ctx context.Context) (status int, orchestrator OrchestratorStatus, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&orchestrator)
+
+	status, err = c.send(ctx, "GetOrchestratorStatus", req, resty.MethodGet, "orchestrator/status")
+
+	return status, orchestrator, err
+}
+
+// ListHosts gets a list of all hosts known to ceph or the orchestrator with their services and labels.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-host
+func (c *Client) ListHosts() (status int, hosts []Host, err error) {
+	return c.ListHostsWithContext(context.Background())
+}
+
+// ListHostsWithContext is like ListHosts but aborts the request if ctx is done.
+func (c *Client) ListHostsWithContext(ctx context.Context) (status int, hosts []Host, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&hosts)
+
+	status, err = c.send(ctx, "ListHosts", req, resty.MethodGet, "host")
+
+	return status, hosts, err
+}
+
+// GetHost gets a host by name.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-host-hostname
+func (c *Client) GetHost(hostname string) (status int, host Host, err error) {
+	return c.GetHostWithContext(context.Background(), hostname)
+}
+
+// GetHostWithContext is like GetHost but aborts the request if ctx is done.
+func (c *Client) GetHostWithContext(ctx context.Context, hostname string) (status int, host Host, err error) {
+	if hostname == "" {
+		return 0, host, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&host)
+
+	status, err = c.send(ctx, "GetHost", req, resty.MethodGet, hostPath(hostname))
+
+	return status, host, err
+}
+
+// AddHost adds a host to the orchestrator, which deploys ceph daemons on it according to the service specs.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-host
+func (c *Client) AddHost(hostAdd HostAdd) (status int, err error) {
+	return c.AddHostWithContext(context.Background(), hostAdd)
+}
+
+// AddHostWithContext is like AddHost but aborts the request if ctx is done.
+func (c *Client) AddHostWithContext(ctx context.Context, hostAdd HostAdd) (status int, err error) {
+	if hostAdd.Hostname == "" {
+		return 0, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJsonV01).
+		SetBody(hostAdd)
+
+	return c.send(ctx, "AddHost", req, resty.MethodPost, "host")
+}
+
+// RemoveHost removes a host from the orchestrator. Drain the host first (see DrainHost).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-host-hostname
+func (c *Client) RemoveHost(hostname string) (status int, err error) {
+	return c.RemoveHostWithContext(context.Background(), hostname)
+}
+
+// RemoveHostWithContext is like RemoveHost but aborts the request if ctx is done.
+func (c *Client) RemoveHostWithContext(ctx context.Context, hostname string) (status int, err error) {
+	if hostname == "" {
+		return 0, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	return c.send(ctx, "RemoveHost", req, resty.MethodDelete, hostPath(hostname))
+}
+
+// SetHostLabels replaces the labels of a host, e.g. SetHostLabels("node-4", []string{"osd", "_admin"}).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-host-hostname
+func (c *Client) SetHostLabels(hostname string, labels []string) (status int, err error) {
+	return c.SetHostLabelsWithContext(context.Background(), hostname, labels)
+}
+
+// SetHostLabelsWithContext is like SetHostLabels but aborts the request if ctx is done.
+func (c *Client) SetHostLabelsWithContext(ctx context.Context, hostname string, labels []string) (status int, err error) {
+	if labels == nil {
+		labels = []string{}
+	}
+
+	return c.updateHost(ctx, "SetHostLabels", hostname, hostUpdate{UpdateLabels: true, Labels: labels})
+}
+
+// SetHostMaintenance enters (enabled is true) or exits the maintenance mode of a host. Set force to enter the
+// maintenance mode even if the mgr warns about it (e.g. data would become unavailable). Nothing is sent if the host
+// is in the requested mode already.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-host-hostname
+func (c *Client) SetHostMaintenance(hostname string, enabled, force bool) (status int, err error) {
+	return c.SetHostMaintenanceWithContext(context.Background(), hostname, enabled, force)
+}
+
+// SetHostMaintenanceWithContext is like SetHostMaintenance but aborts the requests if ctx is done.
+func (c *Client) SetHostMaintenanceWithContext(ctx context.Context, hostname string, enabled, force bool) (status int, err error) {
+	status, host, err := c.GetHostWithContext(ctx, hostname)
+	if err != nil {
+		return status, err
+	}
+
+	// the mgr toggles the maintenance mode
+	if host.InMaintenance() == enabled {
+		return status, nil
+	}
+
+	return c.updateHost(ctx, "SetHostMaintenance", hostname, hostUpdate{Maintenance: true, Force: force})
+}
+
+// DrainHost schedules the removal of all daemons of a host, e.g. before RemoveHost.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-host-hostname
+func (c *Client) DrainHost(hostname string) (status int, err error) {
+	return c.DrainHostWithContext(context.Background(), hostname)
+}
+
+// DrainHostWithContext is like DrainHost but aborts the request if ctx is done.
+func (c *Client) DrainHostWithContext(ctx context.Context, hostname string) (status int, err error) {
+	return c.updateHost(ctx, "DrainHost", hostname, hostUpdate{Drain: true})
+}
+
+// updateHost sends update for hostname.
+func (c *Client) updateHost(ctx context.Context, op, hostname string, update hostUpdate) (status int, err error) {
+	if hostname == "" {
+		return 0, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJsonV01).
+		SetBody(update)
+
+	return c.send(ctx, op, req, resty.MethodPut, hostPath(hostname))
+}
+
+// ListHostDevices gets the devices of a host with the daemons using them.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-host-hostname-devices
+func (c *Client) ListHostDevices(hostname string) (status int, devices []HostDevice, err error) {
+	return c.ListHostDevicesWithContext(context.Background(), hostname)
+}
+
+// ListHostDevicesWithContext is like ListHostDevices but aborts the request if ctx is done.
+func (c *Client) ListHostDevicesWithContext(ctx context.Context, hostname string) (status int, devices []HostDevice, err error) {
+	if hostname == "" {
+		return 0, nil, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&devices)
+
+	status, err = c.send(ctx, "ListHostDevices", req, resty.MethodGet, hostPath(hostname, "devices"))
+
+	return status, devices, err
+}
+
+// GetHostInventory gets the disks of a host found by the orchestrator, e.g. to find disks available for new osds.
+// Set refresh to let the orchestrator scan the host again.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-host-hostname-inventory
+func (c *Client) GetHostInventory(hostname string, refresh bool) (status int, inventory HostInventory, err error) {
+	return c.GetHostInventoryWithContext(context.Background(), hostname, refresh)
+}
+
+// GetHostInventoryWithContext is like GetHostInventory but aborts the request if ctx is done.
+func (c *Client) GetHostInventoryWithContext(ctx context.Context, hostname string, refresh bool) (status int, inventory HostInventory, err error) {
+	if hostname == "" {
+		return 0, inventory, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("refresh", strconv.FormatBool(refresh)).
+		SetResult(&inventory)
+
+	status, err = c.send(ctx, "GetHostInventory", req, resty.MethodGet, hostPath(hostname, "inventory"))
+
+	return status, inventory, err
+}
+
+// ListHostDaemons gets the daemons the orchestrator deployed on a host.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-host-hostname-daemons
+func (c *Client) ListHostDaemons(hostname string) (status int, daemons []HostDaemon, err error) {
+	return c.ListHostDaemonsWithContext(context.Background(), hostname)
+}
+
+// ListHostDaemonsWithContext is like ListHostDaemons but aborts the request if ctx is done.
+func (c *Client) ListHostDaemonsWithContext(ctx context.Context, hostname string) (status int, daemons []HostDaemon, err error) {
+	if hostname == "" {
+		return 0, nil, ErrHostNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&daemons)
+
+	status, err = c.send(ctx, "ListHostDaemons", req, resty.MethodGet, hostPath(hostname, "daemons"))
+
+	return status, daemons, err
+}
+
+// hostPath returns the api path of hostname followed by elem, e.g. host/node-1/devices.
+func hostPath(hostname string, elem ...string) string {
+	p := "host/" + url.QueryEscape(hostname)
+
+	for _, e := range elem {
+		p += "/" + e
+	}
+
+	return p
+}
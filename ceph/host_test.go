@@ -0,0 +1,207 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_EnrollAndRemoveHost(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	client := newLoggedInClient(t, srv)
+
+	_, orchestrator, err := client.GetOrchestratorStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !orchestrator.Available {
+		t.Fatalf("expected orchestrator available - got %+v", orchestrator)
+	}
+
+	status, err := client.AddHost(ceph.HostAdd{Hostname: "node-4", Addr: "10.0.0.4", Labels: []string{"osd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, err = client.AddHost(ceph.HostAdd{Hostname: "node-4"}); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	if _, err = client.SetHostLabels("node-4", []string{"osd", "_admin"}); err != nil {
+		t.Error(err)
+	}
+
+	_, hosts, err := client.ListHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hosts) != 1 || hosts[0].Addr != "10.0.0.4" || len(hosts[0].Labels) != 2 || !hosts[0].Sources.Orchestrator {
+		t.Errorf("expected host node-4 with labels osd and _admin - got %+v", hosts)
+	}
+
+	if _, err = client.SetHostLabels("node-4", nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, host, _ := client.GetHost("node-4"); len(host.Labels) != 0 {
+		t.Errorf("expected labels of node-4 to be cleared - got %v", host.Labels)
+	}
+
+	if _, err = client.SetHostLabels("node-4", []string{"osd", "_admin"}); err != nil {
+		t.Error(err)
+	}
+
+	_, inventory, err := client.GetHostInventory("node-4", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inventory.Devices) == 0 || !inventory.Devices[0].Available {
+		t.Errorf("expected available disks on node-4 - got %+v", inventory.Devices)
+	}
+
+	_, devices, err := client.ListHostDevices("node-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != len(inventory.Devices) || devices[0].Location[0].Host != "node-4" {
+		t.Errorf("expected %d devices on node-4 - got %+v", len(inventory.Devices), devices)
+	}
+
+	// daemons are still running
+	if _, err = client.RemoveHost("node-4"); err == nil {
+		t.Error("expected error removing host with daemons - got nil")
+	}
+
+	if _, err = client.DrainHost("node-4"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, daemons, _ := client.ListHostDaemons("node-4"); len(daemons) != 0 {
+		t.Errorf("expected no daemons after drain - got %+v", daemons)
+	}
+
+	status, err = client.RemoveHost("node-4")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, _, err = client.GetHost("node-4"); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+}
+
+func TestClient_SetHostMaintenance(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddOSD("node-1", "hdd")
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.MaxAttempts = 1
+
+	if _, err := client.AddHost(ceph.HostAdd{Hostname: "node-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.SetHostMaintenance("node-1", true, false); !errors.Is(err, ceph.ErrBusy) {
+		t.Errorf("expected err %v with active osds - got %v", ceph.ErrBusy, err)
+	}
+
+	// entering twice does not toggle back
+	for i := 0; i < 2; i++ {
+		if _, err := client.SetHostMaintenance("node-1", true, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, host, err := client.GetHost("node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !host.InMaintenance() || len(host.Services) != 1 || host.Services[0].Type != "osd" {
+		t.Errorf("expected host in maintenance with osd service - got %+v", host)
+	}
+
+	if updates := srv.RequestCount(http.MethodPut, "/api/host/node-1"); updates != 2 {
+		t.Errorf("expected 2 updates - got %d", updates)
+	}
+
+	if _, err = client.SetHostMaintenance("node-1", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, host, _ = client.GetHost("node-1"); host.InMaintenance() {
+		t.Error("expected host out of maintenance")
+	}
+}
+
+func TestClient_HostAPIVersion(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	client := newLoggedInClient(t, srv)
+
+	if _, err := client.AddHost(ceph.HostAdd{Hostname: "node-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the host endpoints are versioned as experimental, like the dashboard the fake rejects version 1.0
+	resp, err := client.Session.Client.R().
+		SetHeader("Accept", "application/vnd.ceph.api.v1.0+json").
+		SetBody(map[string]interface{}{"drain": true}).
+		Put(srv.URL + "/api/host/node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode() != http.StatusUnsupportedMediaType {
+		t.Errorf("expected http state 415 - got %d", resp.StatusCode())
+	}
+}
+
+func TestClient_OrchestratorUnavailable(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.SetOrchestratorAvailable(false)
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.MaxAttempts = 1
+
+	_, orchestrator, err := client.GetOrchestratorStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if orchestrator.Available || orchestrator.Message == "" {
+		t.Errorf("expected orchestrator unavailable with message - got %+v", orchestrator)
+	}
+
+	status, err := client.AddHost(ceph.HostAdd{Hostname: "node-4"})
+
+	if !errors.Is(err, ceph.ErrOrchestratorUnavailable) {
+		t.Errorf("expected err %v - got %v", ceph.ErrOrchestratorUnavailable, err)
+	}
+
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("expected http state 503 - got %d", status)
+	}
+}
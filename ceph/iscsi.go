@@ -0,0 +1,473 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrISCSITargetIQNIsEmpty is returned if param targetIQN is empty.
+	ErrISCSITargetIQNIsEmpty = errors.New("param targetIQN can not be empty")
+
+	// ErrISCSIImageSpecInvalid is returned by NewISCSIDisk if the image spec is not of the form pool/image. Images in
+	// a rbd namespace can not be exported by the iscsi gateways.
+	ErrISCSIImageSpecInvalid = errors.New("iscsi disks require an image spec of the form pool/image")
+)
+
+// ISCSIBackstoreRBD is the default backstore of a disk, exporting the rbd image by tcmu-runner.
+const ISCSIBackstoreRBD = "user:rbd"
+
+// ISCSIAuth implements the chap credentials of a target, a client or the discovery. The mutual credentials
+// authenticate the target towards the initiator. Empty credentials disable chap.
+type ISCSIAuth struct {
+	User           string `json:"user"`
+	Password       string `json:"password"`
+	MutualUser     string `json:"mutual_user"`
+	MutualPassword string `json:"mutual_password"`
+}
+
+// ISCSIPortal implements a gateway of a target: the host name of the gateway and the ip address it listens on.
+type ISCSIPortal struct {
+	Host string `json:"host"`
+	IP   string `json:"ip"`
+}
+
+// ISCSIDisk implements a rbd image exported by a target. LUN is assigned by the gateways if nil.
+type ISCSIDisk struct {
+	Pool      string                 `json:"pool"`
+	Image     string                 `json:"image"`
+	Backstore string                 `json:"backstore"`
+	Controls  map[string]interface{} `json:"controls"`
+	LUN       *int                   `json:"lun,omitempty"`
+	WWN       string                 `json:"wwn,omitempty"`
+}
+
+// ISCSIImage implements the reference to a disk of the target used by clients and groups.
+type ISCSIImage struct {
+	Pool  string `json:"pool"`
+	Image string `json:"image"`
+}
+
+// ISCSIClientInfo implements the session of a client returned with a target: State maps the session state (e.g.
+// LOGGED_IN) to the gateways.
+type ISCSIClientInfo struct {
+	Alias     string              `json:"alias"`
+	IPAddress []string            `json:"ip_address"`
+	State     map[string][]string `json:"state"`
+}
+
+// ISCSIClient implements an initiator allowed to access the disks LUNs of a target with acl enabled.
+type ISCSIClient struct {
+	ClientIQN string           `json:"client_iqn"`
+	LUNs      []ISCSIImage     `json:"luns"`
+	Auth      ISCSIAuth        `json:"auth"`
+	Info      *ISCSIClientInfo `json:"info,omitempty"`
+}
+
+// ISCSIGroup implements a group of clients (by client iqn) sharing the same disks.
+type ISCSIGroup struct {
+	GroupID string       `json:"group_id"`
+	Members []string     `json:"members"`
+	Disks   []ISCSIImage `json:"disks"`
+}
+
+// ISCSITargetInfo implements the number of sessions of a target.
+type ISCSITargetInfo struct {
+	NumSessions int `json:"num_sessions"`
+}
+
+// ISCSITarget implements struct returned from GET /api/iscsi/target.
+type ISCSITarget struct {
+	TargetIQN      string                 `json:"target_iqn"`
+	TargetControls map[string]interface{} `json:"target_controls"`
+	ACLEnabled     bool                   `json:"acl_enabled"`
+	Auth           ISCSIAuth              `json:"auth"`
+	Portals        []ISCSIPortal          `json:"portals"`
+	Disks          []ISCSIDisk            `json:"disks"`
+	Clients        []ISCSIClient          `json:"clients"`
+	Groups         []ISCSIGroup           `json:"groups"`
+	Info           *ISCSITargetInfo       `json:"info,omitempty"`
+}
+
+// ISCSITargetCreate implements struct send to ceph to create a target on POST /api/iscsi/target or to change it on
+// PUT /api/iscsi/target/{target_iqn}. Clients are only checked by the gateways if ACLEnabled is true, otherwise
+// Auth applies to all initiators.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-iscsi-target
+type ISCSITargetCreate struct {
+	TargetIQN      string                 `json:"target_iqn"`
+	TargetControls map[string]interface{} `json:"target_controls"`
+	ACLEnabled     bool                   `json:"acl_enabled"`
+	Auth           ISCSIAuth              `json:"auth"`
+	Portals        []ISCSIPortal          `json:"portals"`
+	Disks          []ISCSIDisk            `json:"disks"`
+	Clients        []ISCSIClient          `json:"clients"`
+	Groups         []ISCSIGroup           `json:"groups"`
+}
+
+// iscsiTargetEdit implements struct send to ceph on PUT /api/iscsi/target/{target_iqn}, the target iqn is passed by
+// path and renamed to NewTargetIQN.
+type iscsiTargetEdit struct {
+	NewTargetIQN   string                 `json:"new_target_iqn"`
+	TargetControls map[string]interface{} `json:"target_controls"`
+	ACLEnabled     bool                   `json:"acl_enabled"`
+	Auth           ISCSIAuth              `json:"auth"`
+	Portals        []ISCSIPortal          `json:"portals"`
+	Disks          []ISCSIDisk            `json:"disks"`
+	Clients        []ISCSIClient          `json:"clients"`
+	Groups         []ISCSIGroup           `json:"groups"`
+}
+
+// ISCSIStatus implements struct returned from GET /ui-api/iscsi/status. Message tells why the gateways are not
+// available.
+type ISCSIStatus struct {
+	Available bool   `json:"available"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ISCSIGateway implements a gateway returned from GET /ui-api/iscsi/overview. State is up or down.
+type ISCSIGateway struct {
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	NumTargets  int    `json:"num_targets"`
+	NumSessions int    `json:"num_sessions"`
+}
+
+// ISCSIOverview implements struct returned from GET /ui-api/iscsi/overview.
+type ISCSIOverview struct {
+	Gateways []ISCSIGateway           `json:"gateways"`
+	Disks    []map[string]interface{} `json:"disks"`
+}
+
+// NewISCSIDisk returns the disk exporting the rbd image imageSpec (see CreateImageSpec) with ISCSIBackstoreRBD.
+func NewISCSIDisk(imageSpec string) (ISCSIDisk, error) {
+	parts := strings.Split(imageSpec, "/")
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ISCSIDisk{}, fmt.Errorf("%w: %s", ErrISCSIImageSpecInvalid, imageSpec)
+	}
+
+	return ISCSIDisk{
+		Pool:      parts[0],
+		Image:     parts[1],
+		Backstore: ISCSIBackstoreRBD,
+		Controls:  map[string]interface{}{},
+	}, nil
+}
+
+// ISCSIImage returns the reference to the disk used by clients and groups.
+func (d ISCSIDisk) ISCSIImage() ISCSIImage {
+	return ISCSIImage{Pool: d.Pool, Image: d.Image}
+}
+
+// GetISCSIStatus gets whether the iscsi gateways are configured and reachable by the mgr.
+func (c *Client) GetISCSIStatus() (status int, iscsiStatus ISCSIStatus, err error) {
+	return c.GetISCSIStatusWithContext(context.Background())
+}
+
+// GetISCSIStatusWithContext is like GetISCSIStatus but aborts the request if ctx is done.
+func (c *Client) GetISCSIStatusWithContext(ctx context.Context) (status int, iscsiStatus ISCSIStatus, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&iscsiStatus)
+
+	status, err = c.sendURL(ctx, "GetISCSIStatus", req, resty.MethodGet, c.Session.Server.getUIURL("iscsi/status"))
+
+	return status, iscsiStatus, err
+}
+
+// ListISCSIGateways gets the iscsi gateways with their state and the number of targets and sessions.
+func (c *Client) ListISCSIGateways() (status int, gateways []ISCSIGateway, err error) {
+	return c.ListISCSIGatewaysWithContext(context.Background())
+}
+
+// ListISCSIGatewaysWithContext is like ListISCSIGateways but aborts the request if ctx is done.
+func (c *Client) ListISCSIGatewaysWithContext(ctx context.Context) (status int, gateways []ISCSIGateway, err error) {
+	var overview ISCSIOverview
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&overview)
+
+	status, err = c.sendURL(ctx, "ListISCSIGateways", req, resty.MethodGet, c.Session.Server.getUIURL("iscsi/overview"))
+
+	return status, overview.Gateways, err
+}
+
+// GetISCSIDiscoveryAuth gets the chap credentials required to discover the targets.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-iscsi-discoveryauth
+func (c *Client) GetISCSIDiscoveryAuth() (status int, auth ISCSIAuth, err error) {
+	return c.GetISCSIDiscoveryAuthWithContext(context.Background())
+}
+
+// GetISCSIDiscoveryAuthWithContext is like GetISCSIDiscoveryAuth but aborts the request if ctx is done.
+func (c *Client) GetISCSIDiscoveryAuthWithContext(ctx context.Context) (status int, auth ISCSIAuth, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&auth)
+
+	status, err = c.send(ctx, "GetISCSIDiscoveryAuth", req, resty.MethodGet, "iscsi/discoveryauth")
+
+	return status, auth, err
+}
+
+// SetISCSIDiscoveryAuth sets the chap credentials required to discover the targets, empty credentials disable chap.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-iscsi-discoveryauth
+func (c *Client) SetISCSIDiscoveryAuth(auth ISCSIAuth) (status int, err error) {
+	return c.SetISCSIDiscoveryAuthWithContext(context.Background(), auth)
+}
+
+// SetISCSIDiscoveryAuthWithContext is like SetISCSIDiscoveryAuth but aborts the request if ctx is done.
+func (c *Client) SetISCSIDiscoveryAuthWithContext(ctx context.Context, auth ISCSIAuth) (status int, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(auth)
+
+	return c.send(ctx, "SetISCSIDiscoveryAuth", req, resty.MethodPut, "iscsi/discoveryauth")
+}
+
+// ListISCSITargets gets all targets with their disks, clients and groups.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-iscsi-target
+func (c *Client) ListISCSITargets() (status int, targets []ISCSITarget, err error) {
+	return c.ListISCSITargetsWithContext(context.Background())
+}
+
+// ListISCSITargetsWithContext is like ListISCSITargets but aborts the request if ctx is done.
+func (c *Client) ListISCSITargetsWithContext(ctx context.Context) (status int, targets []ISCSITarget, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&targets)
+
+	status, err = c.send(ctx, "ListISCSITargets", req, resty.MethodGet, "iscsi/target")
+
+	return status, targets, err
+}
+
+// GetISCSITarget gets a target by iqn.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-iscsi-target-target_iqn
+func (c *Client) GetISCSITarget(targetIQN string) (status int, target ISCSITarget, err error) {
+	return c.GetISCSITargetWithContext(context.Background(), targetIQN)
+}
+
+// GetISCSITargetWithContext is like GetISCSITarget but aborts the request if ctx is done.
+func (c *Client) GetISCSITargetWithContext(ctx context.Context, targetIQN string) (status int, target ISCSITarget, err error) {
+	if targetIQN == "" {
+		return 0, target, ErrISCSITargetIQNIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&target)
+
+	status, err = c.send(ctx, "GetISCSITarget", req, resty.MethodGet, iscsiTargetPath(targetIQN))
+
+	return status, target, err
+}
+
+// CreateISCSITarget creates a target exporting the disks on the portals. The rbd images of the disks must exist, see
+// NewISCSIDisk.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-iscsi-target
+func (c *Client) CreateISCSITarget(targetCreate ISCSITargetCreate) (status int, err error) {
+	return c.CreateISCSITargetWithContext(context.Background(), targetCreate)
+}
+
+// CreateISCSITargetWithContext is like CreateISCSITarget but aborts the request and the task wait if ctx is done.
+func (c *Client) CreateISCSITargetWithContext(ctx context.Context, targetCreate ISCSITargetCreate) (status int, err error) {
+	err = c.retryTask(ctx, "CreateISCSITarget", func(ctx context.Context) error {
+		status, err = c.createISCSITarget(ctx, targetCreate)
+		return err
+	})
+
+	return status, err
+}
+
+// CreateISCSITargetAsync is like CreateISCSITargetWithContext but returns at once. The TaskFuture reports the
+// progress of the iscsi/target/create task and holds the result once done.
+func (c *Client) CreateISCSITargetAsync(ctx context.Context, targetCreate ISCSITargetCreate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.CreateISCSITargetWithContext(ctx, targetCreate)
+	})
+}
+
+// createISCSITarget submits the iscsi/target/create task once and waits until it is done.
+func (c *Client) createISCSITarget(ctx context.Context, targetCreate ISCSITargetCreate) (status int, err error) {
+	if targetCreate.TargetIQN == "" {
+		return 0, ErrISCSITargetIQNIsEmpty
+	}
+
+	lookForTask := Task{
+		Name: "iscsi/target/create",
+		MetaData: MetaData{
+			TargetIQN: targetCreate.TargetIQN,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(withISCSIDefaults(targetCreate))
+
+	return c.submitTask(ctx, "CreateISCSITarget", req, resty.MethodPost, c.Session.Server.getURL("iscsi/target"), lookForTask, http.StatusCreated)
+}
+
+// UpdateISCSITarget replaces the settings, portals, disks, clients and groups of the target targetIQN.
+// targetUpdate.TargetIQN renames the target if it differs.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-iscsi-target-target_iqn
+func (c *Client) UpdateISCSITarget(targetIQN string, targetUpdate ISCSITargetCreate) (status int, err error) {
+	return c.UpdateISCSITargetWithContext(context.Background(), targetIQN, targetUpdate)
+}
+
+// UpdateISCSITargetWithContext is like UpdateISCSITarget but aborts the request and the task wait if ctx is done.
+func (c *Client) UpdateISCSITargetWithContext(ctx context.Context, targetIQN string, targetUpdate ISCSITargetCreate) (status int, err error) {
+	err = c.retryTask(ctx, "UpdateISCSITarget", func(ctx context.Context) error {
+		status, err = c.updateISCSITarget(ctx, targetIQN, targetUpdate)
+		return err
+	})
+
+	return status, err
+}
+
+// UpdateISCSITargetAsync is like UpdateISCSITargetWithContext but returns at once. The TaskFuture reports the
+// progress of the iscsi/target/edit task and holds the result once done.
+func (c *Client) UpdateISCSITargetAsync(ctx context.Context, targetIQN string, targetUpdate ISCSITargetCreate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.UpdateISCSITargetWithContext(ctx, targetIQN, targetUpdate)
+	})
+}
+
+// updateISCSITarget submits the iscsi/target/edit task once and waits until it is done.
+func (c *Client) updateISCSITarget(ctx context.Context, targetIQN string, targetUpdate ISCSITargetCreate) (status int, err error) {
+	if targetIQN == "" {
+		return 0, ErrISCSITargetIQNIsEmpty
+	}
+
+	if targetUpdate.TargetIQN == "" {
+		targetUpdate.TargetIQN = targetIQN
+	}
+
+	targetUpdate = withISCSIDefaults(targetUpdate)
+
+	lookForTask := Task{
+		Name: "iscsi/target/edit",
+		MetaData: MetaData{
+			TargetIQN: targetIQN,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(iscsiTargetEdit{
+			NewTargetIQN:   targetUpdate.TargetIQN,
+			TargetControls: targetUpdate.TargetControls,
+			ACLEnabled:     targetUpdate.ACLEnabled,
+			Auth:           targetUpdate.Auth,
+			Portals:        targetUpdate.Portals,
+			Disks:          targetUpdate.Disks,
+			Clients:        targetUpdate.Clients,
+			Groups:         targetUpdate.Groups,
+		})
+
+	return c.submitTask(ctx, "UpdateISCSITarget", req, resty.MethodPut, c.Session.Server.getURL(iscsiTargetPath(targetIQN)), lookForTask, http.StatusOK)
+}
+
+// DeleteISCSITarget deletes a target. The rbd images of its disks are not touched.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-iscsi-target-target_iqn
+func (c *Client) DeleteISCSITarget(targetIQN string) (status int, err error) {
+	return c.DeleteISCSITargetWithContext(context.Background(), targetIQN)
+}
+
+// DeleteISCSITargetWithContext is like DeleteISCSITarget but aborts the request and the task wait if ctx is done.
+func (c *Client) DeleteISCSITargetWithContext(ctx context.Context, targetIQN string) (status int, err error) {
+	err = c.retryTask(ctx, "DeleteISCSITarget", func(ctx context.Context) error {
+		status, err = c.deleteISCSITarget(ctx, targetIQN)
+		return err
+	})
+
+	return status, err
+}
+
+// DeleteISCSITargetAsync is like DeleteISCSITargetWithContext but returns at once. The TaskFuture reports the
+// progress of the iscsi/target/delete task and holds the result once done.
+func (c *Client) DeleteISCSITargetAsync(ctx context.Context, targetIQN string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.DeleteISCSITargetWithContext(ctx, targetIQN)
+	})
+}
+
+// deleteISCSITarget submits the iscsi/target/delete task once and waits until it is done.
+func (c *Client) deleteISCSITarget(ctx context.Context, targetIQN string) (status int, err error) {
+	if targetIQN == "" {
+		return 0, ErrISCSITargetIQNIsEmpty
+	}
+
+	lookForTask := Task{
+		Name: "iscsi/target/delete",
+		MetaData: MetaData{
+			TargetIQN: targetIQN,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson)
+
+	return c.submitTask(ctx, "DeleteISCSITarget", req, resty.MethodDelete, c.Session.Server.getURL(iscsiTargetPath(targetIQN)), lookForTask, http.StatusNoContent)
+}
+
+// withISCSIDefaults sets the empty controls, portals, disks, clients and groups of target the gateways expect.
+func withISCSIDefaults(target ISCSITargetCreate) ISCSITargetCreate {
+	if target.TargetControls == nil {
+		target.TargetControls = map[string]interface{}{}
+	}
+
+	if target.Portals == nil {
+		target.Portals = []ISCSIPortal{}
+	}
+
+	disks := make([]ISCSIDisk, 0, len(target.Disks))
+	for _, disk := range target.Disks {
+		if disk.Backstore == "" {
+			disk.Backstore = ISCSIBackstoreRBD
+		}
+
+		if disk.Controls == nil {
+			disk.Controls = map[string]interface{}{}
+		}
+
+		disks = append(disks, disk)
+	}
+	target.Disks = disks
+
+	clients := make([]ISCSIClient, 0, len(target.Clients))
+	for _, client := range target.Clients {
+		if client.LUNs == nil {
+			client.LUNs = []ISCSIImage{}
+		}
+
+		// info is returned by the gateways only
+		client.Info = nil
+
+		clients = append(clients, client)
+	}
+	target.Clients = clients
+
+	if target.Groups == nil {
+		target.Groups = []ISCSIGroup{}
+	}
+
+	return target
+}
+
+// iscsiTargetPath returns the api path of the target targetIQN.
+func iscsiTargetPath(targetIQN string) string {
+	return "iscsi/target/" + url.QueryEscape(targetIQN)
+}
@@ -0,0 +1,160 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_ISCSITarget(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+
+	_, iscsiStatus, err := client.GetISCSIStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if iscsiStatus.Available {
+		t.Errorf("expected iscsi not available without gateways - got %+v", iscsiStatus)
+	}
+
+	srv.AddISCSIGateway("node-1", "10.0.0.1")
+	srv.AddISCSIGateway("node-2", "10.0.0.2")
+
+	if _, err = client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "lun-1", Size: 1073741824}); err != nil {
+		t.Fatal(err)
+	}
+
+	imageSpec, err := ceph.CreateImageSpec("test-pool-1", nil, "lun-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disk, err := ceph.NewISCSIDisk(imageSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := ceph.ISCSITargetCreate{
+		TargetIQN:  "iqn.2001-07.com.ceph:target-1",
+		ACLEnabled: true,
+		Portals:    []ceph.ISCSIPortal{{Host: "node-1", IP: "10.0.0.1"}, {Host: "node-2", IP: "10.0.0.2"}},
+		Disks:      []ceph.ISCSIDisk{disk},
+		Clients: []ceph.ISCSIClient{{
+			ClientIQN: "iqn.1994-05.com.redhat:client-1",
+			LUNs:      []ceph.ISCSIImage{disk.ISCSIImage()},
+			Auth:      ceph.ISCSIAuth{User: "client-1", Password: "client-1-secret"},
+		}},
+	}
+
+	status, err := client.CreateISCSITarget(create)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, err = client.CreateISCSITarget(create); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	_, target, err := client.GetISCSITarget(create.TargetIQN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(target.Disks) != 1 || target.Disks[0].Image != "lun-1" || target.Disks[0].LUN == nil || *target.Disks[0].LUN != 0 {
+		t.Errorf("expected disk lun-1 as lun 0 - got %+v", target.Disks)
+	}
+
+	if len(target.Clients) != 1 || target.Clients[0].Auth.User != "client-1" {
+		t.Errorf("expected client with chap user client-1 - got %+v", target.Clients)
+	}
+
+	_, gateways, err := client.ListISCSIGateways()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gateways) != 2 || gateways[0].Name != "node-1" || gateways[0].NumTargets != 1 {
+		t.Errorf("expected 2 gateways exporting 1 target - got %+v", gateways)
+	}
+
+	// rename the target and drop the acl
+	update := create
+	update.TargetIQN = "iqn.2001-07.com.ceph:target-2"
+	update.ACLEnabled = false
+	update.Clients = nil
+
+	if status, err = client.UpdateISCSITarget(create.TargetIQN, update); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if _, _, err = client.GetISCSITarget(create.TargetIQN); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	if status, err = client.DeleteISCSITarget(update.TargetIQN); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	_, targets, err := client.ListISCSITargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(targets) != 0 {
+		t.Errorf("expected no targets - got %+v", targets)
+	}
+}
+
+func TestClient_ISCSIDiscoveryAuth(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	client := newLoggedInClient(t, srv)
+
+	auth := ceph.ISCSIAuth{User: "discovery", Password: "discovery-secret", MutualUser: "target", MutualPassword: "target-secret"}
+
+	if _, err := client.SetISCSIDiscoveryAuth(auth); err != nil {
+		t.Fatal(err)
+	}
+
+	_, got, err := client.GetISCSIDiscoveryAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != auth {
+		t.Errorf("expected discovery auth %+v - got %+v", auth, got)
+	}
+
+	nameSpace := "ns-1"
+
+	imageSpec, err := ceph.CreateImageSpec("test-pool-1", &nameSpace, "lun-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ceph.NewISCSIDisk(imageSpec); !errors.Is(err, ceph.ErrISCSIImageSpecInvalid) {
+		t.Errorf("expected err %v - got %v", ceph.ErrISCSIImageSpecInvalid, err)
+	}
+}
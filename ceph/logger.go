@@ -1,6 +1,12 @@
 package ceph
 
 import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -12,32 +18,240 @@ type Logger interface {
 	Debugf(string, ...interface{})
 }
 
-// Adapter implements a zerolog compatibility logger.
+// LogLevel implements the severity of a log message.
+type LogLevel int8
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// String returns the name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+
+	return fmt.Sprintf("LogLevel(%d)", int8(l))
+}
+
+// LogField implements a key value pair attached to a structured log message.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// LogHandler implements a structured logger, e.g. a small wrapper passing messages to a log/slog handler.
+type LogHandler interface {
+	Log(ctx context.Context, level LogLevel, msg string, fields ...LogField)
+}
+
+// LogHandlerFunc implements a LogHandler using an ordinary func.
+type LogHandlerFunc func(ctx context.Context, level LogLevel, msg string, fields ...LogField)
+
+// Log calls f(ctx, level, msg, fields...).
+func (f LogHandlerFunc) Log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	f(ctx, level, msg, fields...)
+}
+
+// Adapter implements the logger used by Client and Session.
+//
+// Messages are passed to the LogHandler set with WithLogHandler or WithZerologLogger if any, otherwise to the
+// embedded Logger (structured fields are appended as key=value). If neither is set, the global zerolog logger is
+// used.
 type Adapter struct {
 	Logger
+
+	handler LogHandler
 }
 
 // Errorf returns error.
 func (a *Adapter) Errorf(f string, v ...interface{}) {
-	log.Error().Msgf(f, v...)
+	a.logf(LogLevelError, f, v...)
 }
 
 // Warningf returns warning.
 func (a *Adapter) Warningf(f string, v ...interface{}) {
-	log.Warn().Msgf(f, v...)
+	a.logf(LogLevelWarn, f, v...)
 }
 
 // Infof returns info.
 func (a *Adapter) Infof(f string, v ...interface{}) {
-	log.Info().Msgf(f, v...)
+	a.logf(LogLevelInfo, f, v...)
 }
 
 // Debugf returns debug.
 func (a *Adapter) Debugf(f string, v ...interface{}) {
-	log.Debug().Msgf(f, v...)
+	a.logf(LogLevelDebug, f, v...)
+}
+
+// Log logs msg with structured fields. Fields attached to ctx with WithLogFields are logged as well.
+func (a *Adapter) Log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	fields = append(logFieldsFromContext(ctx), fields...)
+
+	switch {
+	case a != nil && a.handler != nil:
+		a.handler.Log(ctx, level, msg, fields...)
+	case a != nil && a.Logger != nil:
+		var b strings.Builder
+
+		b.WriteString(msg)
+		for _, field := range fields {
+			fmt.Fprintf(&b, " %s=%v", field.Key, field.Value)
+		}
+
+		printf(a.Logger, level, "%s", b.String())
+	default:
+		zerologHandler{logger: log.Logger}.Log(ctx, level, msg, fields...)
+	}
+}
+
+func (a *Adapter) logf(level LogLevel, f string, v ...interface{}) {
+	if a != nil && a.handler == nil && a.Logger != nil {
+		printf(a.Logger, level, f, v...)
+		return
+	}
+
+	a.Log(context.Background(), level, fmt.Sprintf(f, v...))
+}
+
+// printf passes a message to the method of l matching level.
+func printf(l Logger, level LogLevel, f string, v ...interface{}) {
+	switch level {
+	case LogLevelError:
+		l.Errorf(f, v...)
+	case LogLevelWarn:
+		l.Warningf(f, v...)
+	case LogLevelInfo:
+		l.Infof(f, v...)
+	default:
+		l.Debugf(f, v...)
+	}
 }
 
 // NewLogger returns a new logger adapter needed for packages using a std logger.
 func NewLogger() *Adapter {
 	return &Adapter{}
 }
+
+// zerologHandler implements a LogHandler writing to a zerolog.Logger.
+type zerologHandler struct {
+	logger zerolog.Logger
+}
+
+// Log implements LogHandler.
+func (h zerologHandler) Log(_ context.Context, level LogLevel, msg string, fields ...LogField) {
+	var event *zerolog.Event
+
+	switch level {
+	case LogLevelError:
+		event = h.logger.Error()
+	case LogLevelWarn:
+		event = h.logger.Warn()
+	case LogLevelInfo:
+		event = h.logger.Info()
+	default:
+		event = h.logger.Debug()
+	}
+
+	if len(fields) > 0 {
+		kv := make([]interface{}, 0, 2*len(fields))
+		for _, field := range fields {
+			kv = append(kv, field.Key, field.Value)
+		}
+		event = event.Fields(kv)
+	}
+
+	event.Msg(msg)
+}
+
+type logFieldsKey struct{}
+
+// WithLogFields returns a copy of ctx attaching fields to all messages logged for requests made with the returned
+// context, e.g. a request id of the caller. Fields replace fields with the same key attached before.
+func WithLogFields(ctx context.Context, fields ...LogField) context.Context {
+	merged := logFieldsFromContext(ctx)
+
+	for _, field := range fields {
+		replaced := false
+
+		for i := range merged {
+			if merged[i].Key == field.Key {
+				merged[i] = field
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			merged = append(merged, field)
+		}
+	}
+
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+func logFieldsFromContext(ctx context.Context) []LogField {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(logFieldsKey{}).([]LogField)
+
+	// copy to keep appends of callers from sharing the backing array
+	return append([]LogField(nil), fields...)
+}
+
+// withTaskLogFields returns a copy of ctx attaching the name and the spec of task to all messages logged for it.
+func withTaskLogFields(ctx context.Context, task Task) context.Context {
+	fields := []LogField{{Key: "task", Value: task.Name}}
+
+	switch md := task.MetaData; {
+	case md.ImageSpec != "" || md.ImageName != "":
+		fields = append(fields, LogField{Key: "image_spec", Value: taskSpec(task)})
+	case md.ImageIDSpec != "":
+		fields = append(fields, LogField{Key: "image_id_spec", Value: md.ImageIDSpec})
+	case md.SrcImageSpec != "":
+		fields = append(fields, LogField{Key: "image_spec", Value: md.SrcImageSpec})
+	case md.ParentImageSpec != "":
+		fields = append(fields, LogField{Key: "image_spec", Value: md.ParentImageSpec})
+	case md.PoolName != "":
+		fields = append(fields, LogField{Key: "pool_name", Value: md.PoolName})
+		if md.PeerUUID != "" {
+			fields = append(fields, LogField{Key: "peer_uuid", Value: md.PeerUUID})
+		}
+	case md.SvcID != "":
+		fields = append(fields, LogField{Key: "svc_id", Value: md.SvcID})
+	case md.ClusterID != "":
+		fields = append(fields, LogField{Key: "cluster_id", Value: md.ClusterID})
+		if md.ExportID != "" {
+			fields = append(fields, LogField{Key: "export_id", Value: md.ExportID})
+		}
+	case md.TargetIQN != "":
+		fields = append(fields, LogField{Key: "target_iqn", Value: md.TargetIQN})
+	}
+
+	spanFromContext(ctx).SetAttributes(taskAttributes(task)...)
+
+	return WithLogFields(ctx, fields...)
+}
+
+// redacted replaces secrets in logged request and response bodies.
+const redacted = "***"
+
+var secretPattern = regexp.MustCompile(`"(password|mutual_password|token|key|secret_key)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactSecrets replaces the values of password, mutual_password, token, key (cephx keys of mirroring peers) and
+// secret_key (s3 and swift keys of rgw users) attributes in the json body.
+func redactSecrets(body string) string {
+	return secretPattern.ReplaceAllString(body, `"$1"$2"`+redacted+`"`)
+}
@@ -0,0 +1,270 @@
+package ceph_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+	"github.com/rs/zerolog"
+)
+
+// recordingHandler implements a ceph.LogHandler keeping all messages.
+type recordingHandler struct {
+	mu       sync.Mutex
+	messages []recordedMessage
+}
+
+type recordedMessage struct {
+	level  ceph.LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+func (h *recordingHandler) Log(_ context.Context, level ceph.LogLevel, msg string, fields ...ceph.LogField) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m := recordedMessage{level: level, msg: msg, fields: make(map[string]interface{})}
+	for _, field := range fields {
+		m.fields[field.Key] = field.Value
+	}
+
+	h.messages = append(h.messages, m)
+}
+
+// find returns the first message msg having all fields of want.
+func (h *recordingHandler) find(msg string, want map[string]interface{}) (recordedMessage, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, m := range h.messages {
+		if m.msg != msg {
+			continue
+		}
+
+		match := true
+		for key, value := range want {
+			if m.fields[key] != value {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return m, true
+		}
+	}
+
+	return recordedMessage{}, false
+}
+
+func (h *recordingHandler) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	for _, m := range h.messages {
+		fmt.Fprintf(&b, "%s %s %v\n", m.level, m.msg, m.fields)
+	}
+
+	return b.String()
+}
+
+func TestNew_WithLogHandler(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	handler := &recordingHandler{}
+
+	client, err := ceph.New(srv.CephServer(), ceph.WithLogHandler(handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ceph.WithLogFields(context.Background(), ceph.LogField{Key: "request_id", Value: "42"})
+
+	if _, err = client.CreateBlockImageWithContext(ctx, ceph.RBDCreate{PoolName: "test-pool-1", Name: "log-img", Size: 1073741824}); err != nil {
+		t.Fatal(err)
+	}
+
+	request, ok := handler.find("request", map[string]interface{}{
+		"method":     http.MethodPost,
+		"path":       "/api/block/image",
+		"status":     http.StatusCreated,
+		"task":       "rbd/create",
+		"image_spec": "test-pool-1/log-img",
+		"request_id": "42",
+	})
+
+	if !ok {
+		t.Fatalf("expected request message with method, path, status, task and image spec - got\n%s", handler)
+	}
+
+	if _, ok = request.fields["duration"]; !ok {
+		t.Errorf("expected duration in request message - got %v", request.fields)
+	}
+
+	if _, ok = handler.find("task finished", map[string]interface{}{"task": "rbd/create", "success": true}); !ok {
+		t.Errorf("expected task finished message - got\n%s", handler)
+	}
+
+	if _, ok = handler.find("request", map[string]interface{}{"method": http.MethodPost, "path": "/api/auth"}); !ok {
+		t.Errorf("expected login request message - got\n%s", handler)
+	}
+
+	if strings.Contains(handler.String(), cephtest.Password) {
+		t.Errorf("expected password to be redacted - got\n%s", handler)
+	}
+}
+
+func TestNew_WithZerologLogger(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	var buf bytes.Buffer
+
+	client, err := ceph.New(srv.CephServer(), ceph.WithZerologLogger(zerolog.New(&buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// resty debug output includes request and response bodies
+	client.Session.Client.SetDebug(true)
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, `"path":"/api/auth"`) || !strings.Contains(out, `"status":201`) {
+		t.Errorf("expected structured request message - got %s", out)
+	}
+
+	if !strings.Contains(out, "REQUEST") {
+		t.Errorf("expected resty debug output - got %s", out)
+	}
+
+	if strings.Contains(out, cephtest.Password) || strings.Contains(out, client.Session.Token()) {
+		t.Errorf("expected password and token to be redacted - got %s", out)
+	}
+}
+
+func TestNew_WithLogger(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	var logger printfLogger
+
+	client, err := ceph.New(srv.CephServer(), ceph.WithLogger(&logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := logger.String(); !strings.Contains(out, "request method=POST path=/api/auth status=201") {
+		t.Errorf("expected request message - got %s", out)
+	}
+
+	if _, err = ceph.New(srv.CephServer(), ceph.WithLogger(nil)); !errors.Is(err, ceph.ErrLoggerIsNil) {
+		t.Errorf("expected err %v - got %v", ceph.ErrLoggerIsNil, err)
+	}
+}
+
+func TestNew_WithZerologLoggerRGWKeys(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddRGWDaemon("node-1")
+
+	var buf bytes.Buffer
+
+	client, err := ceph.New(srv.CephServer(), ceph.WithZerologLogger(zerolog.New(&buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.Session.Client.SetDebug(true)
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	secretKey := "s3-secret-key-1"
+
+	_, user, err := client.CreateRGWUser(ceph.RGWUserCreate{UID: "alice", DisplayName: "Alice", AccessKey: "s3-access-key-1",
+		SecretKey: secretKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(user.Keys) != 1 || user.Keys[0].SecretKey != secretKey {
+		t.Fatalf("expected key with secret key %s - got %v", secretKey, user.Keys)
+	}
+
+	_, keys, err := client.CreateRGWKey("alice", ceph.RGWKeyCreate{KeyType: ceph.RGWKeyTypeS3, GenerateKey: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, `\"secret_key\": \"***\"`) && !strings.Contains(out, `\"secret_key\":\"***\"`) {
+		t.Errorf("expected redacted secret key in resty debug output - got %s", out)
+	}
+
+	for _, key := range append(keys, user.Keys...) {
+		if strings.Contains(out, key.SecretKey) {
+			t.Errorf("expected secret key %s to be redacted - got %s", key.SecretKey, out)
+		}
+	}
+}
+
+func TestCredentials_String(t *testing.T) {
+	credentials := ceph.Credentials{Username: "admin", Password: "secret"}
+
+	if out := fmt.Sprintf("%v %+v", credentials, credentials); strings.Contains(out, "secret") {
+		t.Errorf("expected password to be redacted - got %s", out)
+	}
+}
+
+// printfLogger implements a ceph.Logger writing to a buffer.
+type printfLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *printfLogger) printf(f string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fmt.Fprintf(&l.buf, f+"\n", v...)
+}
+
+func (l *printfLogger) Errorf(f string, v ...interface{})   { l.printf(f, v...) }
+func (l *printfLogger) Warningf(f string, v ...interface{}) { l.printf(f, v...) }
+func (l *printfLogger) Infof(f string, v ...interface{})    { l.printf(f, v...) }
+func (l *printfLogger) Debugf(f string, v ...interface{})   { l.printf(f, v...) }
+
+func (l *printfLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.buf.String()
+}
@@ -0,0 +1,154 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrMetricsIsNil is returned by New if WithMetrics is used with nil metrics.
+var ErrMetricsIsNil = errors.New("param metrics can not be nil")
+
+// TaskOutcome implements the result of waiting for a task reported to Metrics.ObserveTaskWait.
+type TaskOutcome string
+
+const (
+	// TaskOutcomeSuccess is reported for tasks the mgr finished successfully.
+	TaskOutcomeSuccess TaskOutcome = "success"
+
+	// TaskOutcomeFailure is reported for tasks the mgr finished unsuccessfully.
+	TaskOutcomeFailure TaskOutcome = "failure"
+
+	// TaskOutcomeCanceled is reported if the caller stopped waiting as its context was done.
+	TaskOutcomeCanceled TaskOutcome = "canceled"
+
+	// TaskOutcomeError is reported if polling /api/task failed.
+	TaskOutcomeError TaskOutcome = "error"
+)
+
+// Metrics implements a sink for the metrics of a client, e.g. a small wrapper updating prometheus counters and
+// histograms. Its methods are called synchronously from the goroutine making the request and must not block.
+//
+// endpoint is the path below the api path with all names and ids replaced by {}, e.g. block/image/{}/snap/{}, so it
+// can be used as label without blowing up the number of series.
+type Metrics interface {
+	// ObserveRequest is called for every http request sent, including retries and logins. status is 0 if no
+	// response was received.
+	ObserveRequest(method, endpoint string, status int, duration time.Duration)
+
+	// IncRequestRetry is called before a failed request is sent again according to the RetryPolicy.
+	IncRequestRetry(method, endpoint string)
+
+	// ObserveTaskWait is called once a call stopped waiting for the task name (e.g. rbd/create).
+	ObserveTaskWait(name string, outcome TaskOutcome, duration time.Duration)
+
+	// IncTaskResubmit is called before the method op (e.g. DeleteBlockImage) submits the task name again, as the mgr
+	// finished it unsuccessfully with a retryable error.
+	IncTaskResubmit(op, name string)
+}
+
+// NopMetrics implements Metrics discarding everything. Embed it to implement only some of the methods.
+type NopMetrics struct{}
+
+// ObserveRequest implements Metrics.
+func (NopMetrics) ObserveRequest(string, string, int, time.Duration) {}
+
+// IncRequestRetry implements Metrics.
+func (NopMetrics) IncRequestRetry(string, string) {}
+
+// ObserveTaskWait implements Metrics.
+func (NopMetrics) ObserveTaskWait(string, TaskOutcome, time.Duration) {}
+
+// IncTaskResubmit implements Metrics.
+func (NopMetrics) IncTaskResubmit(string, string) {}
+
+// WithMetrics sets the sink request counts and latencies, retries, task wait durations and task re-submissions are
+// reported to.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) error {
+		if metrics == nil {
+			return ErrMetricsIsNil
+		}
+
+		o.metrics = metrics
+
+		return nil
+	}
+}
+
+// metricsSink returns the Metrics of the session or NopMetrics if none is set.
+func (s *Session) metricsSink() Metrics {
+	if s == nil || s.metrics == nil {
+		return NopMetrics{}
+	}
+
+	return s.metrics
+}
+
+// metrics returns the Metrics of the client or NopMetrics if none is set.
+func (c *Client) metrics() Metrics {
+	return c.Session.metricsSink()
+}
+
+// endpointSegments holds the static path segments of the ceph api endpoints, all others are names or ids.
+var endpointSegments = map[string]bool{}
+
+func init() {
+	for _, s := range strings.Fields(`auth block bootstrap bucket capability cephfs check client clients clone
+		cluster copy daemon daemons destroy devices discoveryauth export flags full get_root_directory group health host
+		image info inventory iscsi key logout ls_dir mark mds_counters minimal mirroring move_trash namespace nfs-ganesha
+		orchestrator osd overview peer pool purge quota restore reweight rgw rollback safe_to_delete safe_to_destroy
+		scrub site_name snap snapshot status subuser subvolume summary target task token trash tree user`) {
+		endpointSegments[s] = true
+	}
+}
+
+// endpoint returns the endpoint of rawURL used as metrics label: the path below the api or ui api path with the
+// segments not being part of the api replaced by {}.
+func (s *Session) endpoint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "{}"
+	}
+
+	segments := strings.Split(s.endpointPath(u), "/")
+
+	for i, segment := range segments {
+		if !endpointSegments[segment] {
+			segments[i] = "{}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// endpointPath returns the escaped path of u below the api or ui api path.
+func (s *Session) endpointPath(u *url.URL) string {
+	p := strings.TrimPrefix(u.EscapedPath(), "/")
+
+	for _, prefix := range []string{s.Server.APIPath, path.Join(path.Dir(s.Server.APIPath), "ui-"+path.Base(s.Server.APIPath))} {
+		if prefix = strings.Trim(prefix, "/") + "/"; strings.HasPrefix(p, prefix) {
+			p = strings.TrimPrefix(p, prefix)
+			break
+		}
+	}
+
+	return strings.Trim(p, "/")
+}
+
+// taskOutcome returns the outcome of a task wait finished with task and err.
+func taskOutcome(ctx context.Context, task Task, err error) TaskOutcome {
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return TaskOutcomeCanceled
+	case err != nil:
+		return TaskOutcomeError
+	case task.Success:
+		return TaskOutcomeSuccess
+	}
+
+	return TaskOutcomeFailure
+}
@@ -0,0 +1,165 @@
+package ceph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+// recordingMetrics implements ceph.Metrics keeping every observation as string.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	requests []string
+	retries  []string
+	waits    []string
+	resubmit []string
+}
+
+func (m *recordingMetrics) ObserveRequest(method, endpoint string, status int, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, fmt.Sprintf("%s %s %d", method, endpoint, status))
+}
+
+func (m *recordingMetrics) IncRequestRetry(method, endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.retries = append(m.retries, method+" "+endpoint)
+}
+
+func (m *recordingMetrics) ObserveTaskWait(name string, outcome ceph.TaskOutcome, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.waits = append(m.waits, fmt.Sprintf("%s %s", name, outcome))
+}
+
+func (m *recordingMetrics) IncTaskResubmit(op, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resubmit = append(m.resubmit, op+" "+name)
+}
+
+// newMetricsClient returns a client logged in to srv reporting to a recordingMetrics.
+func newMetricsClient(t *testing.T, srv *cephtest.Server) (*ceph.Client, *recordingMetrics) {
+	t.Helper()
+
+	metrics := &recordingMetrics{}
+
+	client, err := ceph.New(srv.CephServer(), ceph.WithMetrics(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	client.TaskPollInterval = 10 * time.Millisecond
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	return client, metrics
+}
+
+func TestNew_WithMetricsRequests(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	for i := 0; i < 2; i++ {
+		srv.InjectException(http.MethodGet, "/api/pool/test-pool-1", ceph.Exception{
+			Detail: "service unavailable",
+			Status: http.StatusServiceUnavailable,
+		})
+	}
+
+	client, metrics := newMetricsClient(t, srv)
+
+	if _, _, err := client.GetPool("test-pool-1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"POST auth 201", "GET pool/{} 503", "GET pool/{} 503", "GET pool/{} 200"}
+	if !reflect.DeepEqual(metrics.requests, expected) {
+		t.Errorf("expected requests %v - got %v", expected, metrics.requests)
+	}
+
+	expected = []string{"GET pool/{}", "GET pool/{}"}
+	if !reflect.DeepEqual(metrics.retries, expected) {
+		t.Errorf("expected retries %v - got %v", expected, metrics.retries)
+	}
+
+	nameSpace := "tenant-1"
+	if _, err := client.CreateBlockNameSpaceInPool("test-pool-1", nameSpace); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Namespace: &nameSpace, Name: "img-1", Size: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics.requests = nil
+
+	if _, err := client.CreateBlockSnapShot("test-pool-1", &nameSpace, "img-1", "snap-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// names and ids are replaced, the escaped image spec is a single segment
+	if len(metrics.requests) == 0 || metrics.requests[0] != "POST block/image/{}/snap 201" {
+		t.Errorf("expected POST block/image/{}/snap 201 first - got %v", metrics.requests)
+	}
+}
+
+func TestNew_WithMetricsTasks(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client, metrics := newMetricsClient(t, srv)
+
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "busy-img", Size: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.FailTask("rbd/delete", 1)
+
+	if _, err := client.DeleteBlockImage("test-pool-1", nil, "busy-img"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"rbd/create success", "rbd/delete failure", "rbd/delete success"}
+	if !reflect.DeepEqual(metrics.waits, expected) {
+		t.Errorf("expected task waits %v - got %v", expected, metrics.waits)
+	}
+
+	expected = []string{"DeleteBlockImage rbd/delete"}
+	if !reflect.DeepEqual(metrics.resubmit, expected) {
+		t.Errorf("expected re-submissions %v - got %v", expected, metrics.resubmit)
+	}
+
+	srv.SetTaskDuration(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.CreateBlockImageWithContext(ctx, ceph.RBDCreate{PoolName: "test-pool-1", Name: "slow-img", Size: 1 << 30}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected err %v - got %v", context.DeadlineExceeded, err)
+	}
+
+	if last := metrics.waits[len(metrics.waits)-1]; last != "rbd/create canceled" {
+		t.Errorf("expected rbd/create canceled - got %s", last)
+	}
+}
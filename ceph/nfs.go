@@ -0,0 +1,425 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrNFSClusterIDIsEmpty is returned if param clusterID is empty.
+	ErrNFSClusterIDIsEmpty = errors.New("param clusterID can not be empty")
+
+	// ErrNFSPseudoPathIsEmpty is returned if the pseudo path of an export is empty.
+	ErrNFSPseudoPathIsEmpty = errors.New("param pseudo can not be empty")
+
+	// ErrNFSExportNotFound is returned by CreateNFSExport if the export created can not be found afterwards.
+	ErrNFSExportNotFound = errors.New("nfs export not found")
+
+	// ErrFSNotFound is returned by ExportCephFSDir if no ceph fs has the id.
+	ErrFSNotFound = errors.New("ceph fs not found")
+)
+
+// file system abstraction layers of an export
+const (
+	NFSFSALCephFS = "CEPH"
+	NFSFSALRGW    = "RGW"
+)
+
+// access types of an export and its clients
+const (
+	NFSAccessReadWrite = "RW"
+	NFSAccessReadOnly  = "RO"
+	NFSAccessMDOnly    = "MDONLY"
+	NFSAccessMDOnlyRO  = "MDONLY_RO"
+	NFSAccessNone      = "NONE"
+)
+
+// squash of an export and its clients
+const (
+	NFSSquashNone = "no_root_squash"
+	NFSSquashRoot = "root_squash"
+	NFSSquashAll  = "all_squash"
+)
+
+// NFSFSAL implements the file system abstraction layer of an export: FSName for NFSFSALCephFS, UserID (the owner of
+// the bucket) for NFSFSALRGW.
+type NFSFSAL struct {
+	Name          string `json:"name"`
+	FSName        string `json:"fs_name,omitempty"`
+	UserID        string `json:"user_id,omitempty"`
+	SecLabelXattr string `json:"sec_label_xattr,omitempty"`
+}
+
+// NFSClient implements the access of a group of clients (host names, ip addresses or networks) overriding the access
+// type and squash of an export.
+type NFSClient struct {
+	Addresses  []string `json:"addresses"`
+	AccessType string   `json:"access_type"`
+	Squash     string   `json:"squash"`
+}
+
+// NFSExport implements struct returned from GET /api/nfs-ganesha/export.
+type NFSExport struct {
+	ExportID      int         `json:"export_id"`
+	ClusterID     string      `json:"cluster_id"`
+	Path          string      `json:"path"`
+	Pseudo        string      `json:"pseudo"`
+	AccessType    string      `json:"access_type"`
+	Squash        string      `json:"squash"`
+	SecurityLabel bool        `json:"security_label"`
+	Protocols     []int       `json:"protocols"`
+	Transports    []string    `json:"transports"`
+	FSAL          NFSFSAL     `json:"fsal"`
+	Clients       []NFSClient `json:"clients"`
+}
+
+// NFSExportCreate implements struct send to ceph to create an export on POST /api/nfs-ganesha/export or to change it
+// on PUT /api/nfs-ganesha/export/{cluster_id}/{export_id}. Path is the directory of the ceph fs or the bucket
+// exported, Pseudo the path clients mount (e.g. /tenant-1). Protocols default to nfs v4 and Transports to TCP.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-nfs-ganesha-export
+type NFSExportCreate struct {
+	ClusterID     string      `json:"cluster_id"`
+	Path          string      `json:"path"`
+	Pseudo        string      `json:"pseudo"`
+	AccessType    string      `json:"access_type"`
+	Squash        string      `json:"squash"`
+	SecurityLabel bool        `json:"security_label"`
+	Protocols     []int       `json:"protocols"`
+	Transports    []string    `json:"transports"`
+	FSAL          NFSFSAL     `json:"fsal"`
+	Clients       []NFSClient `json:"clients"`
+}
+
+// NFSDaemon implements struct returned from GET /api/nfs-ganesha/daemon. Status is 1 if the daemon is running.
+type NFSDaemon struct {
+	DaemonID    string `json:"daemon_id"`
+	ClusterID   string `json:"cluster_id"`
+	ClusterType string `json:"cluster_type"`
+	Status      int    `json:"status"`
+	StatusDesc  string `json:"status_desc"`
+}
+
+// ListNFSClusters gets the ids of all nfs clusters.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-nfs-ganesha-cluster
+func (c *Client) ListNFSClusters() (status int, clusterIDs []string, err error) {
+	return c.ListNFSClustersWithContext(context.Background())
+}
+
+// ListNFSClustersWithContext is like ListNFSClusters but aborts the request if ctx is done.
+func (c *Client) ListNFSClustersWithContext(ctx context.Context) (status int, clusterIDs []string, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&clusterIDs)
+
+	status, err = c.send(ctx, "ListNFSClusters", req, resty.MethodGet, "nfs-ganesha/cluster")
+
+	return status, clusterIDs, err
+}
+
+// ListNFSDaemons gets the nfs-ganesha daemons of all nfs clusters.
+// --> https://docs.ceph.com/en/pacific/mgr/ceph_api/#get--api-nfs-ganesha-daemon
+func (c *Client) ListNFSDaemons() (status int, daemons []NFSDaemon, err error) {
+	return c.ListNFSDaemonsWithContext(context.Background())
+}
+
+// ListNFSDaemonsWithContext is like ListNFSDaemons but aborts the request if ctx is done.
+func (c *Client) ListNFSDaemonsWithContext(ctx context.Context) (status int, daemons []NFSDaemon, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&daemons)
+
+	status, err = c.send(ctx, "ListNFSDaemons", req, resty.MethodGet, "nfs-ganesha/daemon")
+
+	return status, daemons, err
+}
+
+// ListNFSExports gets the exports of all nfs clusters.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-nfs-ganesha-export
+func (c *Client) ListNFSExports() (status int, exports []NFSExport, err error) {
+	return c.ListNFSExportsWithContext(context.Background())
+}
+
+// ListNFSExportsWithContext is like ListNFSExports but aborts the request if ctx is done.
+func (c *Client) ListNFSExportsWithContext(ctx context.Context) (status int, exports []NFSExport, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&exports)
+
+	status, err = c.send(ctx, "ListNFSExports", req, resty.MethodGet, "nfs-ganesha/export")
+
+	return status, exports, err
+}
+
+// GetNFSExport gets an export of a nfs cluster by id.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-nfs-ganesha-export-cluster_id-export_id
+func (c *Client) GetNFSExport(clusterID string, exportID int) (status int, export NFSExport, err error) {
+	return c.GetNFSExportWithContext(context.Background(), clusterID, exportID)
+}
+
+// GetNFSExportWithContext is like GetNFSExport but aborts the request if ctx is done.
+func (c *Client) GetNFSExportWithContext(ctx context.Context, clusterID string, exportID int) (status int, export NFSExport, err error) {
+	if clusterID == "" {
+		return 0, export, ErrNFSClusterIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&export)
+
+	status, err = c.send(ctx, "GetNFSExport", req, resty.MethodGet, nfsExportPath(clusterID, exportID))
+
+	return status, export, err
+}
+
+// CreateNFSExport creates an export and returns it with the export id assigned by the nfs cluster.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-nfs-ganesha-export
+func (c *Client) CreateNFSExport(exportCreate NFSExportCreate) (status int, export NFSExport, err error) {
+	return c.CreateNFSExportWithContext(context.Background(), exportCreate)
+}
+
+// CreateNFSExportWithContext is like CreateNFSExport but aborts the requests and the task wait if ctx is done.
+func (c *Client) CreateNFSExportWithContext(ctx context.Context, exportCreate NFSExportCreate) (status int, export NFSExport, err error) {
+	err = c.retryTask(ctx, "CreateNFSExport", func(ctx context.Context) error {
+		status, err = c.createNFSExport(ctx, exportCreate)
+		return err
+	})
+
+	if err != nil {
+		return status, export, err
+	}
+
+	// the export id is assigned by the nfs cluster, the pseudo path identifies the export as well
+	_, exports, err := c.ListNFSExportsWithContext(ctx)
+	if err != nil {
+		return status, export, err
+	}
+
+	for _, e := range exports {
+		if e.ClusterID == exportCreate.ClusterID && e.Pseudo == exportCreate.Pseudo {
+			return status, e, nil
+		}
+	}
+
+	return status, export, fmt.Errorf("%w: %s:%s", ErrNFSExportNotFound, exportCreate.ClusterID, exportCreate.Pseudo)
+}
+
+// CreateNFSExportAsync is like CreateNFSExportWithContext but returns at once. The TaskFuture reports the progress of
+// the nfs/create task and holds the result once done, get the export with ListNFSExports.
+func (c *Client) CreateNFSExportAsync(ctx context.Context, exportCreate NFSExportCreate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		status, _, err := c.CreateNFSExportWithContext(ctx, exportCreate)
+		return status, err
+	})
+}
+
+// createNFSExport submits the nfs/create task once and waits until it is done.
+func (c *Client) createNFSExport(ctx context.Context, exportCreate NFSExportCreate) (status int, err error) {
+	if exportCreate.ClusterID == "" {
+		return 0, ErrNFSClusterIDIsEmpty
+	}
+
+	if exportCreate.Pseudo == "" {
+		return 0, ErrNFSPseudoPathIsEmpty
+	}
+
+	exportCreate = withNFSDefaults(exportCreate)
+
+	lookForTask := Task{
+		Name: "nfs/create",
+		MetaData: MetaData{
+			ClusterID: exportCreate.ClusterID,
+			Path:      exportCreate.Path,
+			Fsal:      exportCreate.FSAL.Name,
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(exportCreate)
+
+	return c.submitTask(ctx, "CreateNFSExport", req, resty.MethodPost, c.Session.Server.getURL("nfs-ganesha/export"), lookForTask, http.StatusCreated)
+}
+
+// UpdateNFSExport replaces the settings of an export.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-nfs-ganesha-export-cluster_id-export_id
+func (c *Client) UpdateNFSExport(exportID int, exportUpdate NFSExportCreate) (status int, err error) {
+	return c.UpdateNFSExportWithContext(context.Background(), exportID, exportUpdate)
+}
+
+// UpdateNFSExportWithContext is like UpdateNFSExport but aborts the request and the task wait if ctx is done.
+func (c *Client) UpdateNFSExportWithContext(ctx context.Context, exportID int, exportUpdate NFSExportCreate) (status int, err error) {
+	err = c.retryTask(ctx, "UpdateNFSExport", func(ctx context.Context) error {
+		status, err = c.updateNFSExport(ctx, exportID, exportUpdate)
+		return err
+	})
+
+	return status, err
+}
+
+// UpdateNFSExportAsync is like UpdateNFSExportWithContext but returns at once. The TaskFuture reports the progress of
+// the nfs/edit task and holds the result once done.
+func (c *Client) UpdateNFSExportAsync(ctx context.Context, exportID int, exportUpdate NFSExportCreate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.UpdateNFSExportWithContext(ctx, exportID, exportUpdate)
+	})
+}
+
+// updateNFSExport submits the nfs/edit task once and waits until it is done.
+func (c *Client) updateNFSExport(ctx context.Context, exportID int, exportUpdate NFSExportCreate) (status int, err error) {
+	if exportUpdate.ClusterID == "" {
+		return 0, ErrNFSClusterIDIsEmpty
+	}
+
+	if exportUpdate.Pseudo == "" {
+		return 0, ErrNFSPseudoPathIsEmpty
+	}
+
+	exportUpdate = withNFSDefaults(exportUpdate)
+
+	lookForTask := Task{
+		Name: "nfs/edit",
+		MetaData: MetaData{
+			ClusterID: exportUpdate.ClusterID,
+			ExportID:  strconv.Itoa(exportID),
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetBody(exportUpdate)
+
+	return c.submitTask(ctx, "UpdateNFSExport", req, resty.MethodPut, c.Session.Server.getURL(nfsExportPath(exportUpdate.ClusterID, exportID)), lookForTask, http.StatusOK)
+}
+
+// DeleteNFSExport deletes an export. The exported directory or bucket is not touched.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-nfs-ganesha-export-cluster_id-export_id
+func (c *Client) DeleteNFSExport(clusterID string, exportID int) (status int, err error) {
+	return c.DeleteNFSExportWithContext(context.Background(), clusterID, exportID)
+}
+
+// DeleteNFSExportWithContext is like DeleteNFSExport but aborts the request and the task wait if ctx is done.
+func (c *Client) DeleteNFSExportWithContext(ctx context.Context, clusterID string, exportID int) (status int, err error) {
+	err = c.retryTask(ctx, "DeleteNFSExport", func(ctx context.Context) error {
+		status, err = c.deleteNFSExport(ctx, clusterID, exportID)
+		return err
+	})
+
+	return status, err
+}
+
+// DeleteNFSExportAsync is like DeleteNFSExportWithContext but returns at once. The TaskFuture reports the progress of
+// the nfs/delete task and holds the result once done.
+func (c *Client) DeleteNFSExportAsync(ctx context.Context, clusterID string, exportID int) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.DeleteNFSExportWithContext(ctx, clusterID, exportID)
+	})
+}
+
+// deleteNFSExport submits the nfs/delete task once and waits until it is done.
+func (c *Client) deleteNFSExport(ctx context.Context, clusterID string, exportID int) (status int, err error) {
+	if clusterID == "" {
+		return 0, ErrNFSClusterIDIsEmpty
+	}
+
+	lookForTask := Task{
+		Name: "nfs/delete",
+		MetaData: MetaData{
+			ClusterID: clusterID,
+			ExportID:  strconv.Itoa(exportID),
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson)
+
+	return c.submitTask(ctx, "DeleteNFSExport", req, resty.MethodDelete, c.Session.Server.getURL(nfsExportPath(clusterID, exportID)), lookForTask, http.StatusNoContent)
+}
+
+// ExportCephFSDir creates the directory quota.Path on the ceph fs fsID (see CreateDir), sets the quota (see SetQuota)
+// and exports the directory (see CreateNFSExport). export.Path defaults to quota.Path, export.FSAL to the ceph fs.
+// The directory is kept if a later step fails, calling ExportCephFSDir again continues where it failed: an existing
+// export of the directory with the same pseudo path is returned as is.
+func (c *Client) ExportCephFSDir(fsID int, quota Quota, export NFSExportCreate) (status int, nfsExport NFSExport, err error) {
+	return c.ExportCephFSDirWithContext(context.Background(), fsID, quota, export)
+}
+
+// ExportCephFSDirWithContext is like ExportCephFSDir but aborts the requests and the task wait if ctx is done.
+func (c *Client) ExportCephFSDirWithContext(ctx context.Context, fsID int, quota Quota, export NFSExportCreate) (status int, nfsExport NFSExport, err error) {
+	if export.FSAL.FSName == "" {
+		var list []FS
+
+		if status, list, err = c.ListFSWithContext(ctx); err != nil {
+			return status, nfsExport, err
+		}
+
+		for _, fs := range list {
+			if fs.ID == fsID {
+				export.FSAL.FSName = fs.MdsMap.FsName
+			}
+		}
+
+		if export.FSAL.FSName == "" {
+			return 0, nfsExport, fmt.Errorf("%w: id %d", ErrFSNotFound, fsID)
+		}
+	}
+
+	export.FSAL.Name = NFSFSALCephFS
+
+	if export.Path == "" {
+		export.Path = quota.Path
+	}
+
+	if status, err = c.CreateDirWithContext(ctx, fsID, quota.Path); err != nil {
+		return status, nfsExport, err
+	}
+
+	if status, err = c.SetQuotaWithContext(ctx, fsID, quota); err != nil {
+		return status, nfsExport, err
+	}
+
+	var exports []NFSExport
+
+	if status, exports, err = c.ListNFSExportsWithContext(ctx); err != nil {
+		return status, nfsExport, err
+	}
+
+	for _, e := range exports {
+		if e.ClusterID == export.ClusterID && e.Pseudo == export.Pseudo && e.Path == export.Path {
+			return status, e, nil
+		}
+	}
+
+	return c.CreateNFSExportWithContext(ctx, export)
+}
+
+// withNFSDefaults sets the default protocols, transports and clients of export.
+func withNFSDefaults(export NFSExportCreate) NFSExportCreate {
+	if len(export.Protocols) == 0 {
+		export.Protocols = []int{4}
+	}
+
+	if len(export.Transports) == 0 {
+		export.Transports = []string{"TCP"}
+	}
+
+	if export.Clients == nil {
+		export.Clients = []NFSClient{}
+	}
+
+	return export
+}
+
+// nfsExportPath returns the api path of the export exportID of the nfs cluster clusterID.
+func nfsExportPath(clusterID string, exportID int) string {
+	return fmt.Sprintf("nfs-ganesha/export/%s/%d", url.QueryEscape(clusterID), exportID)
+}
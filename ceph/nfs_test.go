@@ -0,0 +1,150 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_NFSExport(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddNFSCluster("nfs-1", "node-1", "node-2")
+	srv.AddFS("cephfs")
+
+	client := newLoggedInClient(t, srv)
+
+	_, clusters, err := client.ListNFSClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(clusters) != 1 || clusters[0] != "nfs-1" {
+		t.Errorf("expected cluster nfs-1 - got %v", clusters)
+	}
+
+	_, daemons, err := client.ListNFSDaemons()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(daemons) != 2 || daemons[0].ClusterID != "nfs-1" {
+		t.Errorf("expected 2 daemons of nfs-1 - got %+v", daemons)
+	}
+
+	create := ceph.NFSExportCreate{
+		ClusterID:  "nfs-1",
+		Path:       "/",
+		Pseudo:     "/cephfs",
+		AccessType: ceph.NFSAccessReadOnly,
+		Squash:     ceph.NFSSquashRoot,
+		FSAL:       ceph.NFSFSAL{Name: ceph.NFSFSALCephFS, FSName: "cephfs"},
+	}
+
+	status, export, err := client.CreateNFSExport(create)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if export.ExportID != 1 || export.Pseudo != "/cephfs" || len(export.Protocols) != 1 || export.Protocols[0] != 4 {
+		t.Errorf("expected export 1 of /cephfs with nfs v4 - got %+v", export)
+	}
+
+	if _, _, err = client.CreateNFSExport(create); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	create.AccessType = ceph.NFSAccessReadWrite
+	create.Clients = []ceph.NFSClient{{Addresses: []string{"10.0.0.0/24"}, AccessType: ceph.NFSAccessReadOnly, Squash: ceph.NFSSquashAll}}
+
+	if status, err = client.UpdateNFSExport(export.ExportID, create); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	_, export, err = client.GetNFSExport("nfs-1", export.ExportID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if export.AccessType != ceph.NFSAccessReadWrite || len(export.Clients) != 1 {
+		t.Errorf("expected read-write export with 1 client - got %+v", export)
+	}
+
+	if status, err = client.DeleteNFSExport("nfs-1", export.ExportID); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, _, err = client.GetNFSExport("nfs-1", export.ExportID); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+}
+
+func TestClient_ExportCephFSDir(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddNFSCluster("nfs-1", "node-1")
+	fsID := srv.AddFS("cephfs")
+
+	client := newLoggedInClient(t, srv)
+
+	quota := ceph.Quota{Path: "/tenants/tenant-1", MaxBytes: 1 << 30, MaxFiles: 10000}
+	create := ceph.NFSExportCreate{
+		ClusterID:  "nfs-1",
+		Pseudo:     "/tenant-1",
+		AccessType: ceph.NFSAccessReadWrite,
+		Squash:     ceph.NFSSquashNone,
+	}
+
+	_, export, err := client.ExportCephFSDir(fsID, quota, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if export.Path != quota.Path || export.FSAL.Name != ceph.NFSFSALCephFS || export.FSAL.FSName != "cephfs" {
+		t.Errorf("expected export of %s on ceph fs cephfs - got %+v", quota.Path, export)
+	}
+
+	_, q, err := client.GetQuota(int64(fsID), quota.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.MaxBytes != quota.MaxBytes || q.MaxFiles != quota.MaxFiles {
+		t.Errorf("expected quota %+v - got %+v", quota, q)
+	}
+
+	// a second call returns the existing export
+	_, again, err := client.ExportCephFSDir(fsID, quota, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again.ExportID != export.ExportID {
+		t.Errorf("expected export %d - got %d", export.ExportID, again.ExportID)
+	}
+
+	if creates := srv.RequestCount(http.MethodPost, "/api/nfs-ganesha/export"); creates != 1 {
+		t.Errorf("expected 1 export created - got %d", creates)
+	}
+
+	if _, _, err = client.ExportCephFSDir(fsID+1, quota, create); !errors.Is(err, ceph.ErrFSNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrFSNotFound, err)
+	}
+}
@@ -0,0 +1,218 @@
+package ceph
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrLoggerIsNil is returned by New if a logger option is used with a nil logger.
+	ErrLoggerIsNil = errors.New("param logger can not be nil")
+
+	// ErrCACertPoolIsNil is returned by New if WithCACertPool is used with a nil pool.
+	ErrCACertPoolIsNil = errors.New("param pool can not be nil")
+
+	// ErrClientCertificateInvalid is returned by New if WithClientCertificate is used with a certificate lacking the
+	// certificate chain or the private key.
+	ErrClientCertificateInvalid = errors.New("param cert must hold a certificate and its private key")
+
+	// ErrTimeoutInvalid is returned by New if WithTimeout is used with a timeout <= 0.
+	ErrTimeoutInvalid = errors.New("param timeout must be greater than 0")
+
+	// ErrHTTPClientIsNil is returned by New if WithHTTPClient is used with a nil client.
+	ErrHTTPClientIsNil = errors.New("param httpClient can not be nil")
+
+	// ErrProxyURLInvalid is returned by New if WithProxy is used with an url not being an absolute http, https or
+	// socks5 url.
+	ErrProxyURLInvalid = errors.New("param proxyURL must be an absolute http, https or socks5 url")
+
+	// ErrUserAgentIsEmpty is returned by New if WithUserAgent is used with an empty user agent.
+	ErrUserAgentIsEmpty = errors.New("param userAgent can not be empty")
+
+	// ErrHTTPClientConflict is returned by New if WithHTTPClient is combined with options configuring the transport
+	// (WithCACertPool, WithClientCertificate, WithProxy or Server.InsecureSkipVerify). Configure the transport of the
+	// http.Client instead.
+	ErrHTTPClientConflict = errors.New("transport options can not be combined with WithHTTPClient")
+)
+
+// Option configures a Client created with New or a Session created with NewSession. All options are validated
+// before the first request is made.
+type Option func(o *options) error
+
+// options implements the configuration collected from the Option list passed to New.
+type options struct {
+	logger      *Adapter
+	caCertPool  *x509.CertPool
+	clientCerts []tls.Certificate
+	timeout     time.Duration
+	httpClient  *http.Client
+	proxyURL    *url.URL
+	userAgent   string
+	metrics     Metrics
+	tracer      Tracer
+}
+
+// newOptions applies opts to the defaults and checks that the options can be combined.
+func newOptions(opts []Option) (options, error) {
+	o := options{logger: NewLogger()}
+
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return options{}, err
+		}
+	}
+
+	if o.httpClient != nil && o.configuresTransport() {
+		return options{}, ErrHTTPClientConflict
+	}
+
+	return o, nil
+}
+
+// configuresTransport checks if any option has to configure the http.Transport.
+func (o options) configuresTransport() bool {
+	return o.caCertPool != nil || len(o.clientCerts) > 0 || o.proxyURL != nil
+}
+
+// WithLogger sets a printf-style logger. Structured fields of a message are appended as key=value.
+func WithLogger(logger Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			return ErrLoggerIsNil
+		}
+
+		o.logger = &Adapter{Logger: logger}
+
+		return nil
+	}
+}
+
+// WithZerologLogger sets a zerolog.Logger receiving messages with structured fields.
+func WithZerologLogger(logger zerolog.Logger) Option {
+	return func(o *options) error {
+		o.logger = &Adapter{handler: zerologHandler{logger: logger}}
+		return nil
+	}
+}
+
+// WithLogHandler sets a structured logger, e.g. a wrapper of a log/slog handler:
+//
+//	ceph.WithLogHandler(ceph.LogHandlerFunc(func(ctx context.Context, level ceph.LogLevel, msg string, fields ...ceph.LogField) {
+//		attrs := make([]slog.Attr, 0, len(fields))
+//		for _, f := range fields {
+//			attrs = append(attrs, slog.Any(f.Key, f.Value))
+//		}
+//		logger.LogAttrs(ctx, slog.Level(4*(int(level)-1)), msg, attrs...)
+//	}))
+func WithLogHandler(handler LogHandler) Option {
+	return func(o *options) error {
+		if handler == nil {
+			return ErrLoggerIsNil
+		}
+
+		o.logger = &Adapter{handler: handler}
+
+		return nil
+	}
+}
+
+// WithCACertPool sets the certificate authorities the certificate of the mgr is verified with instead of the system
+// pool, e.g. to pin the CA of a self-signed cluster certificate.
+func WithCACertPool(pool *x509.CertPool) Option {
+	return func(o *options) error {
+		if pool == nil {
+			return ErrCACertPoolIsNil
+		}
+
+		o.caCertPool = pool
+
+		return nil
+	}
+}
+
+// WithClientCertificate adds a client certificate presented to the mgr (mutual TLS), e.g. loaded with
+// tls.LoadX509KeyPair.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(o *options) error {
+		if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+			return ErrClientCertificateInvalid
+		}
+
+		o.clientCerts = append(o.clientCerts, cert)
+
+		return nil
+	}
+}
+
+// WithTimeout limits the time a single request may take, including reading the response. Retries and task waits
+// are not limited, use a context for this.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		if timeout <= 0 {
+			return ErrTimeoutInvalid
+		}
+
+		o.timeout = timeout
+
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests, e.g. to share a http.Transport. The client is copied,
+// so the settings of the session (redirects, timeout) do not change the passed client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) error {
+		if httpClient == nil {
+			return ErrHTTPClientIsNil
+		}
+
+		o.httpClient = httpClient
+
+		return nil
+	}
+}
+
+// WithProxy sends all requests through the proxy at proxyURL (e.g. http://proxy:3128) instead of the proxy set in
+// the environment.
+func WithProxy(proxyURL string) Option {
+	return func(o *options) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrProxyURLInvalid, err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return ErrProxyURLInvalid
+		}
+
+		if u.Host == "" {
+			return ErrProxyURLInvalid
+		}
+
+		o.proxyURL = u
+
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) error {
+		if userAgent == "" {
+			return ErrUserAgentIsEmpty
+		}
+
+		o.userAgent = userAgent
+
+		return nil
+	}
+}
@@ -0,0 +1,247 @@
+package ceph_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestNew_InvalidOptions(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opt  ceph.Option
+		want error
+	}{
+		{"nil logger", ceph.WithLogger(nil), ceph.ErrLoggerIsNil},
+		{"nil log handler", ceph.WithLogHandler(nil), ceph.ErrLoggerIsNil},
+		{"nil ca cert pool", ceph.WithCACertPool(nil), ceph.ErrCACertPoolIsNil},
+		{"empty client certificate", ceph.WithClientCertificate(tls.Certificate{}), ceph.ErrClientCertificateInvalid},
+		{"zero timeout", ceph.WithTimeout(0), ceph.ErrTimeoutInvalid},
+		{"nil http client", ceph.WithHTTPClient(nil), ceph.ErrHTTPClientIsNil},
+		{"proxy without scheme", ceph.WithProxy("proxy:3128"), ceph.ErrProxyURLInvalid},
+		{"proxy with invalid url", ceph.WithProxy("http://[::1"), ceph.ErrProxyURLInvalid},
+		{"empty user agent", ceph.WithUserAgent(""), ceph.ErrUserAgentIsEmpty},
+		{"nil metrics", ceph.WithMetrics(nil), ceph.ErrMetricsIsNil},
+		{"nil tracer provider", ceph.WithTracerProvider(nil), ceph.ErrTracerProviderIsNil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ceph.New(srv.CephServer(), tt.opt)
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected err %v - got %v", tt.want, err)
+			}
+		})
+	}
+
+	_, err := ceph.New(srv.CephServer(), ceph.WithHTTPClient(&http.Client{}), ceph.WithProxy("http://proxy:3128"))
+
+	if !errors.Is(err, ceph.ErrHTTPClientConflict) {
+		t.Errorf("expected err %v - got %v", ceph.ErrHTTPClientConflict, err)
+	}
+
+	// options are validated before any request is made
+	if requests := srv.RequestCount(http.MethodGet, "/api/"); requests != 0 {
+		t.Errorf("expected 0 requests - got %d", requests)
+	}
+}
+
+func TestNew_WithCACertPoolAndClientCertificate(t *testing.T) {
+	clientCert := newClientCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.Leaf)
+
+	srv := cephtest.NewUnstartedServer()
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srv.Certificate())
+
+	server := srv.CephServer()
+	server.InsecureSkipVerify = false
+
+	client, err := ceph.New(server, ceph.WithCACertPool(rootCAs), ceph.WithClientCertificate(clientCert))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.Session.Login(cephtest.Username, cephtest.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	// neither the system pool nor the server accept the other side without the options
+	if _, err = ceph.New(server, ceph.WithClientCertificate(clientCert)); err == nil {
+		t.Error("expected certificate verification to fail without ca cert pool")
+	}
+
+	if _, err = ceph.New(server, ceph.WithCACertPool(rootCAs)); err == nil {
+		t.Error("expected handshake to fail without client certificate")
+	}
+}
+
+func TestNew_WithTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	_, err := ceph.New(cephServerFor(t, slow.URL), ceph.WithTimeout(50*time.Millisecond))
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected timeout error - got %v", err)
+	}
+}
+
+func TestNew_WithProxyAndUserAgent(t *testing.T) {
+	var (
+		proxied   int32
+		userAgent atomic.Value
+	)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a request sent through a proxy holds the absolute url of the target
+		if r.URL.IsAbs() {
+			atomic.AddInt32(&proxied, 1)
+		}
+		userAgent.Store(r.UserAgent())
+	}))
+	defer proxy.Close()
+
+	_, err := ceph.New(cephServerFor(t, "http://ceph-mgr.invalid:8443"), ceph.WithProxy(proxy.URL), ceph.WithUserAgent("backup-tool/1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&proxied) != 1 {
+		t.Errorf("expected 1 proxied request - got %d", proxied)
+	}
+
+	if got := userAgent.Load(); got != "backup-tool/1.0" {
+		t.Errorf("expected user agent backup-tool/1.0 - got %v", got)
+	}
+}
+
+func TestNew_WithHTTPClient(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	transport := &countingTransport{base: http.DefaultTransport}
+	httpClient := &http.Client{Transport: transport}
+
+	client, err := ceph.New(srv.CephServer(), ceph.WithHTTPClient(httpClient), ceph.WithTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests := atomic.LoadInt32(&transport.requests); requests != 2 {
+		t.Errorf("expected 2 requests sent with the http client - got %d", requests)
+	}
+
+	if httpClient.Transport != transport || httpClient.Timeout != 0 || httpClient.CheckRedirect != nil {
+		t.Error("expected passed http client to be unchanged")
+	}
+
+	server := srv.CephServer()
+	server.InsecureSkipVerify = true
+
+	if _, err = ceph.New(server, ceph.WithHTTPClient(httpClient)); !errors.Is(err, ceph.ErrHTTPClientConflict) {
+		t.Errorf("expected err %v - got %v", ceph.ErrHTTPClientConflict, err)
+	}
+}
+
+// countingTransport implements a http.RoundTripper counting the requests sent.
+type countingTransport struct {
+	base     http.RoundTripper
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return t.base.RoundTrip(req)
+}
+
+// cephServerFor returns the ceph.Server configuration for rawURL.
+func cephServerFor(t *testing.T, rawURL string) ceph.Server {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ceph.Server{Address: u.Hostname(), Port: uint(port), Protocol: u.Scheme, APIPath: cephtest.APIPath}
+}
+
+// newClientCertificate creates a self-signed client certificate.
+func newClientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ceph-rest-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
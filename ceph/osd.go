@@ -0,0 +1,521 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrOSDIDInvalid is returned if param id is negative.
+	ErrOSDIDInvalid = errors.New("param id must be >= 0")
+
+	// ErrOSDMarkActionInvalid is returned if param action is not one of the OSDMark* actions.
+	ErrOSDMarkActionInvalid = errors.New("param action must be in, out, down or lost")
+
+	// ErrOSDWeightInvalid is returned if param weight is not within 0 and 1.
+	ErrOSDWeightInvalid = errors.New("param weight must be within 0 and 1")
+
+	// ErrOSDFlagIsEmpty is returned if param flag is empty.
+	ErrOSDFlagIsEmpty = errors.New("param flag can not be empty")
+
+	// ErrOSDNotConfirmed is returned by DestroyOSD, PurgeOSD and DeleteOSD if param confirm does not match
+	// OSDConfirmation of the osd.
+	ErrOSDNotConfirmed = errors.New("param confirm does not match the osd")
+
+	// ErrOSDNotSafeToDestroy is returned by DestroyOSD and PurgeOSD if the mgr reports the osd still stores data.
+	ErrOSDNotSafeToDestroy = errors.New("osd is not safe to destroy")
+
+	// ErrOSDNotSafeToDelete is returned by DeleteOSD if the mgr reports removing the osd would make data unavailable.
+	ErrOSDNotSafeToDelete = errors.New("osd is not safe to delete")
+)
+
+// actions of MarkOSD. An osd can not be marked up, it is up as soon as its daemon is running again.
+const (
+	OSDMarkIn   = "in"
+	OSDMarkOut  = "out"
+	OSDMarkDown = "down"
+	OSDMarkLost = "lost"
+)
+
+// cluster-wide osd flags set with SetOSDFlags or SetOSDFlag
+const (
+	OSDFlagNoOut       = "noout"
+	OSDFlagNoIn        = "noin"
+	OSDFlagNoUp        = "noup"
+	OSDFlagNoDown      = "nodown"
+	OSDFlagNoRebalance = "norebalance"
+	OSDFlagNoBackfill  = "nobackfill"
+	OSDFlagNoRecover   = "norecover"
+	OSDFlagNoScrub     = "noscrub"
+	OSDFlagNoDeepScrub = "nodeep-scrub"
+	OSDFlagPause       = "pause"
+)
+
+// OSD implements struct returned from GET /api/osd.
+type OSD struct {
+	ID                int                    `json:"id"`
+	OSD               int                    `json:"osd"`
+	UUID              string                 `json:"uuid"`
+	Up                int                    `json:"up"`
+	In                int                    `json:"in"`
+	Weight            float64                `json:"weight"`
+	PrimaryAffinity   float64                `json:"primary_affinity"`
+	State             []string               `json:"state"`
+	PublicAddr        string                 `json:"public_addr"`
+	ClusterAddr       string                 `json:"cluster_addr"`
+	Host              OSDHost                `json:"host"`
+	Stats             OSDStats               `json:"stats"`
+	StatsHistory      map[string][][]float64 `json:"stats_history"`
+	Tree              OSDTree                `json:"tree"`
+	OsdType           string                 `json:"osd_type"`
+	OperationalStatus string                 `json:"operational_status"`
+}
+
+// IsUp checks if the osd is up.
+func (o OSD) IsUp() bool {
+	return o.Up == 1
+}
+
+// IsIn checks if the osd is in.
+func (o OSD) IsIn() bool {
+	return o.In == 1
+}
+
+// OSDHost implements the crush host of an osd.
+type OSDHost struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	TypeID   int    `json:"type_id"`
+	Children []int  `json:"children"`
+}
+
+// OSDStats implements the usage and the latest perf counters of an osd.
+type OSDStats struct {
+	OpR           float64 `json:"op_r"`
+	OpW           float64 `json:"op_w"`
+	OpInBytes     float64 `json:"op_in_bytes"`
+	OpOutBytes    float64 `json:"op_out_bytes"`
+	NumPG         int     `json:"numpg"`
+	StatBytes     uint64  `json:"stat_bytes"`
+	StatBytesUsed uint64  `json:"stat_bytes_used"`
+}
+
+// OSDTree implements the crush tree node of an osd.
+type OSDTree struct {
+	ID              int     `json:"id"`
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	TypeID          int     `json:"type_id"`
+	DeviceClass     string  `json:"device_class"`
+	CrushWeight     float64 `json:"crush_weight"`
+	Depth           int     `json:"depth"`
+	Exists          int     `json:"exists"`
+	Status          string  `json:"status"`
+	Reweight        float64 `json:"reweight"`
+	PrimaryAffinity float64 `json:"primary_affinity"`
+}
+
+// OSDDetail implements struct returned from GET /api/osd/{svc_id}. Histogram holds the perf histogram of the osd or
+// an error message if the osd is down.
+type OSDDetail struct {
+	OsdMap      OSD                    `json:"osd_map"`
+	OsdMetadata map[string]interface{} `json:"osd_metadata"`
+	Histogram   interface{}            `json:"histogram"`
+}
+
+// OSDSafeToDestroy implements struct returned from GET /api/osd/safe_to_destroy.
+type OSDSafeToDestroy struct {
+	IsSafeToDestroy bool   `json:"is_safe_to_destroy"`
+	SafeToDestroy   []int  `json:"safe_to_destroy"`
+	Active          []int  `json:"active"`
+	MissingStats    []int  `json:"missing_stats"`
+	StoredPgs       []int  `json:"stored_pgs"`
+	Message         string `json:"message"`
+}
+
+// OSDSafeToDelete implements struct returned from GET /api/osd/safe_to_delete.
+type OSDSafeToDelete struct {
+	IsSafeToDelete bool   `json:"is_safe_to_delete"`
+	Message        string `json:"message"`
+}
+
+// OSDConfirmation returns the confirmation DestroyOSD, PurgeOSD and DeleteOSD require for osd id, e.g. "osd.3".
+func OSDConfirmation(id int) string {
+	return fmt.Sprintf("osd.%d", id)
+}
+
+// ListOSDs gets a list of all osds with host, device class, usage and perf counters.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-osd
+func (c *Client) ListOSDs() (status int, osds []OSD, err error) {
+	return c.ListOSDsWithContext(context.Background())
+}
+
+// ListOSDsWithContext is like ListOSDs but aborts the request if ctx is done.
+func (c *Client) ListOSDsWithContext(ctx context.Context) (status int, osds []OSD, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&osds)
+
+	status, err = c.send(ctx, "ListOSDs", req, resty.MethodGet, "osd")
+
+	return status, osds, err
+}
+
+// GetOSD gets the osd map entry, the metadata and the perf histogram of osd id.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-osd-svc_id
+func (c *Client) GetOSD(id int) (status int, osd OSDDetail, err error) {
+	return c.GetOSDWithContext(context.Background(), id)
+}
+
+// GetOSDWithContext is like GetOSD but aborts the request if ctx is done.
+func (c *Client) GetOSDWithContext(ctx context.Context, id int) (status int, osd OSDDetail, err error) {
+	if id < 0 {
+		return 0, osd, ErrOSDIDInvalid
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&osd)
+
+	status, err = c.send(ctx, "GetOSD", req, resty.MethodGet, osdPath(id))
+
+	return status, osd, err
+}
+
+// MarkOSD marks osd id in, out, down or lost (see OSDMark*).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-osd-svc_id-mark
+func (c *Client) MarkOSD(id int, action string) (status int, err error) {
+	return c.MarkOSDWithContext(context.Background(), id, action)
+}
+
+// MarkOSDWithContext is like MarkOSD but aborts the request if ctx is done.
+func (c *Client) MarkOSDWithContext(ctx context.Context, id int, action string) (status int, err error) {
+	if id < 0 {
+		return 0, ErrOSDIDInvalid
+	}
+
+	switch action {
+	case OSDMarkIn, OSDMarkOut, OSDMarkDown, OSDMarkLost:
+	default:
+		return 0, ErrOSDMarkActionInvalid
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(map[string]string{"action": action})
+
+	return c.send(ctx, "MarkOSD", req, resty.MethodPut, osdPath(id, "mark"))
+}
+
+// ReweightOSD sets the override weight (0 to 1) of osd id.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-osd-svc_id-reweight
+func (c *Client) ReweightOSD(id int, weight float64) (status int, err error) {
+	return c.ReweightOSDWithContext(context.Background(), id, weight)
+}
+
+// ReweightOSDWithContext is like ReweightOSD but aborts the request if ctx is done.
+func (c *Client) ReweightOSDWithContext(ctx context.Context, id int, weight float64) (status int, err error) {
+	if id < 0 {
+		return 0, ErrOSDIDInvalid
+	}
+
+	if weight < 0 || weight > 1 {
+		return 0, ErrOSDWeightInvalid
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(map[string]float64{"weight": weight})
+
+	return c.send(ctx, "ReweightOSD", req, resty.MethodPost, osdPath(id, "reweight"))
+}
+
+// ScrubOSD instructs osd id to scrub, or to deep-scrub if deep is true.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-osd-svc_id-scrub
+func (c *Client) ScrubOSD(id int, deep bool) (status int, err error) {
+	return c.ScrubOSDWithContext(context.Background(), id, deep)
+}
+
+// ScrubOSDWithContext is like ScrubOSD but aborts the request if ctx is done.
+func (c *Client) ScrubOSDWithContext(ctx context.Context, id int, deep bool) (status int, err error) {
+	if id < 0 {
+		return 0, ErrOSDIDInvalid
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(map[string]bool{"deep": deep})
+
+	return c.send(ctx, "ScrubOSD", req, resty.MethodPost, osdPath(id, "scrub"))
+}
+
+// GetOSDFlags gets the cluster-wide osd flags, e.g. noout.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-osd-flags
+func (c *Client) GetOSDFlags() (status int, flags []string, err error) {
+	return c.GetOSDFlagsWithContext(context.Background())
+}
+
+// GetOSDFlagsWithContext is like GetOSDFlags but aborts the request if ctx is done.
+func (c *Client) GetOSDFlagsWithContext(ctx context.Context) (status int, flags []string, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&flags)
+
+	status, err = c.send(ctx, "GetOSDFlags", req, resty.MethodGet, "osd/flags")
+
+	return status, flags, err
+}
+
+// SetOSDFlags replaces the cluster-wide osd flags with flags: flags not listed are unset. The flags set on the mgr
+// are returned.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-osd-flags
+func (c *Client) SetOSDFlags(flags []string) (status int, set []string, err error) {
+	return c.SetOSDFlagsWithContext(context.Background(), flags)
+}
+
+// SetOSDFlagsWithContext is like SetOSDFlags but aborts the request if ctx is done.
+func (c *Client) SetOSDFlagsWithContext(ctx context.Context, flags []string) (status int, set []string, err error) {
+	if flags == nil {
+		flags = []string{}
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(map[string][]string{"flags": flags}).
+		SetResult(&set)
+
+	status, err = c.send(ctx, "SetOSDFlags", req, resty.MethodPut, "osd/flags")
+
+	return status, set, err
+}
+
+// SetOSDFlag sets (enabled is true) or unsets a single cluster-wide osd flag, e.g. SetOSDFlag(OSDFlagNoOut, true)
+// before a maintenance. The other flags are kept.
+func (c *Client) SetOSDFlag(flag string, enabled bool) (status int, err error) {
+	return c.SetOSDFlagWithContext(context.Background(), flag, enabled)
+}
+
+// SetOSDFlagWithContext is like SetOSDFlag but aborts the requests if ctx is done.
+func (c *Client) SetOSDFlagWithContext(ctx context.Context, flag string, enabled bool) (status int, err error) {
+	if flag == "" {
+		return 0, ErrOSDFlagIsEmpty
+	}
+
+	status, current, err := c.GetOSDFlagsWithContext(ctx)
+	if err != nil {
+		return status, err
+	}
+
+	flags := make([]string, 0, len(current)+1)
+	for _, f := range current {
+		if f != flag {
+			flags = append(flags, f)
+		}
+	}
+
+	if enabled {
+		flags = append(flags, flag)
+	}
+
+	status, _, err = c.SetOSDFlagsWithContext(ctx, flags)
+
+	return status, err
+}
+
+// SafeToDestroyOSD checks if the osds ids can be destroyed without reducing data durability.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-osd-safe_to_destroy
+func (c *Client) SafeToDestroyOSD(ids ...int) (status int, check OSDSafeToDestroy, err error) {
+	return c.SafeToDestroyOSDWithContext(context.Background(), ids...)
+}
+
+// SafeToDestroyOSDWithContext is like SafeToDestroyOSD but aborts the request if ctx is done.
+func (c *Client) SafeToDestroyOSDWithContext(ctx context.Context, ids ...int) (status int, check OSDSafeToDestroy, err error) {
+	for _, id := range ids {
+		if id < 0 {
+			return 0, check, ErrOSDIDInvalid
+		}
+	}
+
+	// the mgr expects a json list
+	list, err := json.Marshal(ids)
+	if err != nil {
+		return 0, check, err
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("ids", string(list)).
+		SetResult(&check)
+
+	status, err = c.send(ctx, "SafeToDestroyOSD", req, resty.MethodGet, "osd/safe_to_destroy")
+
+	return status, check, err
+}
+
+// SafeToDeleteOSD checks if the osds ids can be removed without making data unavailable.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-osd-safe_to_delete
+func (c *Client) SafeToDeleteOSD(ids ...int) (status int, check OSDSafeToDelete, err error) {
+	return c.SafeToDeleteOSDWithContext(context.Background(), ids...)
+}
+
+// SafeToDeleteOSDWithContext is like SafeToDeleteOSD but aborts the request if ctx is done.
+func (c *Client) SafeToDeleteOSDWithContext(ctx context.Context, ids ...int) (status int, check OSDSafeToDelete, err error) {
+	svcIDs := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		if id < 0 {
+			return 0, check, ErrOSDIDInvalid
+		}
+		svcIDs = append(svcIDs, strconv.Itoa(id))
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParamsFromValues(map[string][]string{"svc_ids": svcIDs}).
+		SetResult(&check)
+
+	status, err = c.send(ctx, "SafeToDeleteOSD", req, resty.MethodGet, "osd/safe_to_delete")
+
+	return status, check, err
+}
+
+// DestroyOSD marks osd id destroyed, keeping its id and crush entry to replace its disk. confirm must be
+// OSDConfirmation(id) and the mgr must report the osd safe to destroy (see SafeToDestroyOSD), the osd has to be down.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-osd-svc_id-destroy
+func (c *Client) DestroyOSD(id int, confirm string) (status int, err error) {
+	return c.DestroyOSDWithContext(context.Background(), id, confirm)
+}
+
+// DestroyOSDWithContext is like DestroyOSD but aborts the requests if ctx is done.
+func (c *Client) DestroyOSDWithContext(ctx context.Context, id int, confirm string) (status int, err error) {
+	return c.removeOSD(ctx, "DestroyOSD", id, confirm, "destroy")
+}
+
+// PurgeOSD removes osd id from the crush map, its auth key and its id. confirm must be OSDConfirmation(id) and the
+// mgr must report the osd safe to destroy (see SafeToDestroyOSD), the osd has to be down.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-osd-svc_id-purge
+func (c *Client) PurgeOSD(id int, confirm string) (status int, err error) {
+	return c.PurgeOSDWithContext(context.Background(), id, confirm)
+}
+
+// PurgeOSDWithContext is like PurgeOSD but aborts the requests if ctx is done.
+func (c *Client) PurgeOSDWithContext(ctx context.Context, id int, confirm string) (status int, err error) {
+	return c.removeOSD(ctx, "PurgeOSD", id, confirm, "purge")
+}
+
+// removeOSD checks confirm and the safety of osd id and posts action (destroy or purge).
+func (c *Client) removeOSD(ctx context.Context, op string, id int, confirm, action string) (status int, err error) {
+	if id < 0 {
+		return 0, ErrOSDIDInvalid
+	}
+
+	if confirm != OSDConfirmation(id) {
+		return 0, ErrOSDNotConfirmed
+	}
+
+	status, check, err := c.SafeToDestroyOSDWithContext(ctx, id)
+	if err != nil {
+		return status, err
+	}
+
+	if !check.IsSafeToDestroy {
+		return 0, fmt.Errorf("%w: %s", ErrOSDNotSafeToDestroy, check.Message)
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	return c.send(ctx, op, req, resty.MethodPost, osdPath(id, action))
+}
+
+// DeleteOSD removes osd id by the orchestrator, which drains the osd first. Set preserveID to keep the id for a
+// replacement disk and force to skip the safety check of the mgr. confirm must be OSDConfirmation(id) and, unless
+// force is set, the mgr must report the osd safe to delete (see SafeToDeleteOSD).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-osd-svc_id
+func (c *Client) DeleteOSD(id int, preserveID, force bool, confirm string) (status int, err error) {
+	return c.DeleteOSDWithContext(context.Background(), id, preserveID, force, confirm)
+}
+
+// DeleteOSDWithContext is like DeleteOSD but aborts the requests and the task wait if ctx is done.
+func (c *Client) DeleteOSDWithContext(ctx context.Context, id int, preserveID, force bool, confirm string) (status int, err error) {
+	if id < 0 {
+		return 0, ErrOSDIDInvalid
+	}
+
+	if confirm != OSDConfirmation(id) {
+		return 0, ErrOSDNotConfirmed
+	}
+
+	if !force {
+		var check OSDSafeToDelete
+
+		status, check, err = c.SafeToDeleteOSDWithContext(ctx, id)
+		if err != nil {
+			return status, err
+		}
+
+		if !check.IsSafeToDelete {
+			return 0, fmt.Errorf("%w: %s", ErrOSDNotSafeToDelete, check.Message)
+		}
+	}
+
+	err = c.retryTask(ctx, "DeleteOSD", func(ctx context.Context) error {
+		status, err = c.deleteOSD(ctx, id, preserveID, force)
+		return err
+	})
+
+	return status, err
+}
+
+// DeleteOSDAsync is like DeleteOSDWithContext but returns at once. The TaskFuture reports the progress of the
+// osd/delete task and holds the result once done.
+func (c *Client) DeleteOSDAsync(ctx context.Context, id int, preserveID, force bool, confirm string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.DeleteOSDWithContext(ctx, id, preserveID, force, confirm)
+	})
+}
+
+// deleteOSD submits the osd/delete task once and waits until it is done.
+func (c *Client) deleteOSD(ctx context.Context, id int, preserveID, force bool) (status int, err error) {
+	lookForTask := Task{
+		Name: "osd/delete",
+		MetaData: MetaData{
+			SvcID: strconv.Itoa(id),
+		},
+	}
+
+	req := c.Session.Client.R().
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("preserve_id", strconv.FormatBool(preserveID)).
+		SetQueryParam("force", strconv.FormatBool(force))
+
+	return c.submitTask(ctx, "DeleteOSD", req, resty.MethodDelete, c.Session.Server.getURL(osdPath(id)), lookForTask, http.StatusNoContent)
+}
+
+// osdPath returns the api path of osd id followed by elem, e.g. osd/3/mark.
+func osdPath(id int, elem ...string) string {
+	p := "osd/" + strconv.Itoa(id)
+
+	for _, e := range elem {
+		p += "/" + e
+	}
+
+	return p
+}
@@ -0,0 +1,199 @@
+package ceph_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_OSDInventoryAndOperations(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddOSD("node-1", "hdd")
+	id := srv.AddOSD("node-2", "ssd")
+
+	client := newLoggedInClient(t, srv)
+
+	status, osds, err := client.ListOSDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if len(osds) != 2 || osds[1].Host.Name != "node-2" || osds[1].Tree.DeviceClass != "ssd" || !osds[1].IsUp() {
+		t.Fatalf("expected 2 osds with osd.1 up on node-2 (ssd) - got %+v", osds)
+	}
+
+	if osds[1].Stats.StatBytes == 0 || osds[1].Stats.NumPG == 0 {
+		t.Errorf("expected usage and pgs of osd.1 - got %+v", osds[1].Stats)
+	}
+
+	status, err = client.ReweightOSD(id, 0.5)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	_, detail, err := client.GetOSD(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if detail.OsdMap.Weight != 0.5 || detail.OsdMetadata["hostname"] != "node-2" {
+		t.Errorf("expected osd.1 on node-2 with weight 0.5 - got %+v", detail)
+	}
+
+	if _, err = client.ReweightOSD(id, 1.5); err != ceph.ErrOSDWeightInvalid {
+		t.Errorf("expected err %v - got %v", ceph.ErrOSDWeightInvalid, err)
+	}
+
+	if status, err = client.ScrubOSD(id, true); err != nil || status != http.StatusCreated {
+		t.Errorf("expected deep-scrub with http state 201 - got %d %v", status, err)
+	}
+
+	if _, err = client.MarkOSD(id, "up"); err != ceph.ErrOSDMarkActionInvalid {
+		t.Errorf("expected err %v - got %v", ceph.ErrOSDMarkActionInvalid, err)
+	}
+
+	_, _, err = client.GetOSD(42)
+	if !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+}
+
+func TestClient_OSDFlags(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	client := newLoggedInClient(t, srv)
+
+	for _, flag := range []string{ceph.OSDFlagNoOut, ceph.OSDFlagNoRebalance} {
+		status, err := client.SetOSDFlag(flag, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status != http.StatusOK {
+			t.Errorf("expected http state 200 - got %d", status)
+		}
+	}
+
+	if _, err := client.SetOSDFlag(ceph.OSDFlagNoOut, false); err != nil {
+		t.Fatal(err)
+	}
+
+	_, flags, err := client.GetOSDFlags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	has := make(map[string]bool)
+	for _, flag := range flags {
+		has[flag] = true
+	}
+
+	if has[ceph.OSDFlagNoOut] || !has[ceph.OSDFlagNoRebalance] || !has["sortbitwise"] {
+		t.Errorf("expected norebalance and sortbitwise without noout - got %v", flags)
+	}
+}
+
+func TestClient_DestroyAndPurgeOSD(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddOSD("node-1", "hdd")
+	id := srv.AddOSD("node-1", "hdd")
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.MaxAttempts = 1
+
+	// wrong confirmation --> nothing is sent
+	if _, err := client.DestroyOSD(id, "osd.0"); err != ceph.ErrOSDNotConfirmed {
+		t.Errorf("expected err %v - got %v", ceph.ErrOSDNotConfirmed, err)
+	}
+
+	if requests := srv.RequestCount(http.MethodGet, "/api/osd/safe_to_destroy"); requests != 0 {
+		t.Errorf("expected no request without confirmation - got %d", requests)
+	}
+
+	// still stores pgs
+	if _, err := client.DestroyOSD(id, ceph.OSDConfirmation(id)); !errors.Is(err, ceph.ErrOSDNotSafeToDestroy) {
+		t.Errorf("expected err %v - got %v", ceph.ErrOSDNotSafeToDestroy, err)
+	}
+
+	if _, err := client.MarkOSD(id, ceph.OSDMarkOut); err != nil {
+		t.Fatal(err)
+	}
+
+	// out but still up
+	if _, err := client.PurgeOSD(id, ceph.OSDConfirmation(id)); !errors.Is(err, ceph.ErrBusy) {
+		t.Errorf("expected err %v - got %v", ceph.ErrBusy, err)
+	}
+
+	if _, err := client.MarkOSD(id, ceph.OSDMarkDown); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.DestroyOSD(id, ceph.OSDConfirmation(id))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	status, err = client.PurgeOSD(id, ceph.OSDConfirmation(id))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, osds, _ := client.ListOSDs(); len(osds) != 1 {
+		t.Errorf("expected 1 osd left - got %d", len(osds))
+	}
+}
+
+func TestClient_DeleteOSD(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	id := srv.AddOSD("node-1", "hdd")
+
+	client := newLoggedInClient(t, srv)
+
+	if _, err := client.DeleteOSD(id, false, false, ceph.OSDConfirmation(id)); !errors.Is(err, ceph.ErrOSDNotSafeToDelete) {
+		t.Errorf("expected err %v - got %v", ceph.ErrOSDNotSafeToDelete, err)
+	}
+
+	if _, err := client.MarkOSD(id, ceph.OSDMarkOut); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.DeleteOSDAsync(context.Background(), id, false, false, ceph.OSDConfirmation(id)).Wait(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, osds, _ := client.ListOSDs(); len(osds) != 0 {
+		t.Errorf("expected no osd left - got %d", len(osds))
+	}
+}
@@ -1 +1,441 @@
 package ceph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrPoolTypeIsInvalid is returned if param poolType is neither PoolTypeReplicated nor PoolTypeErasure.
+	ErrPoolTypeIsInvalid = errors.New("param poolType must be replicated or erasure")
+
+	// ErrPoolAlreadyExists is returned if pool to be created already exists.
+	ErrPoolAlreadyExists = errors.New("pool already exists")
+)
+
+const (
+	PoolAlreadyExists = "17"
+
+	PoolTypeReplicated = "replicated"
+	PoolTypeErasure    = "erasure"
+
+	PgAutoscaleModeOn   = "on"
+	PgAutoscaleModeOff  = "off"
+	PgAutoscaleModeWarn = "warn"
+
+	PoolApplicationRBD    = "rbd"
+	PoolApplicationCephFS = "cephfs"
+	PoolApplicationRGW    = "rgw"
+)
+
+// PoolStats implements struct for pool statistics returned from GET /api/pool?stats=true.
+type PoolStats struct {
+	Stored      PoolStatsValue `json:"stored"`
+	StoredData  PoolStatsValue `json:"stored_data"`
+	Objects     PoolStatsValue `json:"objects"`
+	MaxAvail    PoolStatsValue `json:"max_avail"`
+	BytesUsed   PoolStatsValue `json:"bytes_used"`
+	PercentUsed PoolStatsValue `json:"percent_used"`
+	Rd          PoolStatsValue `json:"rd"`
+	RdBytes     PoolStatsValue `json:"rd_bytes"`
+	Wr          PoolStatsValue `json:"wr"`
+	WrBytes     PoolStatsValue `json:"wr_bytes"`
+}
+
+// PoolStatsValue implements a single pool statistic value with its rate and recent history.
+type PoolStatsValue struct {
+	Latest float64     `json:"latest"`
+	Rate   float64     `json:"rate"`
+	Rates  [][]float64 `json:"rates"`
+}
+
+// Pool implements struct returned from GET /api/pool/{pool_name}
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-pool-pool_name.
+type Pool struct {
+	Pool                int                    `json:"pool"`
+	PoolName            string                 `json:"pool_name"`
+	Type                string                 `json:"type"`
+	Size                uint                   `json:"size"`
+	MinSize             uint                   `json:"min_size"`
+	CrushRule           string                 `json:"crush_rule"`
+	ErasureCodeProfile  string                 `json:"erasure_code_profile"`
+	Flags               int                    `json:"flags"`
+	FlagsNames          string                 `json:"flags_names"`
+	PgNum               uint                   `json:"pg_num"`
+	PgPlacementNum      uint                   `json:"pg_placement_num"`
+	PgNumTarget         uint                   `json:"pg_num_target"`
+	PgAutoscaleMode     string                 `json:"pg_autoscale_mode"`
+	QuotaMaxBytes       uint64                 `json:"quota_max_bytes"`
+	QuotaMaxObjects     uint64                 `json:"quota_max_objects"`
+	TargetSizeBytes     uint64                 `json:"target_size_bytes"`
+	ApplicationMetadata []string               `json:"application_metadata"`
+	Options             map[string]interface{} `json:"options"`
+	Configuration       []RBDConfiguration     `json:"configuration"`
+	Stats               *PoolStats             `json:"stats"`
+}
+
+// PoolCreate implements struct send to ceph for pool creation on POST /api/pool.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-pool
+type PoolCreate struct {
+	Pool                     string        `json:"pool"`
+	PoolType                 string        `json:"pool_type"`
+	PgNum                    uint          `json:"pg_num,omitempty"`
+	PgAutoscaleMode          string        `json:"pg_autoscale_mode,omitempty"`
+	Size                     uint          `json:"size,omitempty"`
+	MinSize                  uint          `json:"min_size,omitempty"`
+	RuleName                 string        `json:"rule_name,omitempty"`
+	ErasureCodeProfile       string        `json:"erasure_code_profile,omitempty"`
+	Flags                    []string      `json:"flags,omitempty"`
+	ApplicationMetadata      []string      `json:"application_metadata,omitempty"`
+	QuotaMaxBytes            *uint64       `json:"quota_max_bytes,omitempty"`
+	QuotaMaxObjects          *uint64       `json:"quota_max_objects,omitempty"`
+	CompressionMode          string        `json:"compression_mode,omitempty"`
+	CompressionAlgorithm     string        `json:"compression_algorithm,omitempty"`
+	CompressionMinBlobSize   *uint64       `json:"compression_min_blob_size,omitempty"`
+	CompressionMaxBlobSize   *uint64       `json:"compression_max_blob_size,omitempty"`
+	CompressionRequiredRatio *float64      `json:"compression_required_ratio,omitempty"`
+	Configuration            *RBDQosConfig `json:"configuration,omitempty"`
+}
+
+// PoolUpdate implements struct send to ceph for pool updates on PUT /api/pool/{pool_name}.
+// Set Pool to rename the pool.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-pool-pool_name
+type PoolUpdate struct {
+	Pool                     string        `json:"pool,omitempty"`
+	PgNum                    uint          `json:"pg_num,omitempty"`
+	PgAutoscaleMode          string        `json:"pg_autoscale_mode,omitempty"`
+	Size                     uint          `json:"size,omitempty"`
+	MinSize                  uint          `json:"min_size,omitempty"`
+	Flags                    []string      `json:"flags,omitempty"`
+	ApplicationMetadata      []string      `json:"application_metadata,omitempty"`
+	QuotaMaxBytes            *uint64       `json:"quota_max_bytes,omitempty"`
+	QuotaMaxObjects          *uint64       `json:"quota_max_objects,omitempty"`
+	CompressionMode          string        `json:"compression_mode,omitempty"`
+	CompressionAlgorithm     string        `json:"compression_algorithm,omitempty"`
+	CompressionMinBlobSize   *uint64       `json:"compression_min_blob_size,omitempty"`
+	CompressionMaxBlobSize   *uint64       `json:"compression_max_blob_size,omitempty"`
+	CompressionRequiredRatio *float64      `json:"compression_required_ratio,omitempty"`
+	Configuration            *RBDQosConfig `json:"configuration,omitempty"`
+}
+
+// ListPools gets a list of all pools, with statistics if stats is true.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-pool
+func (c *Client) ListPools(stats bool) (status int, pools []Pool, err error) {
+	return c.ListPoolsWithContext(context.Background(), stats)
+}
+
+// ListPoolsWithContext is like ListPools but aborts the request if ctx is done.
+func (c *Client) ListPoolsWithContext(ctx context.Context, stats bool) (status int, pools []Pool, err error) {
+	var resp *resty.Response
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("stats", strconv.FormatBool(stats)).
+		SetResult(&pools)
+
+	resp, err = c.execute("ListPools", req, resty.MethodGet, c.Session.Server.getURL("pool"))
+
+	if err != nil {
+		return 0, nil, ctxErr(ctx, "ListPools", err)
+	}
+
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), nil, newAPIError(resp)
+	}
+
+	return resp.StatusCode(), pools, err
+}
+
+// GetPool gets a pool by name, with statistics if stats is true.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-pool-pool_name
+func (c *Client) GetPool(poolName string, stats bool) (status int, pool Pool, err error) {
+	return c.GetPoolWithContext(context.Background(), poolName, stats)
+}
+
+// GetPoolWithContext is like GetPool but aborts the request if ctx is done.
+func (c *Client) GetPoolWithContext(ctx context.Context, poolName string, stats bool) (status int, pool Pool, err error) {
+	var resp *resty.Response
+
+	if poolName == "" {
+		return 0, pool, ErrPoolNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("stats", strconv.FormatBool(stats)).
+		SetResult(&pool)
+
+	resp, err = c.execute("GetPool", req, resty.MethodGet, c.Session.Server.getURL(fmt.Sprintf("pool/%s", url.QueryEscape(poolName))))
+
+	if err != nil {
+		return 0, pool, ctxErr(ctx, "GetPool", err)
+	}
+
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), pool, newAPIError(resp)
+	}
+
+	return resp.StatusCode(), pool, err
+}
+
+// CreatePool creates a replicated or erasure coded pool.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-pool
+func (c *Client) CreatePool(poolCreate PoolCreate) (status int, err error) {
+	return c.CreatePoolWithContext(context.Background(), poolCreate)
+}
+
+// CreatePoolWithContext is like CreatePool but aborts the request and the task wait if ctx is done.
+func (c *Client) CreatePoolWithContext(ctx context.Context, poolCreate PoolCreate) (status int, err error) {
+	err = c.retryTask(ctx, "CreatePool", func(ctx context.Context) error {
+		status, err = c.createPool(ctx, poolCreate)
+		return err
+	})
+
+	return status, err
+}
+
+// CreatePoolAsync is like CreatePoolWithContext but returns at once. The TaskFuture reports the progress of the
+// pool/create task and holds the result once done.
+func (c *Client) CreatePoolAsync(ctx context.Context, poolCreate PoolCreate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.CreatePoolWithContext(ctx, poolCreate)
+	})
+}
+
+// createPool submits the pool/create task once and waits until it is done.
+func (c *Client) createPool(ctx context.Context, poolCreate PoolCreate) (status int, err error) {
+
+	if poolCreate.Pool == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	if poolCreate.PoolType != PoolTypeReplicated && poolCreate.PoolType != PoolTypeErasure {
+		return 0, ErrPoolTypeIsInvalid
+	}
+
+	var (
+		resp *resty.Response
+	)
+
+	lookForTask := Task{
+		Name: "pool/create",
+		MetaData: MetaData{
+			PoolName: poolCreate.Pool, // only PoolName is set on pool tasks
+		},
+	}
+
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(poolCreate)
+
+	resp, err = c.execute("CreatePool", req, resty.MethodPost, c.Session.Server.getURL("pool"))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "CreatePool", err)
+	}
+
+	if !resp.IsSuccess() {
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == PoolAlreadyExists {
+			apiErr.Err = ErrPoolAlreadyExists
+		}
+
+		return resp.StatusCode(), apiErr
+	}
+
+	status = resp.StatusCode()
+
+	switch status {
+	case http.StatusCreated, http.StatusAccepted:
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
+		if err != nil {
+			return 0, ctxErr(ctx, "CreatePool", err)
+		}
+
+		if !lookForTask.Success {
+			return 0, newTaskError(lookForTask)
+		}
+
+		status = http.StatusCreated
+	}
+
+	return status, nil
+}
+
+// UpdatePool updates a pool (pg_num, applications, quotas, compression, name et al).
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-pool-pool_name
+func (c *Client) UpdatePool(poolName string, poolUpdate PoolUpdate) (status int, err error) {
+	return c.UpdatePoolWithContext(context.Background(), poolName, poolUpdate)
+}
+
+// UpdatePoolWithContext is like UpdatePool but aborts the request and the task wait if ctx is done.
+func (c *Client) UpdatePoolWithContext(ctx context.Context, poolName string, poolUpdate PoolUpdate) (status int, err error) {
+	err = c.retryTask(ctx, "UpdatePool", func(ctx context.Context) error {
+		status, err = c.updatePool(ctx, poolName, poolUpdate)
+		return err
+	})
+
+	return status, err
+}
+
+// UpdatePoolAsync is like UpdatePoolWithContext but returns at once. The TaskFuture reports the progress of the
+// pool/edit task and holds the result once done.
+func (c *Client) UpdatePoolAsync(ctx context.Context, poolName string, poolUpdate PoolUpdate) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.UpdatePoolWithContext(ctx, poolName, poolUpdate)
+	})
+}
+
+// updatePool submits the pool/edit task once and waits until it is done.
+func (c *Client) updatePool(ctx context.Context, poolName string, poolUpdate PoolUpdate) (status int, err error) {
+
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	var (
+		resp *resty.Response
+	)
+
+	lookForTask := Task{
+		Name: "pool/edit",
+		MetaData: MetaData{
+			PoolName: poolName,
+		},
+	}
+
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(poolUpdate)
+
+	resp, err = c.execute("UpdatePool", req, resty.MethodPut, c.Session.Server.getURL(fmt.Sprintf("pool/%s", url.QueryEscape(poolName))))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "UpdatePool", err)
+	}
+
+	if !resp.IsSuccess() {
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		if apiErr.Code == PoolAlreadyExists {
+			apiErr.Err = ErrPoolAlreadyExists
+		}
+
+		return resp.StatusCode(), apiErr
+	}
+
+	status = resp.StatusCode()
+
+	switch status {
+	case http.StatusAccepted, http.StatusOK:
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
+
+		if err != nil {
+			return 0, ctxErr(ctx, "UpdatePool", err)
+		}
+
+		if !lookForTask.Success {
+			return 0, newTaskError(lookForTask)
+		}
+
+		status = http.StatusOK
+		err = nil
+	}
+
+	return status, err
+}
+
+// DeletePool deletes a pool including all images and namespaces stored in it.
+// Attention: the ceph option mon_allow_pool_delete must be enabled.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-pool-pool_name
+func (c *Client) DeletePool(poolName string) (status int, err error) {
+	return c.DeletePoolWithContext(context.Background(), poolName)
+}
+
+// DeletePoolWithContext is like DeletePool but aborts the request and the task wait if ctx is done.
+func (c *Client) DeletePoolWithContext(ctx context.Context, poolName string) (status int, err error) {
+	err = c.retryTask(ctx, "DeletePool", func(ctx context.Context) error {
+		status, err = c.deletePool(ctx, poolName)
+		return err
+	})
+
+	return status, err
+}
+
+// DeletePoolAsync is like DeletePoolWithContext but returns at once. The TaskFuture reports the progress of the
+// pool/delete task and holds the result once done.
+func (c *Client) DeletePoolAsync(ctx context.Context, poolName string) *TaskFuture {
+	return c.async(ctx, func(ctx context.Context) (int, error) {
+		return c.DeletePoolWithContext(ctx, poolName)
+	})
+}
+
+// deletePool submits the pool/delete task once and waits until it is done.
+func (c *Client) deletePool(ctx context.Context, poolName string) (status int, err error) {
+
+	if poolName == "" {
+		return 0, ErrPoolNameIsEmpty
+	}
+
+	var resp *resty.Response
+
+	lookForTask := Task{
+		Name: "pool/delete",
+		MetaData: MetaData{
+			PoolName: poolName,
+		},
+	}
+
+	ctx = withTaskLogFields(ctx, lookForTask)
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	resp, err = c.execute("DeletePool", req, resty.MethodDelete, c.Session.Server.getURL(fmt.Sprintf("pool/%s", url.QueryEscape(poolName))))
+
+	if err != nil {
+		return 0, ctxErr(ctx, "DeletePool", err)
+	}
+
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), newAPIError(resp)
+	}
+
+	status = resp.StatusCode()
+
+	switch status {
+	case http.StatusAccepted, http.StatusNoContent:
+		lookForTask, err = c.WaitForTaskIsDoneWithContext(ctx, lookForTask)
+
+		if err != nil {
+			return 0, ctxErr(ctx, "DeletePool", err)
+		}
+
+		if !lookForTask.Success {
+			return 0, newTaskError(lookForTask)
+		}
+
+		status = http.StatusNoContent
+		err = nil
+	}
+
+	return status, err
+}
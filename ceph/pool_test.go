@@ -0,0 +1,95 @@
+package ceph_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+func TestClient_CreateUpdateDeletePool(t *testing.T) {
+	client, err := ceph.New(getServer())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusLogin, errLogin := client.Session.Login(username, password)
+	if errLogin != nil {
+		t.Error(errLogin)
+	}
+
+	if statusLogin != http.StatusCreated {
+		t.Fatalf("could not login - expected http state 201 - got %d", statusLogin)
+	}
+
+	var poolName = fmt.Sprintf("test-pool-%d", time.Now().Unix())
+
+	status, err := client.CreatePool(ceph.PoolCreate{
+		Pool:                poolName,
+		PoolType:            ceph.PoolTypeReplicated,
+		PgNum:               16,
+		PgAutoscaleMode:     ceph.PgAutoscaleModeOn,
+		ApplicationMetadata: []string{ceph.PoolApplicationRBD},
+	})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	var quota uint64 = 1073741824
+
+	status, err = client.UpdatePool(poolName, ceph.PoolUpdate{QuotaMaxBytes: &quota})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	status, pool, err := client.GetPool(poolName, false)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if pool.QuotaMaxBytes != quota {
+		t.Errorf("expected quota_max_bytes %d - got %d", quota, pool.QuotaMaxBytes)
+	}
+
+	status, err = client.DeletePool(poolName)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+}
+
+func TestClient_CreatePoolInvalidType(t *testing.T) {
+	client, err := ceph.New(getServer())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CreatePool(ceph.PoolCreate{Pool: "test-pool-invalid", PoolType: "mirrored"})
+
+	if err != ceph.ErrPoolTypeIsInvalid {
+		t.Errorf("expected err %v - got %v", ceph.ErrPoolTypeIsInvalid, err)
+	}
+}
@@ -0,0 +1,501 @@
+// Package reconcile brings the rbd namespaces and images of a pool to a desired state.
+//
+// A Reconciler compares a Spec with the pool and computes a Plan of the steps needed: creating namespaces and images,
+// resizing images, updating their features and qos limits and deleting orphans, i.e. namespaces and images not in
+// the Spec. The Plan can be printed (dry run) and applied; applying it again or after a failure is safe, as steps
+// already done are skipped.
+//
+//	r := reconcile.New(client)
+//
+//	plan, err := r.Plan(ctx, reconcile.Spec{Pool: "rbd", Namespaces: []reconcile.Namespace{{Name: "tenant-1",
+//		Images: []reconcile.Image{{Name: "vol-1", Size: 10 << 30}}}}})
+//	if err != nil {
+//		return err
+//	}
+//
+//	fmt.Print(plan)
+//
+//	err = r.Apply(ctx, plan)
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+var (
+	// ErrImageSizeIsZero is returned by Plan if the desired size of an image is 0.
+	ErrImageSizeIsZero = errors.New("image size can not be 0")
+
+	// ErrImageShrink is returned by Plan if an image is larger than desired. Images are never shrunk, as shrinking
+	// loses the data at the end of the image.
+	ErrImageShrink = errors.New("image can not be shrunk")
+
+	// ErrDuplicateName is returned by Plan if a namespace or an image is listed twice in the Spec.
+	ErrDuplicateName = errors.New("name is listed twice")
+)
+
+// Action implements the kind of change a Step makes.
+type Action string
+
+// actions of a Step. A Plan creates and updates the namespaces and images namespace by namespace in the order of the
+// Spec, deletions of orphans come last.
+const (
+	ActionCreateNamespace Action = "create-namespace"
+	ActionCreateImage     Action = "create-image"
+	ActionResizeImage     Action = "resize-image"
+	ActionUpdateFeatures  Action = "update-features"
+	ActionUpdateQos       Action = "update-qos"
+	ActionDeleteImage     Action = "delete-image"
+	ActionDeleteNamespace Action = "delete-namespace"
+)
+
+// Image implements the desired state of an rbd image. Features nil keeps the features of an existing image and
+// creates the image with the default features, Qos nil keeps the qos limits.
+type Image struct {
+	Name     string
+	Size     uint64
+	Features []string
+	Qos      *ceph.RBDQosConfig
+}
+
+// Namespace implements the desired state of an rbd namespace and its images. The empty name is the default namespace
+// of the pool.
+type Namespace struct {
+	Name   string
+	Images []Image
+}
+
+// Spec implements the desired state of the rbd namespaces and images of a pool. The Spec owns the pool: namespaces
+// and images not listed are orphans, which are deleted only if DeleteOrphans is set. The default namespace always
+// exists, its images are orphans unless listed.
+type Spec struct {
+	Pool          string
+	Namespaces    []Namespace
+	DeleteOrphans bool
+}
+
+// Step implements a single change of a Plan. Size, Features and Qos hold the desired values of the image, the
+// current values are kept for the dry-run output.
+type Step struct {
+	Action    Action
+	Pool      string
+	Namespace string
+	Image     string
+
+	Size     uint64
+	Features []string
+	Qos      *ceph.RBDQosConfig
+
+	CurrentSize     uint64
+	CurrentFeatures []string
+	CurrentQos      *ceph.RBDQosConfig
+}
+
+// Spec returns the image spec (pool/namespace/image) or the namespace spec (pool/namespace) the step changes.
+func (s Step) Spec() string {
+	return ceph.PathJoin(s.Pool, s.Namespace, s.Image)
+}
+
+// String implements fmt.Stringer.
+func (s Step) String() string {
+	switch s.Action {
+	case ActionCreateNamespace:
+		return fmt.Sprintf("create namespace %s", s.Spec())
+	case ActionCreateImage:
+		desc := fmt.Sprintf("create image %s with size %s", s.Spec(), formatSize(s.Size))
+		if s.Features != nil {
+			desc += fmt.Sprintf(", features %v", s.Features)
+		}
+
+		if s.Qos != nil {
+			desc += ", qos " + formatQos(*s.Qos, nil)
+		}
+
+		return desc
+	case ActionResizeImage:
+		return fmt.Sprintf("resize image %s from %s to %s", s.Spec(), formatSize(s.CurrentSize), formatSize(s.Size))
+	case ActionUpdateFeatures:
+		return fmt.Sprintf("update features of image %s from %v to %v", s.Spec(), s.CurrentFeatures, s.Features)
+	case ActionUpdateQos:
+		return fmt.Sprintf("update qos of image %s: %s", s.Spec(), formatQos(*s.Qos, s.CurrentQos))
+	case ActionDeleteImage:
+		return fmt.Sprintf("delete image %s", s.Spec())
+	case ActionDeleteNamespace:
+		return fmt.Sprintf("delete namespace %s", s.Spec())
+	}
+
+	return fmt.Sprintf("%s %s", s.Action, s.Spec())
+}
+
+// Plan implements the steps bringing a pool to the state of a Spec. Orphans holds the deletions left out as the
+// Spec does not allow to delete orphans.
+type Plan struct {
+	Steps   []Step
+	Orphans []Step
+}
+
+// Empty tells whether the pool is in the desired state already.
+func (p Plan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+// String implements fmt.Stringer and returns the dry-run output of the plan, one step per line.
+func (p Plan) String() string {
+	var b strings.Builder
+
+	if p.Empty() {
+		b.WriteString("no changes\n")
+	}
+
+	for _, step := range p.Steps {
+		fmt.Fprintf(&b, "%s\n", step)
+	}
+
+	for _, step := range p.Orphans {
+		fmt.Fprintf(&b, "keep orphan: %s (deleting orphans is not allowed)\n", step)
+	}
+
+	return b.String()
+}
+
+// Reconciler implements Plan and Apply with a logged-in ceph client.
+type Reconciler struct {
+	client *ceph.Client
+}
+
+// New returns a Reconciler using client, which must be logged in.
+func New(client *ceph.Client) *Reconciler {
+	return &Reconciler{client: client}
+}
+
+// Plan compares spec with the namespaces and images of spec.Pool and returns the steps needed to reach spec.
+func (r *Reconciler) Plan(ctx context.Context, spec Spec) (plan Plan, err error) {
+	if err = validate(spec); err != nil {
+		return plan, err
+	}
+
+	_, namespaces, err := r.client.GetBlockNameSpaceListInPoolWithContext(ctx, spec.Pool)
+	if err != nil {
+		return plan, err
+	}
+
+	_, rbdList, err := r.client.ListBlockImageWithContext(ctx, spec.Pool)
+	if err != nil {
+		return plan, err
+	}
+
+	existingNamespaces := map[string]bool{"": true}
+	for _, ns := range namespaces {
+		existingNamespaces[ns.NameSpace] = true
+	}
+
+	existingImages := make(map[string]ceph.RBD)
+	for _, pool := range rbdList {
+		if pool.PoolName != spec.Pool {
+			continue
+		}
+
+		for _, image := range pool.Value {
+			existingImages[ceph.PathJoin(image.Namespace, image.Name)] = image
+		}
+	}
+
+	desiredNamespaces := make(map[string]bool)
+	desiredImages := make(map[string]bool)
+
+	for _, ns := range spec.Namespaces {
+		desiredNamespaces[ns.Name] = true
+
+		if !existingNamespaces[ns.Name] {
+			plan.Steps = append(plan.Steps, Step{Action: ActionCreateNamespace, Pool: spec.Pool, Namespace: ns.Name})
+		}
+
+		for _, image := range ns.Images {
+			key := ceph.PathJoin(ns.Name, image.Name)
+			desiredImages[key] = true
+
+			current, exists := existingImages[key]
+			if !exists {
+				plan.Steps = append(plan.Steps, Step{
+					Action: ActionCreateImage, Pool: spec.Pool, Namespace: ns.Name, Image: image.Name,
+					Size: image.Size, Features: image.Features, Qos: image.Qos,
+				})
+
+				continue
+			}
+
+			steps, err := updateSteps(spec.Pool, ns.Name, image, current)
+			if err != nil {
+				return Plan{}, err
+			}
+
+			plan.Steps = append(plan.Steps, steps...)
+		}
+	}
+
+	var orphans []Step
+
+	keys := make([]string, 0, len(existingImages))
+	for key := range existingImages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if desiredImages[key] {
+			continue
+		}
+
+		image := existingImages[key]
+
+		var nameSpace string
+		if image.Namespace != nil {
+			nameSpace = *image.Namespace
+		}
+
+		orphans = append(orphans, Step{Action: ActionDeleteImage, Pool: spec.Pool, Namespace: nameSpace, Image: image.Name})
+	}
+
+	for _, ns := range namespaces {
+		if !desiredNamespaces[ns.NameSpace] {
+			orphans = append(orphans, Step{Action: ActionDeleteNamespace, Pool: spec.Pool, Namespace: ns.NameSpace})
+		}
+	}
+
+	if spec.DeleteOrphans {
+		plan.Steps = append(plan.Steps, orphans...)
+	} else {
+		plan.Orphans = orphans
+	}
+
+	return plan, nil
+}
+
+// Apply runs the steps of plan in order and stops at the first failing step. Steps already done, e.g. by an earlier
+// Apply of the same plan, succeed. To continue after a failure, compute a new plan or apply plan again.
+func (r *Reconciler) Apply(ctx context.Context, plan Plan) error {
+	for _, step := range plan.Steps {
+		if err := r.apply(ctx, step); err != nil {
+			return fmt.Errorf("%s: %w", step, err)
+		}
+	}
+
+	return nil
+}
+
+// Reconcile computes the plan for spec and applies it unless dryRun is set. The plan is returned in both cases.
+func (r *Reconciler) Reconcile(ctx context.Context, spec Spec, dryRun bool) (Plan, error) {
+	plan, err := r.Plan(ctx, spec)
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	return plan, r.Apply(ctx, plan)
+}
+
+// apply runs step, ignoring errors telling the step is done already.
+func (r *Reconciler) apply(ctx context.Context, step Step) error {
+	var (
+		err       error
+		nameSpace *string
+	)
+
+	if step.Namespace != "" {
+		nameSpace = &step.Namespace
+	}
+
+	switch step.Action {
+	case ActionCreateNamespace:
+		if _, err = r.client.CreateBlockNameSpaceInPoolWithContext(ctx, step.Pool, step.Namespace); errors.Is(err, ceph.ErrAlreadyExists) {
+			err = nil
+		}
+	case ActionCreateImage:
+		_, err = r.client.CreateBlockImageWithContext(ctx, ceph.RBDCreate{
+			PoolName:      step.Pool,
+			Namespace:     nameSpace,
+			Name:          step.Image,
+			Size:          step.Size,
+			Features:      step.Features,
+			Configuration: step.Qos,
+		})
+		if errors.Is(err, ceph.ErrAlreadyExists) {
+			err = nil
+		}
+	case ActionResizeImage:
+		_, err = r.client.UpdateBlockImageWithContext(ctx, step.Pool, nameSpace, step.Image, ceph.RBDUpdate{Name: step.Image, Size: int64(step.Size)})
+	case ActionUpdateFeatures:
+		_, err = r.client.UpdateBlockImageWithContext(ctx, step.Pool, nameSpace, step.Image, ceph.RBDUpdate{Name: step.Image, Features: step.Features})
+	case ActionUpdateQos:
+		_, err = r.client.SetBlockImageQosWithContext(ctx, step.Pool, nameSpace, step.Image, *step.Qos)
+	case ActionDeleteImage:
+		if _, err = r.client.DeleteBlockImageWithContext(ctx, step.Pool, nameSpace, step.Image); errors.Is(err, ceph.ErrNotFound) {
+			err = nil
+		}
+	case ActionDeleteNamespace:
+		if _, err = r.client.DeleteBlockNameSpaceInPoolWithContext(ctx, step.Pool, step.Namespace); errors.Is(err, ceph.ErrNotFound) {
+			err = nil
+		}
+	default:
+		err = fmt.Errorf("unknown action %q", step.Action)
+	}
+
+	return err
+}
+
+// updateSteps returns the steps changing the existing image current to image.
+func updateSteps(pool, nameSpace string, image Image, current ceph.RBD) ([]Step, error) {
+	var steps []Step
+
+	step := Step{Pool: pool, Namespace: nameSpace, Image: image.Name}
+
+	switch {
+	case image.Size < current.Size:
+		return nil, fmt.Errorf("%w: %s has %s, desired %s", ErrImageShrink, step.Spec(), formatSize(current.Size), formatSize(image.Size))
+	case image.Size > current.Size:
+		resize := step
+		resize.Action = ActionResizeImage
+		resize.Size = image.Size
+		resize.CurrentSize = current.Size
+		steps = append(steps, resize)
+	}
+
+	if image.Features != nil && !sameFeatures(image.Features, current.FeaturesName) {
+		update := step
+		update.Action = ActionUpdateFeatures
+		update.Features = image.Features
+		update.CurrentFeatures = current.FeaturesName
+		steps = append(steps, update)
+	}
+
+	if image.Qos != nil {
+		if currentQos := current.QosConfig(); currentQos != *image.Qos {
+			update := step
+			update.Action = ActionUpdateQos
+			update.Qos = image.Qos
+			update.CurrentQos = &currentQos
+			steps = append(steps, update)
+		}
+	}
+
+	return steps, nil
+}
+
+// validate checks the names and sizes of spec.
+func validate(spec Spec) error {
+	if spec.Pool == "" {
+		return ceph.ErrPoolNameIsEmpty
+	}
+
+	namespaces := make(map[string]bool)
+
+	for _, ns := range spec.Namespaces {
+		if namespaces[ns.Name] {
+			return fmt.Errorf("%w: namespace %q", ErrDuplicateName, ns.Name)
+		}
+		namespaces[ns.Name] = true
+
+		images := make(map[string]bool)
+
+		for _, image := range ns.Images {
+			imageSpec := ceph.PathJoin(spec.Pool, ns.Name, image.Name)
+
+			if image.Name == "" {
+				return ceph.ErrImageNameIsEmpty
+			}
+
+			if images[image.Name] {
+				return fmt.Errorf("%w: image %s", ErrDuplicateName, imageSpec)
+			}
+			images[image.Name] = true
+
+			if image.Size == 0 {
+				return fmt.Errorf("%w: image %s", ErrImageSizeIsZero, imageSpec)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameFeatures tells whether a and b hold the same features in any order.
+func sameFeatures(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sorted := func(features []string) []string {
+		s := append([]string{}, features...)
+		sort.Strings(s)
+		return s
+	}
+
+	sa, sb := sorted(a), sorted(b)
+	for i := range sa {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatSize formats size in the largest binary unit dividing it, e.g. 10 GiB.
+func formatSize(size uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+	i := 0
+	for size >= 1024 && size%1024 == 0 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+
+	return fmt.Sprintf("%d %s", size, units[i])
+}
+
+// formatQos formats the limits of qos differing from current (all non-zero limits if current is nil), e.g.
+// rbd_qos_iops_limit 0 -> 1000.
+func formatQos(qos ceph.RBDQosConfig, current *ceph.RBDQosConfig) string {
+	values := func(qos ceph.RBDQosConfig) map[string]uint64 {
+		var m map[string]uint64
+		b, _ := json.Marshal(qos)
+		_ = json.Unmarshal(b, &m)
+		return m
+	}
+
+	desired := values(qos)
+
+	var old map[string]uint64
+	if current != nil {
+		old = values(*current)
+	}
+
+	names := make([]string, 0, len(desired))
+	for name, v := range desired {
+		if (current == nil && v != 0) || (current != nil && old[name] != v) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if current == nil {
+			parts = append(parts, fmt.Sprintf("%s %d", name, desired[name]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d -> %d", name, old[name], desired[name]))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "unlimited"
+	}
+
+	return strings.Join(parts, ", ")
+}
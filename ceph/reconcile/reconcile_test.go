@@ -0,0 +1,189 @@
+package reconcile_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+	"github.com/chrisamti/ceph-rest-client/ceph/reconcile"
+)
+
+// newLoggedInClient returns a client logged in to srv.
+func newLoggedInClient(t *testing.T, srv *cephtest.Server) *ceph.Client {
+	t.Helper()
+
+	client, err := ceph.New(srv.CephServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// actions returns the actions of steps.
+func actions(steps []reconcile.Step) []reconcile.Action {
+	list := make([]reconcile.Action, 0, len(steps))
+	for _, step := range steps {
+		list = append(list, step.Action)
+	}
+
+	return list
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+	ctx := context.Background()
+
+	// an image and a namespace not in the spec
+	if _, err := client.CreateBlockNameSpaceInPool("test-pool-1", "tenant-old"); err != nil {
+		t.Fatal(err)
+	}
+
+	old := "tenant-old"
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Namespace: &old, Name: "vol-old", Size: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := reconcile.Spec{
+		Pool: "test-pool-1",
+		Namespaces: []reconcile.Namespace{{
+			Name: "tenant-1",
+			Images: []reconcile.Image{
+				{Name: "vol-1", Size: 1 << 30},
+				{Name: "vol-2", Size: 2 << 30, Features: []string{"layering", "exclusive-lock"}, Qos: &ceph.RBDQosConfig{RbdQosIopsLimit: 500}},
+			},
+		}},
+	}
+
+	r := reconcile.New(client)
+
+	plan, err := r.Reconcile(ctx, spec, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []reconcile.Action{reconcile.ActionCreateNamespace, reconcile.ActionCreateImage, reconcile.ActionCreateImage}
+	if got := actions(plan.Steps); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected steps %v - got %v", expected, got)
+	}
+
+	if got := actions(plan.Orphans); len(got) != 2 || got[0] != reconcile.ActionDeleteImage || got[1] != reconcile.ActionDeleteNamespace {
+		t.Errorf("expected orphan image and namespace - got %v", got)
+	}
+
+	out := plan.String()
+	for _, line := range []string{
+		"create namespace test-pool-1/tenant-1\n",
+		"create image test-pool-1/tenant-1/vol-2 with size 2 GiB, features [layering exclusive-lock], qos rbd_qos_iops_limit 500\n",
+		"keep orphan: delete image test-pool-1/tenant-old/vol-old (deleting orphans is not allowed)\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected dry-run output to contain %q - got\n%s", line, out)
+		}
+	}
+
+	// the dry run changed nothing
+	if _, namespaces, _ := client.GetBlockNameSpaceListInPool("test-pool-1"); len(namespaces) != 1 {
+		t.Errorf("expected 1 namespace after dry run - got %v", namespaces)
+	}
+
+	if _, err = r.Reconcile(ctx, spec, false); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rbd, err := client.GetBlockImage("test-pool-1/tenant-1/vol-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rbd.Size != 2<<30 || len(rbd.FeaturesName) != 2 || rbd.QosConfig().RbdQosIopsLimit != 500 {
+		t.Errorf("expected 2 GiB image with 2 features and 500 iops - got %d %v %+v", rbd.Size, rbd.FeaturesName, rbd.QosConfig())
+	}
+
+	// applying the same plan again is a no-op
+	if err = r.Apply(ctx, plan); err != nil {
+		t.Errorf("expected no err applying the plan twice - got %v", err)
+	}
+
+	if plan, err = r.Plan(ctx, spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if !plan.Empty() {
+		t.Errorf("expected no changes - got\n%s", plan)
+	}
+
+	// grow vol-1, change features and qos of vol-2 and delete the orphans
+	spec.Namespaces[0].Images[0].Size = 4 << 30
+	spec.Namespaces[0].Images[1].Features = []string{"layering"}
+	spec.Namespaces[0].Images[1].Qos = &ceph.RBDQosConfig{RbdQosIopsLimit: 1000}
+	spec.DeleteOrphans = true
+
+	if plan, err = r.Reconcile(ctx, spec, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []reconcile.Action{reconcile.ActionResizeImage, reconcile.ActionUpdateFeatures, reconcile.ActionUpdateQos, reconcile.ActionDeleteImage, reconcile.ActionDeleteNamespace}
+	if got := actions(plan.Steps); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected steps %v - got %v", expected, got)
+	}
+
+	if !strings.Contains(plan.String(), "update qos of image test-pool-1/tenant-1/vol-2: rbd_qos_iops_limit 500 -> 1000\n") {
+		t.Errorf("expected qos change in output - got\n%s", plan)
+	}
+
+	if _, rbd, err = client.GetBlockImage("test-pool-1/tenant-1/vol-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rbd.Size != 4<<30 {
+		t.Errorf("expected size %d - got %d", 4<<30, rbd.Size)
+	}
+
+	if _, namespaces, _ := client.GetBlockNameSpaceListInPool("test-pool-1"); len(namespaces) != 1 || namespaces[0].NameSpace != "tenant-1" {
+		t.Errorf("expected namespace tenant-1 only - got %v", namespaces)
+	}
+
+	// images are never shrunk
+	spec.Namespaces[0].Images[0].Size = 1 << 30
+
+	if _, err = r.Plan(ctx, spec); !errors.Is(err, reconcile.ErrImageShrink) {
+		t.Errorf("expected err %v - got %v", reconcile.ErrImageShrink, err)
+	}
+}
+
+func TestReconciler_PlanInvalidSpec(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	r := reconcile.New(newLoggedInClient(t, srv))
+
+	tests := []struct {
+		name string
+		spec reconcile.Spec
+		err  error
+	}{
+		{name: "no pool", spec: reconcile.Spec{}, err: ceph.ErrPoolNameIsEmpty},
+		{name: "no size", spec: reconcile.Spec{Pool: "rbd", Namespaces: []reconcile.Namespace{{Images: []reconcile.Image{{Name: "vol-1"}}}}}, err: reconcile.ErrImageSizeIsZero},
+		{name: "duplicate namespace", spec: reconcile.Spec{Pool: "rbd", Namespaces: []reconcile.Namespace{{Name: "a"}, {Name: "a"}}}, err: reconcile.ErrDuplicateName},
+	}
+
+	for _, tt := range tests {
+		if _, err := r.Plan(context.Background(), tt.spec); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected err %v - got %v", tt.name, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrRetriesExhausted matches errors returned after an operation failed on every attempt allowed by the RetryPolicy.
+// The error of the last attempt is wrapped and can be checked with errors.Is and errors.As as well.
+var ErrRetriesExhausted = errors.New("retries exhausted")
+
+// RetryPolicy implements how requests and tasks failing with a transient error are retried.
+//
+// The same policy applies to http requests (e.g. a mgr answering with http 503) and to the re-submission of tasks
+// the mgr finished unsuccessfully (e.g. rbd/delete failing with errno 16 as the image still has watchers).
+//
+// Only GET, PUT and DELETE requests are retried on every retryable error. A POST request (e.g. CreateRGWUser) may
+// have been processed by the mgr even if no response was received, sending it again would fail with
+// ErrAlreadyExists and lose the response (e.g. generated keys). POST requests are therefore only retried if they
+// failed before they were sent (e.g. connection refused) or the mgr answered with http 429 or 503.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts per request and per task, including the first one.
+	// Set it to 1 to disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the wait time before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait time between two attempts.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor the wait time grows by with every retry.
+	Multiplier float64
+
+	// Jitter randomizes each wait time by +/- Jitter * wait time (0 <= Jitter <= 1).
+	Jitter float64
+
+	// MaxElapsedTime stops retrying once the next attempt would start later than MaxElapsedTime after the first one.
+	// Zero means no limit.
+	MaxElapsedTime time.Duration
+
+	// RetryableCodes lists the ceph error codes which are retried (e.g. ErrnoBusy). Other codes (e.g.
+	// ErrnoAlreadyExists) are never retried.
+	RetryableCodes []string
+
+	// RetryableStatusCodes lists the http status codes which are retried.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used by clients created with New.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxElapsedTime: 5 * time.Minute,
+		RetryableCodes: []string{ErrnoBusy},
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// Backoff returns the wait time before retry number retry (starting with 1).
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		backoff += backoff * math.Min(p.Jitter, 1) * (2*randFloat64() - 1)
+	}
+
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	return time.Duration(backoff)
+}
+
+// Retryable checks if err is transient and should be retried. Errors of type *APIError are retried if their http
+// status or ceph error code is listed in the policy, all other errors (e.g. connection resets) are retried.
+func (p RetryPolicy) Retryable(err error) bool {
+	var apiErr *APIError
+
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	for _, code := range p.RetryableCodes {
+		if apiErr.Code == code {
+			return true
+		}
+	}
+
+	for _, status := range p.RetryableStatusCodes {
+		if apiErr.StatusCode == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// idempotentMethods lists the http methods whose requests can be sent again without changing the result.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// RetryableRequest checks if a request sent with method failed with err and should be sent again. Requests of
+// idempotent methods are retried like Retryable. Other requests (POST) are only retried if err happened before the
+// request was sent or the mgr answered with http 429 or 503 (if listed in RetryableStatusCodes).
+func (p RetryPolicy) RetryableRequest(method string, err error) bool {
+	if idempotentMethods[method] || !p.Retryable(err) {
+		return p.Retryable(err)
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable
+	}
+
+	return notSent(err)
+}
+
+// notSent checks if err happened before the request was sent, e.g. as the connection was refused.
+func notSent(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryPolicy returns the configured RetryPolicy or DefaultRetryPolicy if unset.
+func (c *Client) retryPolicy() RetryPolicy {
+	if c.RetryPolicy.MaxAttempts <= 0 {
+		return DefaultRetryPolicy()
+	}
+
+	return c.RetryPolicy
+}
+
+// retry calls attempt until it succeeds, fails with an error not retryable, the RetryPolicy is exhausted or ctx is
+// done. The error of the last attempt is returned, wrapped by ErrRetriesExhausted if the policy gave up.
+func (c *Client) retry(ctx context.Context, op string, retryable func(error) bool, attempt func() error) error {
+	policy := c.retryPolicy()
+	start := time.Now()
+
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		backoff := policy.Backoff(n)
+
+		if n >= policy.MaxAttempts ||
+			(policy.MaxElapsedTime > 0 && time.Since(start)+backoff > policy.MaxElapsedTime) {
+			return &retriesExhaustedError{attempts: n, err: err}
+		}
+
+		c.Logger.Debugf("%s: attempt %d failed, retry in %v: %v", op, n, backoff, err)
+		spanFromContext(ctx).AddEvent("retry",
+			Attribute{Key: AttributeAttempt, Value: n},
+			Attribute{Key: "error", Value: err.Error()},
+		)
+
+		if errSleep := sleepWithContext(ctx, backoff); errSleep != nil {
+			return errSleep
+		}
+	}
+}
+
+// execute sends req with method to url, retrying transport errors and retryable error responses according to the
+// RetryPolicy. Like resty, an error status is not returned as error but left to the caller. op names the calling
+// method traced unless req belongs to a traced method already.
+func (c *Client) execute(op string, req *resty.Request, method, url string) (resp *resty.Response, err error) {
+	ctx, end := c.startOp(req.Context(), op)
+	req.SetContext(ctx)
+	attempts := 0
+
+	defer func() {
+		if err == nil && resp != nil && !resp.IsSuccess() {
+			end(newAPIError(resp))
+			return
+		}
+
+		end(err)
+	}()
+
+	retryable := func(err error) bool {
+		return c.retryPolicy().RetryableRequest(method, err)
+	}
+
+	errRetry := c.retry(ctx, method+" "+url, retryable, func() error {
+		if attempts++; attempts > 1 {
+			c.metrics().IncRequestRetry(method, c.Session.endpoint(url))
+		}
+
+		resp, err = req.Execute(method, url)
+		if err != nil {
+			return err
+		}
+
+		if !resp.IsSuccess() {
+			return newAPIError(resp)
+		}
+
+		return nil
+	})
+
+	if errRetry != nil && ctx.Err() != nil {
+		return resp, ctx.Err()
+	}
+
+	return resp, err
+}
+
+// retryTask calls submit until the submitted task succeeds, fails with an error not retryable, the RetryPolicy is
+// exhausted or ctx is done. Only tasks the mgr finished unsuccessfully are re-submitted, failed requests are already
+// retried by execute.
+func (c *Client) retryTask(ctx context.Context, op string, submit func(ctx context.Context) error) (err error) {
+	ctx, end := c.startOp(ctx, op)
+	defer func() { end(err) }()
+
+	retryable := func(err error) bool {
+		var apiErr *APIError
+
+		return errors.As(err, &apiErr) && apiErr.Task != nil && apiErr.Method == "" && c.retryPolicy().Retryable(err)
+	}
+
+	var failedTask string
+
+	return c.retry(ctx, op, retryable, func() error {
+		if failedTask != "" {
+			c.metrics().IncTaskResubmit(op, failedTask)
+			spanFromContext(ctx).AddEvent("resubmit", Attribute{Key: AttributeTask, Value: failedTask})
+		}
+
+		err := submit(ctx)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Task != nil {
+			failedTask = apiErr.Task.Name
+		}
+
+		return err
+	})
+}
+
+// retriesExhaustedError implements the error returned by Client.retry once the RetryPolicy gave up.
+type retriesExhaustedError struct {
+	attempts int
+	err      error
+}
+
+// Error implements error.
+func (e *retriesExhaustedError) Error() string {
+	return fmt.Sprintf("%v after %d attempts: %v", ErrRetriesExhausted, e.attempts, e.err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *retriesExhaustedError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrRetriesExhausted.
+func (e *retriesExhaustedError) Is(target error) bool {
+	return target == ErrRetriesExhausted
+}
+
+var (
+	rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rndMu sync.Mutex
+)
+
+func randFloat64() float64 {
+	rndMu.Lock()
+	defer rndMu.Unlock()
+
+	return rnd.Float64()
+}
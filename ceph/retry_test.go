@@ -0,0 +1,256 @@
+package ceph_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := ceph.RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	for retry, want := range []time.Duration{100, 100, 200, 400, 800, 1000, 1000} {
+		if got := policy.Backoff(retry); got != want*time.Millisecond {
+			t.Errorf("expected backoff %v for retry %d - got %v", want*time.Millisecond, retry, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(2); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("expected backoff with jitter between 100ms and 300ms - got %v", got)
+		}
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	policy := ceph.DefaultRetryPolicy()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"errno 16", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: ceph.ErrnoBusy}, true},
+		{"errno 17", &ceph.APIError{StatusCode: http.StatusBadRequest, Code: ceph.ErrnoAlreadyExists}, false},
+		{"http 503", &ceph.APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"http 404", &ceph.APIError{StatusCode: http.StatusNotFound}, false},
+		{"connection error", errors.New("connection reset by peer"), true},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v - want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_RetryableRequest(t *testing.T) {
+	policy := ceph.DefaultRetryPolicy()
+
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		want   bool
+	}{
+		{"get http 500", http.MethodGet, &ceph.APIError{StatusCode: http.StatusInternalServerError}, true},
+		{"put connection reset", http.MethodPut, reset, true},
+		{"delete errno 16", http.MethodDelete, &ceph.APIError{StatusCode: http.StatusBadRequest, Code: ceph.ErrnoBusy}, true},
+		{"post http 503", http.MethodPost, &ceph.APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"post http 429", http.MethodPost, &ceph.APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"post http 500", http.MethodPost, &ceph.APIError{StatusCode: http.StatusInternalServerError}, false},
+		{"post http 504", http.MethodPost, &ceph.APIError{StatusCode: http.StatusGatewayTimeout}, false},
+		{"post errno 16", http.MethodPost, &ceph.APIError{StatusCode: http.StatusBadRequest, Code: ceph.ErrnoBusy}, false},
+		{"post connection refused", http.MethodPost, &url.Error{Op: "Post", URL: "https://mgr", Err: refused}, true},
+		{"post connection reset", http.MethodPost, &url.Error{Op: "Post", URL: "https://mgr", Err: reset}, false},
+		{"post nil", http.MethodPost, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RetryableRequest(tt.method, tt.err); got != tt.want {
+				t.Errorf("RetryableRequest(%s, %v) = %v - want %v", tt.method, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_RetryRequestNotSentTwice(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	var gets int32
+
+	// the mgr processes the requests, but the posts time out and the connection of the first get is dropped before
+	// the response was received
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/pool":
+			srv.ServeHTTP(w, r)
+			time.Sleep(200 * time.Millisecond)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/rgw/user",
+			r.Method == http.MethodGet && r.URL.Path == "/api/pool/test-pool-1" && atomic.AddInt32(&gets, 1) == 1:
+			srv.ServeHTTP(httptest.NewRecorder(), r)
+
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+		default:
+			srv.ServeHTTP(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	srv.AddRGWDaemon("node-1")
+
+	server := srv.CephServer()
+	u, _ := url.Parse(proxy.URL)
+	port, _ := strconv.Atoi(u.Port())
+	server.Address, server.Port = u.Hostname(), uint(port)
+
+	client, err := ceph.New(server, ceph.WithTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	if _, _, err = client.GetPool("test-pool-1", false); err != nil {
+		t.Errorf("expected get to be retried - got %v", err)
+	}
+
+	if requests := srv.RequestCount(http.MethodGet, "/api/pool/test-pool-1"); requests != 2 {
+		t.Errorf("expected 2 get requests - got %d", requests)
+	}
+
+	if _, err = client.CreatePool(ceph.PoolCreate{Pool: "test-pool-2", PoolType: "replicated", PgNum: 8}); err == nil {
+		t.Error("expected timeout error")
+	}
+
+	if requests := srv.RequestCount(http.MethodPost, "/api/pool"); requests != 1 {
+		t.Errorf("expected post timing out not to be sent twice - got %d requests", requests)
+	}
+
+	if _, _, err = client.CreateRGWUser(ceph.RGWUserCreate{UID: "alice", DisplayName: "Alice", GenerateKey: true}); err == nil {
+		t.Error("expected connection error")
+	}
+
+	if requests := srv.RequestCount(http.MethodPost, "/api/rgw/user"); requests != 1 {
+		t.Errorf("expected post losing the connection not to be sent twice - got %d requests", requests)
+	}
+}
+
+func TestClient_RetryRequest(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	for i := 0; i < 2; i++ {
+		srv.InjectException(http.MethodGet, "/api/pool/test-pool-1", ceph.Exception{
+			Detail: "service unavailable",
+			Status: http.StatusServiceUnavailable,
+		})
+	}
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	status, _, err := client.GetPool("test-pool-1", false)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if requests := srv.RequestCount(http.MethodGet, "/api/pool/test-pool-1"); requests != 3 {
+		t.Errorf("expected 3 requests - got %d", requests)
+	}
+}
+
+func TestClient_RetryNotRetryable(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	if err := srv.ReplayFile(http.MethodPost, "/api/block/image", http.StatusBadRequest, "outputs/err_create_image.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newLoggedInClient(t, srv)
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	_, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "rest-client-one-img-1", Size: 1073741824})
+
+	if !errors.Is(err, ceph.ErrAlreadyExists) || errors.Is(err, ceph.ErrRetriesExhausted) {
+		t.Errorf("expected err %v without retries - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	if requests := srv.RequestCount(http.MethodPost, "/api/block/image"); requests != 1 {
+		t.Errorf("expected 1 request - got %d", requests)
+	}
+}
+
+func TestClient_RetryMaxElapsedTime(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+
+	_, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "busy-img", Size: 1073741824})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.FailTask("rbd/delete", 100)
+
+	client.RetryPolicy = ceph.RetryPolicy{
+		MaxAttempts:    100,
+		InitialBackoff: 20 * time.Millisecond,
+		Multiplier:     1,
+		MaxElapsedTime: 100 * time.Millisecond,
+		RetryableCodes: []string{ceph.ErrnoBusy},
+	}
+
+	_, err = client.DeleteBlockImage("test-pool-1", nil, "busy-img")
+
+	if !errors.Is(err, ceph.ErrRetriesExhausted) || !errors.Is(err, ceph.ErrBusy) {
+		t.Errorf("expected err %v and %v - got %v", ceph.ErrRetriesExhausted, ceph.ErrBusy, err)
+	}
+
+	if deletes := srv.RequestCount(http.MethodDelete, "/api/block/image/test-pool-1%2Fbusy-img"); deletes > 6 {
+		t.Errorf("expected at most 6 delete requests within max elapsed time - got %d", deletes)
+	}
+}
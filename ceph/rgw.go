@@ -0,0 +1,768 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrRGWUserIDIsEmpty is returned if param uid is empty.
+	ErrRGWUserIDIsEmpty = errors.New("param uid can not be empty")
+
+	// ErrRGWBucketNameIsEmpty is returned if param bucket is empty.
+	ErrRGWBucketNameIsEmpty = errors.New("param bucket can not be empty")
+)
+
+// access of a subuser
+const (
+	RGWAccessRead      = "read"
+	RGWAccessWrite     = "write"
+	RGWAccessReadWrite = "readwrite"
+	RGWAccessFull      = "full"
+)
+
+// types of keys
+const (
+	RGWKeyTypeS3    = "s3"
+	RGWKeyTypeSwift = "swift"
+)
+
+// types of quotas set with SetRGWUserQuota. A bucket quota limits each bucket of the user, a user quota all buckets of
+// the user together.
+const (
+	RGWQuotaTypeUser   = "user"
+	RGWQuotaTypeBucket = "bucket"
+)
+
+// versioning states of a bucket
+const (
+	RGWVersioningEnabled   = "Enabled"
+	RGWVersioningSuspended = "Suspended"
+)
+
+// object lock modes of a bucket
+const (
+	RGWLockModeGovernance = "GOVERNANCE"
+	RGWLockModeCompliance = "COMPLIANCE"
+)
+
+// RGWStatus implements struct returned from GET /api/rgw/status. Message tells why the object gateway is not
+// available.
+type RGWStatus struct {
+	Available bool   `json:"available"`
+	Message   string `json:"message"`
+}
+
+// RGWDaemon implements struct returned from GET /api/rgw/daemon.
+type RGWDaemon struct {
+	ID             string `json:"id"`
+	ServiceMapID   string `json:"service_map_id"`
+	Version        string `json:"version"`
+	ServerHostname string `json:"server_hostname"`
+	RealmName      string `json:"realm_name"`
+	ZonegroupName  string `json:"zonegroup_name"`
+	Default        bool   `json:"default"`
+}
+
+// RGWUser implements struct returned from GET /api/rgw/user/{uid}. UID is the user id including the tenant, e.g.
+// tenant$user. Stats is only set if requested.
+type RGWUser struct {
+	UID              string        `json:"uid"`
+	Tenant           string        `json:"tenant"`
+	UserID           string        `json:"user_id"`
+	DisplayName      string        `json:"display_name"`
+	Email            string        `json:"email"`
+	Suspended        int           `json:"suspended"`
+	MaxBuckets       int           `json:"max_buckets"`
+	Subusers         []RGWSubuser  `json:"subusers"`
+	Keys             []RGWKey      `json:"keys"`
+	SwiftKeys        []RGWSwiftKey `json:"swift_keys"`
+	Caps             []RGWCap      `json:"caps"`
+	OpMask           string        `json:"op_mask"`
+	DefaultPlacement string        `json:"default_placement"`
+	BucketQuota      RGWQuota      `json:"bucket_quota"`
+	UserQuota        RGWQuota      `json:"user_quota"`
+	Type             string        `json:"type"`
+	Stats            *RGWUsage     `json:"stats,omitempty"`
+}
+
+// IsSuspended checks if the user is suspended.
+func (u RGWUser) IsSuspended() bool {
+	return u.Suspended != 0
+}
+
+// RGWSubuser implements a subuser (e.g. a swift user) of a user. ID is uid:subuser.
+type RGWSubuser struct {
+	ID          string `json:"id"`
+	Permissions string `json:"permissions"`
+}
+
+// RGWKey implements a s3 key of a user or subuser.
+type RGWKey struct {
+	User      string `json:"user"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// RGWSwiftKey implements a swift key of a subuser.
+type RGWSwiftKey struct {
+	User      string `json:"user"`
+	SecretKey string `json:"secret_key"`
+}
+
+// RGWCap implements an admin capability of a user, e.g. {Type: "buckets", Perm: "read"}.
+type RGWCap struct {
+	Type string `json:"type"`
+	Perm string `json:"perm"`
+}
+
+// RGWQuota implements a user or bucket quota. MaxSize (bytes), MaxSizeKb and MaxObjects are -1 if unlimited.
+type RGWQuota struct {
+	Enabled    bool  `json:"enabled"`
+	CheckOnRaw bool  `json:"check_on_raw"`
+	MaxSize    int64 `json:"max_size"`
+	MaxSizeKb  int64 `json:"max_size_kb"`
+	MaxObjects int64 `json:"max_objects"`
+}
+
+// RGWUsage implements the space and objects used by a user or bucket.
+type RGWUsage struct {
+	Size         uint64 `json:"size"`
+	SizeActual   uint64 `json:"size_actual"`
+	SizeUtilized uint64 `json:"size_utilized"`
+	SizeKb       uint64 `json:"size_kb"`
+	SizeKbActual uint64 `json:"size_kb_actual"`
+	NumObjects   uint64 `json:"num_objects"`
+}
+
+// RGWUserQuota implements struct returned from GET /api/rgw/user/{uid}/quota.
+type RGWUserQuota struct {
+	BucketQuota RGWQuota `json:"bucket_quota"`
+	UserQuota   RGWQuota `json:"user_quota"`
+}
+
+// RGWUserCreate implements struct send to ceph to create a user on POST /api/rgw/user. Set GenerateKey to let ceph
+// create a s3 key, or set AccessKey and SecretKey. MaxBuckets defaults to 1000, 0 is unlimited.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user
+type RGWUserCreate struct {
+	UID         string `json:"uid"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email,omitempty"`
+	MaxBuckets  *int   `json:"max_buckets,omitempty"`
+	Suspended   bool   `json:"suspended,omitempty"`
+	GenerateKey bool   `json:"generate_key,omitempty"`
+	AccessKey   string `json:"access_key,omitempty"`
+	SecretKey   string `json:"secret_key,omitempty"`
+}
+
+// RGWUserUpdate implements struct send to ceph on PUT /api/rgw/user/{uid}. Fields not set are not changed.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-rgw-user-uid
+type RGWUserUpdate struct {
+	DisplayName string `json:"display_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	MaxBuckets  *int   `json:"max_buckets,omitempty"`
+	Suspended   *bool  `json:"suspended,omitempty"`
+}
+
+// RGWSubuserCreate implements struct send to ceph on POST /api/rgw/user/{uid}/subuser. Access is one of the
+// RGWAccess* constants. Set GenerateSecret to let ceph create the secret, or set SecretKey.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user-uid-subuser
+type RGWSubuserCreate struct {
+	Subuser        string `json:"subuser"`
+	Access         string `json:"access"`
+	KeyType        string `json:"key_type,omitempty"`
+	GenerateSecret bool   `json:"generate_secret"`
+	SecretKey      string `json:"secret_key,omitempty"`
+}
+
+// RGWKeyCreate implements struct send to ceph on POST /api/rgw/user/{uid}/key. KeyType is RGWKeyTypeS3 or
+// RGWKeyTypeSwift, Subuser is required for swift keys. Set GenerateKey to let ceph create the key, or set AccessKey
+// and SecretKey.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user-uid-key
+type RGWKeyCreate struct {
+	KeyType     string `json:"key_type"`
+	Subuser     string `json:"subuser,omitempty"`
+	GenerateKey bool   `json:"generate_key"`
+	AccessKey   string `json:"access_key,omitempty"`
+	SecretKey   string `json:"secret_key,omitempty"`
+}
+
+// RGWQuotaUpdate implements struct send to ceph on PUT /api/rgw/user/{uid}/quota. QuotaType is RGWQuotaTypeUser or
+// RGWQuotaTypeBucket, MaxSizeKb and MaxObjects are -1 for unlimited.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-rgw-user-uid-quota
+type RGWQuotaUpdate struct {
+	QuotaType  string `json:"quota_type"`
+	Enabled    bool   `json:"enabled"`
+	MaxSizeKb  int64  `json:"max_size_kb"`
+	MaxObjects int64  `json:"max_objects"`
+}
+
+// RGWBucket implements struct returned from GET /api/rgw/bucket/{bucket}. Usage holds the usage per storage class
+// category, e.g. rgw.main.
+type RGWBucket struct {
+	Bucket                   string              `json:"bucket"`
+	ID                       string              `json:"id"`
+	Marker                   string              `json:"marker"`
+	Tenant                   string              `json:"tenant"`
+	Owner                    string              `json:"owner"`
+	Zonegroup                string              `json:"zonegroup"`
+	PlacementRule            string              `json:"placement_rule"`
+	IndexType                string              `json:"index_type"`
+	NumShards                int                 `json:"num_shards"`
+	Mtime                    string              `json:"mtime"`
+	CreationTime             string              `json:"creation_time"`
+	Usage                    map[string]RGWUsage `json:"usage"`
+	BucketQuota              RGWQuota            `json:"bucket_quota"`
+	Versioning               string              `json:"versioning"`
+	MfaDelete                string              `json:"mfa_delete"`
+	LockEnabled              bool                `json:"lock_enabled"`
+	LockMode                 string              `json:"lock_mode"`
+	LockRetentionPeriodDays  int                 `json:"lock_retention_period_days"`
+	LockRetentionPeriodYears int                 `json:"lock_retention_period_years"`
+}
+
+// RGWBucketCreate implements struct send to ceph to create a bucket on POST /api/rgw/bucket. PlacementTarget
+// requires Zonegroup. Buckets with LockEnabled have versioning enabled and can not suspend it, LockMode (one of the
+// RGWLockMode* constants) and a retention period in days or years set the default retention of new objects.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-bucket
+type RGWBucketCreate struct {
+	Bucket                   string `json:"bucket"`
+	UID                      string `json:"uid"`
+	Zonegroup                string `json:"zonegroup,omitempty"`
+	PlacementTarget          string `json:"placement_target,omitempty"`
+	LockEnabled              bool   `json:"lock_enabled"`
+	LockMode                 string `json:"lock_mode,omitempty"`
+	LockRetentionPeriodDays  int    `json:"lock_retention_period_days,omitempty"`
+	LockRetentionPeriodYears int    `json:"lock_retention_period_years,omitempty"`
+}
+
+// RGWBucketUpdate implements struct send to ceph on PUT /api/rgw/bucket/{bucket}. A UID other than the owner links
+// the bucket to the new owner. Empty fields are not changed.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-rgw-bucket-bucket
+type RGWBucketUpdate struct {
+	BucketID                 string `json:"bucket_id"`
+	UID                      string `json:"uid"`
+	VersioningState          string `json:"versioning_state,omitempty"`
+	MfaDelete                string `json:"mfa_delete,omitempty"`
+	MfaTokenSerial           string `json:"mfa_token_serial,omitempty"`
+	MfaTokenPin              string `json:"mfa_token_pin,omitempty"`
+	LockMode                 string `json:"lock_mode,omitempty"`
+	LockRetentionPeriodDays  int    `json:"lock_retention_period_days,omitempty"`
+	LockRetentionPeriodYears int    `json:"lock_retention_period_years,omitempty"`
+}
+
+// GetRGWStatus gets if the object gateway is available.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-status
+func (c *Client) GetRGWStatus() (status int, rgwStatus RGWStatus, err error) {
+	return c.GetRGWStatusWithContext(context.Background())
+}
+
+// GetRGWStatusWithContext is like GetRGWStatus but aborts the request if ctx is done.
+func (c *Client) GetRGWStatusWithContext(ctx context.Context) (status int, rgwStatus RGWStatus, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&rgwStatus)
+
+	status, err = c.send(ctx, "GetRGWStatus", req, resty.MethodGet, "rgw/status")
+
+	return status, rgwStatus, err
+}
+
+// ListRGWDaemons gets a list of all object gateway daemons.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-daemon
+func (c *Client) ListRGWDaemons() (status int, daemons []RGWDaemon, err error) {
+	return c.ListRGWDaemonsWithContext(context.Background())
+}
+
+// ListRGWDaemonsWithContext is like ListRGWDaemons but aborts the request if ctx is done.
+func (c *Client) ListRGWDaemonsWithContext(ctx context.Context) (status int, daemons []RGWDaemon, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&daemons)
+
+	status, err = c.send(ctx, "ListRGWDaemons", req, resty.MethodGet, "rgw/daemon")
+
+	return status, daemons, err
+}
+
+// ListRGWUsers gets the ids of all users.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-user
+func (c *Client) ListRGWUsers() (status int, uids []string, err error) {
+	return c.ListRGWUsersWithContext(context.Background())
+}
+
+// ListRGWUsersWithContext is like ListRGWUsers but aborts the request if ctx is done.
+func (c *Client) ListRGWUsersWithContext(ctx context.Context) (status int, uids []string, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&uids)
+
+	status, err = c.send(ctx, "ListRGWUsers", req, resty.MethodGet, "rgw/user")
+
+	return status, uids, err
+}
+
+// GetRGWUser gets a user with its keys, subusers, capabilities and quotas. Set stats to get the usage as well.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-user-uid
+func (c *Client) GetRGWUser(uid string, stats bool) (status int, user RGWUser, err error) {
+	return c.GetRGWUserWithContext(context.Background(), uid, stats)
+}
+
+// GetRGWUserWithContext is like GetRGWUser but aborts the request if ctx is done.
+func (c *Client) GetRGWUserWithContext(ctx context.Context, uid string, stats bool) (status int, user RGWUser, err error) {
+	if uid == "" {
+		return 0, user, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("stats", strconv.FormatBool(stats)).
+		SetResult(&user)
+
+	status, err = c.send(ctx, "GetRGWUser", req, resty.MethodGet, rgwUserPath(uid))
+
+	return status, user, err
+}
+
+// CreateRGWUser creates a user and returns it with its keys.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user
+func (c *Client) CreateRGWUser(userCreate RGWUserCreate) (status int, user RGWUser, err error) {
+	return c.CreateRGWUserWithContext(context.Background(), userCreate)
+}
+
+// CreateRGWUserWithContext is like CreateRGWUser but aborts the request if ctx is done.
+func (c *Client) CreateRGWUserWithContext(ctx context.Context, userCreate RGWUserCreate) (status int, user RGWUser, err error) {
+	if userCreate.UID == "" {
+		return 0, user, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(userCreate).
+		SetResult(&user)
+
+	status, err = c.send(ctx, "CreateRGWUser", req, resty.MethodPost, "rgw/user")
+
+	return status, user, err
+}
+
+// UpdateRGWUser changes the display name, email, max buckets or suspension of a user.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-rgw-user-uid
+func (c *Client) UpdateRGWUser(uid string, userUpdate RGWUserUpdate) (status int, user RGWUser, err error) {
+	return c.UpdateRGWUserWithContext(context.Background(), uid, userUpdate)
+}
+
+// UpdateRGWUserWithContext is like UpdateRGWUser but aborts the request if ctx is done.
+func (c *Client) UpdateRGWUserWithContext(ctx context.Context, uid string, userUpdate RGWUserUpdate) (status int, user RGWUser, err error) {
+	if uid == "" {
+		return 0, user, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(userUpdate).
+		SetResult(&user)
+
+	status, err = c.send(ctx, "UpdateRGWUser", req, resty.MethodPut, rgwUserPath(uid))
+
+	return status, user, err
+}
+
+// DeleteRGWUser deletes a user with its keys and subusers.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-rgw-user-uid
+func (c *Client) DeleteRGWUser(uid string) (status int, err error) {
+	return c.DeleteRGWUserWithContext(context.Background(), uid)
+}
+
+// DeleteRGWUserWithContext is like DeleteRGWUser but aborts the request if ctx is done.
+func (c *Client) DeleteRGWUserWithContext(ctx context.Context, uid string) (status int, err error) {
+	if uid == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson)
+
+	return c.send(ctx, "DeleteRGWUser", req, resty.MethodDelete, rgwUserPath(uid))
+}
+
+// CreateRGWSubuser creates a subuser of a user and returns all subusers of the user.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user-uid-subuser
+func (c *Client) CreateRGWSubuser(uid string, subuserCreate RGWSubuserCreate) (status int, subusers []RGWSubuser, err error) {
+	return c.CreateRGWSubuserWithContext(context.Background(), uid, subuserCreate)
+}
+
+// CreateRGWSubuserWithContext is like CreateRGWSubuser but aborts the request if ctx is done.
+func (c *Client) CreateRGWSubuserWithContext(ctx context.Context, uid string, subuserCreate RGWSubuserCreate) (status int, subusers []RGWSubuser, err error) {
+	if uid == "" {
+		return 0, nil, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(subuserCreate).
+		SetResult(&subusers)
+
+	status, err = c.send(ctx, "CreateRGWSubuser", req, resty.MethodPost, rgwUserPath(uid, "subuser"))
+
+	return status, subusers, err
+}
+
+// DeleteRGWSubuser deletes a subuser of a user. Set purgeKeys to delete the keys of the subuser as well.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-rgw-user-uid-subuser-subuser
+func (c *Client) DeleteRGWSubuser(uid, subuser string, purgeKeys bool) (status int, err error) {
+	return c.DeleteRGWSubuserWithContext(context.Background(), uid, subuser, purgeKeys)
+}
+
+// DeleteRGWSubuserWithContext is like DeleteRGWSubuser but aborts the request if ctx is done.
+func (c *Client) DeleteRGWSubuserWithContext(ctx context.Context, uid, subuser string, purgeKeys bool) (status int, err error) {
+	if uid == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("purge_keys", strconv.FormatBool(purgeKeys))
+
+	return c.send(ctx, "DeleteRGWSubuser", req, resty.MethodDelete, rgwUserPath(uid, "subuser", url.QueryEscape(subuser)))
+}
+
+// CreateRGWKey creates a s3 or swift key of a user or subuser and returns all keys of the type.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user-uid-key
+func (c *Client) CreateRGWKey(uid string, keyCreate RGWKeyCreate) (status int, keys []RGWKey, err error) {
+	return c.CreateRGWKeyWithContext(context.Background(), uid, keyCreate)
+}
+
+// CreateRGWKeyWithContext is like CreateRGWKey but aborts the request if ctx is done.
+func (c *Client) CreateRGWKeyWithContext(ctx context.Context, uid string, keyCreate RGWKeyCreate) (status int, keys []RGWKey, err error) {
+	if uid == "" {
+		return 0, nil, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(keyCreate).
+		SetResult(&keys)
+
+	status, err = c.send(ctx, "CreateRGWKey", req, resty.MethodPost, rgwUserPath(uid, "key"))
+
+	return status, keys, err
+}
+
+// DeleteRGWKey deletes a key of a user or subuser. The access key identifies s3 keys, the subuser swift keys.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-rgw-user-uid-key
+func (c *Client) DeleteRGWKey(uid, keyType, subuser, accessKey string) (status int, err error) {
+	return c.DeleteRGWKeyWithContext(context.Background(), uid, keyType, subuser, accessKey)
+}
+
+// DeleteRGWKeyWithContext is like DeleteRGWKey but aborts the request if ctx is done.
+func (c *Client) DeleteRGWKeyWithContext(ctx context.Context, uid, keyType, subuser, accessKey string) (status int, err error) {
+	if uid == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	params := map[string]string{"key_type": keyType}
+
+	if subuser != "" {
+		params["subuser"] = subuser
+	}
+
+	if accessKey != "" {
+		params["access_key"] = accessKey
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParams(params)
+
+	return c.send(ctx, "DeleteRGWKey", req, resty.MethodDelete, rgwUserPath(uid, "key"))
+}
+
+// AddRGWUserCapability adds an admin capability to a user and returns all capabilities of the user.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-user-uid-capability
+func (c *Client) AddRGWUserCapability(uid string, capability RGWCap) (status int, caps []RGWCap, err error) {
+	return c.AddRGWUserCapabilityWithContext(context.Background(), uid, capability)
+}
+
+// AddRGWUserCapabilityWithContext is like AddRGWUserCapability but aborts the request if ctx is done.
+func (c *Client) AddRGWUserCapabilityWithContext(ctx context.Context, uid string, capability RGWCap) (status int, caps []RGWCap, err error) {
+	if uid == "" {
+		return 0, nil, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(capability).
+		SetResult(&caps)
+
+	status, err = c.send(ctx, "AddRGWUserCapability", req, resty.MethodPost, rgwUserPath(uid, "capability"))
+
+	return status, caps, err
+}
+
+// RemoveRGWUserCapability removes an admin capability from a user.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-rgw-user-uid-capability
+func (c *Client) RemoveRGWUserCapability(uid string, capability RGWCap) (status int, err error) {
+	return c.RemoveRGWUserCapabilityWithContext(context.Background(), uid, capability)
+}
+
+// RemoveRGWUserCapabilityWithContext is like RemoveRGWUserCapability but aborts the request if ctx is done.
+func (c *Client) RemoveRGWUserCapabilityWithContext(ctx context.Context, uid string, capability RGWCap) (status int, err error) {
+	if uid == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParams(map[string]string{"type": capability.Type, "perm": capability.Perm})
+
+	return c.send(ctx, "RemoveRGWUserCapability", req, resty.MethodDelete, rgwUserPath(uid, "capability"))
+}
+
+// GetRGWUserQuota gets the user quota and the bucket quota of a user.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-user-uid-quota
+func (c *Client) GetRGWUserQuota(uid string) (status int, quota RGWUserQuota, err error) {
+	return c.GetRGWUserQuotaWithContext(context.Background(), uid)
+}
+
+// GetRGWUserQuotaWithContext is like GetRGWUserQuota but aborts the request if ctx is done.
+func (c *Client) GetRGWUserQuotaWithContext(ctx context.Context, uid string) (status int, quota RGWUserQuota, err error) {
+	if uid == "" {
+		return 0, quota, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&quota)
+
+	status, err = c.send(ctx, "GetRGWUserQuota", req, resty.MethodGet, rgwUserPath(uid, "quota"))
+
+	return status, quota, err
+}
+
+// SetRGWUserQuota sets the user quota or the bucket quota (applying to each bucket of the user) of a user, e.g.
+// SetRGWUserQuota("tenant-1", RGWQuotaUpdate{QuotaType: RGWQuotaTypeUser, Enabled: true, MaxSizeKb: 1 << 20,
+// MaxObjects: -1}) limits all buckets of the user to 1 GiB.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-rgw-user-uid-quota
+func (c *Client) SetRGWUserQuota(uid string, quotaUpdate RGWQuotaUpdate) (status int, err error) {
+	return c.SetRGWUserQuotaWithContext(context.Background(), uid, quotaUpdate)
+}
+
+// SetRGWUserQuotaWithContext is like SetRGWUserQuota but aborts the request if ctx is done.
+func (c *Client) SetRGWUserQuotaWithContext(ctx context.Context, uid string, quotaUpdate RGWQuotaUpdate) (status int, err error) {
+	if uid == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(quotaUpdate)
+
+	return c.send(ctx, "SetRGWUserQuota", req, resty.MethodPut, rgwUserPath(uid, "quota"))
+}
+
+// ListRGWBuckets gets a list of all buckets with their usage. If uid is not empty, only the buckets of the user are
+// listed.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-bucket
+func (c *Client) ListRGWBuckets(uid string) (status int, buckets []RGWBucket, err error) {
+	return c.ListRGWBucketsWithContext(context.Background(), uid)
+}
+
+// ListRGWBucketsWithContext is like ListRGWBuckets but aborts the request if ctx is done.
+func (c *Client) ListRGWBucketsWithContext(ctx context.Context, uid string) (status int, buckets []RGWBucket, err error) {
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("stats", "true").
+		SetResult(&buckets)
+
+	if uid != "" {
+		req.SetQueryParam("uid", uid)
+	}
+
+	status, err = c.send(ctx, "ListRGWBuckets", req, resty.MethodGet, "rgw/bucket")
+
+	return status, buckets, err
+}
+
+// GetRGWBucket gets a bucket with its owner, usage, versioning and object lock settings.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-rgw-bucket-bucket
+func (c *Client) GetRGWBucket(bucket string) (status int, rgwBucket RGWBucket, err error) {
+	return c.GetRGWBucketWithContext(context.Background(), bucket)
+}
+
+// GetRGWBucketWithContext is like GetRGWBucket but aborts the request if ctx is done.
+func (c *Client) GetRGWBucketWithContext(ctx context.Context, bucket string) (status int, rgwBucket RGWBucket, err error) {
+	if bucket == "" {
+		return 0, rgwBucket, ErrRGWBucketNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetResult(&rgwBucket)
+
+	status, err = c.send(ctx, "GetRGWBucket", req, resty.MethodGet, rgwBucketPath(bucket))
+
+	return status, rgwBucket, err
+}
+
+// CreateRGWBucket creates a bucket owned by bucketCreate.UID.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-rgw-bucket
+func (c *Client) CreateRGWBucket(bucketCreate RGWBucketCreate) (status int, err error) {
+	return c.CreateRGWBucketWithContext(context.Background(), bucketCreate)
+}
+
+// CreateRGWBucketWithContext is like CreateRGWBucket but aborts the request if ctx is done.
+func (c *Client) CreateRGWBucketWithContext(ctx context.Context, bucketCreate RGWBucketCreate) (status int, err error) {
+	if bucketCreate.Bucket == "" {
+		return 0, ErrRGWBucketNameIsEmpty
+	}
+
+	if bucketCreate.UID == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(bucketCreate)
+
+	return c.send(ctx, "CreateRGWBucket", req, resty.MethodPost, "rgw/bucket")
+}
+
+// UpdateRGWBucket changes the owner, versioning or object lock settings of a bucket. The mgr requires the bucket id
+// and the owner, if bucketUpdate.BucketID or bucketUpdate.UID is empty it is taken from the bucket.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#put--api-rgw-bucket-bucket
+func (c *Client) UpdateRGWBucket(bucket string, bucketUpdate RGWBucketUpdate) (status int, err error) {
+	return c.UpdateRGWBucketWithContext(context.Background(), bucket, bucketUpdate)
+}
+
+// UpdateRGWBucketWithContext is like UpdateRGWBucket but aborts the requests if ctx is done.
+func (c *Client) UpdateRGWBucketWithContext(ctx context.Context, bucket string, bucketUpdate RGWBucketUpdate) (status int, err error) {
+	if bucket == "" {
+		return 0, ErrRGWBucketNameIsEmpty
+	}
+
+	if bucketUpdate.BucketID == "" || bucketUpdate.UID == "" {
+		var current RGWBucket
+
+		if status, current, err = c.GetRGWBucketWithContext(ctx, bucket); err != nil {
+			return status, err
+		}
+
+		if bucketUpdate.BucketID == "" {
+			bucketUpdate.BucketID = current.ID
+		}
+
+		if bucketUpdate.UID == "" {
+			bucketUpdate.UID = current.Owner
+		}
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetBody(bucketUpdate)
+
+	return c.send(ctx, "UpdateRGWBucket", req, resty.MethodPut, rgwBucketPath(bucket))
+}
+
+// ChangeRGWBucketOwner links a bucket to the user uid.
+func (c *Client) ChangeRGWBucketOwner(bucket, uid string) (status int, err error) {
+	return c.ChangeRGWBucketOwnerWithContext(context.Background(), bucket, uid)
+}
+
+// ChangeRGWBucketOwnerWithContext is like ChangeRGWBucketOwner but aborts the requests if ctx is done.
+func (c *Client) ChangeRGWBucketOwnerWithContext(ctx context.Context, bucket, uid string) (status int, err error) {
+	if uid == "" {
+		return 0, ErrRGWUserIDIsEmpty
+	}
+
+	return c.UpdateRGWBucketWithContext(ctx, bucket, RGWBucketUpdate{UID: uid})
+}
+
+// SetRGWBucketVersioning enables or suspends the versioning of a bucket.
+func (c *Client) SetRGWBucketVersioning(bucket string, enabled bool) (status int, err error) {
+	return c.SetRGWBucketVersioningWithContext(context.Background(), bucket, enabled)
+}
+
+// SetRGWBucketVersioningWithContext is like SetRGWBucketVersioning but aborts the requests if ctx is done.
+func (c *Client) SetRGWBucketVersioningWithContext(ctx context.Context, bucket string, enabled bool) (status int, err error) {
+	state := RGWVersioningSuspended
+	if enabled {
+		state = RGWVersioningEnabled
+	}
+
+	return c.UpdateRGWBucketWithContext(ctx, bucket, RGWBucketUpdate{VersioningState: state})
+}
+
+// SetRGWBucketObjectLock sets the default retention of new objects of a bucket created with object lock enabled,
+// e.g. SetRGWBucketObjectLock("backup", RGWLockModeCompliance, 30, 0).
+func (c *Client) SetRGWBucketObjectLock(bucket, mode string, days, years int) (status int, err error) {
+	return c.SetRGWBucketObjectLockWithContext(context.Background(), bucket, mode, days, years)
+}
+
+// SetRGWBucketObjectLockWithContext is like SetRGWBucketObjectLock but aborts the requests if ctx is done.
+func (c *Client) SetRGWBucketObjectLockWithContext(ctx context.Context, bucket, mode string, days, years int) (status int, err error) {
+	return c.UpdateRGWBucketWithContext(ctx, bucket, RGWBucketUpdate{
+		LockMode:                 mode,
+		LockRetentionPeriodDays:  days,
+		LockRetentionPeriodYears: years,
+	})
+}
+
+// DeleteRGWBucket deletes a bucket. Set purgeObjects to delete a bucket which still holds objects.
+// --> https://docs.ceph.com/en/latest/mgr/ceph_api/#delete--api-rgw-bucket-bucket
+func (c *Client) DeleteRGWBucket(bucket string, purgeObjects bool) (status int, err error) {
+	return c.DeleteRGWBucketWithContext(context.Background(), bucket, purgeObjects)
+}
+
+// DeleteRGWBucketWithContext is like DeleteRGWBucket but aborts the request if ctx is done.
+func (c *Client) DeleteRGWBucketWithContext(ctx context.Context, bucket string, purgeObjects bool) (status int, err error) {
+	if bucket == "" {
+		return 0, ErrRGWBucketNameIsEmpty
+	}
+
+	req := c.Session.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("purge_objects", strconv.FormatBool(purgeObjects))
+
+	return c.send(ctx, "DeleteRGWBucket", req, resty.MethodDelete, rgwBucketPath(bucket))
+}
+
+// rgwUserPath returns the api path of the user uid followed by elem, e.g. rgw/user/tenant%24user/key.
+func rgwUserPath(uid string, elem ...string) string {
+	p := "rgw/user/" + url.QueryEscape(uid)
+
+	for _, e := range elem {
+		p += "/" + e
+	}
+
+	return p
+}
+
+// rgwBucketPath returns the api path of bucket.
+func rgwBucketPath(bucket string) string {
+	return "rgw/bucket/" + url.QueryEscape(bucket)
+}
@@ -0,0 +1,252 @@
+package ceph_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+func TestClient_RGWStatus(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	client := newLoggedInClient(t, srv)
+
+	_, rgwStatus, err := client.GetRGWStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rgwStatus.Available || rgwStatus.Message == "" {
+		t.Errorf("expected rgw unavailable with message - got %+v", rgwStatus)
+	}
+
+	id := srv.AddRGWDaemon("node-1")
+
+	if _, rgwStatus, _ = client.GetRGWStatus(); !rgwStatus.Available {
+		t.Errorf("expected rgw available - got %+v", rgwStatus)
+	}
+
+	_, daemons, err := client.ListRGWDaemons()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(daemons) != 1 || daemons[0].ID != id || daemons[0].ServerHostname != "node-1" {
+		t.Errorf("expected daemon %s on node-1 - got %+v", id, daemons)
+	}
+}
+
+func TestClient_RGWUser(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddRGWDaemon("node-1")
+
+	client := newLoggedInClient(t, srv)
+
+	status, user, err := client.CreateRGWUser(ceph.RGWUserCreate{
+		UID:         "tenant-1$alice",
+		DisplayName: "Alice",
+		GenerateKey: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if user.Tenant != "tenant-1" || user.UserID != "alice" || len(user.Keys) != 1 || user.Keys[0].SecretKey == "" {
+		t.Errorf("expected user alice of tenant-1 with a s3 key - got %+v", user)
+	}
+
+	if _, _, err = client.CreateRGWUser(ceph.RGWUserCreate{UID: "tenant-1$alice"}); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Errorf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	suspended := true
+	if _, user, err = client.UpdateRGWUser("tenant-1$alice", ceph.RGWUserUpdate{Suspended: &suspended}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.IsSuspended() || user.DisplayName != "Alice" {
+		t.Errorf("expected suspended user Alice - got %+v", user)
+	}
+
+	_, subusers, err := client.CreateRGWSubuser("tenant-1$alice", ceph.RGWSubuserCreate{
+		Subuser:        "swift",
+		Access:         ceph.RGWAccessFull,
+		KeyType:        ceph.RGWKeyTypeSwift,
+		GenerateSecret: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(subusers) != 1 || subusers[0].ID != "tenant-1$alice:swift" || subusers[0].Permissions != "full-control" {
+		t.Errorf("expected subuser tenant-1$alice:swift with full-control - got %+v", subusers)
+	}
+
+	_, keys, err := client.CreateRGWKey("tenant-1$alice", ceph.RGWKeyCreate{KeyType: ceph.RGWKeyTypeS3, GenerateKey: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 s3 keys - got %+v", keys)
+	}
+
+	if _, err = client.DeleteRGWKey("tenant-1$alice", ceph.RGWKeyTypeS3, "", keys[0].AccessKey); err != nil {
+		t.Error(err)
+	}
+
+	_, caps, err := client.AddRGWUserCapability("tenant-1$alice", ceph.RGWCap{Type: "buckets", Perm: "read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(caps) != 1 || caps[0].Type != "buckets" {
+		t.Errorf("expected capability buckets - got %+v", caps)
+	}
+
+	_, err = client.SetRGWUserQuota("tenant-1$alice", ceph.RGWQuotaUpdate{
+		QuotaType:  ceph.RGWQuotaTypeUser,
+		Enabled:    true,
+		MaxSizeKb:  1 << 20,
+		MaxObjects: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, quota, err := client.GetRGWUserQuota("tenant-1$alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !quota.UserQuota.Enabled || quota.UserQuota.MaxSize != 1<<30 || quota.BucketQuota.Enabled {
+		t.Errorf("expected user quota of 1 GiB - got %+v", quota)
+	}
+
+	_, user, err = client.GetRGWUser("tenant-1$alice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(user.Keys) != 1 || user.Keys[0].AccessKey != keys[1].AccessKey || len(user.SwiftKeys) != 1 {
+		t.Errorf("expected s3 key %s and a swift key - got %+v", keys[1].AccessKey, user)
+	}
+
+	if _, err = client.DeleteRGWSubuser("tenant-1$alice", "swift", true); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = client.DeleteRGWUser("tenant-1$alice"); err != nil {
+		t.Error(err)
+	}
+
+	if _, _, err = client.GetRGWUser("tenant-1$alice", false); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+}
+
+func TestClient_RGWBucket(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddRGWDaemon("node-1")
+
+	client := newLoggedInClient(t, srv)
+
+	for _, uid := range []string{"alice", "bob"} {
+		if _, _, err := client.CreateRGWUser(ceph.RGWUserCreate{UID: uid, DisplayName: uid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := client.CreateRGWBucket(ceph.RGWBucketCreate{
+		Bucket:          "backup",
+		UID:             "alice",
+		Zonegroup:       "default",
+		PlacementTarget: "default-placement",
+		LockEnabled:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if _, err = client.CreateRGWBucket(ceph.RGWBucketCreate{Bucket: "scratch", UID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.SetRGWBucketObjectLock("backup", ceph.RGWLockModeCompliance, 30, 0); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = client.SetRGWBucketVersioning("backup", false); err == nil {
+		t.Error("expected error suspending versioning of bucket with object lock - got nil")
+	}
+
+	if _, err = client.SetRGWBucketObjectLock("scratch", ceph.RGWLockModeGovernance, 1, 0); err == nil {
+		t.Error("expected error setting object lock of bucket without object lock - got nil")
+	}
+
+	if _, err = client.SetRGWBucketVersioning("scratch", true); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = client.ChangeRGWBucketOwner("scratch", "bob"); err != nil {
+		t.Error(err)
+	}
+
+	_, bucket, err := client.GetRGWBucket("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bucket.Owner != "alice" || bucket.LockMode != ceph.RGWLockModeCompliance || bucket.LockRetentionPeriodDays != 30 ||
+		bucket.Versioning != ceph.RGWVersioningEnabled {
+		t.Errorf("expected bucket backup of alice with compliance lock of 30 days - got %+v", bucket)
+	}
+
+	_, buckets, err := client.ListRGWBuckets("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(buckets) != 1 || buckets[0].Bucket != "scratch" || buckets[0].Versioning != ceph.RGWVersioningEnabled {
+		t.Errorf("expected versioned bucket scratch of bob - got %+v", buckets)
+	}
+
+	srv.AddRGWObjects("scratch", 10, 1<<20)
+
+	if _, user, _ := client.GetRGWUser("bob", true); user.Stats == nil || user.Stats.NumObjects != 10 {
+		t.Errorf("expected stats with 10 objects - got %+v", user.Stats)
+	}
+
+	if _, err = client.DeleteRGWBucket("scratch", false); err == nil {
+		t.Error("expected error deleting bucket with objects - got nil")
+	}
+
+	status, err = client.DeleteRGWBucket("scratch", true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusNoContent {
+		t.Errorf("expected http state 204 - got %d", status)
+	}
+
+	if _, _, err = client.GetRGWBucket("scratch"); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+}
@@ -1,13 +1,18 @@
 package ceph
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"net"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
+	"sync"
+	"time"
 )
 
 // see https://docs.ceph.com/en/pacific/mgr/ceph_api/index.html
@@ -23,32 +28,45 @@ type Credentials struct {
 	Password string `json:"password"`
 }
 
+// String implements fmt.Stringer hiding the password.
+func (c Credentials) String() string {
+	return fmt.Sprintf("{Username:%s Password:%s}", c.Username, redacted)
+}
+
 type Token struct {
 	Token string `json:"token"`
 }
 
+// String implements fmt.Stringer hiding the token.
+func (t Token) String() string {
+	return fmt.Sprintf("{Token:%s}", redacted)
+}
+
+// Permissions implements the permissions per scope granted to a logged-in user.
+type Permissions struct {
+	CephFS            []string `json:"cephfs"`
+	ConfigOpt         []string `json:"config-opt"`
+	DashboardSettings []string `json:"dashboard-settings"`
+	Grafana           []string `json:"grafana"`
+	Hosts             []string `json:"hosts"`
+	Iscsi             []string `json:"iscsi"`
+	Log               []string `json:"log"`
+	Manager           []string `json:"manager"`
+	Monitor           []string `json:"monitor"`
+	NfsGanesha        []string `json:"nfs-ganesha"`
+	Osd               []string `json:"osd"`
+	Pool              []string `json:"pool"`
+	Prometheus        []string `json:"prometheus"`
+	RbdImage          []string `json:"rbd-image"`
+	RbdMirroring      []string `json:"rbd-mirroring"`
+	Rgw               []string `json:"rgw"`
+	User              []string `json:"user"`
+}
+
 type Auth struct {
-	Token       string `json:"token"`
-	Username    string `json:"username"`
-	Permissions struct {
-		CephFS            []string `json:"cephfs"`
-		ConfigOpt         []string `json:"config-opt"`
-		DashboardSettings []string `json:"dashboard-settings"`
-		Grafana           []string `json:"grafana"`
-		Hosts             []string `json:"hosts"`
-		Iscsi             []string `json:"iscsi"`
-		Log               []string `json:"log"`
-		Manager           []string `json:"manager"`
-		Monitor           []string `json:"monitor"`
-		NfsGanesha        []string `json:"nfs-ganesha"`
-		Osd               []string `json:"osd"`
-		Pool              []string `json:"pool"`
-		Prometheus        []string `json:"prometheus"`
-		RbdImage          []string `json:"rbd-image"`
-		RbdMirroring      []string `json:"rbd-mirroring"`
-		Rgw               []string `json:"rgw"`
-		User              []string `json:"user"`
-	} `json:"permissions"`
+	Token             string      `json:"token"`
+	Username          string      `json:"username"`
+	Permissions       Permissions `json:"permissions"`
 	PwdExpirationDate interface{} `json:"pwdExpirationDate"`
 	Sso               bool        `json:"sso"`
 	PwdUpdateRequired bool        `json:"pwdUpdateRequired"`
@@ -60,6 +78,43 @@ type Server struct {
 	Protocol           string
 	APIPath            string
 	InsecureSkipVerify bool
+
+	// Endpoints lists further mgrs of the cluster. The session sends its requests to the active mgr found among
+	// Address and Endpoints and looks for it again once the mgr in use fails or turns standby.
+	Endpoints []Endpoint
+}
+
+// Endpoint implements the address of a single mgr. Port and Protocol default to the ones of the Server.
+type Endpoint struct {
+	Address  string
+	Port     uint
+	Protocol string
+}
+
+// String returns the endpoint as url, e.g. https://mgr-1:8443.
+func (e Endpoint) String() string {
+	return fmt.Sprintf("%s://%s", e.Protocol, e.host())
+}
+
+func (e Endpoint) host() string {
+	return net.JoinHostPort(e.Address, strconv.FormatUint(uint64(e.Port), 10))
+}
+
+// endpoints returns the endpoint of the server followed by Endpoints, defaulting port and protocol.
+func (server *Server) endpoints() []Endpoint {
+	list := []Endpoint{{Address: server.Address, Port: server.Port, Protocol: server.Protocol}}
+
+	for _, e := range server.Endpoints {
+		if e.Port == 0 {
+			e.Port = server.Port
+		}
+		if e.Protocol == "" {
+			e.Protocol = server.Protocol
+		}
+		list = append(list, e)
+	}
+
+	return list
 }
 
 func (server *Server) getURL(subPath string) string {
@@ -71,15 +126,60 @@ func (server *Server) getURL(subPath string) string {
 		subPath)
 }
 
+// getUIURL returns the url of subPath below the ui api path next to the api path (ui-api for api). The dashboard
+// serves endpoints there which are not part of the public api, e.g. the iscsi gateway status.
+func (server *Server) getUIURL(subPath string) string {
+	return fmt.Sprintf("%s://%s:%d/%s/%s",
+		server.Protocol,
+		server.Address,
+		server.Port,
+		path.Join(path.Dir(server.APIPath), "ui-"+path.Base(server.APIPath)),
+		subPath)
+}
+
+// AuthCheck implements struct returned from POST /api/auth/check.
+// LoginURL is only set if the checked token is not valid (anymore).
+type AuthCheck struct {
+	Username          string      `json:"username"`
+	Permissions       Permissions `json:"permissions"`
+	Sso               bool        `json:"sso"`
+	PwdUpdateRequired bool        `json:"pwdUpdateRequired"`
+	LoginURL          string      `json:"login_url"`
+}
+
 type Session struct {
 	Client *resty.Client
 	Server Server
 	Auth   Auth
+
+	// CredentialProvider is used to re-login after the token expired. If nil, the credentials of the last
+	// successful Login are used.
+	CredentialProvider CredentialProvider
+
+	// Logger receives a debug message with method, path, status and duration of every request. If nil, the global
+	// zerolog logger is used.
+	Logger *Adapter
+
+	mu          sync.RWMutex
+	reloginMu   sync.Mutex
+	credentials *Credentials
+
+	discoverMu   sync.Mutex
+	active       Endpoint
+	endpoints    []Endpoint
+	probe        http.RoundTripper
+	probeTimeout time.Duration
+
+	metrics Metrics
+	tracer  Tracer
 }
 
 const (
 	cephMimeType = "application/vnd.ceph.api.v1.0+json"
 	jsonMimeType = "application/json"
+
+	// cephMimeTypeV01 is accepted by the endpoints the dashboard versions as experimental, e.g. POST /api/host.
+	cephMimeTypeV01 = "application/vnd.ceph.api.v0.1+json"
 )
 
 var (
@@ -91,105 +191,423 @@ var (
 		"Accept":       cephMimeType,
 		"Content-type": jsonMimeType,
 	}
+
+	defaultHeaderJsonV01 = map[string]string{
+		"Accept":       cephMimeTypeV01,
+		"Content-type": jsonMimeType,
+	}
 )
 
-func NewSession(server Server) (session *Session, err error) {
+// NewSession creates a new session for server configured by opts (see New).
+func NewSession(server Server, opts ...Option) (session *Session, err error) {
+	return NewSessionWithContext(context.Background(), server, opts...)
+}
+
+// NewSessionWithContext is like NewSession but aborts the active mgr lookup if ctx is done.
+func NewSessionWithContext(ctx context.Context, server Server, opts ...Option) (session *Session, err error) {
+	o, err := newOptions(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newSession(ctx, server, o)
+}
+
+func newSession(ctx context.Context, server Server, o options) (session *Session, err error) {
+
+	if o.httpClient != nil && server.InsecureSkipVerify {
+		return nil, ErrHTTPClientConflict
+	}
 
 	session = &Session{
-		Client: resty.New(),
-		Server: server,
+		Server:       server,
+		Logger:       o.logger,
+		endpoints:    server.endpoints(),
+		probeTimeout: o.timeout,
+		metrics:      o.metrics,
+		tracer:       o.tracer,
+	}
+
+	if o.httpClient != nil {
+		// copy the client, the session changes its redirect policy, timeout and transport below
+		httpClient := *o.httpClient
+		session.Client = resty.NewWithClient(&httpClient)
+	} else {
+		session.Client = resty.New()
 	}
 
 	// session.Client.SetCookieJar(nil)
 
-	if server.InsecureSkipVerify {
-		session.Client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	// configure the base transport before it gets wrapped by the authTransport
+	if server.InsecureSkipVerify || o.configuresTransport() {
+		transport, ok := session.Client.GetClient().Transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("can not configure transport of type %T", session.Client.GetClient().Transport)
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: server.InsecureSkipVerify,
+			RootCAs:            o.caCertPool,
+			Certificates:       o.clientCerts,
+		}
+
+		if o.proxyURL != nil {
+			transport.Proxy = http.ProxyURL(o.proxyURL)
+		}
+	}
+
+	if o.timeout > 0 {
+		session.Client.SetTimeout(o.timeout)
+	}
+
+	if o.userAgent != "" {
+		session.Client.SetHeader("User-Agent", o.userAgent)
 	}
 
 	// do not redirect
 	session.Client.SetRedirectPolicy(resty.NoRedirectPolicy())
 
-	err = session.CheckGetMgrAddress()
+	// send requests to the active mgr, add bearer token and re-login on http 401
+	session.probe = session.Client.GetClient().Transport
+	session.Client.SetTransport(&authTransport{
+		session: session,
+		base:    &failoverTransport{session: session, base: &tracingTransport{session: session, base: session.probe}},
+	})
+
+	// log requests and keep secrets out of resty debug output
+	session.Client.SetLogger(restyLogger{session: session})
+	session.Client.OnAfterResponse(session.logResponse)
+	session.Client.OnError(session.logError)
+	session.Client.OnRequestLog(redactRequestLog)
+	session.Client.OnResponseLog(redactResponseLog)
+
+	err = session.CheckGetMgrAddressWithContext(ctx)
 
 	return session, err
 }
 
 // Login log in to ceph rest api (https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-auth)
 func (s *Session) Login(username, password string) (status int, err error) {
-	var resp *resty.Response
+	return s.LoginWithContext(context.Background(), username, password)
+}
 
+// LoginWithContext is like Login but aborts the request if ctx is done.
+// The credentials are kept to re-login transparently once the token expires.
+func (s *Session) LoginWithContext(ctx context.Context, username, password string) (status int, err error) {
 	authBody := Credentials{
 		Username: username,
 		Password: password,
 	}
 
-	if username == "" {
+	status, err = s.login(ctx, authBody)
+
+	if err == nil {
+		s.mu.Lock()
+		s.credentials = &authBody
+		s.mu.Unlock()
+	}
+
+	return status, err
+}
+
+func (s *Session) login(ctx context.Context, authBody Credentials) (status int, err error) {
+	var (
+		resp *resty.Response
+		auth Auth
+	)
+
+	ctx, end := s.startOp(ctx, "Login")
+	defer func() { end(err) }()
+
+	if authBody.Username == "" {
 		return 0, ErrUserNameEmpty
 	}
 
-	if password == "" {
+	if authBody.Password == "" {
 		return 0, ErrPasswordEmpty
 	}
 
 	resp, err = s.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
 		SetBody(authBody).
-		SetResult(&s.Auth).
+		SetResult(&auth).
 		Post(s.Server.getURL("auth"))
 
 	if err != nil {
-		return 0, err
+		return 0, ctxErr(ctx, "Login", err)
 	}
 
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), fmt.Errorf("could not login: %v", resp.Error())
+		return resp.StatusCode(), newAPIError(resp)
 	}
 
+	s.mu.Lock()
+	s.Auth = auth
+	s.mu.Unlock()
+
 	return resp.StatusCode(), err
 }
 
-func (s *Session) CheckGetMgrAddress() error {
-	resp, err := s.Client.R().SetHeaders(defaultHeaders).Get(s.Server.getURL(""))
-
-	// log.Println(resp)
-	if resp.StatusCode() == http.StatusSeeOther {
-		locationURL := resp.Header().Get("Location")
-		if locationURL != "" {
-			urlParts, urlPartsErr := url.Parse(locationURL)
-			if urlPartsErr != nil {
-				return urlPartsErr
-			}
-			s.Server.Address = urlParts.Hostname()
-			var port int
-			port, err = strconv.Atoi(urlParts.Port())
-			if err != nil {
-				return err
-			}
-
-			s.Server.Port = uint(port)
-			s.Server.Protocol = urlParts.Scheme
-			return nil
+// Token returns the token of the current login, which is empty if not logged in.
+func (s *Session) Token() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Auth.Token
+}
+
+// Relogin logs in again using the CredentialProvider or the credentials of the last successful Login.
+func (s *Session) Relogin() (status int, err error) {
+	return s.ReloginWithContext(context.Background())
+}
+
+// ReloginWithContext is like Relogin but aborts the request if ctx is done.
+func (s *Session) ReloginWithContext(ctx context.Context) (status int, err error) {
+	var credentials Credentials
+
+	s.mu.RLock()
+	provider, stored := s.CredentialProvider, s.credentials
+	s.mu.RUnlock()
+
+	switch {
+	case provider != nil:
+		credentials, err = provider.Credentials(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("could not get credentials: %w", err)
 		}
+	case stored != nil:
+		credentials = *stored
+	default:
+		return 0, ErrNoCredentials
+	}
+
+	return s.login(ctx, credentials)
+}
+
+// relogin re-authenticates after a http 401 received for staleToken. Concurrent callers share a single login.
+func (s *Session) relogin(ctx context.Context, staleToken string) error {
+	s.reloginMu.Lock()
+	defer s.reloginMu.Unlock()
+
+	if token := s.Token(); token != "" && token != staleToken {
+		// already re-authenticated by a concurrent request
+		return nil
+	}
+
+	_, err := s.ReloginWithContext(ctx)
+
+	return err
+}
+
+// Valid checks if the token was accepted by the mgr.
+func (a AuthCheck) Valid() bool {
+	return a.Username != "" && a.LoginURL == ""
+}
+
+// CheckToken validates the current token (https://docs.ceph.com/en/latest/mgr/ceph_api/#post--api-auth-check).
+func (s *Session) CheckToken() (status int, check AuthCheck, err error) {
+	return s.CheckTokenWithContext(context.Background())
+}
+
+// CheckTokenWithContext is like CheckToken but aborts the request if ctx is done.
+func (s *Session) CheckTokenWithContext(ctx context.Context) (status int, check AuthCheck, err error) {
+	var resp *resty.Response
+
+	ctx, end := s.startOp(ctx, "CheckToken")
+	defer func() { end(err) }()
+
+	token := s.Token()
+	if token == "" {
+		return 0, check, nil
+	}
+
+	resp, err = s.Client.R().
+		SetContext(ctx).
+		SetHeaders(defaultHeaderJson).
+		SetQueryParam("token", token).
+		SetBody(Token{Token: token}).
+		SetResult(&check).
+		Post(s.Server.getURL("auth/check"))
+
+	if err != nil {
+		return 0, check, ctxErr(ctx, "CheckToken", err)
 	}
 
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), check, newAPIError(resp)
+	}
+
+	return resp.StatusCode(), check, nil
+}
+
+// CheckGetMgrAddress looks for the active mgr among the address of the server and Server.Endpoints: a standby mgr
+// answering with http 303 points to the active one, mgrs not reachable are skipped. The session sends its requests
+// to the active mgr (see ActiveEndpoint), Server is not changed. ErrNoActiveMgr is returned if no mgr answered.
+func (s *Session) CheckGetMgrAddress() error {
+	return s.CheckGetMgrAddressWithContext(context.Background())
+}
+
+// CheckGetMgrAddressWithContext is like CheckGetMgrAddress but aborts the lookup if ctx is done.
+func (s *Session) CheckGetMgrAddressWithContext(ctx context.Context) error {
+	s.discoverMu.Lock()
+	defer s.discoverMu.Unlock()
+
+	_, err := s.discover(ctx)
+
 	return err
 }
 
+// ActiveEndpoint returns the mgr the session currently sends its requests to.
+func (s *Session) ActiveEndpoint() Endpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.active == (Endpoint{}) {
+		return s.Server.endpoints()[0]
+	}
+
+	return s.active
+}
+
 // Logout from ceph rest api.
 func (s *Session) Logout() (err error) {
+	return s.LogoutWithContext(context.Background())
+}
+
+// LogoutWithContext is like Logout but aborts the request if ctx is done.
+func (s *Session) LogoutWithContext(ctx context.Context) (err error) {
 	var resp *resty.Response
 
-	resp, err = s.Client.R().SetHeaders(defaultHeaders).Post(s.Server.getURL("auth/logout"))
+	ctx, end := s.startOp(ctx, "Logout")
+	defer func() { end(err) }()
+
+	resp, err = s.Client.R().SetContext(ctx).SetHeaders(defaultHeaders).Post(s.Server.getURL("auth/logout"))
 
 	if err != nil {
-		return err
+		return ctxErr(ctx, "Logout", err)
 	}
 
 	if !resp.IsSuccess() {
-		return fmt.Errorf(resp.String())
+		return newAPIError(resp)
 	}
 
+	s.mu.Lock()
 	s.Auth.Token = ""
+	s.mu.Unlock()
 
 	return err
 }
+
+// logger returns the Logger of the session or the global zerolog logger if unset.
+func (s *Session) logger() *Adapter {
+	if s.Logger == nil {
+		return NewLogger()
+	}
+
+	return s.Logger
+}
+
+// logResponse logs method, path, status and duration of a finished request and reports them to the Metrics.
+func (s *Session) logResponse(_ *resty.Client, resp *resty.Response) error {
+	req := resp.Request
+
+	s.metricsSink().ObserveRequest(req.Method, s.endpoint(req.URL), resp.StatusCode(), resp.Time())
+
+	s.logger().Log(req.Context(), LogLevelDebug, "request",
+		LogField{Key: "method", Value: req.Method},
+		LogField{Key: "path", Value: requestPath(req)},
+		LogField{Key: "status", Value: resp.StatusCode()},
+		LogField{Key: "duration", Value: resp.Time()},
+	)
+
+	return nil
+}
+
+// logError logs method, path and duration of a request failed without response, e.g. on connection errors, and
+// reports them to the Metrics.
+func (s *Session) logError(req *resty.Request, err error) {
+	var (
+		status   int
+		duration time.Duration
+	)
+
+	fields := []LogField{
+		{Key: "method", Value: req.Method},
+		{Key: "path", Value: requestPath(req)},
+	}
+
+	var respErr *resty.ResponseError
+	if errors.As(err, &respErr) && respErr.Response.RawResponse != nil {
+		status = respErr.Response.StatusCode()
+		fields = append(fields, LogField{Key: "status", Value: status})
+	}
+
+	if !req.Time.IsZero() {
+		duration = time.Since(req.Time)
+		fields = append(fields, LogField{Key: "duration", Value: duration})
+	}
+
+	s.metricsSink().ObserveRequest(req.Method, s.endpoint(req.URL), status, duration)
+
+	fields = append(fields, LogField{Key: "error", Value: err})
+
+	s.logger().Log(req.Context(), LogLevelDebug, "request failed", fields...)
+}
+
+// requestPath returns the path of req without query, which might hold a token (e.g. on auth/check).
+func requestPath(req *resty.Request) string {
+	if req.RawRequest != nil {
+		return req.RawRequest.URL.Path
+	}
+
+	if u, err := url.Parse(req.URL); err == nil {
+		return u.Path
+	}
+
+	return req.URL
+}
+
+// redactRequestLog hides the password and the token in resty debug output.
+func redactRequestLog(rl *resty.RequestLog) error {
+	if rl.Header.Get("Authorization") != "" {
+		rl.Header.Set("Authorization", "Bearer "+redacted)
+	}
+
+	if rl.Header.Get("Cookie") != "" {
+		rl.Header.Set("Cookie", redacted)
+	}
+
+	rl.Body = redactSecrets(rl.Body)
+
+	return nil
+}
+
+// redactResponseLog hides the token returned on login in resty debug output.
+func redactResponseLog(rl *resty.ResponseLog) error {
+	if rl.Header.Get("Set-Cookie") != "" {
+		rl.Header.Set("Set-Cookie", redacted)
+	}
+
+	rl.Body = redactSecrets(rl.Body)
+
+	return nil
+}
+
+// restyLogger implements resty.Logger passing resty messages (e.g. debug output) to the Logger of the session.
+type restyLogger struct {
+	session *Session
+}
+
+func (l restyLogger) Errorf(f string, v ...interface{}) {
+	l.session.logger().Errorf(f, v...)
+}
+
+func (l restyLogger) Warnf(f string, v ...interface{}) {
+	l.session.logger().Warningf(f, v...)
+}
+
+func (l restyLogger) Debugf(f string, v ...interface{}) {
+	l.session.logger().Debugf(f, v...)
+}
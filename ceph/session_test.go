@@ -1,20 +1,21 @@
 package ceph_test
 
 import (
+	"context"
+	"errors"
 	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
 	"testing"
 )
 
 func TestNewSession(t *testing.T) {
-	server := ceph.Server{
-		Address:            "192.168.21.30",
-		Port:               8443,
-		Protocol:           "https",
-		APIPath:            "api",
-		InsecureSkipVerify: true,
-	}
-	s, err := ceph.NewSession(server)
+	s, err := ceph.NewSession(getServer())
 	if err != nil {
 		t.Error(err)
 	}
@@ -33,14 +34,7 @@ func TestNewSession(t *testing.T) {
 }
 
 func TestSession_Logout(t *testing.T) {
-	server := ceph.Server{
-		Address:            "10.10.2.23",
-		Port:               8443,
-		Protocol:           "https",
-		APIPath:            "api",
-		InsecureSkipVerify: true,
-	}
-	s, err := ceph.NewSession(server)
+	s, err := ceph.NewSession(getServer())
 	if err != nil {
 		t.Error(err)
 	}
@@ -63,3 +57,383 @@ func TestSession_Logout(t *testing.T) {
 	}
 
 }
+
+func TestNewSession_RedirectToActiveMgr(t *testing.T) {
+	active := cephtest.NewServer()
+	defer active.Close()
+
+	standby := cephtest.NewServer()
+	defer standby.Close()
+
+	standby.Redirect(active.URL)
+
+	s, err := ceph.NewSession(standby.CephServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.ActiveEndpoint(); got != active.CephEndpoint() {
+		t.Errorf("expected active endpoint %s - got %s", active.CephEndpoint(), got)
+	}
+
+	status, errLogin := s.Login(username, password)
+	if errLogin != nil {
+		t.Error(errLogin)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+}
+
+func TestSession_BearerTokenAndRelogin(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+
+	// rely on the bearer token only
+	client.Session.Client.SetCookieJar(nil)
+
+	status, _, err := client.ListBlockImage("test-pool-1")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	// token expires --> session logs in again and replays the request
+	token := client.Session.Token()
+	srv.Expire()
+
+	status, err = client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "relogin-img", Size: 1073741824})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if logins := srv.RequestCount(http.MethodPost, "/api/auth"); logins != 2 {
+		t.Errorf("expected 2 logins - got %d", logins)
+	}
+
+	if client.Session.Token() == token {
+		t.Error("expected new token after re-login")
+	}
+}
+
+func TestSession_CredentialProvider(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	s, err := ceph.NewSession(srv.CephServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without credentials the http 401 is returned as is
+	client := &ceph.Client{Session: s, Logger: ceph.NewLogger()}
+
+	status, _, err := client.ListPools(false)
+	if err == nil {
+		t.Error("expected error without login - got nil")
+	}
+
+	if status != http.StatusUnauthorized {
+		t.Errorf("expected http state 401 - got %d", status)
+	}
+
+	s.CredentialProvider = ceph.CredentialProviderFunc(func(ctx context.Context) (ceph.Credentials, error) {
+		return ceph.Credentials{Username: cephtest.Username, Password: cephtest.Password}, nil
+	})
+
+	status, _, err = client.ListPools(false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+}
+
+func TestSession_CheckToken(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	client := newLoggedInClient(t, srv)
+
+	status, check, err := client.Session.CheckToken()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if !check.Valid() || check.Username != cephtest.Username {
+		t.Errorf("expected valid token for %s - got %+v", cephtest.Username, check)
+	}
+
+	srv.Expire()
+
+	_, check, err = client.Session.CheckToken()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if check.Valid() {
+		t.Error("expected expired token to be invalid")
+	}
+}
+
+func TestNewSession_SkipUnavailableMgr(t *testing.T) {
+	down := cephtest.NewServer()
+	down.Close()
+
+	active := cephtest.NewServer()
+	defer active.Close()
+
+	server := down.CephServer()
+	server.Endpoints = []ceph.Endpoint{active.CephEndpoint()}
+
+	s, err := ceph.NewSession(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.ActiveEndpoint(); got != active.CephEndpoint() {
+		t.Errorf("expected active endpoint %s - got %s", active.CephEndpoint(), got)
+	}
+
+	status, err := s.Login(username, password)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+}
+
+func TestSession_CheckGetMgrAddressConcurrent(t *testing.T) {
+	down := cephtest.NewServer()
+	down.Close()
+
+	active := cephtest.NewServer()
+	defer active.Close()
+
+	active.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	server := down.CephServer()
+	server.Endpoints = []ceph.Endpoint{active.CephEndpoint()}
+
+	client, err := ceph.New(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(username, password); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	// looking for the active mgr again while requests are sent must not race (go test -race)
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			if err := client.Session.CheckGetMgrAddress(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			if _, _, err := client.GetPool("test-pool-1", false); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := client.Session.ActiveEndpoint(); got != active.CephEndpoint() {
+		t.Errorf("expected active endpoint %s - got %s", active.CephEndpoint(), got)
+	}
+
+	if client.Session.Server.Address != server.Address || client.Session.Server.Port != server.Port {
+		t.Errorf("expected server %s:%d not to be changed - got %s:%d", server.Address, server.Port,
+			client.Session.Server.Address, client.Session.Server.Port)
+	}
+}
+
+func TestNewSession_NoActiveMgr(t *testing.T) {
+	down := cephtest.NewServer()
+	down.Close()
+
+	_, err := ceph.NewSession(down.CephServer())
+
+	if !errors.Is(err, ceph.ErrNoActiveMgr) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNoActiveMgr, err)
+	}
+}
+
+func TestSession_FailoverOnRedirect(t *testing.T) {
+	first := cephtest.NewServer()
+	defer first.Close()
+
+	second := cephtest.NewServer()
+	defer second.Close()
+
+	for _, srv := range []*cephtest.Server{first, second} {
+		srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	}
+
+	second.Redirect(first.URL)
+
+	server := first.CephServer()
+	server.Endpoints = []ceph.Endpoint{second.CephEndpoint()}
+
+	client, err := ceph.New(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(username, password); err != nil {
+		t.Fatal(err)
+	}
+
+	// mgr fails over --> the session follows the redirect and logs in on the new active mgr
+	second.Redirect("")
+	first.Redirect(second.URL)
+
+	status, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "failover-img", Size: 1073741824})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if got := client.Session.ActiveEndpoint(); got != second.CephEndpoint() {
+		t.Errorf("expected active endpoint %s - got %s", second.CephEndpoint(), got)
+	}
+
+	if logins := second.RequestCount(http.MethodPost, "/api/auth"); logins != 1 {
+		t.Errorf("expected 1 login on new active mgr - got %d", logins)
+	}
+}
+
+func TestSession_FailoverOnConnectionError(t *testing.T) {
+	first := cephtest.NewServer()
+
+	second := cephtest.NewServer()
+	defer second.Close()
+
+	second.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	server := first.CephServer()
+	server.Endpoints = []ceph.Endpoint{second.CephEndpoint()}
+
+	client, err := ceph.New(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(username, password); err != nil {
+		t.Fatal(err)
+	}
+
+	first.Close()
+
+	status, _, err := client.GetPool("test-pool-1", false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if got := client.Session.ActiveEndpoint(); got != second.CephEndpoint() {
+		t.Errorf("expected active endpoint %s - got %s", second.CephEndpoint(), got)
+	}
+}
+
+func TestSession_NoFailoverAfterPostSent(t *testing.T) {
+	first := cephtest.NewServer()
+	defer first.Close()
+
+	second := cephtest.NewServer()
+	defer second.Close()
+
+	for _, srv := range []*cephtest.Server{first, second} {
+		srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	}
+
+	// the first mgr reads the post, drops the connection and turns standby
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/api/block/image" {
+			_, _ = ioutil.ReadAll(r.Body)
+
+			second.Redirect("")
+			first.Redirect(second.URL)
+
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				_ = conn.Close()
+			}
+
+			return
+		}
+
+		first.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	second.Redirect(proxy.URL)
+
+	server := first.CephServer()
+	u, _ := url.Parse(proxy.URL)
+	port, _ := strconv.Atoi(u.Port())
+	server.Address, server.Port = u.Hostname(), uint(port)
+	server.Endpoints = []ceph.Endpoint{second.CephEndpoint()}
+
+	client, err := ceph.New(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(username, password); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "failover-img", Size: 1073741824}); err == nil {
+		t.Error("expected connection error")
+	}
+
+	if requests := second.RequestCount(http.MethodPost, "/api/block/image"); requests != 0 {
+		t.Errorf("expected post not to be sent to the new active mgr - got %d requests", requests)
+	}
+
+	// requests not sent yet go to the new active mgr
+	if _, _, err = client.GetPool("test-pool-1", false); err != nil {
+		t.Error(err)
+	}
+
+	if got := client.Session.ActiveEndpoint(); got != second.CephEndpoint() {
+		t.Errorf("expected active endpoint %s - got %s", second.CephEndpoint(), got)
+	}
+}
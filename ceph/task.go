@@ -1,7 +1,7 @@
 package ceph
 
 import (
-	"fmt"
+	"context"
 	"github.com/go-resty/resty/v2"
 	"time"
 )
@@ -12,6 +12,40 @@ type MetaData struct {
 	Namespace *string `json:"namespace"`
 	ImageName string  `json:"image_name"`
 	ImageSpec string  `json:"image_spec"`
+
+	// set on rbd/snap/* tasks
+	SnapshotName string `json:"snapshot_name,omitempty"`
+
+	// set on rbd/trash/restore and rbd/trash/remove tasks
+	ImageIDSpec  string `json:"image_id_spec,omitempty"`
+	NewImageName string `json:"new_image_name,omitempty"`
+
+	// set on rbd/copy tasks
+	SrcImageSpec  string  `json:"src_image_spec,omitempty"`
+	DestPoolName  string  `json:"dest_pool_name,omitempty"`
+	DestNamespace *string `json:"dest_namespace,omitempty"`
+	DestImageName string  `json:"dest_image_name,omitempty"`
+
+	// set on rbd/clone tasks
+	ParentImageSpec string  `json:"parent_image_spec,omitempty"`
+	ChildPoolName   string  `json:"child_pool_name,omitempty"`
+	ChildNamespace  *string `json:"child_namespace,omitempty"`
+	ChildImageName  string  `json:"child_image_name,omitempty"`
+
+	// set on osd/* tasks
+	SvcID string `json:"svc_id,omitempty"`
+
+	// set on nfs/* tasks, Path and Fsal on nfs/create only
+	ClusterID string `json:"cluster_id,omitempty"`
+	ExportID  string `json:"export_id,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Fsal      string `json:"fsal,omitempty"`
+
+	// set on iscsi/target/* tasks
+	TargetIQN string `json:"target_iqn,omitempty"`
+
+	// set on rbd/mirroring/peer/edit and rbd/mirroring/peer/delete tasks
+	PeerUUID string `json:"peer_uuid,omitempty"`
 }
 
 // Exception implements struct returned on http 400 responses.
@@ -48,23 +82,43 @@ type Tasks struct {
 
 // GetTask get tasks (https://docs.ceph.com/en/latest/mgr/ceph_api/#get--api-task)
 func (c *Client) GetTask() (int, Tasks, error) {
+	return c.GetTaskWithContext(context.Background())
+}
+
+// GetTaskWithContext is like GetTask but aborts the request if ctx is done.
+func (c *Client) GetTaskWithContext(ctx context.Context) (int, Tasks, error) {
+	return c.GetTaskByNameWithContext(ctx, "")
+}
+
+// GetTaskByName get tasks filtered by name (e.g. rbd/create), an empty name returns all tasks.
+func (c *Client) GetTaskByName(name string) (int, Tasks, error) {
+	return c.GetTaskByNameWithContext(context.Background(), name)
+}
+
+// GetTaskByNameWithContext is like GetTaskByName but aborts the request if ctx is done.
+func (c *Client) GetTaskByNameWithContext(ctx context.Context, name string) (int, Tasks, error) {
 	var resp *resty.Response
 
 	var err error
 	var t Tasks
 
-	client := *c.Session.Client
-
-	resp, err = client.
-		SetRetryCount(10).
-		SetRetryWaitTime(10 * time.Second).
-		R().
+	req := c.Session.Client.R().
+		SetContext(ctx).
 		SetHeaders(defaultHeaderJson).
-		SetResult(&t).
-		Get(c.Session.Server.getURL("task"))
+		SetResult(&t)
+
+	if name != "" {
+		req.SetQueryParam("name", name)
+	}
+
+	resp, err = c.execute("GetTask", req, resty.MethodGet, c.Session.Server.getURL("task"))
+
+	if err != nil {
+		return 0, Tasks{}, ctxErr(ctx, "GetTask", err)
+	}
 
 	if !resp.IsSuccess() {
-		return resp.StatusCode(), Tasks{}, fmt.Errorf("%v", resp.RawResponse)
+		return resp.StatusCode(), Tasks{}, newAPIError(resp)
 	}
 
 	c.Logger.Debugf("%d tasks executing, %d tasks finished", len(t.ExecutingTasks), len(t.FinishedTasks))
@@ -72,84 +126,76 @@ func (c *Client) GetTask() (int, Tasks, error) {
 	return resp.StatusCode(), t, err
 }
 
+// WaitForTaskIsDone waits until workTask is no longer executing and returns the matching finished task.
+// The task list is polled by the TaskTracker of the client shared by all waiting calls.
 func (c *Client) WaitForTaskIsDone(workTask Task) (Task, error) {
-	// var status int
-	var finishedTask Task
-	var maxAttempts = 600
-	// var tasks Tasks
-	var isFinished bool
-	// var err error
-
-	// check if workTask is still processed
-	for {
-		_, tasks, err := c.GetTask() // get workTask also does retries...
-		if err != nil {
-			return finishedTask, err
-
-		}
-		if !taskIsStillExecuting(workTask, tasks) {
-			break
-		}
-		if workTask.MetaData.ImageSpec != "" {
-			c.Logger.Debugf("still executing: %s %s", workTask.Name, workTask.MetaData.ImageSpec)
-
-		} else {
-			c.Logger.Debugf("still executing: %s %s\n", workTask.Name,
-				PathJoin(workTask.MetaData.PoolName, workTask.MetaData.Namespace, workTask.MetaData.ImageName))
-		}
-
-		time.Sleep(5 * time.Second) // wait 5 seconds...
-	}
+	return c.WaitForTaskIsDoneWithContext(context.Background(), workTask)
+}
+
+// WaitForTaskIsDoneWithContext is like WaitForTaskIsDone but stops waiting as soon as ctx is done.
+// Progress updates are reported to the callback set with WithTaskProgress.
+func (c *Client) WaitForTaskIsDoneWithContext(ctx context.Context, workTask Task) (finishedTask Task, err error) {
+	ctx, end := c.startOp(ctx, "WaitForTaskIsDone")
+	defer func() { end(err) }()
 
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		_, tasks, err := c.GetTask() // get workTask also does retries...
+	ctx = withTaskLogFields(ctx, workTask)
+	start := time.Now()
 
-		if err != nil {
-			return finishedTask, err
-		}
+	finishedTask, err = c.taskTracker().Wait(ctx, workTask)
 
-		// look into executing workTask
-		if isFinished, finishedTask = taskIsFinished(workTask, tasks); isFinished {
-			c.Logger.Debugf("finished: %s %s", workTask.Name, workTask.MetaData.ImageSpec)
-			return finishedTask, nil
-		}
+	c.metrics().ObserveTaskWait(workTask.Name, taskOutcome(ctx, finishedTask, err), time.Since(start))
 
-		c.Logger.Debugf("still not done : %s %s %d", workTask.Name, workTask.MetaData.ImageSpec, attempt)
-		time.Sleep(5 * time.Second) // wait 5 seconds...
+	if err != nil {
+		return finishedTask, ctxErr(ctx, "WaitForTaskIsDone", err)
 	}
 
+	c.Logger.Log(ctx, LogLevelDebug, "task finished",
+		LogField{Key: "success", Value: finishedTask.Success},
+		LogField{Key: "duration", Value: time.Since(start)},
+	)
+
 	return finishedTask, nil
 }
 
-func taskIsStillExecuting(task Task, tasks Tasks) bool {
-	// var imageSpec = fmt.Sprintf("%s/%s", st.MetaData.PoolName, st.MetaData.ImageName)
-	for _, et := range tasks.ExecutingTasks {
-		if matchTask(et, task) {
-			return true
-		}
+// submitTask sends req with method to url and waits until the task started by the request is done. task defines the
+// name and metadata of the task waited for and doneStatus is returned once the task finished successfully.
+func (c *Client) submitTask(ctx context.Context, op string, req *resty.Request, method, url string, task Task, doneStatus int) (status int, err error) {
+	var resp *resty.Response
+
+	ctx = withTaskLogFields(ctx, task)
+	req.SetContext(ctx)
+
+	resp, err = c.execute(op, req, method, url)
+
+	if err != nil {
+		return 0, ctxErr(ctx, op, err)
+	}
+
+	if !resp.IsSuccess() {
+		apiErr := newAPIError(resp)
+		c.Logger.Debugf("err %s (%s)", apiErr.Code, apiErr.Detail)
+
+		return resp.StatusCode(), apiErr
+	}
+
+	task, err = c.WaitForTaskIsDoneWithContext(ctx, task)
+
+	if err != nil {
+		return 0, ctxErr(ctx, op, err)
 	}
-	return false
-}
 
-func taskIsFinished(task Task, tasks Tasks) (bool, Task) {
-	// var imageSpec = fmt.Sprintf("%s/%s", st.MetaData., st.MetaData.ImageName)
-	for _, ft := range tasks.FinishedTasks {
-		if matchTask(ft, task) {
-			return true, ft
-		}
+	if !task.Success {
+		return 0, newTaskError(task)
 	}
 
-	return false, Task{}
+	return doneStatus, nil
 }
 
-func matchTask(finishedTask, workTask Task) bool {
-	if finishedTask.Name == workTask.Name &&
-		finishedTask.MetaData.ImageSpec == workTask.MetaData.ImageSpec &&
-		finishedTask.MetaData.Namespace == workTask.MetaData.Namespace &&
-		finishedTask.MetaData.ImageName == workTask.MetaData.ImageName &&
-		finishedTask.MetaData.PoolName == workTask.MetaData.PoolName {
-		return true
+// taskPollInterval returns the configured TaskPollInterval or DefaultTaskPollInterval if unset.
+func (c *Client) taskPollInterval() time.Duration {
+	if c.TaskPollInterval <= 0 {
+		return DefaultTaskPollInterval
 	}
 
-	return false
+	return c.TaskPollInterval
 }
@@ -1,9 +1,14 @@
 package ceph_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestClient_GetTask(t *testing.T) {
@@ -33,3 +38,177 @@ func TestClient_GetTask(t *testing.T) {
 
 	t.Log(tasks)
 }
+
+func TestClient_GetTaskReplay(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	if err := srv.ReplayFile(http.MethodGet, "/api/task", http.StatusOK, "outputs/tasks.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newLoggedInClient(t, srv)
+
+	status, tasks, err := client.GetTask()
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if len(tasks.ExecutingTasks) != 2 || len(tasks.FinishedTasks) != 14 {
+		t.Errorf("expected 2 executing and 14 finished tasks - got %d and %d",
+			len(tasks.ExecutingTasks), len(tasks.FinishedTasks))
+	}
+}
+
+func TestClient_WaitForTaskIsDoneWithContext(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	// rbd/create of test-pool-1/rest-client-test-3 is executing forever in the captured task list.
+	if err := srv.ReplayFile(http.MethodGet, "/api/task", http.StatusOK, "outputs/tasks.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newLoggedInClient(t, srv)
+	client.TaskPollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := client.WaitForTaskIsDoneWithContext(ctx, ceph.Task{
+		Name: "rbd/create",
+		MetaData: ceph.MetaData{
+			PoolName:  "test-pool-1",
+			ImageName: "rest-client-test-3",
+		},
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected err %v - got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClient_GetTaskByName(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	client := newLoggedInClient(t, srv)
+
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "img-1", Size: 1073741824}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.DeleteBlockImage("test-pool-1", nil, "img-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, tasks, err := client.GetTaskByName("rbd/delete")
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected http state 200 - got %d", status)
+	}
+
+	if len(tasks.FinishedTasks) != 1 || tasks.FinishedTasks[0].Name != "rbd/delete" {
+		t.Errorf("expected 1 finished rbd/delete task - got %+v", tasks.FinishedTasks)
+	}
+}
+
+func TestTaskTracker_SharedPolling(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	srv.SetTaskDuration(300 * time.Millisecond)
+
+	client := newLoggedInClient(t, srv)
+	client.TaskPollInterval = 50 * time.Millisecond
+
+	var futures []*ceph.TaskFuture
+
+	for i := 0; i < 20; i++ {
+		futures = append(futures, client.CreateBlockImageAsync(context.Background(), ceph.RBDCreate{
+			PoolName: "test-pool-1",
+			Name:     fmt.Sprintf("img-%d", i),
+			Size:     1073741824,
+		}))
+	}
+
+	for _, f := range futures {
+		status, err := f.Wait(context.Background())
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		if status != http.StatusCreated {
+			t.Errorf("expected http state 201 - got %d", status)
+		}
+	}
+
+	// 20 independent polling loops would poll at least 20 * 6 times.
+	if polls := srv.RequestCount(http.MethodGet, "/api/task"); polls > 60 {
+		t.Errorf("expected shared polling of /api/task - got %d polls", polls)
+	}
+}
+
+func TestClient_CreateBlockImageAsyncProgress(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	srv.SetTaskDuration(300 * time.Millisecond)
+
+	client := newLoggedInClient(t, srv)
+	client.TaskPollInterval = 20 * time.Millisecond
+
+	var callbacks int
+
+	ctx := ceph.WithTaskProgress(context.Background(), func(task ceph.Task) {
+		callbacks++
+	})
+
+	f := client.CreateBlockImageAsync(ctx, ceph.RBDCreate{PoolName: "test-pool-1", Name: "img", Size: 1073741824})
+
+	var progress []int
+	for task := range f.Progress() {
+		if task.Name != "rbd/create" {
+			t.Errorf("expected progress of rbd/create - got %s", task.Name)
+		}
+		progress = append(progress, task.Progress)
+	}
+
+	status, err := f.Wait(context.Background())
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status != http.StatusCreated {
+		t.Errorf("expected http state 201 - got %d", status)
+	}
+
+	if len(progress) < 2 {
+		t.Errorf("expected at least 2 progress updates - got %v", progress)
+	}
+
+	for i := 1; i < len(progress); i++ {
+		if progress[i] < progress[i-1] {
+			t.Errorf("expected increasing progress - got %v", progress)
+		}
+	}
+
+	if callbacks < len(progress) {
+		t.Errorf("expected at least %d progress callbacks - got %d", len(progress), callbacks)
+	}
+}
@@ -0,0 +1,386 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned if a task waited for is neither executing nor finished on the mgr.
+var ErrTaskNotFound = errors.New("task is neither executing nor finished")
+
+// maxTaskMisses is the number of polls a task may be missing in /api/task before waiting for it is given up.
+const maxTaskMisses = 600
+
+// TaskTracker implements a poller of /api/task shared by all calls waiting for a task of the same client.
+//
+// The tracker polls /api/task once per TaskPollInterval of the client as long as at least one call is waiting and
+// hands the matching executing and finished tasks to the waiting calls. If all waiting calls wait for tasks with the
+// same name, the task list is filtered by name on the mgr (/api/task?name=).
+type TaskTracker struct {
+	client *Client
+
+	mu      sync.Mutex
+	waiters map[*taskWaiter]struct{}
+	running bool
+	wake    chan struct{}
+}
+
+// taskWaiter implements a single call waiting for a task.
+type taskWaiter struct {
+	ctx      context.Context
+	task     Task
+	misses   int
+	progress int
+	updates  chan Task
+	result   chan taskResult
+}
+
+type taskResult struct {
+	task Task
+	err  error
+}
+
+// NewTaskTracker creates a new TaskTracker polling /api/task with client.
+func NewTaskTracker(client *Client) *TaskTracker {
+	return &TaskTracker{
+		client:  client,
+		waiters: make(map[*taskWaiter]struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Wait waits until task is no longer executing and returns the matching finished task. Progress updates of the
+// executing task are reported to the callback set with WithTaskProgress. Waiting is aborted if ctx is done.
+func (t *TaskTracker) Wait(ctx context.Context, task Task) (Task, error) {
+	w := &taskWaiter{
+		ctx:      ctx,
+		task:     task,
+		progress: -1,
+		updates:  make(chan Task, 1),
+		result:   make(chan taskResult, 1),
+	}
+
+	onProgress := taskProgressFromContext(ctx)
+
+	t.add(w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.remove(w)
+			return Task{}, ctx.Err()
+		case update := <-w.updates:
+			if onProgress != nil {
+				onProgress(update)
+			}
+		case r := <-w.result:
+			return r.task, r.err
+		}
+	}
+}
+
+// add registers w and starts polling if not running yet, otherwise the next poll is triggered at once.
+func (t *TaskTracker) add(w *taskWaiter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.waiters[w] = struct{}{}
+
+	if !t.running {
+		t.running = true
+		go t.run()
+		return
+	}
+
+	t.signal()
+}
+
+// remove unregisters w, polling stops once no call is waiting anymore.
+func (t *TaskTracker) remove(w *taskWaiter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.waiters, w)
+
+	if len(t.waiters) == 0 {
+		t.signal()
+	}
+}
+
+// signal wakes up the poller without blocking.
+func (t *TaskTracker) signal() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run polls /api/task until no call is waiting anymore.
+func (t *TaskTracker) run() {
+	for {
+		t.mu.Lock()
+		if len(t.waiters) == 0 {
+			t.running = false
+			t.mu.Unlock()
+			return
+		}
+		name := t.nameFilter()
+		ctx, spans := t.startPolls()
+		t.mu.Unlock()
+
+		_, tasks, err := t.client.GetTaskByNameWithContext(ctx, name)
+
+		t.dispatch(tasks, err)
+
+		for _, span := range spans {
+			endSpan(span, err)
+		}
+
+		timer := time.NewTimer(t.client.taskPollInterval())
+		select {
+		case <-timer.C:
+		case <-t.wake:
+		}
+		timer.Stop()
+	}
+}
+
+// startPolls starts a span for the poll of every waiter as child of the span of the waiting call. The poll request
+// belongs to the span of the first waiter. t.mu must be held.
+func (t *TaskTracker) startPolls() (context.Context, []Span) {
+	tracer := t.client.Session.tracer
+	if tracer == nil {
+		return context.Background(), nil
+	}
+
+	var (
+		pollCtx context.Context = detachedContext{Context: context.Background()}
+		spans   []Span
+	)
+
+	for w := range t.waiters {
+		ctx, span := tracer.Start(w.ctx, "task poll", Attribute{Key: AttributeTask, Value: w.task.Name})
+
+		if spans == nil {
+			// the poll is shared and must not be aborted by the context of the first waiter
+			pollCtx = context.WithValue(detachedContext{Context: ctx}, opSpanKey{}, span)
+		}
+
+		spans = append(spans, span)
+	}
+
+	return pollCtx, spans
+}
+
+// nameFilter returns the task name all waiters wait for or an empty string if the names differ.
+// t.mu must be held.
+func (t *TaskTracker) nameFilter() string {
+	var name string
+
+	for w := range t.waiters {
+		if name != "" && name != w.task.Name {
+			return ""
+		}
+		name = w.task.Name
+	}
+
+	return name
+}
+
+// dispatch hands the polled tasks (or the error of the poll) to the waiters.
+func (t *TaskTracker) dispatch(tasks Tasks, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for w := range t.waiters {
+		if err != nil {
+			t.finish(w, taskResult{err: err})
+			continue
+		}
+
+		if executing, ok := findTask(w.task, tasks.ExecutingTasks); ok {
+			w.misses = 0
+
+			if executing.Progress != w.progress {
+				w.progress = executing.Progress
+				t.client.Logger.Log(withTaskLogFields(context.Background(), w.task), LogLevelDebug, "task executing",
+					LogField{Key: "progress", Value: executing.Progress})
+
+				// keep the latest update only
+				select {
+				case <-w.updates:
+				default:
+				}
+				w.updates <- executing
+			}
+
+			continue
+		}
+
+		if finished, ok := findTask(w.task, tasks.FinishedTasks); ok {
+			t.finish(w, taskResult{task: finished})
+			continue
+		}
+
+		w.misses++
+		t.client.Logger.Log(withTaskLogFields(context.Background(), w.task), LogLevelDebug, "task not found",
+			LogField{Key: "misses", Value: w.misses})
+
+		if w.misses >= maxTaskMisses {
+			t.finish(w, taskResult{err: ErrTaskNotFound})
+		}
+	}
+}
+
+// finish hands r to w and unregisters w. t.mu must be held.
+func (t *TaskTracker) finish(w *taskWaiter, r taskResult) {
+	w.result <- r
+	delete(t.waiters, w)
+}
+
+// taskTracker returns the TaskTracker of the client, creating it on first use.
+func (c *Client) taskTracker() *TaskTracker {
+	c.trackerMu.Lock()
+	defer c.trackerMu.Unlock()
+
+	if c.TaskTracker == nil {
+		c.TaskTracker = NewTaskTracker(c)
+	}
+
+	return c.TaskTracker
+}
+
+// findTask returns the first task in list matching task.
+func findTask(task Task, list []Task) (Task, bool) {
+	key := taskKey(task)
+
+	for _, t := range list {
+		if taskKey(t) == key {
+			return t, true
+		}
+	}
+
+	return Task{}, false
+}
+
+// taskKey returns the key tasks are matched by: the task name and its metadata.
+func taskKey(task Task) string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	return strings.Join([]string{
+		task.Name,
+		task.MetaData.PoolName,
+		deref(task.MetaData.Namespace),
+		task.MetaData.ImageName,
+		task.MetaData.ImageSpec,
+		task.MetaData.SnapshotName,
+		task.MetaData.ImageIDSpec,
+		task.MetaData.NewImageName,
+		task.MetaData.SrcImageSpec,
+		task.MetaData.DestPoolName,
+		deref(task.MetaData.DestNamespace),
+		task.MetaData.DestImageName,
+		task.MetaData.ParentImageSpec,
+		task.MetaData.ChildPoolName,
+		deref(task.MetaData.ChildNamespace),
+		task.MetaData.ChildImageName,
+		task.MetaData.SvcID,
+		task.MetaData.ClusterID,
+		task.MetaData.ExportID,
+		task.MetaData.Path,
+		task.MetaData.Fsal,
+		task.MetaData.TargetIQN,
+		task.MetaData.PeerUUID,
+	}, "\x00")
+}
+
+// taskSpec returns the image spec of task used in log messages.
+func taskSpec(task Task) string {
+	if task.MetaData.ImageSpec != "" {
+		return task.MetaData.ImageSpec
+	}
+
+	return PathJoin(task.MetaData.PoolName, task.MetaData.Namespace, task.MetaData.ImageName)
+}
+
+type taskProgressKey struct{}
+
+// WithTaskProgress returns a copy of ctx reporting the progress of tasks waited for by methods called with the
+// returned context (e.g. CreateBlockImageWithContext) to onProgress. onProgress is called on the calling goroutine
+// whenever the progress of the executing task changed.
+func WithTaskProgress(ctx context.Context, onProgress func(task Task)) context.Context {
+	return context.WithValue(ctx, taskProgressKey{}, onProgress)
+}
+
+func taskProgressFromContext(ctx context.Context) func(task Task) {
+	onProgress, _ := ctx.Value(taskProgressKey{}).(func(task Task))
+	return onProgress
+}
+
+// TaskFuture implements the pending result of a method started asynchronously, e.g. with CreateBlockImageAsync.
+type TaskFuture struct {
+	progress chan Task
+	done     chan struct{}
+	status   int
+	err      error
+}
+
+// Progress returns a channel receiving the latest progress of the executing task. Updates not received in time are
+// dropped in favor of newer ones. The channel is closed once the method returned.
+func (f *TaskFuture) Progress() <-chan Task {
+	return f.progress
+}
+
+// Done returns a channel which is closed once the method returned.
+func (f *TaskFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits until the method returned and returns its result. If ctx is done first, ctx.Err() is returned while the
+// method keeps running until the context it was started with is done.
+func (f *TaskFuture) Wait(ctx context.Context) (status int, err error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-f.done:
+		return f.status, f.err
+	}
+}
+
+// async runs op on a new goroutine and returns its TaskFuture.
+func (c *Client) async(ctx context.Context, op func(ctx context.Context) (int, error)) *TaskFuture {
+	f := &TaskFuture{
+		progress: make(chan Task, 1),
+		done:     make(chan struct{}),
+	}
+
+	parent := taskProgressFromContext(ctx)
+
+	go func() {
+		f.status, f.err = op(WithTaskProgress(ctx, func(task Task) {
+			if parent != nil {
+				parent(task)
+			}
+
+			// keep the latest update only
+			select {
+			case <-f.progress:
+			default:
+			}
+			f.progress <- task
+		}))
+
+		close(f.progress)
+		close(f.done)
+	}()
+
+	return f
+}
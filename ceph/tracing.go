@@ -0,0 +1,255 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TracerName is the name the tracer of the client is requested from the TracerProvider with.
+const TracerName = "github.com/chrisamti/ceph-rest-client/ceph"
+
+// Attribute keys set on the spans of the client.
+const (
+	AttributePool       = "ceph.pool"
+	AttributeNamespace  = "ceph.namespace"
+	AttributeImage      = "ceph.image"
+	AttributeTask       = "ceph.task"
+	AttributeErrorCode  = "ceph.error_code"
+	AttributeEndpoint   = "ceph.endpoint"
+	AttributeAttempt    = "ceph.attempt"
+	AttributeHTTPMethod = "http.method"
+	AttributeHTTPStatus = "http.status_code"
+)
+
+// ErrTracerProviderIsNil is returned by New if WithTracerProvider is used with a nil provider.
+var ErrTracerProviderIsNil = errors.New("param tracerProvider can not be nil")
+
+// Attribute implements a key value pair set on a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span implements a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	AddEvent(name string, attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer implements a tracer, e.g. a small wrapper of an OpenTelemetry tracer and propagator.
+type Tracer interface {
+	// Start starts a span named name as child of the span in ctx and returns a context holding the new span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+
+	// Inject adds the trace context of ctx to the headers of a request sent to the mgr, e.g. the W3C traceparent
+	// header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// TracerProvider implements the source of the Tracer used by the client.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// WithTracerProvider traces the client: every public method gets a span with a child span for each http request
+// sent and each /api/task poll while waiting for a task. The trace context is passed to the mgr with Tracer.Inject.
+func WithTracerProvider(tracerProvider TracerProvider) Option {
+	return func(o *options) error {
+		if tracerProvider == nil {
+			return ErrTracerProviderIsNil
+		}
+
+		o.tracer = tracerProvider.Tracer(TracerName)
+
+		return nil
+	}
+}
+
+// nopSpan implements a Span doing nothing, used if the client is not traced.
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute)    {}
+func (nopSpan) AddEvent(string, ...Attribute) {}
+func (nopSpan) RecordError(error)             {}
+func (nopSpan) End()                          {}
+
+type opSpanKey struct{}
+
+// spanFromContext returns the span of the method or the /api/task poll ctx belongs to.
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(opSpanKey{}).(Span); ok {
+		return span
+	}
+
+	return nopSpan{}
+}
+
+// startOp starts the span of the public method op unless ctx already belongs to a traced method, e.g. the
+// WaitForTaskIsDone called by CreateBlockImage. The returned func ends the span with the error of the method.
+func (s *Session) startOp(ctx context.Context, op string) (context.Context, func(err error)) {
+	if s == nil || s.tracer == nil || ctx.Value(opSpanKey{}) != nil {
+		return ctx, func(error) {}
+	}
+
+	ctx, span := s.tracer.Start(ctx, op)
+
+	return context.WithValue(ctx, opSpanKey{}, span), func(err error) {
+		endSpan(span, err)
+	}
+}
+
+// startOp starts the span of the public method op, see Session.startOp.
+func (c *Client) startOp(ctx context.Context, op string) (context.Context, func(err error)) {
+	return c.Session.startOp(ctx, op)
+}
+
+// endSpan records err (with the ceph error code of an *APIError) and ends span.
+func endSpan(span Span, err error) {
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if apiErr.Code != "" {
+				span.SetAttributes(Attribute{Key: AttributeErrorCode, Value: apiErr.Code})
+			}
+			if apiErr.StatusCode != 0 {
+				span.SetAttributes(Attribute{Key: AttributeHTTPStatus, Value: apiErr.StatusCode})
+			}
+		}
+
+		span.RecordError(err)
+	}
+
+	span.End()
+}
+
+// taskAttributes returns the attributes of task: its name, pool, namespace and image.
+func taskAttributes(task Task) []Attribute {
+	md := task.MetaData
+	attrs := []Attribute{{Key: AttributeTask, Value: task.Name}}
+
+	switch {
+	case md.ImageName != "":
+		attrs = append(attrs, Attribute{Key: AttributeImage, Value: md.ImageName})
+		if md.PoolName != "" {
+			attrs = append(attrs, Attribute{Key: AttributePool, Value: md.PoolName})
+		}
+		if md.Namespace != nil && *md.Namespace != "" {
+			attrs = append(attrs, Attribute{Key: AttributeNamespace, Value: *md.Namespace})
+		}
+	case md.ImageSpec != "":
+		attrs = append(attrs, imageSpecAttributes(md.ImageSpec)...)
+	case md.SrcImageSpec != "":
+		attrs = append(attrs, imageSpecAttributes(md.SrcImageSpec)...)
+	case md.ParentImageSpec != "":
+		attrs = append(attrs, imageSpecAttributes(md.ParentImageSpec)...)
+	case md.PoolName != "":
+		attrs = append(attrs, Attribute{Key: AttributePool, Value: md.PoolName})
+	}
+
+	return attrs
+}
+
+// imageSpecAttributes returns the pool, namespace and image of an image spec (pool/[namespace/]image).
+func imageSpecAttributes(spec string) []Attribute {
+	parts := strings.Split(spec, "/")
+
+	switch len(parts) {
+	case 2:
+		return []Attribute{{Key: AttributePool, Value: parts[0]}, {Key: AttributeImage, Value: parts[1]}}
+	case 3:
+		return []Attribute{
+			{Key: AttributePool, Value: parts[0]},
+			{Key: AttributeNamespace, Value: parts[1]},
+			{Key: AttributeImage, Value: parts[2]},
+		}
+	}
+
+	return nil
+}
+
+// urlAttributes returns the pool, namespace and image addressed by the url of a request below the api path, e.g.
+// block/image/{image_spec} or block/pool/{pool_name}/namespace/{namespace}.
+func (s *Session) urlAttributes(u *url.URL) []Attribute {
+	var attrs []Attribute
+
+	segments := strings.Split(s.endpointPath(u), "/")
+	value := func(i int) string {
+		v, _ := url.PathUnescape(segments[i])
+		return v
+	}
+
+	switch {
+	case len(segments) >= 3 && segments[0] == "block" && segments[1] == "image" && segments[2] != "trash":
+		attrs = imageSpecAttributes(value(2))
+	case len(segments) >= 3 && segments[0] == "block" && segments[1] == "pool":
+		attrs = append(attrs, Attribute{Key: AttributePool, Value: value(2)})
+		if len(segments) >= 5 && segments[3] == "namespace" {
+			attrs = append(attrs, Attribute{Key: AttributeNamespace, Value: value(4)})
+		}
+	case len(segments) >= 2 && segments[0] == "pool":
+		attrs = append(attrs, Attribute{Key: AttributePool, Value: value(1)})
+	}
+
+	if pool := u.Query().Get("pool_name"); pool != "" && len(attrs) == 0 {
+		attrs = append(attrs, Attribute{Key: AttributePool, Value: pool})
+	}
+
+	return attrs
+}
+
+// tracingTransport implements a http.RoundTripper starting a span for every request sent and passing the trace
+// context to the mgr.
+type tracingTransport struct {
+	session *Session
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracer := t.session.tracer
+	if tracer == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	endpoint := t.session.endpoint(req.URL.String())
+	attrs := append([]Attribute{
+		{Key: AttributeHTTPMethod, Value: req.Method},
+		{Key: AttributeEndpoint, Value: endpoint},
+	}, t.session.urlAttributes(req.URL)...)
+
+	// the pool, namespace and image addressed are attributes of the method as well
+	spanFromContext(req.Context()).SetAttributes(attrs[2:]...)
+
+	ctx, span := tracer.Start(req.Context(), req.Method+" "+endpoint, attrs...)
+
+	req = req.Clone(ctx)
+	tracer.Inject(ctx, req.Header)
+
+	resp, err := t.base.RoundTrip(req)
+
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(Attribute{Key: AttributeHTTPStatus, Value: resp.StatusCode})
+	}
+
+	span.End()
+
+	return resp, err
+}
+
+// detachedContext implements a context holding the values of a parent context but not its deadline and
+// cancellation, e.g. for a /api/task poll shared by several waiting calls.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,288 @@
+package ceph_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+// recordingSpan implements ceph.Span keeping everything set on it.
+type recordingSpan struct {
+	tracer *recordingTracer
+	id     int
+	parent int
+	name   string
+	attrs  map[string]interface{}
+	events []string
+	err    error
+	ended  bool
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...ceph.Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) AddEvent(name string, _ ...ceph.Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.events = append(s.events, name)
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.err = err
+}
+
+func (s *recordingSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.ended = true
+}
+
+type spanKey struct{}
+
+// recordingTracer implements ceph.TracerProvider and ceph.Tracer keeping every span started. Inject sets the id of
+// the current span as traceparent header.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Tracer(string) ceph.Tracer {
+	return t
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...ceph.Attribute) (context.Context, ceph.Span) {
+	t.mu.Lock()
+
+	span := &recordingSpan{tracer: t, id: len(t.spans) + 1, name: name, attrs: map[string]interface{}{}}
+	if parent, ok := ctx.Value(spanKey{}).(*recordingSpan); ok {
+		span.parent = parent.id
+	}
+	t.spans = append(t.spans, span)
+
+	t.mu.Unlock()
+
+	span.SetAttributes(attrs...)
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, header http.Header) {
+	if span, ok := ctx.Value(spanKey{}).(*recordingSpan); ok {
+		header.Set("traceparent", strconv.Itoa(span.id))
+	}
+}
+
+// find returns the spans named name.
+func (t *recordingTracer) find(name string) []*recordingSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var spans []*recordingSpan
+
+	for _, span := range t.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+
+	return spans
+}
+
+// children returns the spans named name started as children of span.
+func (t *recordingTracer) children(span *recordingSpan, name string) []*recordingSpan {
+	var spans []*recordingSpan
+
+	for _, child := range t.find(name) {
+		if child.parent == span.id {
+			spans = append(spans, child)
+		}
+	}
+
+	return spans
+}
+
+// newTracedClient returns a client logged in to srv reporting to a recordingTracer. The requests are sent through a
+// proxy recording the traceparent header received per request.
+func newTracedClient(t *testing.T, srv *cephtest.Server) (*ceph.Client, *recordingTracer, func(method, path string) []string) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		parents = map[string][]string{}
+	)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		parents[r.Method+" "+r.URL.Path] = append(parents[r.Method+" "+r.URL.Path], r.Header.Get("traceparent"))
+		mu.Unlock()
+
+		srv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	server := srv.CephServer()
+	u, _ := url.Parse(proxy.URL)
+	port, _ := strconv.Atoi(u.Port())
+	server.Address, server.Port = u.Hostname(), uint(port)
+
+	tracer := &recordingTracer{}
+
+	client, err := ceph.New(server, ceph.WithTracerProvider(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Session.Login(cephtest.Username, cephtest.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	client.TaskPollInterval = 10 * time.Millisecond
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	return client, tracer, func(method, path string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return parents[method+" "+path]
+	}
+}
+
+func TestNew_WithTracerProviderTasks(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+	srv.SetTaskDuration(50 * time.Millisecond)
+
+	client, tracer, parents := newTracedClient(t, srv)
+
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "img-1", Size: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := tracer.find("CreateBlockImage")
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 CreateBlockImage span - got %d", len(ops))
+	}
+
+	op := ops[0]
+	if op.parent != 0 || !op.ended || op.err != nil {
+		t.Errorf("expected ended root span without error - got parent %d, ended %v, err %v", op.parent, op.ended, op.err)
+	}
+
+	for key, value := range map[string]interface{}{
+		ceph.AttributePool:  "test-pool-1",
+		ceph.AttributeImage: "img-1",
+		ceph.AttributeTask:  "rbd/create",
+	} {
+		if op.attrs[key] != value {
+			t.Errorf("expected attribute %s %v - got %v", key, value, op.attrs[key])
+		}
+	}
+
+	// nested methods do not get a span of their own
+	if spans := tracer.find("WaitForTaskIsDone"); len(spans) != 0 {
+		t.Errorf("expected no WaitForTaskIsDone span - got %d", len(spans))
+	}
+
+	posts := tracer.children(op, "POST block/image")
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 POST block/image span - got %d", len(posts))
+	}
+
+	if posts[0].attrs[ceph.AttributeHTTPStatus] != http.StatusAccepted {
+		t.Errorf("expected http status %d - got %v", http.StatusAccepted, posts[0].attrs[ceph.AttributeHTTPStatus])
+	}
+
+	// the trace context of the request span is passed to the mgr
+	expected := strconv.Itoa(posts[0].id)
+	if got := parents(http.MethodPost, "/api/block/image"); len(got) != 1 || got[0] != expected {
+		t.Errorf("expected traceparent [%s] - got %v", expected, got)
+	}
+
+	polls := tracer.children(op, "task poll")
+	if len(polls) < 2 {
+		t.Fatalf("expected at least 2 task poll spans - got %d", len(polls))
+	}
+
+	for _, poll := range polls {
+		if !poll.ended || poll.attrs[ceph.AttributeTask] != "rbd/create" {
+			t.Errorf("expected ended task poll span of rbd/create - got ended %v, task %v", poll.ended, poll.attrs[ceph.AttributeTask])
+		}
+
+		if gets := tracer.children(poll, "GET task"); len(gets) != 1 {
+			t.Errorf("expected 1 GET task span per poll - got %d", len(gets))
+		}
+	}
+
+	if _, err := client.CreateBlockImage(ceph.RBDCreate{PoolName: "test-pool-1", Name: "img-1", Size: 1 << 30}); !errors.Is(err, ceph.ErrAlreadyExists) {
+		t.Fatalf("expected err %v - got %v", ceph.ErrAlreadyExists, err)
+	}
+
+	ops = tracer.find("CreateBlockImage")
+	if op = ops[len(ops)-1]; op.err == nil || op.attrs[ceph.AttributeErrorCode] != ceph.RBDImageAlreadyExists {
+		t.Errorf("expected error code %s - got %v (err %v)", ceph.RBDImageAlreadyExists, op.attrs[ceph.AttributeErrorCode], op.err)
+	}
+}
+
+func TestNew_WithTracerProviderRetries(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("test-pool-1", ceph.PoolApplicationRBD)
+
+	for i := 0; i < 2; i++ {
+		srv.InjectException(http.MethodGet, "/api/pool/test-pool-1", ceph.Exception{
+			Detail: "service unavailable",
+			Status: http.StatusServiceUnavailable,
+		})
+	}
+
+	client, tracer, _ := newTracedClient(t, srv)
+
+	if _, _, err := client.GetPool("test-pool-1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := tracer.find("GetPool")
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 GetPool span - got %d", len(ops))
+	}
+
+	op := ops[0]
+	if op.attrs[ceph.AttributePool] != "test-pool-1" {
+		t.Errorf("expected attribute %s test-pool-1 - got %v", ceph.AttributePool, op.attrs[ceph.AttributePool])
+	}
+
+	if len(op.events) != 2 || op.events[0] != "retry" {
+		t.Errorf("expected 2 retry events - got %v", op.events)
+	}
+
+	if attempts := tracer.children(op, "GET pool/{}"); len(attempts) != 3 {
+		t.Errorf("expected 3 GET pool/{} spans - got %d", len(attempts))
+	}
+
+	if logins := tracer.find("Login"); len(logins) != 1 || len(tracer.children(logins[0], "POST auth")) != 1 {
+		t.Errorf("expected 1 Login span with 1 POST auth span - got %d", len(logins))
+	}
+}
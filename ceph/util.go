@@ -1,6 +1,11 @@
 package ceph
 
-import "path"
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+)
 
 // PathJoin joins array of interfaces having string and pointer to strings.
 func PathJoin(v ...interface{}) string {
@@ -24,6 +29,28 @@ func PathJoin(v ...interface{}) string {
 	return path.Join(segments...)
 }
 
+// ctxErr returns ctx.Err() wrapped with the operation name op if ctx is done, otherwise err is returned unchanged.
+func ctxErr(ctx context.Context, op string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	return err
+}
+
+// sleepWithContext pauses for d or until ctx is done, whichever happens first.
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func StaticCounter() (f func() uint) {
 	var i uint
 	f = func() uint {
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// Environment variables read by cephrest. They override the config file and are overridden by the global flags.
+const (
+	envConfig     = "CEPHREST_CONFIG"
+	envAddress    = "CEPHREST_ADDRESS"
+	envPort       = "CEPHREST_PORT"
+	envProtocol   = "CEPHREST_PROTOCOL"
+	envAPIPath    = "CEPHREST_API_PATH"
+	envInsecure   = "CEPHREST_INSECURE"
+	envEndpoints  = "CEPHREST_ENDPOINTS"
+	envUsername   = "CEPHREST_USERNAME"
+	envPassword   = "CEPHREST_PASSWORD"
+	envTimeout    = "CEPHREST_TIMEOUT"
+	envTokenCache = "CEPHREST_TOKEN_CACHE"
+)
+
+// ErrNoAddress is returned if neither the config file, the environment nor the flags set the mgr address.
+var ErrNoAddress = errors.New("no mgr address set, use -address, " + envAddress + " or a config file")
+
+// config implements the settings of cephrest. Server is the ceph.Server used by the services as well, so their
+// config can be reused as the "server" section of the config file:
+//
+//	{
+//	  "server": {"Address": "mgr-1", "Port": 8443, "Protocol": "https", "APIPath": "api",
+//	             "Endpoints": [{"Address": "mgr-2"}, {"Address": "mgr-3"}]},
+//	  "username": "admin",
+//	  "timeout": "30s"
+//	}
+type config struct {
+	Server   ceph.Server `json:"server"`
+	Username string      `json:"username"`
+	Password string      `json:"password"`
+	Timeout  string      `json:"timeout"`
+
+	// TokenCache is the directory the tokens of logged in sessions are kept in, an empty one disables the cache.
+	TokenCache string `json:"token_cache"`
+}
+
+// defaultConfig returns the config used if nothing else is set.
+func defaultConfig() config {
+	cfg := config{Server: ceph.Server{Port: 8443, Protocol: "https", APIPath: "api"}}
+
+	if dir, err := os.UserCacheDir(); err == nil {
+		cfg.TokenCache = filepath.Join(dir, "cephrest")
+	}
+
+	return cfg
+}
+
+// defaultConfigPath returns the config file read if neither -config nor CEPHREST_CONFIG is set.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "cephrest", "config.json")
+}
+
+// globalFlags implements the flags given before the command.
+type globalFlags struct {
+	config     string
+	address    string
+	port       uint
+	protocol   string
+	apiPath    string
+	insecure   bool
+	endpoints  string
+	username   string
+	timeout    time.Duration
+	tokenCache string
+	output     string
+	verbose    bool
+}
+
+// register defines the global flags on fs.
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", "", "config file (default "+defaultConfigPath()+")")
+	fs.StringVar(&g.address, "address", "", "address of the mgr")
+	fs.UintVar(&g.port, "port", 0, "port of the mgr (default 8443)")
+	fs.StringVar(&g.protocol, "protocol", "", "protocol of the mgr (default https)")
+	fs.StringVar(&g.apiPath, "api-path", "", "api path of the mgr (default api)")
+	fs.BoolVar(&g.insecure, "insecure", false, "skip verifying the certificate of the mgr")
+	fs.StringVar(&g.endpoints, "endpoints", "", "comma separated list of further mgrs, host[:port]")
+	fs.StringVar(&g.username, "user", "", "user name")
+	fs.DurationVar(&g.timeout, "timeout", 0, "timeout of a single request")
+	fs.StringVar(&g.tokenCache, "token-cache", "", "directory the login token is cached in, \"none\" disables the cache")
+	fs.StringVar(&g.output, "o", formatTable, "output format: table, json or yaml")
+	fs.BoolVar(&g.verbose, "v", false, "log every request to stderr")
+}
+
+// loadConfig reads the config file and applies the environment and the flags set in fs on top of it.
+func loadConfig(fs *flag.FlagSet, g *globalFlags, getenv func(string) string) (cfg config, err error) {
+	cfg = defaultConfig()
+
+	path, explicit := g.config, true
+	if path == "" {
+		path = getenv(envConfig)
+	}
+	if path == "" {
+		path, explicit = defaultConfigPath(), false
+	}
+
+	if path != "" {
+		if err = readConfig(path, &cfg); err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+			return cfg, err
+		}
+	}
+
+	if err = applyEnv(&cfg, getenv); err != nil {
+		return cfg, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "address":
+			cfg.Server.Address = g.address
+		case "port":
+			cfg.Server.Port = g.port
+		case "protocol":
+			cfg.Server.Protocol = g.protocol
+		case "api-path":
+			cfg.Server.APIPath = g.apiPath
+		case "insecure":
+			cfg.Server.InsecureSkipVerify = g.insecure
+		case "endpoints":
+			cfg.Server.Endpoints, err = parseEndpoints(g.endpoints)
+		case "user":
+			cfg.Username = g.username
+		case "timeout":
+			cfg.Timeout = g.timeout.String()
+		case "token-cache":
+			cfg.TokenCache = g.tokenCache
+		}
+	})
+
+	if err != nil {
+		return cfg, err
+	}
+
+	if cfg.TokenCache == "none" {
+		cfg.TokenCache = ""
+	}
+
+	if cfg.Server.Address == "" {
+		return cfg, ErrNoAddress
+	}
+
+	return cfg, nil
+}
+
+// readConfig decodes the json config file at path into cfg.
+func readConfig(path string, cfg *config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(b, cfg); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv sets the values of the CEPHREST_* environment variables in cfg.
+func applyEnv(cfg *config, getenv func(string) string) (err error) {
+	if v := getenv(envAddress); v != "" {
+		cfg.Server.Address = v
+	}
+
+	if v := getenv(envPort); v != "" {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", envPort, v, err)
+		}
+		cfg.Server.Port = uint(port)
+	}
+
+	if v := getenv(envProtocol); v != "" {
+		cfg.Server.Protocol = v
+	}
+
+	if v := getenv(envAPIPath); v != "" {
+		cfg.Server.APIPath = v
+	}
+
+	if v := getenv(envInsecure); v != "" {
+		if cfg.Server.InsecureSkipVerify, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", envInsecure, v, err)
+		}
+	}
+
+	if v := getenv(envEndpoints); v != "" {
+		if cfg.Server.Endpoints, err = parseEndpoints(v); err != nil {
+			return err
+		}
+	}
+
+	if v := getenv(envUsername); v != "" {
+		cfg.Username = v
+	}
+
+	if v := getenv(envPassword); v != "" {
+		cfg.Password = v
+	}
+
+	if v := getenv(envTimeout); v != "" {
+		cfg.Timeout = v
+	}
+
+	if v := getenv(envTokenCache); v != "" {
+		cfg.TokenCache = v
+	}
+
+	return nil
+}
+
+// parseEndpoints parses a comma separated list of mgrs given as host or host:port.
+func parseEndpoints(s string) ([]ceph.Endpoint, error) {
+	var endpoints []ceph.Endpoint
+
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(e)
+		if err != nil {
+			endpoints = append(endpoints, ceph.Endpoint{Address: e})
+			continue
+		}
+
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q: %w", e, err)
+		}
+
+		endpoints = append(endpoints, ceph.Endpoint{Address: host, Port: uint(p)})
+	}
+
+	return endpoints, nil
+}
+
+// options returns the client options of cfg.
+func (cfg config) options() ([]ceph.Option, error) {
+	var opts []ceph.Option
+
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", cfg.Timeout, err)
+		}
+		opts = append(opts, ceph.WithTimeout(timeout))
+	}
+
+	return append(opts, ceph.WithUserAgent("cephrest")), nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// fs runs the ceph fs subcommands.
+func (a *app) fs(ctx context.Context, args []string) error {
+	return subcommand(ctx, "fs", args, map[string]func(ctx context.Context, args []string) error{
+		"ls":    a.fsList,
+		"mkdir": a.fsMkdir,
+		"rmdir": a.fsRmdir,
+		"quota": a.fsQuota,
+		"snap":  a.fsSnap,
+	})
+}
+
+// fsID returns the id of the ceph fs given by its id or name.
+func (a *app) fsID(ctx context.Context, fs string) (int, error) {
+	if id, err := strconv.Atoi(fs); err == nil {
+		return id, nil
+	}
+
+	_, list, err := a.client.ListFSWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range list {
+		if f.MdsMap.FsName == fs {
+			return f.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("ceph fs %q not found", fs)
+}
+
+// fsPath returns the id of the ceph fs and the path given as first and second argument.
+func (a *app) fsPath(ctx context.Context, args []string) (id int, path string, err error) {
+	id, err = a.fsID(ctx, args[0])
+
+	return id, args[1], err
+}
+
+func (a *app) fsList(ctx context.Context, args []string) error {
+	fs := a.flagSet("fs ls", "[fs [path]]")
+	depth := fs.Uint("depth", 1, "depth of the directories listed")
+
+	args, err := parseArgs(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return a.fsListFS(ctx)
+	}
+
+	id, err := a.fsID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	path := "/"
+	if len(args) == 2 {
+		path = args[1]
+	}
+
+	_, dirs, err := a.client.ListDirWithContext(ctx, id, path, *depth)
+	if err != nil {
+		return err
+	}
+
+	if dirs == nil {
+		dirs = []ceph.Directory{}
+	}
+
+	return a.out.print(dirs, func() table {
+		t := table{header: []string{"PATH", "MAX BYTES", "MAX FILES", "SNAPSHOTS"}}
+
+		for _, dir := range dirs {
+			t.add(dir.Path, formatQuota(dir.Quotas.MaxBytes, true), formatQuota(dir.Quotas.MaxFiles, false),
+				strconv.Itoa(len(dir.Snapshots)))
+		}
+
+		return t
+	})
+}
+
+// fsListFS prints the ceph fs of the cluster.
+func (a *app) fsListFS(ctx context.Context) error {
+	_, list, err := a.client.ListFSWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if list == nil {
+		list = []ceph.FS{}
+	}
+
+	return a.out.print(list, func() table {
+		t := table{header: []string{"ID", "NAME", "ENABLED", "MAX MDS", "DATA POOLS"}}
+
+		for _, f := range list {
+			t.add(strconv.Itoa(f.ID), f.MdsMap.FsName, strconv.FormatBool(f.MdsMap.Enabled),
+				strconv.Itoa(f.MdsMap.MaxMds), strconv.Itoa(len(f.MdsMap.DataPools)))
+		}
+
+		return t
+	})
+}
+
+// formatQuota returns a quota or - if not set.
+func formatQuota(value int, bytes bool) string {
+	switch {
+	case value <= 0:
+		return "-"
+	case bytes:
+		return formatSize(uint64(value))
+	}
+
+	return strconv.Itoa(value)
+}
+
+func (a *app) fsMkdir(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("fs mkdir", "<fs> <path>"), args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	id, path, err := a.fsPath(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.CreateDirWithContext(ctx, id, path)
+
+	return err
+}
+
+func (a *app) fsRmdir(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("fs rmdir", "<fs> <path>"), args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	id, path, err := a.fsPath(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.DeleteDirWithContext(ctx, id, path)
+
+	return err
+}
+
+func (a *app) fsQuota(ctx context.Context, args []string) error {
+	var maxBytes string
+
+	fs := a.flagSet("fs quota", "<fs> <path>")
+	fs.StringVar(&maxBytes, "max-bytes", "", "set the max bytes of the directory, e.g. 10G, 0 removes the limit")
+	maxFiles := fs.Int("max-files", 0, "set the max files of the directory, 0 removes the limit")
+
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	id, path, err := a.fsPath(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	_, quota, err := a.client.GetQuotaWithContext(ctx, int64(id), path)
+	if err != nil {
+		return err
+	}
+
+	set := false
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-bytes":
+			var size uint64
+			if size, err = parseSize(maxBytes); err == nil {
+				quota.MaxBytes, set = int(size), true
+			}
+		case "max-files":
+			quota.MaxFiles, set = *maxFiles, true
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	quota.Path = path
+
+	if set {
+		if _, err = a.client.SetQuotaWithContext(ctx, id, quota); err != nil {
+			return err
+		}
+	}
+
+	return a.out.print(quota, func() table {
+		t := table{header: []string{"PATH", "MAX BYTES", "MAX FILES"}}
+		t.add(quota.Path, formatQuota(quota.MaxBytes, true), formatQuota(quota.MaxFiles, false))
+
+		return t
+	})
+}
+
+// fsSnap runs the ceph fs snap subcommands.
+func (a *app) fsSnap(ctx context.Context, args []string) error {
+	return subcommand(ctx, "fs snap", args, map[string]func(ctx context.Context, args []string) error{
+		"create": a.fsSnapshotCommand("create", a.client.CreateSnapShotWithContext),
+		"rm":     a.fsSnapshotCommand("rm", a.client.DeleteSnapShotWithContext),
+	})
+}
+
+// fsSnapshotCommand returns a command calling op for the snapshot given as <fs> <path> <name>.
+func (a *app) fsSnapshotCommand(name string, op func(ctx context.Context, id int, snap ceph.SnapShot) (int, error)) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		args, err := parseArgs(a.flagSet("fs snap "+name, "<fs> <path> <name>"), args, 3, 3)
+		if err != nil {
+			return err
+		}
+
+		id, path, err := a.fsPath(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		_, err = op(ctx, id, ceph.SnapShot{Name: args[2], Path: path})
+
+		return err
+	}
+}
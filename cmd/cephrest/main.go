@@ -0,0 +1,293 @@
+// Command cephrest exposes the operations of the ceph rest client on the command line, e.g. for on-call engineers
+// and scripts. It talks to the mgr exactly like the services using the client do.
+//
+// Usage:
+//
+//	cephrest [global flags] <command> [flags] [args]
+//
+// The ceph.Server to use is read from a json config file, CEPHREST_* environment variables and the global flags, in
+// this order. The login token is cached per cluster, so a password is only needed once the token expired.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/rs/zerolog"
+)
+
+const usage = `Usage: cephrest [global flags] <command> [flags] [args]
+
+Commands:
+  login [-password-stdin]                  log in and cache the token
+  logout                                   log out and remove the cached token
+  rbd ls [pool]                            list rbd images
+  rbd info <pool/[ns/]image>               show an rbd image
+  rbd create -size <size> <image spec>     create an rbd image
+  rbd copy <image spec> <image spec>       copy an rbd image
+  rbd rm <image spec>                      delete an rbd image
+  rbd trash ls|mv|restore|rm|purge         manage the rbd trash
+  rbd snap ls|create|rm|rollback|protect|unprotect
+                                           manage rbd snapshots, given as <image spec>@<snapshot>
+  ns ls|create|rm                          manage rbd namespaces, given as <pool>/<namespace>
+  fs ls [fs [path]]                        list ceph fs or the directories of path
+  fs mkdir|rmdir <fs> <path>               create or delete a ceph fs directory
+  fs quota <fs> <path>                     show or set (-max-bytes, -max-files) the quota of a directory
+  fs snap create|rm <fs> <path> <name>     create or delete a ceph fs snapshot
+  task ls [-name name]                     list executing and finished tasks
+  task wait <name> [key=value ...]         wait for the task with the given metadata
+
+Run cephrest <command> -h for the flags of a command.
+
+Global flags:
+`
+
+// errUsage is returned for invalid command lines, cephrest exits with 2 then.
+type errUsage struct {
+	msg string
+}
+
+func (e errUsage) Error() string {
+	return e.msg
+}
+
+// usageErrorf returns an errUsage.
+func usageErrorf(format string, v ...interface{}) error {
+	return errUsage{msg: fmt.Sprintf(format, v...)}
+}
+
+// app implements the state shared by the commands.
+type app struct {
+	cfg    config
+	client *ceph.Client
+	out    *printer
+	stdin  io.Reader
+	stderr io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv)
+
+	stop()
+
+	var usageErr errUsage
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "cephrest: %s\n", err)
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "cephrest: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command line args.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) error {
+	var g globalFlags
+
+	fs := flag.NewFlagSet("cephrest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	g.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return usageErrorf("no command given")
+	}
+
+	out, err := newPrinter(stdout, g.output)
+	if err != nil {
+		return errUsage{msg: err.Error()}
+	}
+
+	cfg, err := loadConfig(fs, &g, getenv)
+	if err != nil {
+		return err
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		return err
+	}
+
+	level := zerolog.WarnLevel
+	if g.verbose {
+		level = zerolog.DebugLevel
+	}
+
+	opts = append(opts, ceph.WithZerologLogger(zerolog.New(zerolog.ConsoleWriter{Out: stderr}).Level(level).With().Timestamp().Logger()))
+
+	a := &app{cfg: cfg, out: out, stdin: stdin, stderr: stderr}
+
+	command, args := fs.Arg(0), fs.Args()[1:]
+
+	commands := map[string]func(ctx context.Context, args []string) error{
+		"login":  a.login,
+		"logout": a.logout,
+		"rbd":    a.rbd,
+		"ns":     a.ns,
+		"fs":     a.fs,
+		"task":   a.task,
+	}
+
+	cmd, ok := commands[command]
+	if !ok {
+		return usageErrorf("unknown command %q", command)
+	}
+
+	if a.client, err = ceph.NewWithContext(ctx, cfg.Server, opts...); err != nil {
+		return err
+	}
+
+	if command == "login" || command == "logout" {
+		return cmd(ctx, args)
+	}
+
+	if err = authenticate(ctx, cfg, a.client.Session); err != nil {
+		return err
+	}
+
+	token := a.client.Session.Token()
+
+	err = cmd(ctx, args)
+
+	// keep the token of a re-login done by the session in the meantime
+	if t := a.client.Session.Token(); t != "" && t != token {
+		if errSave := saveToken(cfg, a.client.Session); err == nil {
+			err = errSave
+		}
+	}
+
+	return err
+}
+
+// login logs in with the credentials of the config and caches the token.
+func (a *app) login(ctx context.Context, args []string) error {
+	var passwordStdin bool
+
+	fs := a.flagSet("login", "")
+	fs.BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if a.cfg.Username == "" {
+		return usageErrorf("no user name set, use -user or %s", envUsername)
+	}
+
+	password := a.cfg.Password
+
+	if passwordStdin {
+		line, err := bufio.NewReader(a.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	if password == "" {
+		return usageErrorf("no password set, use -password-stdin or %s", envPassword)
+	}
+
+	if _, err := a.client.Session.LoginWithContext(ctx, a.cfg.Username, password); err != nil {
+		return err
+	}
+
+	if err := saveToken(a.cfg, a.client.Session); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "logged in as %s to %s\n", a.client.Session.Auth.Username, a.client.Session.ActiveEndpoint())
+
+	return nil
+}
+
+// logout logs out the cached token and removes it from the cache.
+func (a *app) logout(ctx context.Context, args []string) error {
+	if _, err := parseArgs(a.flagSet("logout", ""), args, 0, 0); err != nil {
+		return err
+	}
+
+	if token := loadToken(a.cfg); token != "" {
+		a.client.Session.Auth.Token = token
+
+		if err := a.client.Session.LogoutWithContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return removeToken(a.cfg)
+}
+
+// flagSet returns the flag set of a command. args describes its positional arguments in the usage message.
+func (a *app) flagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: cephrest %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseArgs parses args with fs and returns the positional arguments. Unlike fs.Parse, flags may follow positional
+// arguments. An errUsage is returned if there are less than min or more than max (if max >= 0) positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		fs.Usage()
+		return nil, usageErrorf("%s: wrong number of arguments", fs.Name())
+	}
+
+	return positional, nil
+}
+
+// subcommand runs the subcommand of group named by args[0].
+func subcommand(ctx context.Context, group string, args []string, commands map[string]func(ctx context.Context, args []string) error) error {
+	if len(args) == 0 {
+		return usageErrorf("%s: no subcommand given", group)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return usageErrorf("%s: unknown subcommand %q", group, args[0])
+	}
+
+	return cmd(ctx, args[1:])
+}
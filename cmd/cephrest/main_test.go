@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+	"github.com/chrisamti/ceph-rest-client/ceph/cephtest"
+)
+
+// cli runs cephrest commands against a cephtest server with its own config file and token cache.
+type cli struct {
+	t   *testing.T
+	env map[string]string
+}
+
+// newCLI returns a cli configured by a config file holding the ceph.Server of srv.
+func newCLI(t *testing.T, srv *cephtest.Server) *cli {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	b, err := json.Marshal(config{Server: srv.CephServer(), Username: cephtest.Username})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err = os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return &cli{t: t, env: map[string]string{
+		envConfig:     path,
+		envTokenCache: filepath.Join(dir, "cache"),
+	}}
+}
+
+// run runs args and returns stdout.
+func (c *cli) run(stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr, func(key string) string {
+		return c.env[key]
+	})
+
+	return stdout.String(), err
+}
+
+// mustRun runs args and fails the test on error.
+func (c *cli) mustRun(args ...string) string {
+	c.t.Helper()
+
+	out, err := c.run("", args...)
+	if err != nil {
+		c.t.Fatalf("cephrest %s: %v", strings.Join(args, " "), err)
+	}
+
+	return out
+}
+
+func TestRun_Login(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	c := newCLI(t, srv)
+
+	if _, err := c.run("", "rbd", "ls"); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("expected err %v - got %v", ErrNotLoggedIn, err)
+	}
+
+	if _, err := c.run(cephtest.Password+"\n", "login", "-password-stdin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached token is used without a password
+	c.mustRun("rbd", "ls")
+
+	c.mustRun("logout")
+
+	if _, err := c.run("", "rbd", "ls"); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("expected err %v after logout - got %v", ErrNotLoggedIn, err)
+	}
+
+	// a password in the environment logs in once the cached token is not valid anymore
+	c.env[envPassword] = cephtest.Password
+	c.mustRun("rbd", "ls")
+
+	if _, err := c.run("", "-user", "someone-else", "rbd", "ls"); err == nil {
+		t.Error("expected err logging in as unknown user - got nil")
+	}
+
+	var usageErr errUsage
+	if _, err := c.run("", "rbd", "create", "rbd/vol-1"); !errors.As(err, &usageErr) {
+		t.Errorf("expected usage err without -size - got %v", err)
+	}
+}
+
+func TestRun_RBD(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	srv.AddPool("rbd", ceph.PoolApplicationRBD)
+
+	c := newCLI(t, srv)
+	c.env[envPassword] = cephtest.Password
+
+	c.mustRun("ns", "create", "rbd/tenant-1")
+	c.mustRun("rbd", "create", "rbd/tenant-1/vol-1", "-size", "1G", "-features", "layering")
+	c.mustRun("rbd", "copy", "rbd/tenant-1/vol-1", "rbd/vol-2")
+
+	out := c.mustRun("rbd", "ls", "rbd")
+	for _, line := range []string{"IMAGE", "rbd/tenant-1/vol-1  1 GiB", "rbd/vol-2"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected table to contain %q - got\n%s", line, out)
+		}
+	}
+
+	var images []ceph.RBD
+	if err := json.Unmarshal([]byte(c.mustRun("-o", "json", "rbd", "ls", "rbd")), &images); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(images) != 2 {
+		t.Errorf("expected 2 images - got %d", len(images))
+	}
+
+	out = c.mustRun("-o", "yaml", "rbd", "info", "rbd/tenant-1/vol-1")
+	for _, line := range []string{"size: 1073741824\n", "name: vol-1\n", "namespace: tenant-1\n", "features_name:\n  - layering\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected yaml to contain %q - got\n%s", line, out)
+		}
+	}
+
+	c.mustRun("rbd", "snap", "create", "rbd/tenant-1/vol-1@snap-1")
+
+	if out = c.mustRun("rbd", "snap", "ls", "rbd/tenant-1/vol-1"); !strings.Contains(out, "snap-1") {
+		t.Errorf("expected snapshot snap-1 - got\n%s", out)
+	}
+
+	c.mustRun("rbd", "snap", "rm", "rbd/tenant-1/vol-1@snap-1")
+	c.mustRun("rbd", "trash", "mv", "rbd/vol-2")
+
+	var trash []ceph.RBDTrash
+	if err := json.Unmarshal([]byte(c.mustRun("-o", "json", "rbd", "trash", "ls", "rbd")), &trash); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trash) != 1 || trash[0].Name != "vol-2" {
+		t.Fatalf("expected vol-2 in trash - got %+v", trash)
+	}
+
+	c.mustRun("rbd", "trash", "restore", "rbd", trash[0].ID, "vol-3")
+	c.mustRun("rbd", "rm", "rbd/vol-3")
+	c.mustRun("rbd", "rm", "rbd/tenant-1/vol-1")
+
+	if out = c.mustRun("ns", "ls", "rbd"); !strings.Contains(out, "tenant-1") {
+		t.Errorf("expected namespace tenant-1 - got\n%s", out)
+	}
+
+	c.mustRun("ns", "rm", "rbd/tenant-1")
+
+	if out = c.mustRun("-o", "json", "ns", "ls", "rbd"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected no namespaces - got %s", out)
+	}
+
+	if _, err := c.run("", "rbd", "rm", "rbd/vol-1"); !errors.Is(err, ceph.ErrNotFound) {
+		t.Errorf("expected err %v - got %v", ceph.ErrNotFound, err)
+	}
+
+	var tasks ceph.Tasks
+	if err := json.Unmarshal([]byte(c.mustRun("-o", "json", "task", "ls", "-name", "rbd/create")), &tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks.FinishedTasks) != 1 || tasks.FinishedTasks[0].MetaData.ImageName != "vol-1" {
+		t.Errorf("expected finished rbd/create task of vol-1 - got %+v", tasks)
+	}
+
+	out = c.mustRun("task", "wait", "rbd/create", "pool_name=rbd", "namespace=tenant-1", "image_name=vol-1")
+	if !strings.Contains(out, "succeeded  rbd/create") {
+		t.Errorf("expected succeeded task - got\n%s", out)
+	}
+}
+
+func TestRun_FS(t *testing.T) {
+	srv := cephtest.NewServer()
+	defer srv.Close()
+
+	id := srv.AddFS("cephfs")
+
+	c := newCLI(t, srv)
+	c.env[envPassword] = cephtest.Password
+
+	if out := c.mustRun("fs", "ls"); !strings.Contains(out, strconv.Itoa(id)+"   cephfs") {
+		t.Errorf("expected ceph fs cephfs - got\n%s", out)
+	}
+
+	c.mustRun("fs", "mkdir", "cephfs", "/tenant-1")
+	c.mustRun("fs", "quota", "cephfs", "/tenant-1", "-max-bytes", "10G", "-max-files", "1000")
+
+	var quota ceph.Quota
+	if err := json.Unmarshal([]byte(c.mustRun("-o", "json", "fs", "quota", strconv.Itoa(id), "/tenant-1")), &quota); err != nil {
+		t.Fatal(err)
+	}
+
+	if quota.MaxBytes != 10<<30 || quota.MaxFiles != 1000 {
+		t.Errorf("expected quota of 10 GiB and 1000 files - got %+v", quota)
+	}
+
+	c.mustRun("fs", "snap", "create", "cephfs", "/tenant-1", "snap-1")
+
+	if out := c.mustRun("fs", "ls", "cephfs", "/"); !strings.Contains(out, "/tenant-1  10 GiB     1000       1") {
+		t.Errorf("expected /tenant-1 with quota and snapshot - got\n%s", out)
+	}
+
+	c.mustRun("fs", "snap", "rm", "cephfs", "/tenant-1", "snap-1")
+	c.mustRun("fs", "rmdir", "cephfs", "/tenant-1")
+
+	if _, err := c.run("", "fs", "mkdir", "no-fs", "/a"); err == nil {
+		t.Error("expected err for unknown ceph fs - got nil")
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	v := map[string]interface{}{
+		"name":   "vol-1",
+		"size":   1024,
+		"flags":  []string{"a", "yes"},
+		"empty":  []string{},
+		"nested": []map[string]interface{}{{"b": true, "c": nil}},
+		"time":   "2022-01-01T00:00:00Z",
+	}
+
+	b, err := marshalYAML(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// encoding/json sorts map keys
+	expected := `empty: []
+flags:
+  - a
+  - "yes"
+name: vol-1
+nested:
+  - b: true
+    c: null
+size: 1024
+time: "2022-01-01T00:00:00Z"
+`
+	if string(b) != expected {
+		t.Errorf("expected\n%s- got\n%s", expected, b)
+	}
+}
+
+// yamlNonString matches the plain scalars yaml 1.1 and 1.2 parsers read as bool, null, int or float.
+var yamlNonString = regexp.MustCompile(`(?i)^(true|false|yes|no|on|off|y|n|null|~|[-+]?\.(inf|nan)|[-+]?(\d[\d_]*)?\.?\d*([eE][-+]?\d+)?|0x[0-9a-f]+|0o?[0-7]+)$`)
+
+func TestMarshalYAML_Strings(t *testing.T) {
+	for _, s := range []string{".5", ".05e3", ".inf", ".Inf", ".NAN", "-.inf", "1.5", "1e3", "0x1f", "yes", "Null",
+		"", ".", ".hidden", "a.5", "vol-1", "test-pool-1/img-1"} {
+		b, err := marshalYAML(map[string]string{"v": s})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		scalar := strings.TrimSuffix(strings.TrimPrefix(string(b), "v: "), "\n")
+
+		// read the scalar back: quoted strings are unquoted, plain ones must not resolve to another type
+		got := scalar
+		if strings.HasPrefix(scalar, `"`) {
+			if got, err = strconv.Unquote(scalar); err != nil {
+				t.Fatalf("expected valid quoted string for %q - got %s", s, scalar)
+			}
+		} else if yamlNonString.MatchString(scalar) {
+			t.Errorf("expected %q to be quoted - got %s", s, scalar)
+		}
+
+		if got != s {
+			t.Errorf("expected %q to read back - got %q", s, got)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size  string
+		bytes uint64
+	}{
+		{size: "512", bytes: 512},
+		{size: "512M", bytes: 512 << 20},
+		{size: "10G", bytes: 10 << 30},
+		{size: "10GiB", bytes: 10 << 30},
+		{size: "1t", bytes: 1 << 40},
+		{size: "16383P", bytes: 16383 << 50},
+	}
+
+	for _, tt := range tests {
+		bytes, err := parseSize(tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes != tt.bytes {
+			t.Errorf("expected %d bytes for %s - got %d", tt.bytes, tt.size, bytes)
+		}
+	}
+
+	for _, size := range []string{"", "G", "10X", "-1", "16384P", "20000000000000000000", "18014398509481984K"} {
+		if _, err := parseSize(size); err == nil {
+			t.Errorf("expected err for size %q - got nil", size)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(path, []byte(`{"server": {"address": "mgr-1", "port": 8080, "protocol": "http"}, "username": "admin"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var g globalFlags
+
+	fs := newTestFlagSet(&g)
+	if err := fs.Parse([]string{"-config", path, "-port", "9443", "rbd"}); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{envProtocol: "https", envEndpoints: "mgr-2,mgr-3:8444", envTokenCache: "none"}
+
+	cfg, err := loadConfig(fs, &g, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := cfg.Server
+	if server.Address != "mgr-1" || server.Port != 9443 || server.Protocol != "https" || server.APIPath != "api" {
+		t.Errorf("expected mgr-1 on https port 9443 with api path api - got %+v", server)
+	}
+
+	if len(server.Endpoints) != 2 || server.Endpoints[1].Address != "mgr-3" || server.Endpoints[1].Port != 8444 {
+		t.Errorf("expected endpoints mgr-2 and mgr-3:8444 - got %+v", server.Endpoints)
+	}
+
+	if cfg.Username != "admin" || cfg.TokenCache != "" {
+		t.Errorf("expected user admin without token cache - got %q %q", cfg.Username, cfg.TokenCache)
+	}
+
+	g = globalFlags{}
+	fs = newTestFlagSet(&g)
+	_ = fs.Parse([]string{"-config", filepath.Join(dir, "missing.json")})
+
+	if _, err = loadConfig(fs, &g, func(string) string { return "" }); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected err %v for missing config file - got %v", os.ErrNotExist, err)
+	}
+}
+
+// newTestFlagSet returns a flag set with the global flags registered to g.
+func newTestFlagSet(g *globalFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("cephrest", flag.ContinueOnError)
+	g.register(fs)
+
+	return fs
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// ns runs the rbd namespace subcommands.
+func (a *app) ns(ctx context.Context, args []string) error {
+	return subcommand(ctx, "ns", args, map[string]func(ctx context.Context, args []string) error{
+		"ls":     a.nsList,
+		"create": a.nsCreate,
+		"rm":     a.nsRemove,
+	})
+}
+
+func (a *app) nsList(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("ns ls", "<pool>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	_, namespaces, err := a.client.GetBlockNameSpaceListInPoolWithContext(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if namespaces == nil {
+		namespaces = []ceph.NameSpace{}
+	}
+
+	return a.out.print(namespaces, func() table {
+		t := table{header: []string{"NAMESPACE", "IMAGES"}}
+
+		for _, ns := range namespaces {
+			t.add(ns.NameSpace, strconv.FormatUint(uint64(ns.NumImages), 10))
+		}
+
+		return t
+	})
+}
+
+// namespaceArg returns the namespace given as pool/namespace.
+func namespaceArg(s string) (pool, namespace string, err error) {
+	p, ns, err := parsePoolSpec(s)
+	if err != nil {
+		return "", "", err
+	}
+
+	if ns == nil {
+		return "", "", usageErrorf("invalid namespace %q, use pool/namespace", s)
+	}
+
+	return p, *ns, nil
+}
+
+func (a *app) nsCreate(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("ns create", "<pool/namespace>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	pool, namespace, err := namespaceArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.CreateBlockNameSpaceInPoolWithContext(ctx, pool, namespace)
+
+	return err
+}
+
+func (a *app) nsRemove(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("ns rm", "<pool/namespace>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	pool, namespace, err := namespaceArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.DeleteBlockNameSpaceInPoolWithContext(ctx, pool, namespace)
+
+	return err
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats selected with -o.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// table implements the rows printed for a result in table format.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// add appends a row.
+func (t *table) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// printer writes results in the format selected with -o.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// newPrinter returns a printer for format writing to w.
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return &printer{w: w, format: format}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q, use table, json or yaml", format)
+}
+
+// print writes v as json or yaml or the rows of t as table. t is only called in table format.
+func (p *printer) print(v interface{}, t func() table) error {
+	switch p.format {
+	case formatJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYAML:
+		b, err := marshalYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(b)
+		return err
+	}
+
+	tbl := t()
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+
+	if len(tbl.header) > 0 {
+		fmt.Fprintln(tw, strings.Join(tbl.header, "\t"))
+	}
+
+	for _, row := range tbl.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// yamlMap implements a json object keeping the order of its keys.
+type yamlMap []yamlItem
+
+type yamlItem struct {
+	key   string
+	value interface{}
+}
+
+// marshalYAML returns v as yaml document. v is encoded as json first, so the json tags of the ceph types define the
+// keys and their order.
+func marshalYAML(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if lines := yamlLines(doc, 0); lines != nil {
+		for _, line := range lines {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	} else {
+		buf.WriteString(yamlScalar(doc))
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes the next json value of dec into a yamlMap, a []interface{} or a scalar.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := yamlMap{}
+
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			m = append(m, yamlItem{key: key.(string), value: value})
+		}
+
+		_, err = dec.Token()
+
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, value)
+		}
+
+		_, err = dec.Token()
+
+		return list, err
+	}
+
+	return tok, nil
+}
+
+// yamlLines returns the block lines of a non-empty map or list indented by indent spaces and nil for any other value.
+func yamlLines(v interface{}, indent int) []string {
+	prefix := strings.Repeat(" ", indent)
+
+	switch v := v.(type) {
+	case yamlMap:
+		if len(v) == 0 {
+			return nil
+		}
+
+		var lines []string
+
+		for _, item := range v {
+			key := yamlScalar(item.key)
+
+			if child := yamlLines(item.value, indent+2); child != nil {
+				lines = append(lines, prefix+key+":")
+				lines = append(lines, child...)
+				continue
+			}
+
+			lines = append(lines, prefix+key+": "+yamlScalar(item.value))
+		}
+
+		return lines
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+
+		var lines []string
+
+		for _, item := range v {
+			child := yamlLines(item, indent+2)
+			if child == nil {
+				lines = append(lines, prefix+"- "+yamlScalar(item))
+				continue
+			}
+
+			// the first line of the nested block follows the dash
+			child[0] = prefix + "- " + child[0][indent+2:]
+			lines = append(lines, child...)
+		}
+
+		return lines
+	}
+
+	return nil
+}
+
+// A leading dot must not be followed by a digit, yaml parsers read e.g. .5 as float.
+var yamlPlain = regexp.MustCompile(`^([A-Za-z_/]|\.[A-Za-z_./@+-])[A-Za-z0-9_./@+-]*$`)
+
+// yamlReserved holds plain strings yaml parsers would read as bool, null or float (compared in lower case).
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true, "y": true, "n": true,
+	"null": true, "~": true, ".inf": true, ".nan": true,
+}
+
+// yamlScalar returns a scalar or an empty map or list as yaml flow value. Strings are quoted unless they read back
+// as the same string.
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if yamlPlain.MatchString(v) && !yamlReserved[strings.ToLower(v)] {
+			return v
+		}
+		return strconv.Quote(v)
+	case yamlMap:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+
+	return strconv.Quote(fmt.Sprint(v))
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// rbd runs the rbd subcommands.
+func (a *app) rbd(ctx context.Context, args []string) error {
+	return subcommand(ctx, "rbd", args, map[string]func(ctx context.Context, args []string) error{
+		"ls":     a.rbdList,
+		"info":   a.rbdInfo,
+		"create": a.rbdCreate,
+		"copy":   a.rbdCopy,
+		"rm":     a.rbdRemove,
+		"trash":  a.rbdTrash,
+		"snap":   a.rbdSnap,
+	})
+}
+
+// imageTable returns the table of images.
+func imageTable(images []ceph.RBD) table {
+	t := table{header: []string{"IMAGE", "SIZE", "USED", "FEATURES", "SNAPSHOTS"}}
+
+	for _, image := range images {
+		t.add(
+			ceph.PathJoin(image.PoolName, image.Namespace, image.Name),
+			formatSize(image.Size),
+			formatSize(image.DiskUsage),
+			orDash(strings.Join(image.FeaturesName, ",")),
+			strconv.Itoa(len(image.Snapshots)),
+		)
+	}
+
+	return t
+}
+
+func (a *app) rbdList(ctx context.Context, args []string) error {
+	fs := a.flagSet("rbd ls", "[pool]")
+
+	args, err := parseArgs(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	var pool string
+	if len(args) == 1 {
+		pool = args[0]
+	}
+
+	_, list, err := a.client.ListBlockImageWithContext(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	images := []ceph.RBD{}
+	for _, p := range list {
+		images = append(images, p.Value...)
+	}
+
+	return a.out.print(images, func() table { return imageTable(images) })
+}
+
+func (a *app) rbdInfo(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("rbd info", "<image spec>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseImageSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, image, err := a.client.GetBlockImageWithContext(ctx, spec.String())
+	if err != nil {
+		return err
+	}
+
+	return a.out.print(image, func() table { return imageTable([]ceph.RBD{image}) })
+}
+
+func (a *app) rbdCreate(ctx context.Context, args []string) error {
+	var size, features, dataPool string
+
+	fs := a.flagSet("rbd create", "<image spec>")
+	fs.StringVar(&size, "size", "", "size of the image, e.g. 10G (required)")
+	fs.StringVar(&features, "features", "", "comma separated list of image features")
+	fs.StringVar(&dataPool, "data-pool", "", "pool the data of the image is stored in")
+
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseImageSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	if size == "" {
+		return usageErrorf("rbd create: -size is required")
+	}
+
+	create := ceph.RBDCreate{
+		PoolName:  spec.pool,
+		Namespace: spec.namespace,
+		Name:      spec.image,
+		Features:  splitList(features),
+	}
+
+	if create.Size, err = parseSize(size); err != nil {
+		return err
+	}
+
+	if dataPool != "" {
+		create.DataPool = &dataPool
+	}
+
+	_, err = a.client.CreateBlockImageWithContext(ctx, create)
+
+	return err
+}
+
+func (a *app) rbdCopy(ctx context.Context, args []string) error {
+	var features string
+
+	fs := a.flagSet("rbd copy", "<image spec> <image spec>")
+	fs.StringVar(&features, "features", "", "comma separated list of features of the copy")
+
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	src, err := parseImageSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	dst, err := parseImageSpec(args[1])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.CopyBlockImageWithContext(ctx, src.pool, src.namespace, src.image, ceph.RBDCopy{
+		DestPoolName:  dst.pool,
+		DestNameSpace: dst.namespace,
+		DestImageName: dst.image,
+		Features:      splitList(features),
+	})
+
+	return err
+}
+
+func (a *app) rbdRemove(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("rbd rm", "<image spec>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseImageSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.DeleteBlockImageWithContext(ctx, spec.pool, spec.namespace, spec.image)
+
+	return err
+}
+
+// rbdTrash runs the rbd trash subcommands.
+func (a *app) rbdTrash(ctx context.Context, args []string) error {
+	return subcommand(ctx, "rbd trash", args, map[string]func(ctx context.Context, args []string) error{
+		"ls":      a.rbdTrashList,
+		"mv":      a.rbdTrashMove,
+		"restore": a.rbdTrashRestore,
+		"rm":      a.rbdTrashRemove,
+		"purge":   a.rbdTrashPurge,
+	})
+}
+
+func (a *app) rbdTrashList(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("rbd trash ls", "[pool]"), args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	var pool string
+	if len(args) == 1 {
+		pool = args[0]
+	}
+
+	_, list, err := a.client.ListBlockTrashWithContext(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	trash := []ceph.RBDTrash{}
+	for _, p := range list {
+		trash = append(trash, p.Value...)
+	}
+
+	return a.out.print(trash, func() table {
+		t := table{header: []string{"ID", "IMAGE", "SOURCE", "DELETED", "DEFERMENT END"}}
+
+		for _, image := range trash {
+			t.add(image.ID, ceph.PathJoin(image.PoolName, image.Namespace, image.Name), image.Source,
+				formatTime(image.DeletionTime), formatTime(image.DefermentEndTime))
+		}
+
+		return t
+	})
+}
+
+func (a *app) rbdTrashMove(ctx context.Context, args []string) error {
+	fs := a.flagSet("rbd trash mv", "<image spec>")
+	delay := fs.Duration("delay", 0, "time the image is kept in the trash before it can be removed")
+
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseImageSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.MoveBlockImageToTrashWithContext(ctx, spec.pool, spec.namespace, spec.image, *delay)
+
+	return err
+}
+
+func (a *app) rbdTrashRestore(ctx context.Context, args []string) error {
+	fs := a.flagSet("rbd trash restore", "<pool[/namespace]> <image id> <image name>")
+
+	args, err := parseArgs(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	pool, namespace, err := parsePoolSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.RestoreBlockImageFromTrashWithContext(ctx, pool, namespace, args[1], args[2])
+
+	return err
+}
+
+func (a *app) rbdTrashRemove(ctx context.Context, args []string) error {
+	fs := a.flagSet("rbd trash rm", "<pool[/namespace]> <image id>")
+	force := fs.Bool("force", false, "remove the image even if its deferment did not end yet")
+
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	pool, namespace, err := parsePoolSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.RemoveBlockImageFromTrashWithContext(ctx, pool, namespace, args[1], *force)
+
+	return err
+}
+
+func (a *app) rbdTrashPurge(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("rbd trash purge", "[pool]"), args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	var pool string
+	if len(args) == 1 {
+		pool = args[0]
+	}
+
+	_, err = a.client.PurgeBlockTrashWithContext(ctx, pool)
+
+	return err
+}
+
+// rbdSnap runs the rbd snap subcommands.
+func (a *app) rbdSnap(ctx context.Context, args []string) error {
+	return subcommand(ctx, "rbd snap", args, map[string]func(ctx context.Context, args []string) error{
+		"ls":        a.rbdSnapList,
+		"create":    a.rbdSnapshotCommand("create", a.client.CreateBlockSnapShotWithContext),
+		"rm":        a.rbdSnapshotCommand("rm", a.client.DeleteBlockSnapShotWithContext),
+		"rollback":  a.rbdSnapshotCommand("rollback", a.client.RollbackBlockSnapShotWithContext),
+		"protect":   a.rbdSnapshotCommand("protect", a.protectSnapshot(true)),
+		"unprotect": a.rbdSnapshotCommand("unprotect", a.protectSnapshot(false)),
+	})
+}
+
+func (a *app) rbdSnapList(ctx context.Context, args []string) error {
+	args, err := parseArgs(a.flagSet("rbd snap ls", "<image spec>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseImageSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, snapshots, err := a.client.ListBlockSnapShotsWithContext(ctx, spec.pool, spec.namespace, spec.image)
+	if err != nil {
+		return err
+	}
+
+	if snapshots == nil {
+		snapshots = []ceph.RBDSnapshot{}
+	}
+
+	return a.out.print(snapshots, func() table {
+		t := table{header: []string{"ID", "NAME", "SIZE", "PROTECTED", "CHILDREN", "TIMESTAMP"}}
+
+		for _, snap := range snapshots {
+			t.add(strconv.Itoa(snap.ID), snap.Name, formatSize(snap.Size), strconv.FormatBool(snap.IsProtected),
+				strconv.Itoa(len(snap.Children)), formatTime(snap.Timestamp))
+		}
+
+		return t
+	})
+}
+
+// rbdSnapshotCommand returns a command calling op for the snapshot given as <image spec>@<snapshot>.
+func (a *app) rbdSnapshotCommand(name string, op func(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (int, error)) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		args, err := parseArgs(a.flagSet("rbd snap "+name, "<image spec>@<snapshot>"), args, 1, 1)
+		if err != nil {
+			return err
+		}
+
+		spec, snapshot, err := parseSnapshotSpec(args[0])
+		if err != nil {
+			return err
+		}
+
+		_, err = op(ctx, spec.pool, spec.namespace, spec.image, snapshot)
+
+		return err
+	}
+}
+
+// protectSnapshot returns an op protecting or unprotecting a snapshot.
+func (a *app) protectSnapshot(protect bool) func(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (int, error) {
+	return func(ctx context.Context, poolName string, nameSpace *string, imageName, snapShotName string) (int, error) {
+		return a.client.UpdateBlockSnapShotWithContext(ctx, poolName, nameSpace, imageName, snapShotName, ceph.RBDSnapshotUpdate{IsProtected: &protect})
+	}
+}
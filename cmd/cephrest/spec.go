@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// imageSpec implements an rbd image given as pool/image or pool/namespace/image.
+type imageSpec struct {
+	pool      string
+	namespace *string
+	image     string
+}
+
+func (s imageSpec) String() string {
+	return ceph.PathJoin(s.pool, s.namespace, s.image)
+}
+
+// parseImageSpec parses an image spec.
+func parseImageSpec(s string) (spec imageSpec, err error) {
+	parts := strings.Split(s, "/")
+
+	switch len(parts) {
+	case 2:
+		spec = imageSpec{pool: parts[0], image: parts[1]}
+	case 3:
+		spec = imageSpec{pool: parts[0], namespace: &parts[1], image: parts[2]}
+	default:
+		return spec, usageErrorf("invalid image spec %q, use pool/image or pool/namespace/image", s)
+	}
+
+	if spec.pool == "" || spec.image == "" || (spec.namespace != nil && *spec.namespace == "") {
+		return spec, usageErrorf("invalid image spec %q, use pool/image or pool/namespace/image", s)
+	}
+
+	return spec, nil
+}
+
+// parseSnapshotSpec parses an rbd snapshot given as <image spec>@<snapshot>.
+func parseSnapshotSpec(s string) (spec imageSpec, snapshot string, err error) {
+	i := strings.LastIndex(s, "@")
+	if i < 0 || i == len(s)-1 {
+		return spec, "", usageErrorf("invalid snapshot spec %q, use pool/[namespace/]image@snapshot", s)
+	}
+
+	spec, err = parseImageSpec(s[:i])
+
+	return spec, s[i+1:], err
+}
+
+// parsePoolSpec parses a pool given as pool or pool/namespace.
+func parsePoolSpec(s string) (pool string, namespace *string, err error) {
+	parts := strings.Split(s, "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return parts[0], nil, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return parts[0], &parts[1], nil
+	}
+
+	return "", nil, usageErrorf("invalid pool spec %q, use pool or pool/namespace", s)
+}
+
+var sizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+// parseSize parses a size in bytes, optionally with a binary unit, e.g. 512M, 10G or 10GiB.
+func parseSize(s string) (uint64, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+
+	n, err := strconv.ParseUint(s[:i], 10, 64)
+	if err != nil {
+		return 0, usageErrorf("invalid size %q", s)
+	}
+
+	unit := strings.ToUpper(s[i:])
+
+	for exp, u := range []string{"B", "K", "M", "G", "T", "P"} {
+		if unit == "" || unit == u || unit == u+"B" || unit == u+"IB" {
+			if n > math.MaxUint64>>(10*uint(exp)) {
+				return 0, usageErrorf("size %q too large", s)
+			}
+
+			return n << (10 * uint(exp)), nil
+		}
+	}
+
+	return 0, usageErrorf("invalid size %q", s)
+}
+
+// formatSize returns bytes in binary units rounded to two decimals, e.g. 10 GiB.
+func formatSize(bytes uint64) string {
+	size, i := float64(bytes), 0
+
+	for size >= 1024 && i < len(sizeUnits)-1 {
+		size /= 1024
+		i++
+	}
+
+	return strconv.FormatFloat(math.Round(size*100)/100, 'f', -1, 64) + " " + sizeUnits[i]
+}
+
+// formatTime returns t in RFC 3339 or - for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// orDash returns s or - if s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// deref returns *s or an empty string if s is nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// splitList splits a comma separated list, an empty string is an empty list.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// formatTaskSpec returns the object a task works on.
+func formatTaskSpec(t ceph.Task) string {
+	md := t.MetaData
+
+	switch {
+	case md.ImageSpec != "":
+		return md.ImageSpec
+	case md.ImageName != "":
+		return ceph.PathJoin(md.PoolName, md.Namespace, md.ImageName)
+	case md.SrcImageSpec != "":
+		return md.SrcImageSpec
+	case md.ImageIDSpec != "":
+		return md.ImageIDSpec
+	case md.PoolName != "":
+		return md.PoolName
+	}
+
+	return fmt.Sprintf("%s%s%s%s", md.SvcID, md.ClusterID, md.ExportID, md.TargetIQN)
+}
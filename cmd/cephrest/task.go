@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// task runs the task subcommands.
+func (a *app) task(ctx context.Context, args []string) error {
+	return subcommand(ctx, "task", args, map[string]func(ctx context.Context, args []string) error{
+		"ls":   a.taskList,
+		"wait": a.taskWait,
+	})
+}
+
+// taskTable returns the table of executing and finished tasks.
+func taskTable(executing, finished []ceph.Task) table {
+	t := table{header: []string{"STATE", "NAME", "SPEC", "PROGRESS", "BEGIN", "DURATION", "ERROR"}}
+
+	row := func(state string, task ceph.Task) {
+		duration := "-"
+		if !task.EndTime.IsZero() {
+			duration = task.EndTime.Sub(task.BeginTime).Round(time.Millisecond).String()
+		}
+
+		t.add(state, task.Name, orDash(formatTaskSpec(task)), strconv.Itoa(task.Progress)+"%",
+			formatTime(task.BeginTime), duration, orDash(task.Exception.Detail))
+	}
+
+	for _, task := range executing {
+		row("executing", task)
+	}
+
+	for _, task := range finished {
+		state := "failed"
+		if task.Success {
+			state = "succeeded"
+		}
+		row(state, task)
+	}
+
+	return t
+}
+
+func (a *app) taskList(ctx context.Context, args []string) error {
+	fs := a.flagSet("task ls", "")
+	name := fs.String("name", "", "list the tasks of this name only, e.g. rbd/create")
+
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	_, tasks, err := a.client.GetTaskByNameWithContext(ctx, *name)
+	if err != nil {
+		return err
+	}
+
+	return a.out.print(tasks, func() table { return taskTable(tasks.ExecutingTasks, tasks.FinishedTasks) })
+}
+
+// parseMetaData parses task metadata given as key=value pairs, the keys are the ones of the /api/task metadata.
+func parseMetaData(pairs []string) (md ceph.MetaData, err error) {
+	values := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			return md, usageErrorf("invalid task metadata %q, use key=value", pair)
+		}
+
+		values[pair[:i]] = pair[i+1:]
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return md, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(&md); err != nil {
+		return md, usageErrorf("invalid task metadata: %s", err)
+	}
+
+	return md, nil
+}
+
+func (a *app) taskWait(ctx context.Context, args []string) error {
+	fs := a.flagSet("task wait", "<name> [key=value ...]")
+	maxWait := fs.Duration("max-wait", 0, "stop waiting after this duration (default no limit)")
+
+	args, err := parseArgs(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	md, err := parseMetaData(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if *maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *maxWait)
+		defer cancel()
+	}
+
+	task, err := a.client.WaitForTaskIsDoneWithContext(ctx, ceph.Task{Name: args[0], MetaData: md})
+	if err != nil {
+		return err
+	}
+
+	if err = a.out.print(task, func() table { return taskTable(nil, []ceph.Task{task}) }); err != nil {
+		return err
+	}
+
+	if !task.Success {
+		return fmt.Errorf("task %s failed: %s", task.Name, orDash(task.Exception.Detail))
+	}
+
+	return nil
+}
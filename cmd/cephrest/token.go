@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chrisamti/ceph-rest-client/ceph"
+)
+
+// ErrNotLoggedIn is returned if no valid token is cached and no password is set to log in with.
+var ErrNotLoggedIn = errors.New("not logged in, run cephrest login or set " + envPassword)
+
+// cachedToken implements the content of a token cache file.
+type cachedToken struct {
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// tokenPath returns the cache file of the token for the mgr of cfg, one file per cluster.
+func (cfg config) tokenPath() string {
+	if cfg.TokenCache == "" {
+		return ""
+	}
+
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':':
+			return '_'
+		}
+		return r
+	}, cfg.Server.Address+"_"+strconv.FormatUint(uint64(cfg.Server.Port), 10))
+
+	return filepath.Join(cfg.TokenCache, "token-"+name+".json")
+}
+
+// loadToken returns the cached token of cfg, which is empty if none is cached for the user of cfg.
+func loadToken(cfg config) string {
+	path := cfg.tokenPath()
+	if path == "" {
+		return ""
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var cached cachedToken
+	if json.Unmarshal(b, &cached) != nil {
+		return ""
+	}
+
+	if cfg.Username != "" && cfg.Username != cached.Username {
+		return ""
+	}
+
+	return cached.Token
+}
+
+// saveToken writes the token of session to the cache of cfg, readable by the current user only.
+func saveToken(cfg config, session *ceph.Session) error {
+	path := cfg.tokenPath()
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("could not cache token: %w", err)
+	}
+
+	b, err := json.Marshal(cachedToken{Username: session.Auth.Username, Token: session.Token()})
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(path, b, 0o600); err != nil {
+		return fmt.Errorf("could not cache token: %w", err)
+	}
+
+	return nil
+}
+
+// removeToken deletes the cached token of cfg.
+func removeToken(cfg config) error {
+	path := cfg.tokenPath()
+	if path == "" {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// authenticate makes session use the cached token if the mgr still accepts it and logs in with the credentials of
+// cfg otherwise. If a password is set, the session logs in again with it once the token expires.
+func authenticate(ctx context.Context, cfg config, session *ceph.Session) error {
+	if cfg.Username != "" && cfg.Password != "" {
+		credentials := ceph.Credentials{Username: cfg.Username, Password: cfg.Password}
+		session.CredentialProvider = ceph.CredentialProviderFunc(func(context.Context) (ceph.Credentials, error) {
+			return credentials, nil
+		})
+	}
+
+	if token := loadToken(cfg); token != "" {
+		session.Auth.Token = token
+
+		_, check, err := session.CheckTokenWithContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		if check.Valid() {
+			session.Auth.Username = check.Username
+			return nil
+		}
+
+		session.Auth.Token = ""
+	}
+
+	if session.CredentialProvider == nil {
+		return ErrNotLoggedIn
+	}
+
+	if _, err := session.ReloginWithContext(ctx); err != nil {
+		return err
+	}
+
+	return saveToken(cfg, session)
+}